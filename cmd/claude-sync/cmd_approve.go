@@ -2,18 +2,81 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/huh"
+	"github.com/ruminaider/claude-sync/internal/approval"
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/paths"
 	"github.com/spf13/cobra"
 )
 
+var (
+	approveYes    bool
+	approveReject []string
+)
+
 var approveCmd = &cobra.Command{
-	Use:   "approve",
+	Use:   "approve [key...]",
 	Short: "Approve and apply pending high-risk changes",
+	Long: `Show the high-risk changes a pull deferred, then apply them.
+
+Each pending change has a key: permissions.allow.<rule>,
+permissions.deny.<rule>, mcp.<server> or hooks.<event>. Name keys to accept
+only those changes, and use --reject to turn changes down. A key can also
+name a whole group, such as "mcp". Rejected changes aren't proposed again
+until their value changes upstream. Changes you don't name stay pending.
+With no keys, you pick the changes to apply and the rest are rejected.
+
+Each changed hook is shown before and after, with what its commands do that
+deserves a look: network access, writes outside the project, sudo, rm -rf,
+base64-decoded payloads and scripts missing on disk.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		result, err := commands.Approve(paths.ClaudeDir(), paths.SyncDir())
+		claudeDir := paths.ClaudeDir()
+		syncDir := paths.SyncDir()
+
+		pending, err := approval.ReadPending(syncDir)
+		if err != nil {
+			return fmt.Errorf("reading pending changes: %w", err)
+		}
+		items := pending.Items()
+
+		opts := commands.ApproveOptions{Accept: args, Reject: approveReject}
+		if len(items) > 0 && len(args) == 0 && len(approveReject) == 0 {
+			printPendingChanges(items, commands.PendingHookChanges(claudeDir, pending))
+
+			if approveYes {
+				opts.All = true
+			} else {
+				options := make([]huh.Option[string], len(items))
+				for i, item := range items {
+					options[i] = huh.NewOption(item.Key, item.Key).Selected(true)
+				}
+				var selected []string
+				err := huh.NewForm(
+					huh.NewGroup(
+						huh.NewMultiSelect[string]().
+							Title("Apply which changes?").
+							Description("Unselected changes are rejected and won't be proposed again until they change.").
+							Options(options...).
+							Value(&selected),
+					),
+				).Run()
+				if err != nil {
+					fmt.Println("Cancelled. The changes stay pending.")
+					return nil
+				}
+				opts.Accept = selected
+				for _, item := range items {
+					if !slices.Contains(selected, item.Key) {
+						opts.Reject = append(opts.Reject, item.Key)
+					}
+				}
+			}
+		}
+
+		result, err := commands.ApproveItems(claudeDir, syncDir, opts)
 		if err != nil {
 			return err
 		}
@@ -27,8 +90,48 @@ var approveCmd = &cobra.Command{
 		if len(result.HooksApplied) > 0 {
 			fmt.Printf("\u2713 Hooks applied: %s\n", strings.Join(result.HooksApplied, ", "))
 		}
+		if len(result.Rejected) > 0 {
+			fmt.Printf("\u2717 Rejected: %s\n", strings.Join(result.Rejected, ", "))
+		}
 
-		fmt.Println("\nAll pending changes approved and applied.")
+		if result.Remaining > 0 {
+			fmt.Printf("\n%d change(s) still pending. Run 'claude-sync approve' to review them.\n", result.Remaining)
+		} else {
+			fmt.Println("\nAll pending changes reviewed.")
+		}
 		return nil
 	},
 }
+
+// printPendingChanges lists pending changes by key, with a diff and risk
+// findings for each hook.
+func printPendingChanges(items []approval.Item, hooks []approval.HookChange) {
+	byEvent := make(map[string]approval.HookChange, len(hooks))
+	for _, h := range hooks {
+		byEvent[h.Event] = h
+	}
+	fmt.Println("Pending changes:")
+	for _, item := range items {
+		h, ok := byEvent[item.Name]
+		if item.Category != "hooks" || !ok {
+			fmt.Printf("  %s\n", item.Key)
+			continue
+		}
+		fmt.Printf("  %s (%s)\n", item.Key, h.Status)
+		for _, c := range h.Before {
+			fmt.Printf("    - %s\n", c)
+		}
+		for _, c := range h.After {
+			fmt.Printf("    + %s\n", c)
+		}
+		for _, f := range h.Findings {
+			fmt.Printf("    ⚠ %s\n", f)
+		}
+	}
+	fmt.Println()
+}
+
+func init() {
+	approveCmd.Flags().BoolVarP(&approveYes, "yes", "y", false, "Apply every pending change without prompting")
+	approveCmd.Flags().StringArrayVar(&approveReject, "reject", nil, "Reject the pending changes with these keys")
+}
@@ -29,6 +29,9 @@ var autoCommitCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if warning := commands.TemplateEditedWarning(result.TemplateEdited); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
 
 		if !result.Changed {
 			if !autoCommitIfChanged {
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+var claudeMDCmd = &cobra.Command{
+	Use:   "claude-md",
+	Short: "Manage the synced CLAUDE.md",
+}
+
+var claudeMDPromoteAll bool
+
+var claudeMDPromoteCmd = &cobra.Command{
+	Use:   "promote [section...]",
+	Short: "Turn hand-written CLAUDE.md sections into synced fragments",
+	Long: `Turn sections of ~/.claude/CLAUDE.md outside the managed region into
+fragments, include them in config.yaml, and move them into the region.
+
+Content between the ` + claudemd.RegionBegin + ` and ` + claudemd.RegionEnd + `
+markers is written by pull. Everything outside them is yours and is never
+pushed until promoted. Sections are named as fragments are: "## Build Notes"
+becomes build-notes. Without arguments, the sections outside the region are
+listed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		claudeDir := paths.ClaudeDir()
+		if len(args) == 0 && !claudeMDPromoteAll {
+			sections, err := commands.UnmanagedClaudeMDSections(claudeDir)
+			if err != nil {
+				return err
+			}
+			if len(sections) == 0 {
+				fmt.Println("No hand-written sections outside the managed region.")
+				return nil
+			}
+			fmt.Println("Sections outside the managed region:")
+			for _, sec := range sections {
+				fmt.Printf("  %s\n", claudemd.SectionFragmentName(sec))
+			}
+			fmt.Println("\nRun 'claude-sync claude-md promote <section...>' or '--all' to sync them.")
+			return nil
+		}
+
+		names, err := commands.PromoteClaudeMD(claudeDir, paths.SyncDir(), args)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Println("No hand-written sections outside the managed region.")
+			return nil
+		}
+		for _, name := range names {
+			fmt.Printf("Promoted %s\n", name)
+		}
+		fmt.Println("Run 'claude-sync push' to share them.")
+		return nil
+	},
+}
+
+func init() {
+	claudeMDPromoteCmd.Flags().BoolVar(&claudeMDPromoteAll, "all", false, "Promote every section outside the managed region")
+
+	claudeMDCmd.AddCommand(claudeMDPromoteCmd)
+
+	rootCmd.AddCommand(claudeMDCmd)
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
 
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/paths"
@@ -24,11 +28,12 @@ var conflictsCmd = &cobra.Command{
 		}
 		fmt.Printf("%d pending conflict(s):\n\n", len(conflicts))
 		for i, c := range conflicts {
-			fmt.Printf("  [%d] %s\n", i, c.Key)
+			fmt.Printf("  [%d] %s\n", i, conflictTitle(c))
 			fmt.Printf("      Local:  %s\n", string(c.LocalValue))
 			fmt.Printf("      Remote: %s\n", string(c.RemoteValue))
 			fmt.Println()
 		}
+		fmt.Println("Run 'claude-sync conflicts resolve' to resolve them.")
 		return nil
 	},
 }
@@ -50,6 +55,147 @@ var conflictsDiscardCmd = &cobra.Command{
 	},
 }
 
+var conflictsResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Resolve pending conflicts one by one",
+	Long: `Walk through the pending conflicts. For each one, see the local and remote
+values side by side and keep local, keep remote, keep both (for lists), or
+edit a merged value in $EDITOR. The result is written into config.yaml (and
+into settings.json for conflicts found there) and the conflict is cleared.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		claudeDir := paths.ClaudeDir()
+		syncDir := paths.SyncDir()
+		conflicts, err := commands.ListPendingConflicts(syncDir)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) == 0 {
+			fmt.Println("No pending conflicts.")
+			return nil
+		}
+
+		resolved, index := 0, 0
+		for n, c := range conflicts {
+			fmt.Printf("\nConflict %d of %d: %s\n\n", n+1, len(conflicts), conflictTitle(c))
+			printSideBySide(c.SideBySide())
+			fmt.Println()
+
+			value, ok, err := promptConflictValue(c)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				index++
+				continue
+			}
+			if err := commands.ResolveConflictValue(claudeDir, syncDir, index, value); err != nil {
+				return err
+			}
+			resolved++
+			fmt.Printf("  ✓ Resolved %s\n", c.Key)
+		}
+
+		fmt.Printf("\n%d of %d conflict(s) resolved.", resolved, len(conflicts))
+		if resolved > 0 {
+			fmt.Print(" Run 'claude-sync push' to share the result.")
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+func conflictTitle(c commands.PendingConflict) string {
+	if c.Source != "" {
+		return c.Source + ": " + c.Key
+	}
+	return c.Key
+}
+
+// printSideBySide prints local and remote columns, marking differing rows.
+func printSideBySide(rows []commands.ConflictLine) {
+	width := len("Local")
+	for _, r := range rows {
+		width = max(width, len(r.Local))
+	}
+	width = min(width, 48)
+	clip := func(s string) string {
+		if len(s) > width {
+			return s[:width-1] + "…"
+		}
+		return s
+	}
+	fmt.Printf("    %-*s   %s\n", width, "Local", "Remote")
+	for _, r := range rows {
+		mark := " "
+		if r.Changed {
+			mark = "|"
+		}
+		fmt.Printf("    %-*s %s %s\n", width, clip(r.Local), mark, r.Remote)
+	}
+}
+
+// promptConflictValue asks how to resolve c. It reports false if the user
+// skips it.
+func promptConflictValue(c commands.PendingConflict) (json.RawMessage, bool, error) {
+	options := []huh.Option[string]{
+		huh.NewOption("Keep local", commands.ResolveLocal),
+		huh.NewOption("Keep remote", commands.ResolveRemote),
+	}
+	if c.CanKeepBoth() {
+		options = append(options, huh.NewOption("Keep both", commands.ResolveBoth))
+	}
+	options = append(options,
+		huh.NewOption("Edit in $EDITOR", "edit"),
+		huh.NewOption("Skip for now", "skip"),
+	)
+
+	for {
+		var choice string
+		err := huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title("Resolve " + c.Key).
+					Options(options...).
+					Value(&choice),
+			),
+		).Run()
+		if err != nil {
+			return nil, false, fmt.Errorf("aborted")
+		}
+
+		switch choice {
+		case "skip":
+			return nil, false, nil
+		case "edit":
+			value, err := editConflictValue(c)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %v\n", err)
+				continue
+			}
+			return value, true, nil
+		default:
+			value, err := c.Value(choice)
+			return value, err == nil, err
+		}
+	}
+}
+
+// editConflictValue opens the local value in $EDITOR and returns what was
+// saved.
+func editConflictValue(c commands.PendingConflict) (json.RawMessage, error) {
+	path, err := commands.WriteConflictEditFile(c, c.LocalValue)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+	if err := commands.EditorCommand(path).Run(); err != nil {
+		return nil, fmt.Errorf("running editor: %w", err)
+	}
+	return commands.ReadConflictEditFile(path)
+}
+
 func init() {
+	conflictsCmd.AddCommand(conflictsResolveCmd)
 	conflictsCmd.AddCommand(conflictsDiscardCmd)
 }
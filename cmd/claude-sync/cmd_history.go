@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show config history with a summary of each change",
+	Long: `List commits to the sync repo, newest first, with what each one changed:
+plugins, hooks, permissions, MCP servers, settings, CLAUDE.md fragments,
+memory fragments and profiles.
+
+Pass any listed commit to 'claude-sync rollback' to return to it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := commands.History(paths.SyncDir(), historyLimit)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No history yet.")
+			return nil
+		}
+
+		for i, e := range entries {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s  %s  %s\n", e.ShortSHA(), e.Date.Local().Format("2006-01-02 15:04"), e.Subject)
+			for _, c := range e.Changes {
+				fmt.Printf("    %s\n", c)
+			}
+		}
+		return nil
+	},
+}
+
+var rollbackYes bool
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <ref>",
+	Short: "Restore config to an earlier commit and apply it",
+	Long: `Restore config.yaml, profiles, profile-rules.yaml and CLAUDE.md/memory
+fragments to their state at <ref>, commit that as a new change, and re-run
+pull so ~/.claude matches.
+
+The rollback is an ordinary commit: push it to share it, or roll back again
+to undo it. Use 'claude-sync history' to find a ref.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		claudeDir := paths.ClaudeDir()
+		syncDir := paths.SyncDir()
+
+		if !rollbackYes {
+			var confirm bool
+			err := huh.NewForm(
+				huh.NewGroup(
+					huh.NewConfirm().
+						Title(fmt.Sprintf("Roll back config to %s?", args[0])).
+						Description("Your current config stays in history.").
+						Affirmative("Yes, roll back").
+						Negative("Cancel").
+						Value(&confirm),
+				),
+			).Run()
+			if err != nil || !confirm {
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		}
+
+		result, err := commands.Rollback(syncDir, args[0])
+		if err != nil {
+			return err
+		}
+		if !result.Committed {
+			fmt.Printf("Config already matches %s (%s).\n", result.Target.ShortSHA(), result.Target.Subject)
+			return nil
+		}
+
+		fmt.Printf("✓ Rolled back to %s (%s)\n", result.Target.ShortSHA(), result.Target.Subject)
+		for _, c := range result.Changes {
+			fmt.Printf("    %s\n", c)
+		}
+
+		pullResult, err := commands.PullWithOptions(commands.PullOptions{
+			ClaudeDir: claudeDir,
+			SyncDir:   syncDir,
+			SkipFetch: true,
+		})
+		if err != nil {
+			return fmt.Errorf("applying rollback: %w", err)
+		}
+		printPullResult(pullResult)
+
+		fmt.Println("\nRun 'claude-sync push' to share the rollback.")
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of commits to show (0 = all)")
+	rollbackCmd.Flags().BoolVarP(&rollbackYes, "yes", "y", false, "Skip confirmation prompt")
+}
@@ -214,6 +214,15 @@ func printInitResult(result *commands.InitResult, scan *commands.InitScanResult,
 	if result.SkillsIncluded > 0 {
 		fmt.Printf("  Skills:      %d included\n", result.SkillsIncluded)
 	}
+	if result.AgentsIncluded > 0 {
+		fmt.Printf("  Agents:      %d included\n", result.AgentsIncluded)
+	}
+	if result.OutputStylesIncluded > 0 {
+		fmt.Printf("  Output styles: %d included\n", result.OutputStylesIncluded)
+	}
+	if len(result.ScriptsIncluded) > 0 {
+		fmt.Printf("  Scripts:     %s\n", strings.Join(result.ScriptsIncluded, ", "))
+	}
 
 	if result.RemotePushed {
 		fmt.Println()
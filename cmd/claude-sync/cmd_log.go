@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ruminaider/claude-sync/internal/audit"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logCategory string
+	logItem     string
+	logCommand  string
+	logSince    string
+	logUntil    string
+	logLimit    int
+	logJSON     bool
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show what pull, push, approve and rollback changed on this machine",
+	Long: `Show the audit log, newest first. Each pull, push, approve and rollback
+records the sync repo commit, subscription commits, active profile and every
+item it applied, skipped (with the reason) or removed.
+
+--since and --until take a date (2026-03-01), an RFC 3339 time, or an age
+such as 7d or 12h. --until includes the whole of the day it names.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		q := audit.Query{Command: logCommand, Category: logCategory, Name: logItem}
+		now := time.Now()
+		var err error
+		if logSince != "" {
+			if q.Since, err = audit.ParseTime(logSince, now, false); err != nil {
+				return err
+			}
+		}
+		if logUntil != "" {
+			if q.Until, err = audit.ParseTime(logUntil, now, true); err != nil {
+				return err
+			}
+		}
+
+		entries, err := audit.Read(paths.SyncDir())
+		if err != nil {
+			return err
+		}
+		entries = q.Filter(entries)
+		if logLimit > 0 && len(entries) > logLimit {
+			entries = entries[len(entries)-logLimit:]
+		}
+
+		if logJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, e := range entries {
+				if err := enc.Encode(e); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No matching audit entries.")
+			return nil
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			if i < len(entries)-1 {
+				fmt.Println()
+			}
+			line := fmt.Sprintf("%s  %-8s", e.Time.Local().Format("2006-01-02 15:04"), e.Command)
+			if len(e.Commit) >= 7 {
+				line += "  " + e.Commit[:7]
+			}
+			if e.Profile != "" {
+				line += "  profile: " + e.Profile
+			}
+			fmt.Println(line)
+			subs := make([]string, 0, len(e.Subscriptions))
+			for name := range e.Subscriptions {
+				subs = append(subs, name)
+			}
+			sort.Strings(subs)
+			for _, name := range subs {
+				sha := e.Subscriptions[name]
+				if len(sha) > 7 {
+					sha = sha[:7]
+				}
+				fmt.Printf("    subscription %s @ %s\n", name, sha)
+			}
+			if e.Error != "" {
+				fmt.Printf("    error: %s\n", e.Error)
+			}
+			for _, item := range e.Items {
+				fmt.Printf("    %-8s %s\n", item.Action, item)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	logCmd.Flags().StringVar(&logCategory, "category", "", "Only show items in this category (e.g. hooks, mcp, permissions)")
+	logCmd.Flags().StringVar(&logItem, "item", "", "Only show items whose name contains this")
+	logCmd.Flags().StringVar(&logCommand, "command", "", "Only show entries for this command (pull, push, approve, rollback)")
+	logCmd.Flags().StringVar(&logSince, "since", "", "Only show entries from this time on")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "Only show entries before this time")
+	logCmd.Flags().IntVarP(&logLimit, "limit", "n", 20, "Number of entries to show (0 = all)")
+	logCmd.Flags().BoolVar(&logJSON, "json", false, "Output entries as JSON lines")
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/config"
 	"github.com/ruminaider/claude-sync/internal/memory"
 	"github.com/ruminaider/claude-sync/internal/paths"
@@ -212,6 +214,83 @@ var memoryRemoveCmd = &cobra.Command{
 	},
 }
 
+var memoryProjectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage synced per-project memory",
+}
+
+var memoryProjectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects whose memory is synced",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgData, err := os.ReadFile(paths.ConfigFile())
+		if err != nil {
+			return fmt.Errorf("reading config: %w", err)
+		}
+		cfg, err := config.Parse(cfgData)
+		if err != nil {
+			return err
+		}
+		if len(cfg.Memory.Projects) == 0 {
+			fmt.Println("No project memory is synced.")
+			return nil
+		}
+
+		local := make(map[string][]string)
+		for _, p := range commands.ScanLocalProjects(paths.ClaudeDir()) {
+			local[p.Identity] = append(local[p.Identity], p.Dir)
+		}
+		for _, id := range cfg.Memory.Projects {
+			dirs := local[id]
+			if len(dirs) == 0 {
+				fmt.Printf("  %s (not checked out here)\n", id)
+				continue
+			}
+			fmt.Printf("  %s → %s\n", id, strings.Join(dirs, ", "))
+		}
+		return nil
+	},
+}
+
+var memoryProjectAddCmd = &cobra.Command{
+	Use:   "add [dir]",
+	Short: "Sync the memory of the project at dir (default: current directory)",
+	Long: `Sync the memory Claude Code keeps for a project under ~/.claude/projects.
+
+The project is identified by its git origin remote and its path within the
+repository, so pull finds its memory on machines where it is checked out
+somewhere else.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		identity, result, err := commands.ProjectMemoryAdd(paths.ClaudeDir(), paths.SyncDir(), dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Syncing memory for %s (%d new, %d updated fragment(s))\n", identity, len(result.New), len(result.Updated))
+		fmt.Println("Run 'claude-sync push' to share it.")
+		return nil
+	},
+}
+
+var memoryProjectRemovePurge bool
+
+var memoryProjectRemoveCmd = &cobra.Command{
+	Use:   "remove <identity>",
+	Short: "Stop syncing a project's memory",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := commands.ProjectMemoryRemove(paths.SyncDir(), args[0], memoryProjectRemovePurge); err != nil {
+			return err
+		}
+		fmt.Printf("Stopped syncing memory for %s\n", args[0])
+		return nil
+	},
+}
+
 func init() {
 	memoryRemoveCmd.Flags().BoolVar(&memoryRemovePurge, "purge", false, "Also delete the fragment file")
 
@@ -220,5 +299,11 @@ func init() {
 	memoryCmd.AddCommand(memoryAddCmd)
 	memoryCmd.AddCommand(memoryRemoveCmd)
 
+	memoryProjectRemoveCmd.Flags().BoolVar(&memoryProjectRemovePurge, "purge", false, "Also delete the project's memory from the sync repo")
+	memoryProjectCmd.AddCommand(memoryProjectListCmd)
+	memoryProjectCmd.AddCommand(memoryProjectAddCmd)
+	memoryProjectCmd.AddCommand(memoryProjectRemoveCmd)
+	memoryCmd.AddCommand(memoryProjectCmd)
+
 	rootCmd.AddCommand(memoryCmd)
 }
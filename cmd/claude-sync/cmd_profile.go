@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/ruminaider/claude-sync/internal/commands"
@@ -33,7 +34,7 @@ var profileListCmd = &cobra.Command{
 			return nil
 		}
 
-		active, err := profiles.ReadActiveProfile(syncDir)
+		active, err := profiles.ReadActiveProfiles(syncDir)
 		if err != nil {
 			return err
 		}
@@ -45,7 +46,7 @@ var profileListCmd = &cobra.Command{
 			}
 
 			summary := profiles.ProfileSummary(p)
-			if name == active {
+			if slices.Contains(active, name) {
 				fmt.Printf("* %s: %s\n", name, summary)
 			} else {
 				fmt.Printf("  %s: %s\n", name, summary)
@@ -57,25 +58,35 @@ var profileListCmd = &cobra.Command{
 }
 
 var profileShowCmd = &cobra.Command{
-	Use:   "show",
-	Short: "Show active profile and resolved plugin list",
+	Use:   "show [name...]",
+	Short: "Show profiles (default: the active ones), fully resolved, and the resolved plugin list",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		syncDir := paths.SyncDir()
 
-		active, err := profiles.ReadActiveProfile(syncDir)
+		active, err := profiles.ReadActiveProfiles(syncDir)
 		if err != nil {
 			return err
 		}
+		if len(args) > 0 {
+			active = args
+		}
 
-		if active == "" {
+		var resolvedProfile profiles.ResolvedProfile
+		if len(active) == 0 {
 			fmt.Println("No profile active (using base only)")
 		} else {
-			p, err := profiles.ReadProfile(syncDir, active)
+			var conflicts []profiles.Conflict
+			resolvedProfile, conflicts, err = profiles.ResolveProfiles(syncDir, active)
 			if err != nil {
 				return err
 			}
-			fmt.Printf("Active profile: %s\n", active)
-			fmt.Printf("  %s\n", profiles.ProfileSummary(p))
+			fmt.Printf("Profile: %s\n", resolvedProfile.Name)
+			if len(resolvedProfile.Chain) > 1 {
+				fmt.Printf("  Applied in order: %s\n", strings.Join(resolvedProfile.Chain, " -> "))
+			}
+			fmt.Printf("  %s\n", profiles.ProfileSummary(resolvedProfile.Profile))
+			printResolvedItems(resolvedProfile)
+			printProfileConflicts(conflicts)
 		}
 
 		// Read base config to get plugin keys.
@@ -94,12 +105,8 @@ var profileShowCmd = &cobra.Command{
 
 		// Merge with active profile if one is set.
 		resolved := basePlugins
-		if active != "" {
-			p, err := profiles.ReadProfile(syncDir, active)
-			if err != nil {
-				return err
-			}
-			resolved = profiles.MergePlugins(basePlugins, p)
+		if len(active) > 0 {
+			resolved = profiles.MergePlugins(basePlugins, resolvedProfile.Profile)
 		}
 
 		fmt.Println()
@@ -116,16 +123,69 @@ var profileShowCmd = &cobra.Command{
 	},
 }
 
+// printResolvedItems lists every directive of a resolved profile, grouped by
+// section, with the profile in the extends chain it came from.
+func printResolvedItems(r profiles.ResolvedProfile) {
+	items := r.Items()
+	if len(items) == 0 {
+		return
+	}
+
+	section := ""
+	fmt.Println()
+	fmt.Println("Resolved profile:")
+	for _, item := range items {
+		if item.Section != section {
+			section = item.Section
+			fmt.Printf("  %s:\n", section)
+		}
+		fmt.Printf("    %s  (from %s)\n", resolvedItemLabel(r, item), item.Origin)
+	}
+}
+
+// resolvedItemLabel formats one directive of r for printResolvedItems.
+func resolvedItemLabel(r profiles.ResolvedProfile, item profiles.ResolvedItem) string {
+	switch item.Op {
+	case "add":
+		return "+ " + item.Item
+	case "remove":
+		return "- " + item.Item
+	case "set":
+		var values map[string]any
+		switch item.Section {
+		case "settings":
+			values = r.Profile.Settings
+		case "keybindings":
+			values = r.Profile.Keybindings.Override
+		case "vars":
+			values = r.Profile.Vars
+		}
+		return fmt.Sprintf("%s: %v", item.Item, values[item.Item])
+	}
+	return item.Op + " " + item.Item
+}
+
+// printProfileConflicts warns about items the active profiles disagree on.
+func printProfileConflicts(conflicts []profiles.Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n⚠ %d conflict(s) between active profiles:\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "  • %s\n", c)
+	}
+}
+
 var profileSetNone bool
 
 var profileSetCmd = &cobra.Command{
-	Use:   "set [name]",
-	Short: "Set the active profile",
+	Use:   "set [name...]",
+	Short: "Set the active profile(s); later profiles take precedence",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if profileSetNone {
 			return nil
 		}
-		if len(args) != 1 {
+		if len(args) == 0 {
 			return fmt.Errorf("requires a profile name argument (or use --none to deactivate)")
 		}
 		return nil
@@ -149,40 +209,46 @@ var profileSetCmd = &cobra.Command{
 			return nil
 		}
 
-		name := args[0]
-
-		// Validate that the profile exists.
+		// Validate that the profiles exist.
 		names, err := profiles.ListProfiles(syncDir)
 		if err != nil {
 			return err
 		}
 
-		found := false
-		for _, n := range names {
-			if n == name {
-				found = true
-				break
+		for i, name := range args {
+			if slices.Contains(args[:i], name) {
+				return fmt.Errorf("profile %q listed more than once", name)
 			}
-		}
-		if !found {
-			available := strings.Join(names, ", ")
-			if available == "" {
-				return fmt.Errorf("profile %q not found (no profiles configured)", name)
+			if !slices.Contains(names, name) {
+				available := strings.Join(names, ", ")
+				if available == "" {
+					return fmt.Errorf("profile %q not found (no profiles configured)", name)
+				}
+				return fmt.Errorf("profile %q not found (available: %s)", name, available)
 			}
-			return fmt.Errorf("profile %q not found (available: %s)", name, available)
 		}
 
-		if err := profiles.WriteActiveProfile(syncDir, name); err != nil {
+		// Refuse to activate profiles whose extends chains are broken.
+		if _, _, err := profiles.ResolveProfiles(syncDir, args); err != nil {
 			return err
 		}
-		fmt.Printf("Active profile set to %q\n", name)
+
+		if err := profiles.WriteActiveProfiles(syncDir, args); err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			fmt.Printf("Active profile set to %q\n", args[0])
+		} else {
+			fmt.Printf("Active profiles set to %s (later ones take precedence)\n", strings.Join(args, ", "))
+		}
 
 		// Re-apply config.
-		_, err = commands.Pull(claudeDir, syncDir, true)
+		result, err := commands.Pull(claudeDir, syncDir, true)
 		if err != nil {
 			return fmt.Errorf("re-applying config: %w", err)
 		}
 		fmt.Println("Config re-applied.")
+		printProfileConflicts(result.ProfileConflicts)
 
 		return nil
 	},
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvedItemLabel(t *testing.T) {
+	r := profiles.ResolvedProfile{Profile: profiles.Profile{
+		Settings:    map[string]any{"model": "opus"},
+		Keybindings: profiles.ProfileKeybindings{Override: map[string]any{"ctrl+k": "clear"}},
+		Vars:        map[string]any{"team": "platform"},
+	}}
+
+	tests := []struct {
+		item profiles.ResolvedItem
+		want string
+	}{
+		{profiles.ResolvedItem{Section: "plugins", Op: "add", Item: "a@m"}, "+ a@m"},
+		{profiles.ResolvedItem{Section: "plugins", Op: "remove", Item: "b@m"}, "- b@m"},
+		{profiles.ResolvedItem{Section: "settings", Op: "set", Item: "model"}, "model: opus"},
+		{profiles.ResolvedItem{Section: "keybindings", Op: "set", Item: "ctrl+k"}, "ctrl+k: clear"},
+		{profiles.ResolvedItem{Section: "vars", Op: "set", Item: "team"}, "team: platform"},
+		{profiles.ResolvedItem{Section: "permissions", Op: "allow", Item: "Read"}, "allow Read"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, resolvedItemLabel(r, tt.item), tt.item.Key())
+	}
+}
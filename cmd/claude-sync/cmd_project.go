@@ -10,6 +10,7 @@ import (
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/paths"
 	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/ruminaider/claude-sync/internal/project"
 	"github.com/spf13/cobra"
 )
 
@@ -174,6 +175,82 @@ Managed keys will remain but will no longer be updated by pull.`,
 	},
 }
 
+var projectRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Inspect automatic profile selection rules",
+	Long: `Inspect profile-rules.yaml in the sync repo.
+
+Rules map project directories (path globs) and git remotes to a profile and
+projected keys. When pull runs in an unmanaged project that matches a rule,
+the project is initialized automatically instead of prompting.
+
+  rules:
+    - path: ~/work/**
+      profile: work
+    - remote: github.com/acme/*
+      profile: acme
+      keys: [hooks, permissions, mcp]`,
+}
+
+var projectRulesTestCmd = &cobra.Command{
+	Use:   "test [dir]",
+	Short: "Show which profile rule matches a directory",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		result, err := commands.ProjectRulesTest(paths.SyncDir(), dir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Project: %s\n", result.Dir)
+		if result.Remote != "" {
+			fmt.Printf("Remote:  %s\n", result.Remote)
+		} else {
+			fmt.Println("Remote:  (none)")
+		}
+		if len(result.Evaluations) == 0 {
+			fmt.Printf("\nNo rules defined. Add them to %s in the sync repo.\n", project.RulesFileName)
+			return nil
+		}
+
+		fmt.Println()
+		matched := false
+		for i, e := range result.Evaluations {
+			mark := "✗"
+			if e.Matched && !matched {
+				mark = "✓"
+				matched = true
+			} else if e.Matched {
+				mark = "·" // matches, but an earlier rule wins
+			}
+			fmt.Printf("  %s %d. %s → %s\n", mark, i+1, e.Rule, profileLabel(e.Rule.Profile))
+			fmt.Printf("       %s\n", e.Reason)
+		}
+
+		fmt.Println()
+		if result.Match == nil {
+			fmt.Println("No rule matches; pull will offer to initialize the project interactively.")
+			return nil
+		}
+		fmt.Printf("Profile: %s\n", profileLabel(result.Match.Profile))
+		fmt.Printf("Projected keys: %s\n", strings.Join(result.Match.ProjectedKeys(), ", "))
+		return nil
+	},
+}
+
+// profileLabel renders an empty profile name as "(base)".
+func profileLabel(name string) string {
+	if name == "" {
+		return "(base)"
+	}
+	return name
+}
+
 func promptProjectProfile(available []string, active string) (string, error) {
 	options := make([]huh.Option[string], 0, len(available)+1)
 	for _, name := range available {
@@ -221,4 +298,7 @@ func init() {
 	projectCmd.AddCommand(projectInitCmd)
 	projectCmd.AddCommand(projectListCmd)
 	projectCmd.AddCommand(projectRemoveCmd)
+
+	projectRulesCmd.AddCommand(projectRulesTestCmd)
+	projectCmd.AddCommand(projectRulesCmd)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/huh"
@@ -18,6 +19,7 @@ import (
 var quietFlag bool
 var autoFlag bool
 var forceFlag bool
+var pullDryRunFlag bool
 
 var pullCmd = &cobra.Command{
 	Use:   "pull",
@@ -29,6 +31,15 @@ var pullCmd = &cobra.Command{
 		claudeDir := paths.ClaudeDir()
 		syncDir := paths.SyncDir()
 
+		if pullDryRunFlag {
+			dry, err := commands.PullDryRun(claudeDir, syncDir)
+			if err != nil {
+				return err
+			}
+			printPullDryRun(dry)
+			return nil
+		}
+
 		if autoFlag {
 			projectDir := readCWDFromStdin()
 			result, err = commands.PullWithOptions(commands.PullOptions{
@@ -168,6 +179,15 @@ var pullCmd = &cobra.Command{
 					}
 				}
 			}
+			// In auto mode, say when team config may be out of date or untrusted.
+			if autoFlag {
+				for _, w := range result.SubscriptionWarnings {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+				}
+				for _, u := range result.UntrustedCommits {
+					fmt.Fprintf(os.Stderr, "Warning: %s; its hooks, MCP servers and permissions were not applied\n", u)
+				}
+			}
 			// In auto mode, still show pending high-risk warnings.
 			if autoFlag && len(result.PendingHighRisk) > 0 {
 				fmt.Fprintf(os.Stderr, "%d high-risk change(s) deferred. Run 'claude-sync approve' to apply:\n", len(result.PendingHighRisk))
@@ -215,11 +235,11 @@ func promptProjectInit(claudeDir, syncDir, projectDir string, profileNames []str
 	}
 
 	// Show profile picker.
-	active, _ := profiles.ReadActiveProfile(syncDir)
+	active, _ := profiles.ReadActiveProfiles(syncDir)
 	options := make([]huh.Option[string], 0, len(profileNames)+1)
 	for _, name := range profileNames {
 		label := capitalize(name)
-		if name == active {
+		if slices.Contains(active, name) {
 			label += " (global default)"
 		}
 		options = append(options, huh.NewOption(label, name))
@@ -274,4 +294,5 @@ func init() {
 	pullCmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress output")
 	pullCmd.Flags().BoolVar(&autoFlag, "auto", false, "Auto mode: apply safe changes, defer high-risk to pending")
 	pullCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Overwrite locally modified managed files")
+	pullCmd.Flags().BoolVar(&pullDryRunFlag, "dry-run", false, "Show what pull would apply from the local sync repo without fetching or changing anything")
 }
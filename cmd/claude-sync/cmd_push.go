@@ -39,6 +39,9 @@ var pushCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if warning := commands.TemplateEditedWarning(scan.TemplateEdited); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
 
 		// Detect duplicate plugins (same name, different sources).
 		dupes, dupErr := plugins.DetectDuplicates(claudeDir)
@@ -101,21 +104,27 @@ var pushCmd = &cobra.Command{
 		if pushAutoFlag {
 			headBefore, _ := git.RevParse(syncDir, "HEAD")
 			err := commands.PushApply(commands.PushApplyOptions{
-				ClaudeDir:         claudeDir,
-				SyncDir:           syncDir,
-				AddPlugins:        scan.AddedPlugins,
-				RemovePlugins:     scan.RemovedPlugins,
-				UpdatePermissions: scan.ChangedPermissions,
-				UpdateClaudeMD:    scan.ChangedClaudeMD != nil,
-				UpdateMCP:         scan.ChangedMCP,
-				UpdateKeybindings: scan.ChangedKeybindings,
-				UpdateCommands:    scan.ChangedCommands,
-				UpdateSkills:      scan.ChangedSkills,
-				OrphanedCommands:  scan.OrphanedCommands,
-				OrphanedSkills:    scan.OrphanedSkills,
-				DirtyWorkingTree:  scan.DirtyWorkingTree,
-				Message:           pushMessage,
-				Force:             pushForceFlag,
+				ClaudeDir:            claudeDir,
+				SyncDir:              syncDir,
+				AddPlugins:           scan.AddedPlugins,
+				RemovePlugins:        scan.RemovedPlugins,
+				UpdatePermissions:    scan.ChangedPermissions,
+				UpdateClaudeMD:       scan.ChangedClaudeMD != nil,
+				UpdateMCP:            scan.ChangedMCP,
+				UpdateKeybindings:    scan.ChangedKeybindings,
+				UpdateCommands:       scan.ChangedCommands,
+				UpdateSkills:         scan.ChangedSkills,
+				UpdateAgents:         scan.ChangedAgents,
+				UpdateOutputStyles:   scan.ChangedOutputStyles,
+				UpdateScripts:        scan.ChangedScripts,
+				UpdateProjectMemory:  scan.ChangedProjectMemory,
+				OrphanedCommands:     scan.OrphanedCommands,
+				OrphanedSkills:       scan.OrphanedSkills,
+				OrphanedAgents:       scan.OrphanedAgents,
+				OrphanedOutputStyles: scan.OrphanedOutputStyles,
+				DirtyWorkingTree:     scan.DirtyWorkingTree,
+				Message:              pushMessage,
+				Force:                pushForceFlag,
 			})
 			if err != nil {
 				var nffErr *git.NonFastForwardError
@@ -146,8 +155,12 @@ var pushCmd = &cobra.Command{
 		updateKB := scan.ChangedKeybindings
 		updateCmds := scan.ChangedCommands
 		updateSkills := scan.ChangedSkills
-		hasOrphans := len(scan.OrphanedCommands) > 0 || len(scan.OrphanedSkills) > 0
-		hasNonPluginChanges := updatePerms || updateClaudeMD || updateMCP || updateKB || updateCmds || updateSkills || hasOrphans || scan.DirtyWorkingTree
+		updateAgents := scan.ChangedAgents
+		updateStyles := scan.ChangedOutputStyles
+		updateScripts := scan.ChangedScripts
+		updateProjectMemory := scan.ChangedProjectMemory
+		hasOrphans := len(scan.OrphanedCommands) > 0 || len(scan.OrphanedSkills) > 0 || len(scan.OrphanedAgents) > 0 || len(scan.OrphanedOutputStyles) > 0
+		hasNonPluginChanges := updatePerms || updateClaudeMD || updateMCP || updateKB || updateCmds || updateSkills || updateAgents || updateStyles || updateScripts || len(updateProjectMemory) > 0 || hasOrphans || scan.DirtyWorkingTree
 
 		var selectedAdd []string
 		var selectedRemove []string
@@ -204,12 +217,30 @@ var pushCmd = &cobra.Command{
 			if updateSkills {
 				kinds = append(kinds, "skills")
 			}
+			if updateAgents {
+				kinds = append(kinds, "agents")
+			}
+			if updateStyles {
+				kinds = append(kinds, "output styles")
+			}
+			if updateScripts {
+				kinds = append(kinds, "scripts")
+			}
+			if len(updateProjectMemory) > 0 {
+				kinds = append(kinds, fmt.Sprintf("memory for %d project(s)", len(updateProjectMemory)))
+			}
 			if len(scan.OrphanedCommands) > 0 {
 				kinds = append(kinds, fmt.Sprintf("removing %d orphaned command(s)", len(scan.OrphanedCommands)))
 			}
 			if len(scan.OrphanedSkills) > 0 {
 				kinds = append(kinds, fmt.Sprintf("removing %d orphaned skill(s)", len(scan.OrphanedSkills)))
 			}
+			if len(scan.OrphanedAgents) > 0 {
+				kinds = append(kinds, fmt.Sprintf("removing %d orphaned agent(s)", len(scan.OrphanedAgents)))
+			}
+			if len(scan.OrphanedOutputStyles) > 0 {
+				kinds = append(kinds, fmt.Sprintf("removing %d orphaned output style(s)", len(scan.OrphanedOutputStyles)))
+			}
 			if scan.DirtyWorkingTree && len(kinds) == 0 {
 				kinds = append(kinds, "uncommitted changes from config update")
 			}
@@ -246,17 +277,23 @@ var pushCmd = &cobra.Command{
 
 		// Show final summary and confirm before pushing.
 		effectiveScan := &commands.PushScanResult{
-			AddedPlugins:       selectedAdd,
-			RemovedPlugins:     selectedRemove,
-			ChangedPermissions: updatePerms,
-			ChangedClaudeMD:    scan.ChangedClaudeMD,
-			ChangedMCP:         updateMCP,
-			ChangedKeybindings: updateKB,
-			ChangedCommands:    updateCmds,
-			ChangedSkills:      updateSkills,
-			OrphanedCommands:   scan.OrphanedCommands,
-			OrphanedSkills:     scan.OrphanedSkills,
-			DirtyWorkingTree:   scan.DirtyWorkingTree,
+			AddedPlugins:         selectedAdd,
+			RemovedPlugins:       selectedRemove,
+			ChangedPermissions:   updatePerms,
+			ChangedClaudeMD:      scan.ChangedClaudeMD,
+			ChangedMCP:           updateMCP,
+			ChangedKeybindings:   updateKB,
+			ChangedCommands:      updateCmds,
+			ChangedSkills:        updateSkills,
+			ChangedAgents:        updateAgents,
+			ChangedOutputStyles:  updateStyles,
+			ChangedScripts:       updateScripts,
+			ChangedProjectMemory: updateProjectMemory,
+			OrphanedCommands:     scan.OrphanedCommands,
+			OrphanedSkills:       scan.OrphanedSkills,
+			OrphanedAgents:       scan.OrphanedAgents,
+			OrphanedOutputStyles: scan.OrphanedOutputStyles,
+			DirtyWorkingTree:     scan.DirtyWorkingTree,
 		}
 		fmt.Println()
 		fmt.Println(commands.PushPreviewSummary(effectiveScan))
@@ -274,23 +311,29 @@ var pushCmd = &cobra.Command{
 
 		headBefore, _ := git.RevParse(syncDir, "HEAD")
 		err = commands.PushApply(commands.PushApplyOptions{
-			ClaudeDir:         claudeDir,
-			SyncDir:           syncDir,
-			AddPlugins:        selectedAdd,
-			RemovePlugins:     selectedRemove,
-			ExcludePlugins:    unselectedPlugins,
-			ProfileTarget:     profileTarget,
-			Message:           pushMessage,
-			UpdatePermissions: updatePerms,
-			UpdateClaudeMD:    updateClaudeMD,
-			UpdateMCP:         updateMCP,
-			UpdateKeybindings: updateKB,
-			UpdateCommands:    updateCmds,
-			UpdateSkills:      updateSkills,
-			OrphanedCommands:  scan.OrphanedCommands,
-			OrphanedSkills:    scan.OrphanedSkills,
-			DirtyWorkingTree:  scan.DirtyWorkingTree,
-			Force:             pushForceFlag,
+			ClaudeDir:            claudeDir,
+			SyncDir:              syncDir,
+			AddPlugins:           selectedAdd,
+			RemovePlugins:        selectedRemove,
+			ExcludePlugins:       unselectedPlugins,
+			ProfileTarget:        profileTarget,
+			Message:              pushMessage,
+			UpdatePermissions:    updatePerms,
+			UpdateClaudeMD:       updateClaudeMD,
+			UpdateMCP:            updateMCP,
+			UpdateKeybindings:    updateKB,
+			UpdateCommands:       updateCmds,
+			UpdateSkills:         updateSkills,
+			UpdateAgents:         updateAgents,
+			UpdateOutputStyles:   updateStyles,
+			UpdateScripts:        updateScripts,
+			UpdateProjectMemory:  updateProjectMemory,
+			OrphanedCommands:     scan.OrphanedCommands,
+			OrphanedSkills:       scan.OrphanedSkills,
+			OrphanedAgents:       scan.OrphanedAgents,
+			OrphanedOutputStyles: scan.OrphanedOutputStyles,
+			DirtyWorkingTree:     scan.DirtyWorkingTree,
+			Force:                pushForceFlag,
 		})
 		if err != nil {
 			var nffErr *git.NonFastForwardError
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the local store that resolves ${NAME} references in MCP configs",
+	Long: `MCP env values like ${RENDER_API_KEY} are resolved at pull time from, in
+order:
+
+  1. the local secret store (~/.claude-sync/secrets.age, never committed),
+  2. secret_command in user-preferences.yaml, e.g. "pass show {name}",
+  3. the environment.
+
+The store is age-encrypted to the identity at ~/.config/claude-sync/identity.txt
+(or $CLAUDE_SYNC_IDENTITY), which is created on first use.
+
+Team secrets are values in config.yaml encrypted to every recipient listed
+there (see 'secrets share' and 'secrets recipients'). Pull decrypts them with
+the same identity.`,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <name> [value]",
+	Short: "Store a secret (prompts for the value if omitted)",
+	Long: `Store a secret in the local store. If value is omitted it is read from a
+hidden prompt, or from stdin when stdin is not a terminal, so it doesn't end
+up in shell history.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		var value string
+		switch {
+		case len(args) == 2:
+			value = args[1]
+		case term.IsTerminal(os.Stdin.Fd()):
+			err := huh.NewForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title(fmt.Sprintf("Value for %s", name)).
+						EchoMode(huh.EchoModePassword).
+						Value(&value),
+				),
+			).Run()
+			if err != nil {
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		default:
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("reading value from stdin: %w", err)
+			}
+			value = strings.TrimRight(line, "\r\n")
+		}
+		if value == "" {
+			return fmt.Errorf("empty value for %s", name)
+		}
+
+		if err := commands.SecretsSet(paths.SyncDir(), name, value); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Stored %s\n", name)
+		return nil
+	},
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print a secret from the local store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, ok, err := commands.SecretsGet(paths.SyncDir(), args[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("secret %s not found in the local store", args[0])
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List secret names in the local store",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := commands.SecretsList(paths.SyncDir())
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Println("No secrets stored. Add one with 'claude-sync secrets set <name>'.")
+			return nil
+		}
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
+		}
+		return nil
+	},
+}
+
+var secretsRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a secret from the local store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := commands.SecretsRemove(paths.SyncDir(), args[0])
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("secret %s not found in the local store", args[0])
+		}
+		fmt.Printf("✓ Removed %s\n", args[0])
+		return nil
+	},
+}
+
+var secretsPubkeyCmd = &cobra.Command{
+	Use:   "pubkey",
+	Short: "Print this machine's public key for team secrets",
+	Long: `Print the age public key of this machine's identity, creating the identity
+if needed. Send it to a team member who can already read the team's secrets;
+they run 'claude-sync secrets recipients add <you> <key>' and push.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := commands.SecretsPublicKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	},
+}
+
+var secretsRecipientsCmd = &cobra.Command{
+	Use:   "recipients",
+	Short: "List the team members team secrets are encrypted to",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recipients, err := commands.SecretsRecipients(paths.SyncDir())
+		if err != nil {
+			return err
+		}
+		if len(recipients) == 0 {
+			fmt.Println("No recipients. Add one with 'claude-sync secrets recipients add <name> <age1...>'.")
+			return nil
+		}
+		names := make([]string, 0, len(recipients))
+		for name := range recipients {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-16s %s\n", name, recipients[name])
+		}
+		return nil
+	},
+}
+
+var secretsRecipientsAddCmd = &cobra.Command{
+	Use:   "add <name> <public-key>",
+	Short: "Add a recipient and re-encrypt team secrets to include them",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := commands.SecretsAddRecipient(paths.SyncDir(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Added %s; re-encrypted %d value(s)\n", args[0], n)
+		fmt.Println("Run 'claude-sync push' to share the change.")
+		return nil
+	},
+}
+
+var secretsRecipientsRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a recipient and re-encrypt team secrets without them",
+	Long: `Remove a recipient and re-encrypt every team secret to the remaining
+recipients. The removed member can still read old values from git history,
+so rotate any token they should no longer have.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := commands.SecretsRemoveRecipient(paths.SyncDir(), args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed %s; re-encrypted %d value(s)\n", args[0], n)
+		fmt.Println("Run 'claude-sync push' to share the change.")
+		return nil
+	},
+}
+
+var secretsShareCmd = &cobra.Command{
+	Use:   "share <target> [value]",
+	Short: "Encrypt a config.yaml value to the team's recipients",
+	Long: `Encrypt an MCP env value or a setting in config.yaml to every recipient, so
+it can be committed to the shared repo. Pull decrypts it for anyone whose
+identity is a recipient.
+
+Targets:
+  mcp.<server>.env.<KEY>   an MCP server env var
+  settings.<key>[.<key>]   a settings value, e.g. settings.env.INTERNAL_TOKEN
+
+If value is omitted, the value currently in config.yaml is encrypted.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := commands.ParseSecretTarget(args[0])
+		if err != nil {
+			return err
+		}
+		var value string
+		if len(args) == 2 {
+			value = args[1]
+		}
+		if err := commands.SecretsShare(paths.SyncDir(), target, value); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Encrypted %s\n", target)
+		fmt.Println("Run 'claude-sync push' to share the change.")
+		return nil
+	},
+}
+
+func init() {
+	secretsRecipientsCmd.AddCommand(secretsRecipientsAddCmd)
+	secretsRecipientsCmd.AddCommand(secretsRecipientsRmCmd)
+
+	secretsCmd.AddCommand(secretsPubkeyCmd)
+	secretsCmd.AddCommand(secretsRecipientsCmd)
+	secretsCmd.AddCommand(secretsShareCmd)
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsGetCmd)
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsRmCmd)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "List and restore snapshots of local files taken before each pull",
+	Long: `Before pull writes anything, it saves a copy of every local file it may
+touch (settings.json, CLAUDE.md, keybindings.json, .mcp.json files, memory,
+commands, skills and the current project's settings.local.json) to
+~/.claude-sync/snapshots/. The newest 10 are kept; set sync.snapshot_keep in
+user-preferences.yaml to change that.`,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifests, err := commands.SnapshotList(paths.SyncDir())
+		if err != nil {
+			return err
+		}
+		if len(manifests) == 0 {
+			fmt.Println("No snapshots yet. One is taken before each pull.")
+			return nil
+		}
+		for _, m := range manifests {
+			fmt.Printf("  %s  %s  %-24s %d file(s)\n", m.ID, m.Created.Local().Format("2006-01-02 15:04"), m.Reason, len(m.Files))
+		}
+		return nil
+	},
+}
+
+var snapshotShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the files in a snapshot and how they differ from now",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, statuses, err := commands.SnapshotShow(paths.SyncDir(), args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Snapshot %s (%s), taken %s\n\n", m.ID, m.Reason, m.Created.Local().Format("2006-01-02 15:04:05"))
+		changed := 0
+		for _, s := range statuses {
+			if s.Status != "unchanged" {
+				changed++
+			}
+			fmt.Printf("  %-9s  %s\n", s.Status, s.Path)
+		}
+		fmt.Printf("\n%d of %d file(s) differ from the current state.\n", changed, len(statuses))
+		return nil
+	},
+}
+
+var snapshotRestoreYes bool
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Put the files in a snapshot back in place",
+	Long: `Restore every file captured in a snapshot. Files that didn't exist when
+the snapshot was taken are deleted. The current state is snapshotted first,
+so a restore can be undone by restoring that snapshot.
+
+Pull treats restored files as local edits and leaves them alone; use
+'claude-sync pull --force' to apply the config over them again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		syncDir := paths.SyncDir()
+		if !snapshotRestoreYes {
+			_, statuses, err := commands.SnapshotShow(syncDir, args[0])
+			if err != nil {
+				return err
+			}
+			changed := 0
+			for _, s := range statuses {
+				if s.Status != "unchanged" {
+					changed++
+				}
+			}
+			var confirm bool
+			err = huh.NewForm(
+				huh.NewGroup(
+					huh.NewConfirm().
+						Title(fmt.Sprintf("Restore snapshot %s?", args[0])).
+						Description(fmt.Sprintf("%d file(s) will change.", changed)).
+						Affirmative("Yes, restore").
+						Negative("Cancel").
+						Value(&confirm),
+				),
+			).Run()
+			if err != nil || !confirm {
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		}
+
+		result, err := commands.SnapshotRestore(syncDir, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Restored %d file(s)\n", len(result.Restored))
+		for _, p := range result.Removed {
+			fmt.Printf("✓ Removed %s (not present in snapshot)\n", p)
+		}
+		fmt.Printf("  Previous state saved as snapshot %s\n", result.Backup.ID)
+		return nil
+	},
+}
+
+func init() {
+	snapshotRestoreCmd.Flags().BoolVarP(&snapshotRestoreYes, "yes", "y", false, "Skip confirmation prompt")
+
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotShowCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+}
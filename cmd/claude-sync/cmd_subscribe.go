@@ -20,7 +20,9 @@ var subscribeCmd = &cobra.Command{
 	Short: "Subscribe to another team's claude-sync config",
 	Long: `Subscribe to another team's claude-sync config repo.
 Opens a TUI to browse and select which items (MCP servers, plugins, settings, etc.) to include.
-The optional name argument sets the subscription name; defaults to the repo name.`,
+The optional name argument sets the subscription name; defaults to the repo name.
+With --lock, pulls stay on the commit fetched now until
+'claude-sync subscriptions update <name>' advances it.`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		syncDir := paths.SyncDir()
@@ -82,7 +84,8 @@ The optional name argument sets the subscription name; defaults to the repo name
 
 		// Build the subscription entry.
 		entry := config.SubscriptionEntry{
-			URL: url,
+			URL:  url,
+			Lock: subscribeLock,
 		}
 		if len(result.Categories) > 0 {
 			entry.Categories = make(map[string]any, len(result.Categories))
@@ -128,6 +131,17 @@ The optional name argument sets the subscription name; defaults to the repo name
 		}
 		subscriptions.UpdateState(&state, name, sha, acceptedItems)
 		subscriptions.WriteState(syncDir, state)
+		if subscribeLock {
+			lock, err := subscriptions.ReadLock(syncDir)
+			if err != nil {
+				return err
+			}
+			lock.Set(name, sha)
+			if err := subscriptions.WriteLock(syncDir, lock); err != nil {
+				return err
+			}
+			gitpkg.Add(syncDir, "subscriptions.lock")
+		}
 
 		// Commit.
 		gitpkg.Add(syncDir, "config.yaml")
@@ -140,6 +154,12 @@ The optional name argument sets the subscription name; defaults to the repo name
 	},
 }
 
+var subscribeLock bool
+
+func init() {
+	subscribeCmd.Flags().BoolVar(&subscribeLock, "lock", false, "Stay on the fetched commit until 'subscriptions update' advances it")
+}
+
 // deriveSubName extracts a short name from a git URL.
 func deriveSubName(url string) string {
 	// Handle SSH URLs: git@github.com:org/repo.git
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/huh"
 	"github.com/ruminaider/claude-sync/internal/config"
+	gitpkg "github.com/ruminaider/claude-sync/internal/git"
 	"github.com/ruminaider/claude-sync/internal/paths"
 	"github.com/ruminaider/claude-sync/internal/subscriptions"
 	"github.com/spf13/cobra"
@@ -37,6 +39,7 @@ var subscriptionsCmd = &cobra.Command{
 		}
 
 		state, _ := subscriptions.ReadState(syncDir)
+		lock, _ := subscriptions.ReadLock(syncDir)
 
 		fmt.Println("SUBSCRIPTIONS")
 
@@ -63,6 +66,13 @@ var subscriptionsCmd = &cobra.Command{
 			} else {
 				fmt.Println("    Last fetched: never")
 			}
+			if sub.Lock {
+				if pin := lock.Commit(name); pin != "" {
+					fmt.Printf("    Locked at: %s\n", shortSHA(pin))
+				} else {
+					fmt.Println("    Locked at: next pull")
+				}
+			}
 
 			// Show category summary.
 			if sub.Categories != nil {
@@ -94,6 +104,122 @@ var subscriptionsCmd = &cobra.Command{
 	},
 }
 
+var subscriptionsUpdateYes bool
+
+var subscriptionsUpdateCmd = &cobra.Command{
+	Use:   "update <name>",
+	Short: "Advance a locked subscription to its latest commit",
+	Long: `Fetch the latest commit of a subscription with lock: true, show what
+changed since the commit in subscriptions.lock, and advance the lock if
+you accept. Pulls stay on the locked commit until then.
+
+The lock is committed to your config repo: push to share it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		syncDir := paths.SyncDir()
+		name := args[0]
+
+		cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+		if err != nil {
+			return fmt.Errorf("reading config: %w", err)
+		}
+		cfg, err := config.Parse(cfgData)
+		if err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+		sub, exists := cfg.Subscriptions[name]
+		if !exists {
+			return fmt.Errorf("subscription %q not found", name)
+		}
+		if !sub.Lock {
+			return fmt.Errorf("subscription %q isn't locked; it follows %s on every pull. Set lock: true on it in config.yaml to pin it", name, sub.URL)
+		}
+
+		state, _ := subscriptions.ReadState(syncDir)
+		lock, err := subscriptions.ReadLock(syncDir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Fetching %s...\n", sub.URL)
+		update, err := subscriptions.PreviewUpdate(syncDir, name, sub, state, lock, subscriptions.DefaultFetchTimeout)
+		if err != nil {
+			return err
+		}
+		if update.UpToDate() {
+			fmt.Printf("%s is up to date (%s).\n", name, shortSHA(update.To))
+			return nil
+		}
+
+		from := shortSHA(update.From)
+		if from == "" {
+			from = "unlocked"
+		}
+		fmt.Printf("\n%s: %s -> %s\n", name, from, shortSHA(update.To))
+		printUpdateItems("New", update.New)
+		printUpdateItems("Changed", update.Changed)
+		printUpdateItems("Removed", update.Removed)
+		if len(update.New)+len(update.Changed)+len(update.Removed) == 0 {
+			fmt.Println("  No changes to the items you subscribe to.")
+		}
+		fmt.Println()
+
+		if !subscriptionsUpdateYes {
+			var confirm bool
+			err := huh.NewForm(
+				huh.NewGroup(
+					huh.NewConfirm().
+						Title(fmt.Sprintf("Lock %s at %s?", name, shortSHA(update.To))).
+						Affirmative("Yes, update").
+						Negative("Cancel").
+						Value(&confirm),
+				),
+			).Run()
+			if err != nil || !confirm {
+				if err := subscriptions.CheckoutLocked(syncDir, name, sub, state, lock); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		}
+
+		subscriptions.AcceptUpdate(&state, &lock, update)
+		if err := subscriptions.WriteLock(syncDir, lock); err != nil {
+			return err
+		}
+		subscriptions.WriteState(syncDir, state)
+
+		gitpkg.Add(syncDir, "subscriptions.lock")
+		gitpkg.Commit(syncDir, fmt.Sprintf("Update subscription %s to %s", name, shortSHA(update.To)))
+
+		fmt.Printf("Locked %s at %s.\n", name, shortSHA(update.To))
+		fmt.Println("Run 'claude-sync pull' to apply it, and 'claude-sync push' to share the lock.")
+		return nil
+	},
+}
+
+// printUpdateItems prints one section of a subscription update, by category.
+func printUpdateItems(heading string, items map[string][]string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("  %s:\n", heading)
+	categories := make([]string, 0, len(items))
+	for cat := range items {
+		categories = append(categories, cat)
+	}
+	sort.Strings(categories)
+	for _, cat := range categories {
+		fmt.Printf("    %s: %s\n", titleCase(cat), strings.Join(items[cat], ", "))
+	}
+}
+
+func init() {
+	subscriptionsUpdateCmd.Flags().BoolVarP(&subscriptionsUpdateYes, "yes", "y", false, "Skip confirmation prompt")
+	subscriptionsCmd.AddCommand(subscriptionsUpdateCmd)
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%ds", int(d.Seconds()))
@@ -52,6 +52,15 @@ Subscription-provided items will be removed on the next pull.`,
 			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 		}
 		subscriptions.WriteState(syncDir, state)
+		if lock, err := subscriptions.ReadLock(syncDir); err == nil {
+			if _, locked := lock.Subscriptions[name]; locked {
+				delete(lock.Subscriptions, name)
+				if err := subscriptions.WriteLock(syncDir, lock); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+				gitpkg.Add(syncDir, "subscriptions.lock")
+			}
+		}
 
 		// Commit.
 		gitpkg.Add(syncDir, "config.yaml")
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/ruminaider/claude-sync/internal/project"
+	"github.com/ruminaider/claude-sync/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var validateSchema string
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check config files for errors (exits non-zero if any are found)",
+	Long: `Check a config repo, or a single file, against claude-sync's schemas and
+report each problem as file:line:column.
+
+With no path, the sync repo (~/.claude-sync) is checked. A directory is
+checked as a config repo: config.yaml, profiles/*.yaml, profile-rules.yaml and
+user-preferences.yaml. A directory holding only .claude/.claude-sync.yaml is
+checked as a project. A file is checked according to its name.
+
+Besides unknown keys and wrong types, validate reports malformed plugin keys
+(name@marketplace), hooks that look like JSON but don't parse, profile removes
+that match nothing, and CLAUDE.md or memory includes whose fragment files
+don't exist.
+
+Use --schema <kind> to print a JSON Schema for editors, where kind is one of
+config, profile, profile-rules, project or user-preferences.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if validateSchema != "" {
+			data, err := schema.Source(schema.Kind(validateSchema))
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		}
+
+		target := paths.SyncDir()
+		if len(args) == 1 {
+			target = args[0]
+		}
+		issues, err := validatePath(target)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("%d problem(s) found", len(issues))
+		}
+		fmt.Println("✓ No problems found")
+		return nil
+	},
+}
+
+func validatePath(target string) ([]commands.ValidationIssue, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return commands.ValidateFile(target)
+	}
+	if _, err := os.Stat(filepath.Join(target, "config.yaml")); err == nil {
+		return commands.ValidateSyncDir(target)
+	}
+	projectFile := filepath.Join(target, ".claude", project.ConfigFileName)
+	if _, err := os.Stat(projectFile); err == nil {
+		return commands.ValidateFile(projectFile)
+	}
+	return nil, fmt.Errorf("%s has neither config.yaml nor .claude/%s", target, project.ConfigFileName)
+}
+
+func init() {
+	validateCmd.Flags().StringVar(&validateSchema, "schema", "", "print the JSON Schema for a file kind and exit")
+}
@@ -46,6 +46,12 @@ func init() {
 	rootCmd.AddCommand(subscribeCmd)
 	rootCmd.AddCommand(unsubscribeCmd)
 	rootCmd.AddCommand(subscriptionsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(validateCmd)
 }
 
 func main() {
@@ -2,12 +2,34 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
+	"github.com/ruminaider/claude-sync/internal/claudemd"
 	"github.com/ruminaider/claude-sync/internal/commands"
 )
 
+// printPullDryRun displays what a pull would apply from the local sync repo.
+func printPullDryRun(result *commands.PullResult) {
+	for _, p := range result.ToInstall {
+		fmt.Printf("  Would install %s\n", p)
+	}
+	for _, p := range result.ToRemove {
+		fmt.Printf("  Would uninstall %s (exact sync mode)\n", p)
+	}
+	for _, mcpPath := range slices.Sorted(maps.Keys(result.MCPToRemove)) {
+		fmt.Printf("  Would remove MCP servers from %s (no longer in config): %s\n", mcpPath, strings.Join(result.MCPToRemove[mcpPath], ", "))
+	}
+	for _, e := range result.ClaudeMDTemplateErrors {
+		fmt.Fprintf(os.Stderr, "  Warning: CLAUDE.md fragment %s (would be included as-is)\n", e)
+	}
+	if len(result.ToInstall) == 0 && len(result.ToRemove) == 0 && len(result.MCPToRemove) == 0 {
+		fmt.Println("No plugins to install or remove and no MCP servers to remove.")
+	}
+}
+
 // printPullResult displays the results of a pull operation.
 func printPullResult(result *commands.PullResult) {
 	if len(result.Installed) > 0 {
@@ -31,23 +53,56 @@ func printPullResult(result *commands.PullResult) {
 			fmt.Fprintf(os.Stderr, "  Warning: %s\n", w)
 		}
 	}
+	if len(result.ScriptsRestored) > 0 {
+		fmt.Printf("✓ %d script(s) restored\n", len(result.ScriptsRestored))
+	}
+	if len(result.ScriptsSkipped) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d script(s) left as-is (edited locally)\n", len(result.ScriptsSkipped))
+	}
 	if result.PermissionsApplied {
 		fmt.Println("✓ Permissions applied")
 	}
+	for _, rule := range result.PermissionsRemoved.Allow {
+		fmt.Printf("✓ Allow rule removed (no longer in config): %s\n", rule)
+	}
+	for _, rule := range result.PermissionsRemoved.Deny {
+		fmt.Printf("✓ Deny rule removed (no longer in config): %s\n", rule)
+	}
 	if result.ClaudeMDAssembled {
 		fmt.Println("✓ CLAUDE.md assembled from fragments")
 	}
+	for _, e := range result.ClaudeMDTemplateErrors {
+		fmt.Fprintf(os.Stderr, "  Warning: CLAUDE.md fragment %s (included as-is)\n", e)
+	}
 	if len(result.MCPApplied) > 0 {
 		fmt.Printf("✓ MCP servers applied: %s\n", strings.Join(result.MCPApplied, ", "))
 	}
 	for projectPath, names := range result.MCPProjectApplied {
 		fmt.Printf("✓ MCP servers applied to %s: %s\n", projectPath, strings.Join(names, ", "))
 	}
+	for mcpPath, names := range result.MCPRemoved {
+		fmt.Printf("✓ MCP servers removed from %s (no longer in config): %s\n", mcpPath, strings.Join(names, ", "))
+	}
+	for mcpPath, names := range result.MCPSkipped {
+		fmt.Fprintf(os.Stderr, "⚠ MCP servers in %s left as-is (edited locally): %s\n", mcpPath, strings.Join(names, ", "))
+	}
 	if len(result.MCPEnvWarnings) > 0 {
 		for _, w := range result.MCPEnvWarnings {
 			fmt.Fprintf(os.Stderr, "  Warning: %s\n", w)
 		}
 	}
+	for _, w := range result.SecretWarnings {
+		fmt.Fprintf(os.Stderr, "  Warning: %s\n", w)
+	}
+	for _, w := range result.SubscriptionWarnings {
+		fmt.Fprintf(os.Stderr, "  Warning: %s\n", w)
+	}
+	for _, u := range result.UntrustedCommits {
+		fmt.Fprintf(os.Stderr, "  Warning: %s; its hooks, MCP servers and permissions were not applied\n", u)
+		if len(u.Projects) > 0 {
+			fmt.Fprintf(os.Stderr, "  Not applied to projects either: %s\n", strings.Join(u.Projects, ", "))
+		}
+	}
 	if result.KeybindingsApplied {
 		fmt.Println("✓ Keybindings applied")
 	}
@@ -65,11 +120,37 @@ func printPullResult(result *commands.PullResult) {
 	}
 	if skippedAny {
 		fmt.Fprintf(os.Stderr, "  To accept upstream: delete the file and re-run pull, or use --force\n")
+		if result.ClaudeMDSkipped {
+			fmt.Fprintf(os.Stderr, "  To keep your own notes in CLAUDE.md, write them outside the %s and %s lines around the synced part\n", claudemd.RegionBegin, claudemd.RegionEnd)
+		}
+	}
+	if result.SettingsMerged {
+		fmt.Println("✓ Settings merged with your local changes to settings.json")
+	}
+	if len(result.SettingsConflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d setting(s) changed both locally and upstream: %s\n", len(result.SettingsConflicts), strings.Join(result.SettingsConflicts, ", "))
+		fmt.Fprintf(os.Stderr, "  Your local values were kept. Run 'claude-sync conflicts' to review.\n")
 	}
 
+	if len(result.ProjectMemoryPending) > 0 {
+		fmt.Printf("  Project memory waiting for a local checkout: %s\n", strings.Join(result.ProjectMemoryPending, ", "))
+	}
 	if len(result.SkippedCategories) > 0 {
 		fmt.Printf("  Skipped: %s (per user-preferences.yaml)\n", strings.Join(result.SkippedCategories, ", "))
 	}
+	if len(result.FilteredByWhen) > 0 {
+		fmt.Printf("  Not applied on this machine (when: selector):\n")
+		for _, f := range result.FilteredByWhen {
+			fmt.Printf("    • %s\n", f)
+		}
+	}
+
+	if len(result.ProfileConflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "\n⚠ Active profiles %s disagree on %d item(s):\n", strings.Join(result.ActiveProfiles, ", "), len(result.ProfileConflicts))
+		for _, c := range result.ProfileConflicts {
+			fmt.Fprintf(os.Stderr, "  • %s\n", c)
+		}
+	}
 
 	if len(result.UndefinedMarketplaces) > 0 {
 		for mktName, pluginNames := range result.UndefinedMarketplaces {
@@ -92,13 +173,18 @@ func printPullResult(result *commands.PullResult) {
 	nothingChanged := len(result.ToInstall) == 0 && len(result.Updated) == 0 && len(result.EnabledPluginsReconciled) == 0 &&
 		len(result.SettingsApplied) == 0 && len(result.HooksApplied) == 0 &&
 		len(result.HooksSkipped) == 0 && len(result.SkippedCategories) == 0 && !result.PermissionsApplied && !result.ClaudeMDAssembled &&
-		len(result.MCPApplied) == 0 && len(result.MCPProjectApplied) == 0 && !result.KeybindingsApplied &&
+		len(result.PermissionsRemoved.Allow) == 0 && len(result.PermissionsRemoved.Deny) == 0 &&
+		len(result.MCPApplied) == 0 && len(result.MCPProjectApplied) == 0 && len(result.MCPRemoved) == 0 &&
+		len(result.MCPSkipped) == 0 && !result.KeybindingsApplied &&
 		!result.SettingsSkipped && !result.ClaudeMDSkipped && !result.KeybindingsSkipped
 	if len(allFailed) > 0 {
 		fmt.Fprintf(os.Stderr, "\nSome plugins could not be installed. Check the errors above.\n")
 	} else if nothingChanged {
 		fmt.Println("Everything up to date.")
 	}
+	if !nothingChanged && result.Snapshot != "" {
+		fmt.Printf("  Previous local files saved as snapshot %s (undo with 'claude-sync snapshot restore %s')\n", result.Snapshot, result.Snapshot)
+	}
 
 	if len(result.PendingHighRisk) > 0 {
 		fmt.Printf("\n⚠ %d high-risk change(s) deferred. Run 'claude-sync approve' to apply:\n", len(result.PendingHighRisk))
@@ -115,7 +201,10 @@ func printPullResult(result *commands.PullResult) {
 		fmt.Println("Run 'claude-sync push' to add them, or keep as local-only.")
 	}
 
-	if result.ProjectUnmanagedDetected && !result.ProjectInitEligible {
+	if r := result.ProjectRuleApplied; r != nil {
+		fmt.Printf("\n✓ Project initialized at %s with profile %s (%s)\n", result.ProjectInitDir, profileLabel(r.Profile), r)
+		fmt.Printf("  Projected keys: %s\n", strings.Join(r.ProjectedKeys(), ", "))
+	} else if result.ProjectUnmanagedDetected && !result.ProjectInitEligible {
 		fmt.Println("\nThis project has settings.local.json but isn't managed by claude-sync.")
 		fmt.Println("Run 'claude-sync project init' to sync hooks and permissions.")
 	}
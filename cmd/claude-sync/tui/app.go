@@ -281,9 +281,7 @@ func (m AppModel) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.state.HasPending {
 			return m.openSubView(ActionApprove)
 		} else if m.state.HasConflicts {
-			m.executing = true
-			m.executingActionID = ActionConflicts
-			return m, executeAction(m.actionCursor, ActionConflicts, nil, m.claudeDir, m.syncDir)
+			return m.openSubView(ActionConflicts)
 		}
 	case "j", "down":
 		m.moveCursor(+1, filteredRecs, filteredIntents)
@@ -391,6 +389,9 @@ func (m AppModel) openSubView(actionID string) (tea.Model, tea.Cmd) {
 	case ActionApprove:
 		m.subView = NewApproveView(m.width, m.height, m.claudeDir, m.syncDir)
 		m.activeView = viewSubView
+	case ActionConflicts:
+		m.subView = NewConflictsView(m.width, m.height, m.claudeDir, m.syncDir)
+		m.activeView = viewSubView
 	}
 	if m.subView != nil {
 		return m, m.subView.Init()
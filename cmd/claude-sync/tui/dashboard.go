@@ -36,9 +36,9 @@ func renderSummary(state commands.MenuState, version string) string {
 	}
 
 	// User profile line
-	if state.ActiveProfile != "" {
+	if len(state.ActiveProfiles) > 0 {
 		lines = append(lines, labelStyle.Render("User profile    ")+
-			valueStyle.Render(state.ActiveProfile))
+			valueStyle.Render(strings.Join(state.ActiveProfiles, ", ")))
 	} else if len(state.Profiles) > 0 {
 		lines = append(lines, labelStyle.Render("User profile    ")+
 			valueStyle.Render("base (default)")+"  "+
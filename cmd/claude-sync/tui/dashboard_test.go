@@ -47,9 +47,9 @@ func TestRenderSummary_NoConfigRepo(t *testing.T) {
 
 func TestRenderSummary_ActiveProfile(t *testing.T) {
 	state := commands.MenuState{
-		ConfigExists:  true,
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "work",
+		ConfigExists:   true,
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"work"},
 	}
 	view := renderSummary(state, "0.7.0")
 	assert.Contains(t, view, "work")
@@ -42,7 +42,7 @@ func ErrorGuidance(actionID string, err error) *ErrorHelp {
 		case strings.Contains(msg, "conflict"):
 			return &ErrorHelp{
 				Why:    "Merge conflict detected during pull.",
-				Action: "Run 'claude-sync conflicts' to resolve, then retry.",
+				Action: "Run 'claude-sync conflicts resolve', then retry.",
 			}
 		}
 	case ActionPush, ActionPushChanges:
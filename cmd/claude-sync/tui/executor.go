@@ -79,27 +79,35 @@ func executeAction(index int, actionID string, args []string,
 				msg = "No changes to push"
 			} else {
 				err = commands.PushApply(commands.PushApplyOptions{
-					ClaudeDir:         claudeDir,
-					SyncDir:           syncDir,
-					AddPlugins:        scanResult.AddedPlugins,
-					RemovePlugins:     scanResult.RemovedPlugins,
-					UpdatePermissions: scanResult.ChangedPermissions,
-					UpdateClaudeMD:    scanResult.ChangedClaudeMD != nil,
-					UpdateMCP:         scanResult.ChangedMCP,
-					UpdateKeybindings: scanResult.ChangedKeybindings,
-					UpdateCommands:    scanResult.ChangedCommands,
-					UpdateSkills:      scanResult.ChangedSkills,
-					OrphanedCommands:  scanResult.OrphanedCommands,
-					OrphanedSkills:    scanResult.OrphanedSkills,
-					DirtyWorkingTree:  scanResult.DirtyWorkingTree,
+					ClaudeDir:            claudeDir,
+					SyncDir:              syncDir,
+					AddPlugins:           scanResult.AddedPlugins,
+					RemovePlugins:        scanResult.RemovedPlugins,
+					UpdatePermissions:    scanResult.ChangedPermissions,
+					UpdateClaudeMD:       scanResult.ChangedClaudeMD != nil,
+					UpdateMCP:            scanResult.ChangedMCP,
+					UpdateKeybindings:    scanResult.ChangedKeybindings,
+					UpdateCommands:       scanResult.ChangedCommands,
+					UpdateSkills:         scanResult.ChangedSkills,
+					UpdateAgents:         scanResult.ChangedAgents,
+					UpdateOutputStyles:   scanResult.ChangedOutputStyles,
+					UpdateScripts:        scanResult.ChangedScripts,
+					UpdateProjectMemory:  scanResult.ChangedProjectMemory,
+					OrphanedCommands:     scanResult.OrphanedCommands,
+					OrphanedSkills:       scanResult.OrphanedSkills,
+					OrphanedAgents:       scanResult.OrphanedAgents,
+					OrphanedOutputStyles: scanResult.OrphanedOutputStyles,
+					DirtyWorkingTree:     scanResult.DirtyWorkingTree,
 				})
 				if err == nil {
 					msg = fmt.Sprintf("Pushed \u2014 %s", commands.PushPreviewSummary(scanResult))
 				}
 			}
-		case ActionConflicts:
-			// For now, just report -- real conflict resolution needs a sub-view
-			msg = "Conflict resolution not yet available in TUI"
+			if scanResult != nil && err == nil {
+				if warning := commands.TemplateEditedWarning(scanResult.TemplateEdited); warning != "" {
+					msg += ". Warning: " + warning
+				}
+			}
 		case ActionPluginUpdate:
 			if len(args) > 0 {
 				msg = fmt.Sprintf("Plugin update for %s not yet available in TUI", args[0])
@@ -144,9 +152,22 @@ func formatPullResult(r *commands.PullResult) string {
 	if len(r.SettingsApplied) > 0 {
 		parts = append(parts, "settings updated")
 	}
+	mcpRemoved := 0
+	for _, names := range r.MCPRemoved {
+		mcpRemoved += len(names)
+	}
+	if mcpRemoved > 0 {
+		parts = append(parts, fmt.Sprintf("%d MCP server(s) removed", mcpRemoved))
+	}
+	if n := len(r.PermissionsRemoved.Allow) + len(r.PermissionsRemoved.Deny); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d permission rule(s) removed", n))
+	}
 	if len(r.PendingHighRisk) > 0 {
 		parts = append(parts, "pending high-risk changes need approval")
 	}
+	if n := len(r.ProfileConflicts); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d profile conflict(s)", n))
+	}
 	if len(parts) == 0 {
 		return "Config is up to date"
 	}
@@ -293,14 +293,6 @@ func TestExecuteAction_UnknownAction(t *testing.T) {
 	assert.Contains(t, result.err.Error(), "unknown action")
 }
 
-func TestExecuteAction_ConflictsAction(t *testing.T) {
-	cmd := executeAction(0, "conflicts", nil, "/tmp/claude", "/tmp/sync")
-	msg := cmd()
-	result := msg.(actionResultMsg)
-	assert.True(t, result.success)
-	assert.Contains(t, result.message, "not yet available")
-}
-
 func TestExecuteAction_PluginUpdateAction(t *testing.T) {
 	cmd := executeAction(0, "plugin-update", []string{"test-plugin"}, "/tmp/claude", "/tmp/sync")
 	msg := cmd()
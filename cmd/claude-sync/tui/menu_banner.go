@@ -49,7 +49,7 @@ func buildBanner(state commands.MenuState) string {
 	}
 
 	// Profile
-	profileName := state.ActiveProfile
+	profileName := strings.Join(state.ActiveProfiles, ", ")
 	if profileName == "" {
 		profileName = "none"
 	}
@@ -101,9 +101,9 @@ func TestBanner_ShowsPluginCount(t *testing.T) {
 
 func TestBanner_ShowsProfile(t *testing.T) {
 	state := commands.MenuState{
-		ConfigExists:  true,
-		ConfigRepo:    "owner/repo",
-		ActiveProfile: "work",
+		ConfigExists:   true,
+		ConfigRepo:     "owner/repo",
+		ActiveProfiles: []string{"work"},
 	}
 	banner := buildBanner(state)
 	assert.Contains(t, banner, "profile: work")
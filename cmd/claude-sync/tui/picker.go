@@ -33,7 +33,7 @@ type PickerItem struct {
 	IsHeader    bool   // section header, not selectable
 	IsBase      bool   // inherited from base config (profile view)
 	IsReadOnly  bool   // read-only items cannot be toggled (e.g. plugin-provided commands)
-	Tag         string // type indicator: "[cmd]", "[skill]", etc.
+	Tag         string // type indicator: "[cmd]", "[skill]", "[agent]", "[style]", etc.
 	ProviderTag string // provenance: "via plugin-name" (only shown when IsReadOnly)
 	Description string // optional description rendered below headers
 }
@@ -378,7 +378,7 @@ func CommandsSkillsPickerItems(scan *cmdskill.ScanResult) []PickerItem {
 	}
 
 	// Group items by source.
-	var pluginItems, globalCmds, globalSkills []cmdskill.Item
+	var pluginItems, globalCmds, globalSkills, globalAgents, globalStyles []cmdskill.Item
 	projectGroups := make(map[string][]cmdskill.Item) // sourceLabel -> items
 
 	for _, item := range scan.Items {
@@ -386,9 +386,14 @@ func CommandsSkillsPickerItems(scan *cmdskill.ScanResult) []PickerItem {
 		case cmdskill.SourcePlugin:
 			pluginItems = append(pluginItems, item)
 		case cmdskill.SourceGlobal:
-			if item.Type == cmdskill.TypeCommand {
+			switch item.Type {
+			case cmdskill.TypeCommand:
 				globalCmds = append(globalCmds, item)
-			} else {
+			case cmdskill.TypeAgent:
+				globalAgents = append(globalAgents, item)
+			case cmdskill.TypeOutputStyle:
+				globalStyles = append(globalStyles, item)
+			default:
 				globalSkills = append(globalSkills, item)
 			}
 		case cmdskill.SourceProject:
@@ -411,16 +416,12 @@ func CommandsSkillsPickerItems(scan *cmdskill.ScanResult) []PickerItem {
 			return pluginItems[i].Key() < pluginItems[j].Key()
 		})
 		for _, item := range pluginItems {
-			typeTag := "[cmd]"
-			if item.Type == cmdskill.TypeSkill {
-				typeTag = "[skill]"
-			}
 			items = append(items, PickerItem{
 				Key:         item.Key(),
 				Display:     item.Name,
 				Selected:    true,
 				IsReadOnly:  true,
-				Tag:         typeTag,
+				Tag:         cmdSkillTag(item.Type),
 				ProviderTag: "via " + item.SourceLabel,
 			})
 		}
@@ -464,6 +465,44 @@ func CommandsSkillsPickerItems(scan *cmdskill.ScanResult) []PickerItem {
 		}
 	}
 
+	// Global agents.
+	if len(globalAgents) > 0 {
+		items = append(items, PickerItem{
+			Display:  fmt.Sprintf("Global agents (%d)", len(globalAgents)),
+			IsHeader: true,
+		})
+		sort.Slice(globalAgents, func(i, j int) bool {
+			return globalAgents[i].Name < globalAgents[j].Name
+		})
+		for _, item := range globalAgents {
+			items = append(items, PickerItem{
+				Key:      item.Key(),
+				Display:  item.Name,
+				Selected: true,
+				Tag:      "[agent]",
+			})
+		}
+	}
+
+	// Global output styles.
+	if len(globalStyles) > 0 {
+		items = append(items, PickerItem{
+			Display:  fmt.Sprintf("Global output styles (%d)", len(globalStyles)),
+			IsHeader: true,
+		})
+		sort.Slice(globalStyles, func(i, j int) bool {
+			return globalStyles[i].Name < globalStyles[j].Name
+		})
+		for _, item := range globalStyles {
+			items = append(items, PickerItem{
+				Key:      item.Key(),
+				Display:  item.Name,
+				Selected: true,
+				Tag:      "[style]",
+			})
+		}
+	}
+
 	// Project-local items.
 	projectLabels := make([]string, 0, len(projectGroups))
 	for label := range projectGroups {
@@ -481,15 +520,11 @@ func CommandsSkillsPickerItems(scan *cmdskill.ScanResult) []PickerItem {
 			return projItems[i].Key() < projItems[j].Key()
 		})
 		for _, item := range projItems {
-			typeTag := "[cmd]"
-			if item.Type == cmdskill.TypeSkill {
-				typeTag = "[skill]"
-			}
 			items = append(items, PickerItem{
 				Key:      item.Key(),
 				Display:  item.Name,
 				Selected: true,
-				Tag:      typeTag,
+				Tag:      cmdSkillTag(item.Type),
 			})
 		}
 	}
@@ -497,6 +532,19 @@ func CommandsSkillsPickerItems(scan *cmdskill.ScanResult) []PickerItem {
 	return items
 }
 
+// cmdSkillTag returns the picker tag for an item type.
+func cmdSkillTag(t cmdskill.ItemType) string {
+	switch t {
+	case cmdskill.TypeSkill:
+		return "[skill]"
+	case cmdskill.TypeAgent:
+		return "[agent]"
+	case cmdskill.TypeOutputStyle:
+		return "[style]"
+	}
+	return "[cmd]"
+}
+
 // --- Methods ---
 
 // SetSearchAction enables or disables the virtual [+ Search projects] row.
@@ -362,6 +362,18 @@ func profileToSectionDiffs(p profiles.Profile) map[Section]*sectionDiff {
 	for _, k := range p.Skills.Remove {
 		d.removes[k] = true
 	}
+	for _, k := range p.Agents.Add {
+		d.adds[k] = true
+	}
+	for _, k := range p.Agents.Remove {
+		d.removes[k] = true
+	}
+	for _, k := range p.OutputStyles.Add {
+		d.adds[k] = true
+	}
+	for _, k := range p.OutputStyles.Remove {
+		d.removes[k] = true
+	}
 	diffs[SectionCommandsSkills] = d
 
 	// Memory
@@ -385,6 +397,10 @@ func (m Model) diffsToProfile(name string) profiles.Profile {
 
 	pm := m.profilePickers[name]
 	pvals, hasPvals := m.profileAddValues[name]
+	if hasPvals {
+		p.Extends = pvals.Extends
+		p.Vars = pvals.Vars
+	}
 
 	// Plugins
 	basePlugins := m.pickers[SectionPlugins].SelectedKeys()
@@ -503,8 +519,8 @@ func (m Model) diffsToProfile(name string) profiles.Profile {
 	// Commands & Skills
 	baseCS := m.pickers[SectionCommandsSkills].SelectedKeys()
 	profCS := pm[SectionCommandsSkills].SelectedKeys()
-	baseCmds, baseSkills := splitCmdSkillKeys(baseCS)
-	profCmds, profSkills := splitCmdSkillKeys(profCS)
+	baseCmds, baseSkills, baseAgents, baseStyles := splitCmdSkillKeys(baseCS)
+	profCmds, profSkills, profAgents, profStyles := splitCmdSkillKeys(profCS)
 
 	cmdDiff := computeSectionDiff(baseCmds, profCmds)
 	p.Commands.Add = sortedKeys(cmdDiff.adds)
@@ -514,6 +530,14 @@ func (m Model) diffsToProfile(name string) profiles.Profile {
 	p.Skills.Add = sortedKeys(skillDiff.adds)
 	p.Skills.Remove = sortedKeys(skillDiff.removes)
 
+	agentDiff := computeSectionDiff(baseAgents, profAgents)
+	p.Agents.Add = sortedKeys(agentDiff.adds)
+	p.Agents.Remove = sortedKeys(agentDiff.removes)
+
+	styleDiff := computeSectionDiff(baseStyles, profStyles)
+	p.OutputStyles.Add = sortedKeys(styleDiff.adds)
+	p.OutputStyles.Remove = sortedKeys(styleDiff.removes)
+
 	// Memory
 	baseMem := m.pickers[SectionMemory].SelectedKeys()
 	profMem := pm[SectionMemory].SelectedKeys()
@@ -37,9 +37,8 @@ func buildRecommendations(state commands.MenuState) []recommendation {
 			title:  "Merge conflicts need resolution",
 			detail: "Config has conflicts that must be resolved before syncing",
 			action: actionItem{
-				id:     ActionConflicts,
-				label:  "Resolve conflicts",
-				inline: true,
+				id:    ActionConflicts,
+				label: "Resolve conflicts",
 			},
 		})
 	}
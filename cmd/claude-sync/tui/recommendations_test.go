@@ -32,7 +32,7 @@ func TestRecommendations_Conflicts(t *testing.T) {
 	require.Len(t, recs, 1)
 	assert.Contains(t, recs[0].title, "conflict")
 	assert.Equal(t, "conflicts", recs[0].action.id)
-	assert.True(t, recs[0].action.inline)
+	assert.False(t, recs[0].action.inline, "conflicts open the resolution sub-view")
 }
 
 func TestRecommendations_BehindRemote(t *testing.T) {
@@ -49,11 +49,15 @@ const (
 // profileValues stores original values from a profile's add sections.
 // Used when profile values differ from scan values (e.g., MCP server
 // with uvx config in profile vs Docker config in local scan).
+// Extends is carried through unchanged since the editor only edits a
+// profile's own directives.
 type profileValues struct {
+	Extends     []string
 	MCP         map[string]json.RawMessage
 	Hooks       map[string]json.RawMessage
 	Settings    map[string]any
 	Keybindings map[string]any
+	Vars        map[string]any
 }
 
 // Model is the root bubbletea model that composes all TUI child components.
@@ -820,7 +824,7 @@ func helperText(section Section, isProfile bool, mcpSecretsCount int) (string, s
 		case SectionHooks:
 			line1 = "Select which auto-sync hooks to include."
 		case SectionCommandsSkills:
-			line1 = "Choose commands and skills to sync."
+			line1 = "Choose commands, skills, agents and output styles to sync."
 		default:
 			line1 = "Configure this section."
 		}
@@ -1287,14 +1291,7 @@ func (m Model) buildInitOptions() *commands.InitOptions {
 	}
 
 	// Commands & Skills: split selected keys by type prefix.
-	csKeys := m.pickers[SectionCommandsSkills].SelectedKeys()
-	for _, k := range csKeys {
-		if strings.HasPrefix(k, "cmd:") {
-			opts.Commands = append(opts.Commands, k)
-		} else if strings.HasPrefix(k, "skill:") {
-			opts.Skills = append(opts.Skills, k)
-		}
-	}
+	opts.Commands, opts.Skills, opts.Agents, opts.OutputStyles = splitCmdSkillKeys(m.pickers[SectionCommandsSkills].SelectedKeys())
 
 	// Memory fragments.
 	opts.MemoryIncludes = m.pickers[SectionMemory].SelectedKeys()
@@ -1304,6 +1301,11 @@ func (m Model) buildInitOptions() *commands.InitOptions {
 		opts.ExtraMarketplaces = m.existingConfig.Marketplaces
 	}
 
+	// Preserve CLAUDE.md template vars, which aren't scanned from local files.
+	if m.editMode && m.existingConfig != nil {
+		opts.ExtraVars = m.existingConfig.Vars
+	}
+
 	// Profiles.
 	if m.useProfiles && len(m.profilePickers) > 0 {
 		opts.Profiles = m.buildProfiles()
@@ -1523,16 +1525,22 @@ func (m Model) handleCmdSkillSearchDone(msg CmdSkillSearchDoneMsg) Model {
 	var newItems []PickerItem
 	existingKeys := toSet(m.pickers[SectionCommandsSkills].AllKeys())
 
-	// In edit mode, build a set of saved command/skill keys for selection lookup.
+	// In edit mode, build a set of saved command/skill/agent/style keys for selection lookup.
 	var savedCSSet map[string]bool
 	if m.editMode && m.existingConfig != nil {
-		savedCSSet = make(map[string]bool, len(m.existingConfig.Commands)+len(m.existingConfig.Skills))
+		savedCSSet = make(map[string]bool, len(m.existingConfig.Commands)+len(m.existingConfig.Skills)+len(m.existingConfig.Agents)+len(m.existingConfig.OutputStyles))
 		for _, k := range m.existingConfig.Commands {
 			savedCSSet[k] = true
 		}
 		for _, k := range m.existingConfig.Skills {
 			savedCSSet[k] = true
 		}
+		for _, k := range m.existingConfig.Agents {
+			savedCSSet[k] = true
+		}
+		for _, k := range m.existingConfig.OutputStyles {
+			savedCSSet[k] = true
+		}
 	}
 
 	// Group by project.
@@ -1560,10 +1568,6 @@ func (m Model) handleCmdSkillSearchDone(msg CmdSkillSearchDoneMsg) Model {
 			return items[i].Key() < items[j].Key()
 		})
 		for _, item := range items {
-			typeTag := "[cmd]"
-			if item.Type == cmdskill.TypeSkill {
-				typeTag = "[skill]"
-			}
 			// In edit mode, respect the saved config selection.
 			selected := true
 			if savedCSSet != nil {
@@ -1573,7 +1577,7 @@ func (m Model) handleCmdSkillSearchDone(msg CmdSkillSearchDoneMsg) Model {
 				Key:      item.Key(),
 				Display:  item.Name,
 				Selected: selected,
-				Tag:      typeTag,
+				Tag:      cmdSkillTag(item.Type),
 			})
 		}
 	}
@@ -1782,6 +1786,12 @@ func (m *Model) applyExistingConfig(cfg *config.Config, existingProfiles map[str
 	for _, k := range cfg.Skills {
 		csSet[k] = true
 	}
+	for _, k := range cfg.Agents {
+		csSet[k] = true
+	}
+	for _, k := range cfg.OutputStyles {
+		csSet[k] = true
+	}
 	applyPickerSelection(pickerPtr(m.pickers, SectionCommandsSkills), csSet)
 
 	// Memory: select only fragments in the existing config.
@@ -1805,7 +1815,7 @@ func (m *Model) restoreProfiles(cfg *config.Config, existingProfiles map[string]
 
 		// Store original profile add-section values before they are lost
 		// to scan-based reconstruction.
-		vals := profileValues{}
+		vals := profileValues{Extends: profile.Extends, Vars: profile.Vars}
 		if len(profile.MCP.Add) > 0 {
 			vals.MCP = make(map[string]json.RawMessage, len(profile.MCP.Add))
 			for k, v := range profile.MCP.Add {
@@ -1878,17 +1888,22 @@ func hasScanData(scan *commands.InitScanResult) bool {
 		len(scan.MemoryFiles) > 0
 }
 
-// splitCmdSkillKeys splits a list of keys into command keys and skill keys
-// based on their prefix.
-func splitCmdSkillKeys(keys []string) (cmds, skills []string) {
+// splitCmdSkillKeys splits a list of keys into command, skill, agent and
+// output style keys based on their prefix.
+func splitCmdSkillKeys(keys []string) (cmds, skills, agents, styles []string) {
 	for _, k := range keys {
-		if strings.HasPrefix(k, "cmd:") {
+		switch {
+		case strings.HasPrefix(k, "cmd:"):
 			cmds = append(cmds, k)
-		} else if strings.HasPrefix(k, "skill:") {
+		case strings.HasPrefix(k, "skill:"):
 			skills = append(skills, k)
+		case strings.HasPrefix(k, "agent:"):
+			agents = append(agents, k)
+		case strings.HasPrefix(k, "style:"):
+			styles = append(styles, k)
 		}
 	}
-	return cmds, skills
+	return cmds, skills, agents, styles
 }
 
 func toSet(keys []string) map[string]bool {
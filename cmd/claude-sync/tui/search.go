@@ -208,13 +208,13 @@ func splitLines(s string) []string {
 }
 
 // CmdSkillSearchDoneMsg is sent when the background commands/skills search is
-// complete. It contains all discovered project-local command and skill items.
+// complete. It contains all discovered project-local command, skill and agent items.
 type CmdSkillSearchDoneMsg struct {
 	Items []cmdskill.Item
 }
 
 // SearchCommandsSkills returns a tea.Cmd that searches for project directories
-// containing .claude/commands/ or .claude/skills/ under the home directory.
+// containing .claude/commands/, .claude/skills/ or .claude/agents/ under the home directory.
 // It uses fd/find to locate .claude directories, then calls cmdskill.ScanProject
 // for each found project.
 func SearchCommandsSkills() tea.Cmd {
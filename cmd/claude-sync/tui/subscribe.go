@@ -235,6 +235,8 @@ func CategoryItems(cfg config.Config, category string) []string {
 		return cfg.Commands
 	case "skills":
 		return cfg.Skills
+	case "agents":
+		return cfg.Agents
 	default:
 		return nil
 	}
@@ -266,6 +268,9 @@ func availableCategories(cfg config.Config) []string {
 	if len(cfg.Skills) > 0 {
 		cats = append(cats, "skills")
 	}
+	if len(cfg.Agents) > 0 {
+		cats = append(cats, "agents")
+	}
 	return cats
 }
 
@@ -279,6 +284,7 @@ func categoryDisplayName(cat string) string {
 		"claude_md":   "CLAUDE.md",
 		"commands":    "Commands",
 		"skills":      "Skills",
+		"agents":      "Agents",
 	}
 	if name, ok := names[cat]; ok {
 		return name
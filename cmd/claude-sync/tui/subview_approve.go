@@ -57,7 +57,7 @@ func NewApproveView(width, height int, claudeDir, syncDir string) ApproveView {
 	}
 
 	if err == nil {
-		v.content = buildApproveContent(pending)
+		v.content = buildApproveContent(pending, commands.PendingHookChanges(claudeDir, pending))
 		v.scroll, v.maxScroll = recalcScroll(v.content, height, 0)
 	}
 
@@ -171,7 +171,9 @@ func runApprove(claudeDir, syncDir string) tea.Cmd {
 }
 
 // buildApproveContent renders pending changes into a human-readable string.
-func buildApproveContent(pending approval.PendingChanges) string {
+// Hooks with an entry in hookChanges are shown as a diff against the current
+// settings, with their risk findings.
+func buildApproveContent(pending approval.PendingChanges, hookChanges []approval.HookChange) string {
 	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(colorText)
 
 	var lines []string
@@ -211,11 +213,33 @@ func buildApproveContent(pending approval.PendingChanges) string {
 	if len(pending.Hooks) > 0 {
 		hookNames := sortedJSONKeys(pending.Hooks)
 		lines = append(lines, sectionLine(stSection, fmt.Sprintf("Hooks (%d)", len(hookNames)), 60))
+		changes := make(map[string]approval.HookChange, len(hookChanges))
+		for _, c := range hookChanges {
+			changes[c.Event] = c
+		}
 		for _, name := range hookNames {
-			lines = append(lines, stText.Render("  + "+name))
-			detail := parseHookDetail(pending.Hooks[name])
-			if detail != "" {
-				lines = append(lines, stDim.Render("    "+detail))
+			c, ok := changes[name]
+			if !ok {
+				lines = append(lines, stText.Render("  + "+name))
+				detail := parseHookDetail(pending.Hooks[name])
+				if detail != "" {
+					lines = append(lines, stDim.Render("    "+detail))
+				}
+				continue
+			}
+			marker := "  + "
+			if c.Status == "changed" {
+				marker = "  ~ "
+			}
+			lines = append(lines, stText.Render(marker+name))
+			for _, cmd := range c.Before {
+				lines = append(lines, stRed.Render("    - "+cmd))
+			}
+			for _, cmd := range c.After {
+				lines = append(lines, stGreen.Render("    + "+cmd))
+			}
+			for _, f := range c.Findings {
+				lines = append(lines, stYellow.Render("    ⚠ "+f.String()))
 			}
 		}
 		lines = append(lines, "")
@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ruminaider/claude-sync/internal/commands"
+)
+
+// conflictEditedMsg is sent when $EDITOR exits after editing a conflict.
+type conflictEditedMsg struct {
+	path string
+	err  error
+}
+
+// ConflictsView walks through the pending merge conflicts one at a time,
+// showing local and remote side by side and resolving each with the
+// user's choice.
+type ConflictsView struct {
+	conflicts []commands.PendingConflict
+	current   int // position in conflicts
+	index     int // index of the current conflict in the pending list
+	resolved  int
+	content   string
+	scroll    int
+	maxScroll int
+	width     int
+	height    int
+	errMsg    string // last resolution error, shown above the diff
+
+	// Paths for execution
+	claudeDir string
+	syncDir   string
+
+	// Error loading conflicts
+	loadErr error
+}
+
+// NewConflictsView creates a ConflictsView by reading pending conflicts.
+func NewConflictsView(width, height int, claudeDir, syncDir string) ConflictsView {
+	conflicts, err := commands.ListPendingConflicts(syncDir)
+	v := ConflictsView{
+		conflicts: conflicts,
+		width:     width,
+		height:    height,
+		claudeDir: claudeDir,
+		syncDir:   syncDir,
+		loadErr:   err,
+	}
+	v.render()
+	return v
+}
+
+func (m ConflictsView) Init() tea.Cmd {
+	return nil
+}
+
+func (m ConflictsView) done() bool {
+	return m.current >= len(m.conflicts)
+}
+
+func (m ConflictsView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.render()
+		return m, nil
+
+	case conflictEditedMsg:
+		defer os.Remove(msg.path)
+		if msg.err != nil {
+			m.errMsg = "Editor failed: " + msg.err.Error()
+			m.render()
+			return m, nil
+		}
+		value, err := commands.ReadConflictEditFile(msg.path)
+		if err != nil {
+			m.errMsg = err.Error()
+			m.render()
+			return m, nil
+		}
+		m.apply(value)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loadErr != nil || m.done() {
+			return m, m.close()
+		}
+
+		c := m.conflicts[m.current]
+		switch msg.String() {
+		case "esc":
+			return m, m.close()
+		case "l", "r", "b":
+			choice := map[string]string{"l": commands.ResolveLocal, "r": commands.ResolveRemote, "b": commands.ResolveBoth}[msg.String()]
+			if choice == commands.ResolveBoth && !c.CanKeepBoth() {
+				return m, nil
+			}
+			value, err := c.Value(choice)
+			if err != nil {
+				m.errMsg = err.Error()
+				m.render()
+				return m, nil
+			}
+			m.apply(value)
+		case "e":
+			path, err := commands.WriteConflictEditFile(c, c.LocalValue)
+			if err != nil {
+				m.errMsg = err.Error()
+				m.render()
+				return m, nil
+			}
+			return m, tea.ExecProcess(commands.EditorCommand(path), func(err error) tea.Msg {
+				return conflictEditedMsg{path: path, err: err}
+			})
+		case "s":
+			m.current++
+			m.index++
+			m.errMsg = ""
+			m.render()
+		case "j", "down":
+			if m.scroll < m.maxScroll {
+				m.scroll++
+			}
+		case "k", "up":
+			if m.scroll > 0 {
+				m.scroll--
+			}
+		}
+	}
+	return m, nil
+}
+
+// apply resolves the current conflict to value and moves to the next.
+func (m *ConflictsView) apply(value json.RawMessage) {
+	if err := commands.ResolveConflictValue(m.claudeDir, m.syncDir, m.index, value); err != nil {
+		m.errMsg = err.Error()
+		m.render()
+		return
+	}
+	m.resolved++
+	m.current++
+	m.errMsg = ""
+	m.render()
+}
+
+func (m ConflictsView) close() tea.Cmd {
+	refresh := m.resolved > 0
+	return func() tea.Msg {
+		return subViewCloseMsg{refreshState: refresh}
+	}
+}
+
+func (m *ConflictsView) render() {
+	if m.loadErr != nil || m.done() {
+		m.content = ""
+		return
+	}
+	m.content = buildConflictContent(m.conflicts[m.current], m.current, len(m.conflicts), m.errMsg)
+	m.scroll, m.maxScroll = recalcScroll(m.content, m.height, 0)
+}
+
+func (m ConflictsView) View() string {
+	maxWidth, _ := clampWidth(m.width)
+	box := contentBox(maxWidth, colorSurface1)
+
+	if m.loadErr != nil {
+		var lines []string
+		lines = renderResultLines(lines, false, "Could not read conflicts: "+m.loadErr.Error())
+		return box.Render(strings.Join(lines, "\n"))
+	}
+
+	if len(m.conflicts) == 0 {
+		return box.Render(stDim.Render("No pending conflicts.") + "\n\n" + stDim.Render("esc back"))
+	}
+
+	if m.done() {
+		var lines []string
+		lines = renderResultLines(lines, true, fmt.Sprintf("%d of %d conflict(s) resolved", m.resolved, len(m.conflicts)))
+		return box.Render(strings.Join(lines, "\n"))
+	}
+
+	hint := "l local  r remote  e edit  s skip  esc back"
+	if m.conflicts[m.current].CanKeepBoth() {
+		hint = "l local  r remote  b both  e edit  s skip  esc back"
+	}
+	return renderScrollable(m.content, m.width, m.height, m.scroll, hint)
+}
+
+// buildConflictContent renders one conflict with its local and remote
+// values side by side.
+func buildConflictContent(c commands.PendingConflict, n, total int, errMsg string) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(colorText)
+
+	title := c.Key
+	if c.Source != "" {
+		title = c.Source + ": " + c.Key
+	}
+
+	var lines []string
+	lines = append(lines, headerStyle.Render(fmt.Sprintf("Conflict %d of %d", n+1, total)))
+	lines = append(lines, stText.Render(title))
+	lines = append(lines, "")
+	if errMsg != "" {
+		lines = append(lines, stRed.Render("✗ "+errMsg))
+		lines = append(lines, "")
+	}
+
+	rows := c.SideBySide()
+	width := len("Local")
+	for _, r := range rows {
+		width = max(width, len(r.Local))
+	}
+	width = min(width, 36)
+	pad := func(s string) string {
+		if len(s) > width {
+			s = s[:width-1] + "…"
+		}
+		return s + strings.Repeat(" ", width-len(s))
+	}
+
+	lines = append(lines, stDim.Render("  "+pad("Local")+" │ Remote"))
+	for _, r := range rows {
+		if !r.Changed {
+			lines = append(lines, stDim.Render("  "+pad(r.Local)+" │ "+r.Remote))
+			continue
+		}
+		lines = append(lines, "  "+stRed.Render(pad(r.Local))+stDim.Render(" │ ")+stGreen.Render(r.Remote))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupConflicts(t *testing.T) (claudeDir, syncDir string) {
+	t.Helper()
+	claudeDir = t.TempDir()
+	syncDir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\nsettings:\n  model: opus\n"), 0644))
+	require.NoError(t, commands.SaveConflicts(syncDir, []commands.PendingConflict{
+		{Key: "settings.model", LocalValue: json.RawMessage(`"opus"`), RemoteValue: json.RawMessage(`"sonnet"`)},
+		{Key: "permissions.allow", LocalValue: json.RawMessage(`["Read"]`), RemoteValue: json.RawMessage(`["Edit"]`)},
+	}))
+	return claudeDir, syncDir
+}
+
+func pressKey(t *testing.T, v ConflictsView, key string) (ConflictsView, tea.Cmd) {
+	t.Helper()
+	updated, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return updated.(ConflictsView), cmd
+}
+
+func TestNewConflictsView_ShowsFirstConflict(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	v := NewConflictsView(80, 24, claudeDir, syncDir)
+	require.Nil(t, v.loadErr)
+	assert.Len(t, v.conflicts, 2)
+	assert.Contains(t, v.content, "Conflict 1 of 2")
+	assert.Contains(t, v.content, "settings.model")
+	assert.Contains(t, v.content, "sonnet")
+	assert.NotContains(t, v.View(), "b both", "scalar values can't keep both")
+}
+
+func TestConflictsView_ResolveWalksConflicts(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	v := NewConflictsView(80, 24, claudeDir, syncDir)
+
+	v, _ = pressKey(t, v, "r")
+	assert.Equal(t, 1, v.resolved)
+	assert.Contains(t, v.content, "Conflict 2 of 2")
+	assert.Contains(t, v.View(), "b both")
+
+	v, _ = pressKey(t, v, "b")
+	assert.True(t, v.done())
+	assert.Contains(t, v.View(), "2 of 2 conflict(s) resolved")
+	assert.False(t, commands.HasPendingConflicts(syncDir))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "sonnet", cfg.Settings["model"])
+	assert.Equal(t, []string{"Read", "Edit"}, cfg.Permissions.Allow)
+
+	_, cmd := pressKey(t, v, "x")
+	require.NotNil(t, cmd)
+	closeMsg, ok := cmd().(subViewCloseMsg)
+	require.True(t, ok)
+	assert.True(t, closeMsg.refreshState)
+}
+
+func TestConflictsView_SkipLeavesConflictPending(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	v := NewConflictsView(80, 24, claudeDir, syncDir)
+
+	v, _ = pressKey(t, v, "s")
+	v, _ = pressKey(t, v, "l")
+	assert.True(t, v.done())
+
+	remaining, err := commands.ListPendingConflicts(syncDir)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "settings.model", remaining[0].Key)
+}
+
+func TestConflictsView_BothIgnoredForScalars(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	v := NewConflictsView(80, 24, claudeDir, syncDir)
+
+	v, _ = pressKey(t, v, "b")
+	assert.Equal(t, 0, v.resolved)
+	assert.True(t, commands.HasPendingConflicts(syncDir))
+}
+
+func TestConflictsView_EditedValueIsApplied(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	v := NewConflictsView(80, 24, claudeDir, syncDir)
+
+	path := filepath.Join(t.TempDir(), "edit.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("haiku\n"), 0644))
+	updated, _ := v.Update(conflictEditedMsg{path: path})
+	v = updated.(ConflictsView)
+	assert.Equal(t, 1, v.resolved)
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "model: haiku")
+}
+
+func TestConflictsView_EscCloses(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	v := NewConflictsView(80, 24, claudeDir, syncDir)
+	_, cmd := v.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	require.NotNil(t, cmd)
+	closeMsg, ok := cmd().(subViewCloseMsg)
+	require.True(t, ok)
+	assert.False(t, closeMsg.refreshState)
+}
+
+func TestAppModel_OpenSubView_Conflicts(t *testing.T) {
+	claudeDir, syncDir := setupConflicts(t)
+	m := AppModel{width: 80, height: 24, claudeDir: claudeDir, syncDir: syncDir}
+	updated, _ := m.openSubView(ActionConflicts)
+	app := updated.(AppModel)
+	assert.Equal(t, viewSubView, app.activeView)
+	_, ok := app.subView.(ConflictsView)
+	assert.True(t, ok)
+}
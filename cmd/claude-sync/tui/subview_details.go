@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -61,8 +62,8 @@ func buildConfigDetailsContent(state commands.MenuState) string {
 	} else {
 		lines = append(lines, stDim.Render("Repository: (none)"))
 	}
-	if state.ActiveProfile != "" {
-		lines = append(lines, stText.Render("Active profile: "+state.ActiveProfile))
+	if len(state.ActiveProfiles) > 0 {
+		lines = append(lines, stText.Render("Active profile: "+strings.Join(state.ActiveProfiles, ", ")))
 	} else {
 		lines = append(lines, stDim.Render("Active profile: (base)"))
 	}
@@ -94,7 +95,7 @@ func buildConfigDetailsContent(state commands.MenuState) string {
 		lines = append(lines, stDim.Render("No profiles configured"))
 	} else {
 		for _, name := range state.Profiles {
-			if name == state.ActiveProfile {
+			if slices.Contains(state.ActiveProfiles, name) {
 				lines = append(lines, stGreen.Render(fmt.Sprintf("  \u25cf %s (active)", name)))
 			} else {
 				lines = append(lines, stText.Render("    "+name))
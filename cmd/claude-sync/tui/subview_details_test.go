@@ -24,9 +24,9 @@ func TestConfigDetails_ShowsPlugins(t *testing.T) {
 
 func TestConfigDetails_ShowsProfiles(t *testing.T) {
 	state := commands.MenuState{
-		ConfigExists:  true,
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "work",
+		ConfigExists:   true,
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewConfigDetails(state, 70, 30)
 	view := m.View()
@@ -101,9 +101,9 @@ func TestConfigDetails_ShowsConfigRepo(t *testing.T) {
 
 func TestConfigDetails_ShowsActiveProfileInSection(t *testing.T) {
 	state := commands.MenuState{
-		ConfigExists:  true,
-		ActiveProfile: "work",
-		Profiles:      []string{"work", "personal", "base"},
+		ConfigExists:   true,
+		ActiveProfiles: []string{"work"},
+		Profiles:       []string{"work", "personal", "base"},
 	}
 	m := NewConfigDetails(state, 70, 30)
 	view := m.View()
@@ -200,8 +200,8 @@ func TestAppModel_SubscribeIntent_OpensSubView(t *testing.T) {
 		Plugins: []commands.PluginInfo{
 			{Name: "beads", Key: "beads@mkt", Status: "upstream", Marketplace: "mkt"},
 		},
-		Profiles:      []string{"work"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewAppModel(state)
 	m.width = 80
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -56,7 +57,7 @@ func NewProfilePicker(state commands.MenuState, width, height int) ProfilePicker
 		opts = append(opts, profileOption{
 			name:        name,
 			displayName: name,
-			active:      name == state.ActiveProfile,
+			active:      slices.Contains(state.ActiveProfiles, name),
 		})
 	}
 
@@ -64,7 +65,7 @@ func NewProfilePicker(state commands.MenuState, width, height int) ProfilePicker
 	opts = append(opts, profileOption{
 		name:        "",
 		displayName: "base (no profile)",
-		active:      state.ActiveProfile == "",
+		active:      len(state.ActiveProfiles) == 0,
 	})
 
 	return ProfilePicker{
@@ -11,8 +11,8 @@ import (
 
 func TestProfilePicker_ShowsAllProfiles(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 	view := m.View()
@@ -24,8 +24,8 @@ func TestProfilePicker_ShowsAllProfiles(t *testing.T) {
 
 func TestProfilePicker_CursorNavigation(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 	assert.Equal(t, 0, m.cursor)
@@ -75,8 +75,8 @@ func TestProfilePicker_EscCancels(t *testing.T) {
 
 func TestProfilePicker_SelectActiveProfile_NoOp(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 	// Cursor is on "work" (index 0), which is active
@@ -93,8 +93,8 @@ func TestProfilePicker_SelectActiveProfile_NoOp(t *testing.T) {
 
 func TestProfilePicker_BaseOption(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 	// Should have base option
@@ -110,8 +110,7 @@ func TestProfilePicker_BaseOption(t *testing.T) {
 
 func TestProfilePicker_BaseOptionActiveWhenNoProfile(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "",
+		Profiles: []string{"work", "personal"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 	// Base option should be active
@@ -185,8 +184,8 @@ func TestProfilePicker_FailedResultDismiss_StillRefreshes(t *testing.T) {
 
 func TestProfilePicker_SelectNonActiveProfile_StartsExecution(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 
@@ -246,8 +245,8 @@ func TestProfilePicker_ViewShowsHeader(t *testing.T) {
 
 func TestProfilePicker_ViewShowsCurrentlyActive(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "personal",
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"personal"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 	view := m.View()
@@ -256,8 +255,8 @@ func TestProfilePicker_ViewShowsCurrentlyActive(t *testing.T) {
 
 func TestProfilePicker_ProfileOrdering(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{"work", "personal", "dev"},
-		ActiveProfile: "work",
+		Profiles:       []string{"work", "personal", "dev"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewProfilePicker(state, 70, 30)
 
@@ -271,8 +270,7 @@ func TestProfilePicker_ProfileOrdering(t *testing.T) {
 
 func TestProfilePicker_NoProfiles_OnlyBase(t *testing.T) {
 	state := commands.MenuState{
-		Profiles:      []string{},
-		ActiveProfile: "",
+		Profiles: []string{},
 	}
 	m := NewProfilePicker(state, 70, 30)
 
@@ -285,9 +283,9 @@ func TestProfilePicker_NoProfiles_OnlyBase(t *testing.T) {
 
 func TestAppModel_SwitchProfileIntent_OpensProfilePicker(t *testing.T) {
 	state := commands.MenuState{
-		ConfigExists:  true,
-		Profiles:      []string{"work", "personal"},
-		ActiveProfile: "work",
+		ConfigExists:   true,
+		Profiles:       []string{"work", "personal"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewAppModel(state)
 	m.activeView = viewMain
@@ -333,9 +331,9 @@ func TestAppModel_SubViewCloseMsg_ReturnsToActions(t *testing.T) {
 
 func TestAppModel_SubViewCloseMsg_RefreshesState(t *testing.T) {
 	state := commands.MenuState{
-		ConfigExists:  true,
-		Profiles:      []string{"work"},
-		ActiveProfile: "work",
+		ConfigExists:   true,
+		Profiles:       []string{"work"},
+		ActiveProfiles: []string{"work"},
 	}
 	m := NewAppModel(state)
 	m.activeView = viewSubView
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"go.yaml.in/yaml/v3"
 )
@@ -14,7 +17,9 @@ type ConfigChanges struct {
 	Settings       map[string]any
 	Permissions    *PermissionChanges
 	HasHookChanges bool
+	Hooks          map[string]json.RawMessage // if set, hooks are listed per event with their risks
 	HasMCPChanges  bool
+	MCPServers     []string // if set, MCP changes are listed per server
 	ClaudeMD       []string // changed fragment names
 	Keybindings    bool
 }
@@ -84,7 +89,32 @@ func Classify(changes ConfigChanges) ClassifiedChanges {
 	}
 
 	// Hooks → high-risk
-	if changes.HasHookChanges {
+	if len(changes.Hooks) > 0 {
+		events := make([]string, 0, len(changes.Hooks))
+		for event := range changes.Hooks {
+			events = append(events, event)
+		}
+		sort.Strings(events)
+		for _, event := range events {
+			cmds, _ := HookCommands(changes.Hooks[event])
+			var risks []string
+			for _, cmd := range cmds {
+				for _, f := range AnalyzeCommand(cmd) {
+					if !slices.Contains(risks, string(f.Risk)) {
+						risks = append(risks, string(f.Risk))
+					}
+				}
+			}
+			desc := fmt.Sprintf("hook %q changed", event)
+			if len(risks) > 0 {
+				desc += " (" + strings.Join(risks, ", ") + ")"
+			}
+			result.HighRisk = append(result.HighRisk, Change{
+				Category:    "hooks",
+				Description: desc,
+			})
+		}
+	} else if changes.HasHookChanges {
 		result.HighRisk = append(result.HighRisk, Change{
 			Category:    "hooks",
 			Description: "hooks changed",
@@ -92,7 +122,13 @@ func Classify(changes ConfigChanges) ClassifiedChanges {
 	}
 
 	// MCP → high-risk
-	if changes.HasMCPChanges {
+	for _, name := range changes.MCPServers {
+		result.HighRisk = append(result.HighRisk, Change{
+			Category:    "mcp",
+			Description: fmt.Sprintf("MCP server %q changed", name),
+		})
+	}
+	if changes.HasMCPChanges && len(changes.MCPServers) == 0 {
 		result.HighRisk = append(result.HighRisk, Change{
 			Category:    "mcp",
 			Description: "MCP server configuration changed",
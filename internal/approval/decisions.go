@@ -0,0 +1,81 @@
+package approval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const rejectionsFile = "rejected-changes.yaml"
+
+// Rejections remembers the pending changes that were rejected, keyed by
+// item, so pull doesn't propose them again until their value changes.
+type Rejections struct {
+	Items map[string]Rejection `yaml:"items,omitempty"`
+}
+
+// Rejection is the value an item had when it was rejected.
+type Rejection struct {
+	Hash       string `yaml:"hash"`
+	RejectedAt string `yaml:"rejected_at"`
+}
+
+// ReadRejections reads syncDir/rejected-changes.yaml. A missing file means
+// nothing has been rejected.
+func ReadRejections(syncDir string) (Rejections, error) {
+	data, err := os.ReadFile(filepath.Join(syncDir, rejectionsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Rejections{}, nil
+		}
+		return Rejections{}, fmt.Errorf("reading rejected changes: %w", err)
+	}
+	var r Rejections
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return Rejections{}, fmt.Errorf("parsing rejected changes: %w", err)
+	}
+	return r, nil
+}
+
+// WriteRejections writes syncDir/rejected-changes.yaml, removing it when
+// there are no rejections.
+func WriteRejections(syncDir string, r Rejections) error {
+	path := filepath.Join(syncDir, rejectionsFile)
+	if len(r.Items) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing rejected changes: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshaling rejected changes: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Reject records item's current value as rejected.
+func (r *Rejections) Reject(item Item, at string) {
+	if r.Items == nil {
+		r.Items = make(map[string]Rejection)
+	}
+	r.Items[item.Key] = Rejection{Hash: item.Hash(), RejectedAt: at}
+}
+
+// Forget drops any rejection of item, e.g. once it has been accepted.
+func (r *Rejections) Forget(item Item) {
+	delete(r.Items, item.Key)
+}
+
+// Rejected reports whether item was rejected with the value it has now.
+func (r Rejections) Rejected(item Item) bool {
+	rej, ok := r.Items[item.Key]
+	return ok && rej.Hash == item.Hash()
+}
+
+// Filter returns p without the items rejected at their current value.
+func (r Rejections) Filter(p PendingChanges) PendingChanges {
+	return p.filter(func(item Item) bool { return !r.Rejected(item) })
+}
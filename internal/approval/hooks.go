@@ -0,0 +1,521 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Risk names something a hook command does that deserves a closer look
+// before it is approved.
+type Risk string
+
+const (
+	RiskNetwork       Risk = "network access"
+	RiskOutsideWrite  Risk = "writes outside the project"
+	RiskSudo          Risk = "runs as root"
+	RiskRecursiveRm   Risk = "recursive delete"
+	RiskBase64        Risk = "decodes a base64 payload"
+	RiskMissingScript Risk = "script not found"
+)
+
+// Finding is one risk found in a hook command.
+type Finding struct {
+	Risk   Risk
+	Detail string // the part of the command that raised it
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Risk, f.Detail)
+}
+
+// HookChange is a hook event whose commands differ between two configs.
+type HookChange struct {
+	Event    string
+	Status   string   // "added", "changed" or "removed"
+	Before   []string // commands before the change
+	After    []string // commands after it
+	Findings []Finding
+}
+
+// CompareHooks returns the events whose hooks differ between before and
+// after, sorted by event, with findings for the commands in after.
+func CompareHooks(before, after map[string]json.RawMessage) []HookChange {
+	events := make(map[string]bool)
+	for e := range before {
+		events[e] = true
+	}
+	for e := range after {
+		events[e] = true
+	}
+	names := make([]string, 0, len(events))
+	for e := range events {
+		names = append(names, e)
+	}
+	sort.Strings(names)
+
+	var changes []HookChange
+	for _, event := range names {
+		old, hadOld := before[event]
+		cur, hasCur := after[event]
+		c := HookChange{Event: event}
+		c.Before, _ = HookCommands(old)
+		c.After, _ = HookCommands(cur)
+		switch {
+		case !hadOld:
+			c.Status = "added"
+		case !hasCur:
+			c.Status = "removed"
+		case equalStrings(c.Before, c.After) && compactJSON(old) == compactJSON(cur):
+			continue
+		default:
+			c.Status = "changed"
+		}
+		for _, cmd := range c.After {
+			c.Findings = appendFindings(c.Findings, AnalyzeCommand(cmd)...)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// HookCommands returns the commands in a hook definition in Claude Code's
+// format: a list of matchers, each with a list of command hooks.
+func HookCommands(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var entries []hookEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("malformed hook JSON: %w", err)
+	}
+	var cmds []string
+	for _, entry := range entries {
+		for _, h := range entry.Hooks {
+			if h.Command != "" {
+				cmds = append(cmds, h.Command)
+			}
+		}
+	}
+	return cmds, nil
+}
+
+// hookEntry represents a single hook entry in the Claude hook JSON format.
+type hookEntry struct {
+	Hooks []struct {
+		Command string `json:"command"`
+	} `json:"hooks"`
+}
+
+// MissingHookScripts inspects a hook's JSON data for commands that reference
+// external script files. Returns paths of scripts that don't exist on disk.
+// Returns an error if the hook JSON is malformed.
+func MissingHookScripts(hookData json.RawMessage) ([]string, error) {
+	cmds, err := HookCommands(hookData)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, cmd := range cmds {
+		path := ScriptPath(cmd)
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(expandHome(path)); err != nil {
+			if os.IsNotExist(err) {
+				missing = append(missing, path)
+			} else {
+				return nil, fmt.Errorf("checking script %s: %w", path, err)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// scriptInterpreters are command prefixes that indicate the next argument is a script file.
+var scriptInterpreters = map[string]bool{
+	"bash": true, "sh": true, "zsh": true,
+	"python": true, "python3": true,
+	"ruby": true, "perl": true, "node": true,
+}
+
+// ScriptPath returns the script file path from a command string like
+// "bash ~/.claude/hooks/foo.sh" or "python3 /path/to/script.py".
+// Returns empty string if the command doesn't reference an external script.
+func ScriptPath(command string) string {
+	parts := strings.Fields(command)
+	if len(parts) < 2 {
+		return ""
+	}
+	if !scriptInterpreters[parts[0]] {
+		return ""
+	}
+	// Skip flags (e.g. -e, -x) to find the first positional argument.
+	for _, arg := range parts[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		path := strings.Trim(arg, `"'`)
+		if strings.HasPrefix(path, "/") || strings.HasPrefix(path, "~/") {
+			return path
+		}
+		return ""
+	}
+	return ""
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+// Programs that reach the network, and wrappers that run the command after
+// them.
+var (
+	networkPrograms = map[string]bool{
+		"curl": true, "wget": true, "nc": true, "ncat": true, "netcat": true,
+		"socat": true, "telnet": true,
+	}
+	privilegePrograms = map[string]bool{"sudo": true, "doas": true, "su": true}
+	wrapperPrograms   = map[string]bool{
+		"env": true, "exec": true, "nohup": true, "time": true, "command": true, "xargs": true,
+	}
+	writingPrograms = map[string]bool{"tee": true, "cp": true, "mv": true, "install": true, "ln": true}
+)
+
+// AnalyzeCommand reports the risks in one shell command. It reads the
+// command the way a shell would split it, so quoted text isn't mistaken
+// for a program, and looks inside sh -c / bash -c and eval arguments and
+// inside $(...) and backquotes, quoted or not.
+func AnalyzeCommand(command string) []Finding {
+	var findings []Finding
+	if path := ScriptPath(command); path != "" {
+		if _, err := os.Stat(expandHome(path)); os.IsNotExist(err) {
+			findings = append(findings, Finding{RiskMissingScript, path})
+		}
+	}
+	if strings.Contains(command, "/dev/tcp/") || strings.Contains(command, "/dev/udp/") {
+		findings = append(findings, Finding{RiskNetwork, "/dev/tcp"})
+	}
+	tokens, substitutions := lexShell(command)
+	for _, words := range simpleCommands(tokens) {
+		findings = appendFindings(findings, analyzeSimple(words)...)
+	}
+	for _, sub := range substitutions {
+		findings = appendFindings(findings, AnalyzeCommand(sub)...)
+	}
+	return findings
+}
+
+// shellToken is a word or an operator from lexShell.
+type shellToken struct {
+	text string
+	op   bool
+}
+
+// lexShell splits a command into words and operators, honoring quotes and
+// backslashes. It is not a full shell parser, only enough to tell programs
+// and redirections from their arguments. Command substitutions inside
+// double quotes stay part of their word, and are also returned on their own
+// so they can be analyzed as commands.
+func lexShell(s string) (tokens []shellToken, substitutions []string) {
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, shellToken{text: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				end = len(s) - i - 1
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				switch {
+				case s[j] == '\\' && j+1 < len(s):
+					j++
+				case s[j] == '$' && j+1 < len(s) && s[j+1] == '(':
+					end := closingParen(s, j+2)
+					substitutions = append(substitutions, s[j+2:end])
+					word.WriteString(s[j:min(end+1, len(s))])
+					j = end
+					continue
+				case s[j] == '`':
+					end := strings.IndexByte(s[j+1:], '`')
+					if end < 0 {
+						end = len(s) - j - 1
+					}
+					substitutions = append(substitutions, s[j+1:j+1+end])
+					word.WriteString(s[j:min(j+2+end, len(s))])
+					j += end + 1
+					continue
+				}
+				word.WriteByte(s[j])
+			}
+			i = j
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+		case c == '$' && i+1 < len(s) && s[i+1] == '(':
+			flush()
+			tokens = append(tokens, shellToken{text: "$(", op: true})
+			i++
+		case strings.IndexByte("|&;<>()`", c) >= 0:
+			flush()
+			op := string(c)
+			if i+1 < len(s) && (s[i+1] == c && c != '(' && c != ')' && c != '`' || c == '>' && s[i+1] == '|') {
+				op += string(s[i+1])
+				i++
+			}
+			tokens = append(tokens, shellToken{text: op, op: true})
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return tokens, substitutions
+}
+
+// closingParen returns the index of the ) that closes a $( whose contents
+// start at i, or len(s) if it isn't closed.
+func closingParen(s string, i int) int {
+	depth := 1
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// shellCommandArg returns the command string a shell runs with -c, which
+// may be combined with other flags (bash -lc "..."), or "" if it has none.
+func shellCommandArg(args []string) string {
+	hasC := false
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") {
+			hasC = hasC || strings.Contains(a, "c")
+			continue
+		}
+		if hasC {
+			return a
+		}
+		return ""
+	}
+	return ""
+}
+
+// simpleCommands splits tokens at control operators into simple commands.
+// Redirections stay in, as operator tokens followed by their target.
+func simpleCommands(tokens []shellToken) [][]shellToken {
+	var cmds [][]shellToken
+	var cur []shellToken
+	for _, t := range tokens {
+		if t.op && !isRedirect(t.text) {
+			if len(cur) > 0 {
+				cmds = append(cmds, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	if len(cur) > 0 {
+		cmds = append(cmds, cur)
+	}
+	return cmds
+}
+
+func isRedirect(op string) bool {
+	return op == ">" || op == ">>" || op == ">|" || op == "<" || op == "<<"
+}
+
+// analyzeSimple reports the risks in one simple command.
+func analyzeSimple(tokens []shellToken) []Finding {
+	var findings []Finding
+	var words []string
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.op {
+			if (t.text == ">" || t.text == ">>" || t.text == ">|") && i+1 < len(tokens) {
+				i++
+				if outsideProject(tokens[i].text) {
+					findings = append(findings, Finding{RiskOutsideWrite, t.text + " " + tokens[i].text})
+				}
+			} else {
+				i++ // input redirection target
+			}
+			continue
+		}
+		words = append(words, t.text)
+	}
+
+	// Skip variable assignments and wrappers to find the program.
+	for len(words) > 0 {
+		prog := filepath.Base(words[0])
+		switch {
+		case strings.Contains(words[0], "=") && !strings.HasPrefix(words[0], "="):
+			words = words[1:]
+		case privilegePrograms[prog]:
+			findings = append(findings, Finding{RiskSudo, strings.Join(words, " ")})
+			words = skipFlags(words[1:])
+		case wrapperPrograms[prog]:
+			words = skipFlags(words[1:])
+		default:
+			return append(findings, analyzeProgram(prog, words)...)
+		}
+	}
+	return findings
+}
+
+func analyzeProgram(prog string, words []string) []Finding {
+	var findings []Finding
+	args := words[1:]
+	line := strings.Join(words, " ")
+	switch {
+	case networkPrograms[prog]:
+		findings = append(findings, Finding{RiskNetwork, line})
+	case prog == "rm" && recursiveForce(args):
+		findings = append(findings, Finding{RiskRecursiveRm, line})
+	case prog == "base64" && (containsAny(args, "-d", "-D", "--decode")):
+		findings = append(findings, Finding{RiskBase64, line})
+	case prog == "openssl" && containsAny(args, "base64", "enc") && containsAny(args, "-d"):
+		findings = append(findings, Finding{RiskBase64, line})
+	case prog == "sh" || prog == "bash" || prog == "zsh":
+		if cmd := shellCommandArg(args); cmd != "" {
+			findings = append(findings, AnalyzeCommand(cmd)...)
+		}
+	case prog == "eval":
+		findings = append(findings, AnalyzeCommand(strings.Join(args, " "))...)
+	case writingPrograms[prog] && len(args) > 0:
+		if target := args[len(args)-1]; !strings.HasPrefix(target, "-") && outsideProject(target) {
+			findings = append(findings, Finding{RiskOutsideWrite, line})
+		}
+	case prog == "dd":
+		for _, a := range args {
+			if strings.HasPrefix(a, "of=") && outsideProject(strings.TrimPrefix(a, "of=")) {
+				findings = append(findings, Finding{RiskOutsideWrite, line})
+			}
+		}
+	}
+	return findings
+}
+
+// outsideProject reports whether a write target is outside the project a
+// hook runs in: an absolute or home path other than the project dir or a
+// device, or one that climbs out with "..".
+func outsideProject(path string) bool {
+	switch {
+	case strings.HasPrefix(path, "$CLAUDE_PROJECT_DIR"), strings.HasPrefix(path, "${CLAUDE_PROJECT_DIR}"):
+		return strings.Contains(path, "..")
+	case strings.HasPrefix(path, "/dev/"), strings.HasPrefix(path, "&"):
+		return false
+	case strings.HasPrefix(path, "/"), strings.HasPrefix(path, "~"),
+		strings.HasPrefix(path, "$HOME"), strings.HasPrefix(path, "${HOME}"):
+		return true
+	}
+	return path == ".." || strings.HasPrefix(path, "../") || strings.Contains(path, "/../")
+}
+
+func recursiveForce(args []string) bool {
+	var r, f bool
+	for _, a := range args {
+		switch {
+		case a == "--recursive":
+			r = true
+		case a == "--force":
+			f = true
+		case strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--"):
+			r = r || strings.ContainsAny(a, "rR")
+			f = f || strings.Contains(a, "f")
+		}
+	}
+	return r && f
+}
+
+func skipFlags(words []string) []string {
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		words = words[1:]
+	}
+	return words
+}
+
+func containsAny(list []string, want ...string) bool {
+	for _, s := range list {
+		for _, w := range want {
+			if s == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func appendFindings(list []Finding, add ...Finding) []Finding {
+	for _, f := range add {
+		dup := false
+		for _, have := range list {
+			if have == f {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func compactJSON(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,136 @@
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Item is one pending change that can be accepted or rejected on its own:
+// a permission rule, an MCP server or a hook event.
+type Item struct {
+	Key      string          // "permissions.allow.<rule>", "permissions.deny.<rule>", "mcp.<server>" or "hooks.<event>"
+	Category string          // "permissions", "mcp" or "hooks"
+	Name     string          // the rule, server or event
+	Value    json.RawMessage // the server or hook definition; nil for permission rules
+}
+
+// Hash identifies the item's proposed value, so a rejection can be matched
+// against a later proposal of the same item.
+func (i Item) Hash() string {
+	data := []byte(i.Key)
+	if len(i.Value) > 0 {
+		data = append(data, 0)
+		data = append(data, compactJSON(i.Value)...)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Items lists the pending changes one item at a time: permission rules,
+// then MCP servers, then hook events, each sorted by name.
+func (p PendingChanges) Items() []Item {
+	var items []Item
+	if p.Permissions != nil {
+		for _, rule := range p.Permissions.Allow {
+			items = append(items, Item{Key: "permissions.allow." + rule, Category: "permissions", Name: rule})
+		}
+		for _, rule := range p.Permissions.Deny {
+			items = append(items, Item{Key: "permissions.deny." + rule, Category: "permissions", Name: rule})
+		}
+	}
+	for _, name := range sortedNames(p.MCP) {
+		items = append(items, Item{Key: "mcp." + name, Category: "mcp", Name: name, Value: p.MCP[name]})
+	}
+	for _, name := range sortedNames(p.Hooks) {
+		items = append(items, Item{Key: "hooks." + name, Category: "hooks", Name: name, Value: p.Hooks[name]})
+	}
+	return items
+}
+
+// MatchItems returns the items each key selects. A key names one item
+// ("mcp.github") or a group of them ("mcp", "permissions.allow"). A key
+// that selects nothing is an error.
+func MatchItems(items []Item, keys []string) ([]Item, error) {
+	var matched []Item
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		found := false
+		for _, item := range items {
+			if item.Key != key && !strings.HasPrefix(item.Key, key+".") {
+				continue
+			}
+			found = true
+			if !seen[item.Key] {
+				seen[item.Key] = true
+				matched = append(matched, item)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no pending change matches %q", key)
+		}
+	}
+	return matched, nil
+}
+
+// Without returns p minus the given items. PendingSince and Commit are kept.
+func (p PendingChanges) Without(items []Item) PendingChanges {
+	drop := make(map[string]bool, len(items))
+	for _, item := range items {
+		drop[item.Key] = true
+	}
+	return p.filter(func(item Item) bool { return !drop[item.Key] })
+}
+
+// Only returns the subset of p made of the given items.
+func (p PendingChanges) Only(items []Item) PendingChanges {
+	keep := make(map[string]bool, len(items))
+	for _, item := range items {
+		keep[item.Key] = true
+	}
+	return p.filter(func(item Item) bool { return keep[item.Key] })
+}
+
+func (p PendingChanges) filter(keep func(Item) bool) PendingChanges {
+	out := PendingChanges{PendingSince: p.PendingSince, Commit: p.Commit}
+	for _, item := range p.Items() {
+		if !keep(item) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(item.Key, "permissions.allow."):
+			if out.Permissions == nil {
+				out.Permissions = &PendingPermissions{}
+			}
+			out.Permissions.Allow = append(out.Permissions.Allow, item.Name)
+		case strings.HasPrefix(item.Key, "permissions.deny."):
+			if out.Permissions == nil {
+				out.Permissions = &PendingPermissions{}
+			}
+			out.Permissions.Deny = append(out.Permissions.Deny, item.Name)
+		case item.Category == "mcp":
+			if out.MCP == nil {
+				out.MCP = make(map[string]json.RawMessage)
+			}
+			out.MCP[item.Name] = item.Value
+		case item.Category == "hooks":
+			if out.Hooks == nil {
+				out.Hooks = make(map[string]json.RawMessage)
+			}
+			out.Hooks[item.Name] = item.Value
+		}
+	}
+	return out
+}
+
+func sortedNames(m map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,97 @@
+package approval_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/approval"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePending() approval.PendingChanges {
+	return approval.PendingChanges{
+		Commit: "abc123",
+		Permissions: &approval.PendingPermissions{
+			Allow: []string{"Bash(git *)"},
+			Deny:  []string{"Bash(rm -rf *)"},
+		},
+		MCP: map[string]json.RawMessage{
+			"slack":  json.RawMessage(`{"command":"slack-mcp"}`),
+			"github": json.RawMessage(`{"command":"gh-mcp"}`),
+		},
+		Hooks: map[string]json.RawMessage{
+			"PreToolUse": json.RawMessage(`[{"hooks":[{"type":"command","command":"validate"}]}]`),
+		},
+	}
+}
+
+func keys(items []approval.Item) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Key)
+	}
+	return out
+}
+
+func TestPendingChanges_Items(t *testing.T) {
+	assert.Equal(t, []string{
+		"permissions.allow.Bash(git *)",
+		"permissions.deny.Bash(rm -rf *)",
+		"mcp.github",
+		"mcp.slack",
+		"hooks.PreToolUse",
+	}, keys(samplePending().Items()))
+}
+
+func TestMatchItems(t *testing.T) {
+	items := samplePending().Items()
+
+	matched, err := approval.MatchItems(items, []string{"mcp", "hooks.PreToolUse", "mcp.github"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mcp.github", "mcp.slack", "hooks.PreToolUse"}, keys(matched))
+
+	matched, err = approval.MatchItems(items, []string{"permissions.deny"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"permissions.deny.Bash(rm -rf *)"}, keys(matched))
+
+	_, err = approval.MatchItems(items, []string{"mcp.git"})
+	assert.Error(t, err)
+}
+
+func TestPendingChanges_OnlyAndWithout(t *testing.T) {
+	p := samplePending()
+	matched, err := approval.MatchItems(p.Items(), []string{"mcp.github", "permissions.allow"})
+	require.NoError(t, err)
+
+	only := p.Only(matched)
+	assert.Equal(t, []string{"Bash(git *)"}, only.Permissions.Allow)
+	assert.Empty(t, only.Permissions.Deny)
+	assert.Len(t, only.MCP, 1)
+	assert.Empty(t, only.Hooks)
+
+	rest := p.Without(matched)
+	assert.Equal(t, "abc123", rest.Commit)
+	assert.Equal(t, []string{"permissions.deny.Bash(rm -rf *)", "mcp.slack", "hooks.PreToolUse"}, keys(rest.Items()))
+}
+
+func TestRejections_FilterUntilValueChanges(t *testing.T) {
+	syncDir := t.TempDir()
+	p := samplePending()
+	hook := p.Items()[4]
+
+	var r approval.Rejections
+	r.Reject(hook, "2026-01-01T00:00:00Z")
+	require.NoError(t, approval.WriteRejections(syncDir, r))
+
+	loaded, err := approval.ReadRejections(syncDir)
+	require.NoError(t, err)
+	assert.NotContains(t, loaded.Filter(p).Hooks, "PreToolUse")
+	assert.Contains(t, loaded.Filter(p).MCP, "github")
+
+	// The same whitespace-insensitive value stays rejected; a new one doesn't.
+	p.Hooks["PreToolUse"] = json.RawMessage(`[ {"hooks": [{"type": "command", "command": "validate"}]} ]`)
+	assert.NotContains(t, loaded.Filter(p).Hooks, "PreToolUse")
+	p.Hooks["PreToolUse"] = json.RawMessage(`[{"hooks":[{"type":"command","command":"validate --strict"}]}]`)
+	assert.Contains(t, loaded.Filter(p).Hooks, "PreToolUse")
+}
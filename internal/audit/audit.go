@@ -0,0 +1,165 @@
+// Package audit keeps an append-only log of what claude-sync changed on
+// this machine: one JSON object per line in the sync dir's audit.jsonl.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileName is the audit log's name in the sync dir. It is gitignored: the
+// log describes this machine.
+const FileName = "audit.jsonl"
+
+// Entry records one pull, push, auto-commit, approve or rollback.
+type Entry struct {
+	Time          time.Time         `json:"time"`
+	Command       string            `json:"command"`                 // "pull", "push", "auto-commit", "approve" or "rollback"
+	User          string            `json:"user,omitempty"`          // approve: who accepted or rejected the changes
+	Commit        string            `json:"commit,omitempty"`        // sync repo HEAD afterwards
+	Proposed      string            `json:"proposed,omitempty"`      // approve: sync repo commit the changes were proposed at
+	Subscriptions map[string]string `json:"subscriptions,omitempty"` // subscription name -> commit
+	Profile       string            `json:"profile,omitempty"`       // active profiles, comma-separated
+	Snapshot      string            `json:"snapshot,omitempty"`      // snapshot taken before applying
+	Error         string            `json:"error,omitempty"`
+	Items         []Item            `json:"items,omitempty"`
+}
+
+// Item is one thing the command did to one item.
+type Item struct {
+	Action   string `json:"action"` // "applied", "skipped", "removed", "pending", "rejected", "pushed", "added", "changed"
+	Category string `json:"category"`
+	Name     string `json:"name,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Hash     string `json:"hash,omitempty"` // approve: hash of the value accepted or rejected
+}
+
+func (i Item) String() string {
+	s := i.Category
+	if i.Name != "" {
+		s += " " + i.Name
+	}
+	if i.Reason != "" {
+		s += " — " + i.Reason
+	}
+	return s
+}
+
+// Append adds e to syncDir/audit.jsonl.
+func Append(syncDir string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling audit entry: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(syncDir, FileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries in syncDir/audit.jsonl, oldest first. Lines that
+// don't parse, such as one cut short by a crash, are skipped.
+func Read(syncDir string) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(syncDir, FileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Query selects entries. Zero fields match everything.
+type Query struct {
+	Command  string
+	Category string
+	Name     string // matches item names containing it
+	Since    time.Time
+	Until    time.Time
+}
+
+// Filter returns the entries q matches. With Category or Name set, each
+// entry keeps only the matching items and entries with none are dropped.
+func (q Query) Filter(entries []Entry) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if q.Command != "" && e.Command != q.Command {
+			continue
+		}
+		if !q.Since.IsZero() && e.Time.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+			continue
+		}
+		if q.Category != "" || q.Name != "" {
+			var items []Item
+			for _, item := range e.Items {
+				if q.Category != "" && item.Category != q.Category {
+					continue
+				}
+				if q.Name != "" && !strings.Contains(item.Name, q.Name) {
+					continue
+				}
+				items = append(items, item)
+			}
+			if len(items) == 0 {
+				continue
+			}
+			e.Items = items
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// ParseTime reads a --since/--until value: a date (2006-01-02), an RFC 3339
+// time, or an age such as "36h" or "7d" counted back from now. A date is
+// the start of that day, or with endOfDay the start of the next one.
+func ParseTime(s string, now time.Time, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a date (2006-01-02), an RFC 3339 time or an age like 7d", s)
+}
@@ -0,0 +1,101 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ruminaider/claude-sync/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	syncDir := t.TempDir()
+
+	entries, err := audit.Read(syncDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	first := audit.Entry{
+		Time:    time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		Command: "pull",
+		Commit:  "abc123",
+		Items: []audit.Item{
+			{Action: "applied", Category: "hooks", Name: "PreToolUse"},
+			{Action: "skipped", Category: "settings", Reason: "local modification"},
+		},
+	}
+	require.NoError(t, audit.Append(syncDir, first))
+	require.NoError(t, audit.Append(syncDir, audit.Entry{Time: first.Time.Add(time.Hour), Command: "push"}))
+
+	// A line cut short by a crash is skipped.
+	f, err := os.OpenFile(filepath.Join(syncDir, audit.FileName), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":"2026-03-01T`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err = audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, first, entries[0])
+	assert.Equal(t, "push", entries[1].Command)
+}
+
+func TestQueryFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	entries := []audit.Entry{
+		{Time: day(1), Command: "pull", Items: []audit.Item{
+			{Action: "applied", Category: "permissions", Name: "Bash(git *)"},
+			{Action: "applied", Category: "mcp", Name: "github"},
+		}},
+		{Time: day(2), Command: "approve", Items: []audit.Item{
+			{Action: "rejected", Category: "hooks", Name: "PreToolUse"},
+		}},
+		{Time: day(3), Command: "push"},
+	}
+
+	got := audit.Query{Category: "permissions"}.Filter(entries)
+	require.Len(t, got, 1)
+	assert.Equal(t, []audit.Item{{Action: "applied", Category: "permissions", Name: "Bash(git *)"}}, got[0].Items)
+
+	got = audit.Query{Name: "Tool"}.Filter(entries)
+	require.Len(t, got, 1)
+	assert.Equal(t, "approve", got[0].Command)
+
+	got = audit.Query{Since: day(2), Until: day(3)}.Filter(entries)
+	require.Len(t, got, 1)
+	assert.Equal(t, "approve", got[0].Command)
+
+	assert.Len(t, audit.Query{Command: "push"}.Filter(entries), 1)
+	assert.Len(t, audit.Query{}.Filter(entries), 3)
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	got, err := audit.ParseTime("2026-03-01", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), got)
+
+	got, err = audit.ParseTime("2026-03-01", now, true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), got)
+
+	got, err = audit.ParseTime("7d", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 3, 15, 30, 0, 0, time.UTC), got)
+
+	got, err = audit.ParseTime("90m", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), got)
+
+	got, err = audit.ParseTime("2026-03-01T08:00:00Z", now, true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), got)
+
+	_, err = audit.ParseTime("last tuesday", now, false)
+	assert.Error(t, err)
+}
@@ -369,27 +369,32 @@ func AssembleFromDir(syncDir string, include []string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		// Recover the header from the content to build a proper Section
-		sec := Section{Content: content}
-		if strings.HasPrefix(content, "### ") {
-			if idx := strings.Index(content, "\n"); idx != -1 {
-				sec.Header = strings.TrimPrefix(content[:idx], "### ")
-			} else {
-				sec.Header = strings.TrimPrefix(content, "### ")
-			}
-		} else if strings.HasPrefix(content, "## ") {
-			if idx := strings.Index(content, "\n"); idx != -1 {
-				sec.Header = strings.TrimPrefix(content[:idx], "## ")
-			} else {
-				sec.Header = strings.TrimPrefix(content, "## ")
-			}
-		}
-		sections = append(sections, sec)
+		sections = append(sections, fragmentSection(content))
 	}
 
 	return Assemble(sections), nil
 }
 
+// fragmentSection recovers the header from fragment content to build a
+// proper Section.
+func fragmentSection(content string) Section {
+	sec := Section{Content: content}
+	if strings.HasPrefix(content, "### ") {
+		if idx := strings.Index(content, "\n"); idx != -1 {
+			sec.Header = strings.TrimPrefix(content[:idx], "### ")
+		} else {
+			sec.Header = strings.TrimPrefix(content, "### ")
+		}
+	} else if strings.HasPrefix(content, "## ") {
+		if idx := strings.Index(content, "\n"); idx != -1 {
+			sec.Header = strings.TrimPrefix(content[:idx], "## ")
+		} else {
+			sec.Header = strings.TrimPrefix(content, "## ")
+		}
+	}
+	return sec
+}
+
 // ContentSimilarity computes Jaccard similarity on lowercased word sets.
 func ContentSimilarity(a, b string) float64 {
 	setA := wordSet(a)
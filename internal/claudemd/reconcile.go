@@ -3,6 +3,7 @@ package claudemd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // RenamedFragment describes a fragment whose header changed but content is similar.
@@ -18,11 +19,27 @@ type ReconcileResult struct {
 	New     []Section         // sections not matching any fragment
 	Deleted []string          // fragment names not found in current content
 	Renamed []RenamedFragment // sections with similar content but different header
+	// TemplateEdited lists template fragments whose rendered section was
+	// edited locally. They're left alone: storing the rendered text would
+	// drop the template, so the fragment has to be edited in the sync repo.
+	TemplateEdited []string
 }
 
 // Reconcile compares currentContent against stored fragments and returns
-// what changed. This is the push-direction function.
+// what changed. This is the push-direction function. When currentContent
+// has managed region markers, only the region is compared.
 func Reconcile(syncDir, currentContent string) (*ReconcileResult, error) {
+	return reconcile(syncDir, currentContent, nil)
+}
+
+// ReconcileRendered is Reconcile for a CLAUDE.md assembled with
+// AssembleTemplates: template fragments are compared in their rendered
+// form, and one that rendered to nothing isn't reported as deleted.
+func ReconcileRendered(syncDir, currentContent string, data TemplateData) (*ReconcileResult, error) {
+	return reconcile(syncDir, currentContent, &data)
+}
+
+func reconcile(syncDir, currentContent string, data *TemplateData) (*ReconcileResult, error) {
 	claudeMdDir := filepath.Join(syncDir, claudeMdSubdir)
 
 	manifest, err := ReadManifest(claudeMdDir)
@@ -30,7 +47,8 @@ func Reconcile(syncDir, currentContent string) (*ReconcileResult, error) {
 		return nil, err
 	}
 
-	sections := Split(currentContent)
+	// Hand-written content outside the managed region isn't synced.
+	sections := Split(ManagedContent(currentContent))
 
 	result := &ReconcileResult{}
 
@@ -48,6 +66,15 @@ func Reconcile(syncDir, currentContent string) (*ReconcileResult, error) {
 		matchedFragments[name] = true
 		matchedSections[i] = true
 
+		if data != nil {
+			if tmpl, ok := templateFragment(claudeMdDir, name); ok {
+				if rendered, err := RenderFragment(name, tmpl, *data); err == nil && rendered != sec.Content {
+					result.TemplateEdited = append(result.TemplateEdited, name)
+				}
+				continue
+			}
+		}
+
 		// Check if content changed.
 		newHash := ContentHash(sec.Content)
 		if newHash != meta.ContentHash {
@@ -74,9 +101,17 @@ func Reconcile(syncDir, currentContent string) (*ReconcileResult, error) {
 
 	var unmatchedFragments []string
 	for name := range manifest.Fragments {
-		if !matchedFragments[name] {
-			unmatchedFragments = append(unmatchedFragments, name)
+		if matchedFragments[name] {
+			continue
+		}
+		if data != nil {
+			if tmpl, ok := templateFragment(claudeMdDir, name); ok {
+				if rendered, err := RenderFragment(name, tmpl, *data); err == nil && strings.TrimSpace(rendered) == "" {
+					continue
+				}
+			}
 		}
+		unmatchedFragments = append(unmatchedFragments, name)
 	}
 
 	// Pass 2: rename detection via content similarity.
@@ -149,6 +184,16 @@ func Reconcile(syncDir, currentContent string) (*ReconcileResult, error) {
 	return result, nil
 }
 
+// templateFragment returns the content of the named fragment if it is a
+// template.
+func templateFragment(claudeMdDir, name string) (string, bool) {
+	content, err := ReadFragment(claudeMdDir, name)
+	if err != nil || !IsTemplate(content) {
+		return "", false
+	}
+	return content, true
+}
+
 // ReconcileProjectFragments compares current project CLAUDE.md content against
 // stored project fragments and updates any that changed. The qualifiedKeys list
 // contains keys like "~/Work/evvy/CLAUDE.md::section-name". The expandHome
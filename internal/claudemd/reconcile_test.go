@@ -106,6 +106,21 @@ func TestReconcile(t *testing.T) {
 		assert.Empty(t, result.Deleted)
 		assert.Empty(t, result.Renamed)
 	})
+
+	t.Run("content outside managed region ignored", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, "## Synced\nSynced content")
+		require.NoError(t, err)
+
+		current := "## Personal\nMy notes\n" + claudemd.RegionBegin + "\n## Synced\nSynced content\n" + claudemd.RegionEnd + "\n## Later\nMore notes\n"
+		result, err := claudemd.Reconcile(syncDir, current)
+		require.NoError(t, err)
+
+		assert.Empty(t, result.Updated)
+		assert.Empty(t, result.New)
+		assert.Empty(t, result.Deleted)
+		assert.Empty(t, result.Renamed)
+	})
 }
 
 func TestReconcileWithSubSections(t *testing.T) {
@@ -0,0 +1,72 @@
+package claudemd
+
+import "strings"
+
+// Markers delimiting the managed region of a CLAUDE.md. When a file has
+// them, pull rewrites only what is between them and everything outside is
+// left to the user.
+const (
+	RegionBegin = "<!-- claude-sync:begin -->"
+	RegionEnd   = "<!-- claude-sync:end -->"
+)
+
+// Regions is a CLAUDE.md split at its managed region markers.
+type Regions struct {
+	Before  string // hand-written content before RegionBegin
+	Managed string // content between the markers, without the newlines next to them
+	After   string // hand-written content after RegionEnd
+}
+
+// SplitRegions splits content at the first RegionBegin and the RegionEnd
+// following it. Returns false when content has no such pair.
+func SplitRegions(content string) (Regions, bool) {
+	begin := strings.Index(content, RegionBegin)
+	if begin < 0 {
+		return Regions{}, false
+	}
+	rest := content[begin+len(RegionBegin):]
+	end := strings.Index(rest, RegionEnd)
+	if end < 0 {
+		return Regions{}, false
+	}
+	managed := strings.TrimPrefix(rest[:end], "\n")
+	managed = strings.TrimSuffix(managed, "\n")
+	return Regions{
+		Before:  content[:begin],
+		Managed: managed,
+		After:   rest[end+len(RegionEnd):],
+	}, true
+}
+
+// Join reassembles the file, markers included.
+func (r Regions) Join() string {
+	var b strings.Builder
+	b.WriteString(r.Before)
+	b.WriteString(RegionBegin + "\n")
+	if r.Managed != "" {
+		b.WriteString(r.Managed + "\n")
+	}
+	b.WriteString(RegionEnd)
+	b.WriteString(r.After)
+	return b.String()
+}
+
+// Unmanaged returns the hand-written content outside the markers, before
+// and after joined by a newline.
+func (r Regions) Unmanaged() string {
+	before := strings.TrimSpace(r.Before)
+	after := strings.TrimSpace(r.After)
+	if before == "" || after == "" {
+		return before + after
+	}
+	return before + "\n" + after
+}
+
+// ManagedContent returns the part of a CLAUDE.md that claude-sync owns: the
+// managed region when the file has markers, otherwise the whole file.
+func ManagedContent(content string) string {
+	if r, ok := SplitRegions(content); ok {
+		return r.Managed
+	}
+	return content
+}
@@ -0,0 +1,55 @@
+package claudemd_test
+
+import (
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const regionFile = "# Notes\nMy own notes\n" +
+	claudemd.RegionBegin + "\n## Synced\nSynced content\n" + claudemd.RegionEnd +
+	"\n## Later\nMore notes\n"
+
+func TestSplitRegions(t *testing.T) {
+	t.Run("with markers", func(t *testing.T) {
+		r, ok := claudemd.SplitRegions(regionFile)
+		require.True(t, ok)
+		assert.Equal(t, "# Notes\nMy own notes\n", r.Before)
+		assert.Equal(t, "## Synced\nSynced content", r.Managed)
+		assert.Equal(t, "\n## Later\nMore notes\n", r.After)
+		assert.Equal(t, regionFile, r.Join())
+		assert.Equal(t, "# Notes\nMy own notes\n## Later\nMore notes", r.Unmanaged())
+	})
+
+	t.Run("replacing the region keeps the rest", func(t *testing.T) {
+		r, ok := claudemd.SplitRegions(regionFile)
+		require.True(t, ok)
+		r.Managed = "## Synced\nNew content"
+		assert.Equal(t, "# Notes\nMy own notes\n"+claudemd.RegionBegin+"\n## Synced\nNew content\n"+claudemd.RegionEnd+"\n## Later\nMore notes\n", r.Join())
+	})
+
+	t.Run("empty region", func(t *testing.T) {
+		content := "Notes\n" + claudemd.RegionBegin + "\n" + claudemd.RegionEnd + "\n"
+		r, ok := claudemd.SplitRegions(content)
+		require.True(t, ok)
+		assert.Empty(t, r.Managed)
+		assert.Equal(t, content, r.Join())
+	})
+
+	t.Run("no markers", func(t *testing.T) {
+		_, ok := claudemd.SplitRegions("## Plain\nContent")
+		assert.False(t, ok)
+	})
+
+	t.Run("end marker without begin", func(t *testing.T) {
+		_, ok := claudemd.SplitRegions("## Plain\n" + claudemd.RegionEnd + "\n" + claudemd.RegionBegin)
+		assert.False(t, ok)
+	})
+}
+
+func TestManagedContent(t *testing.T) {
+	assert.Equal(t, "## Synced\nSynced content", claudemd.ManagedContent(regionFile))
+	assert.Equal(t, "## Plain\nContent", claudemd.ManagedContent("## Plain\nContent"))
+}
@@ -0,0 +1,80 @@
+package claudemd
+
+import (
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is what fragments are rendered with. Fragments use Go
+// text/template syntax, so one fragment can adapt to the machine:
+//
+//	{{if eq .OS "darwin"}}Install tools with `brew`.{{else}}Install tools with `apt`.{{end}}
+type TemplateData struct {
+	Hostname string
+	OS       string            // runtime.GOOS: "darwin", "linux", "windows"
+	Profile  string            // active profile names, comma-separated
+	Env      map[string]string // environment variables; unset ones render empty
+	Vars     map[string]any    // user-defined vars from config, profiles and user preferences
+}
+
+// TemplateError is a fragment that failed to parse or render.
+type TemplateError struct {
+	Fragment string
+	Err      error
+}
+
+func (e TemplateError) Error() string {
+	return e.Fragment + ": " + e.Err.Error()
+}
+
+// IsTemplate reports whether content contains template actions. Fragments
+// without them are used verbatim.
+func IsTemplate(content string) bool {
+	return strings.Contains(content, "{{")
+}
+
+// RenderFragment renders the fragment content against data. Content without
+// template actions is returned unchanged.
+func RenderFragment(name, content string, data TemplateData) (string, error) {
+	if !IsTemplate(content) {
+		return content, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// AssembleTemplates is AssembleFromDir with each fragment rendered against
+// data first. A fragment that fails to render is included verbatim and
+// reported in the returned errors; one that renders to nothing, such as a
+// section wrapped in a condition that doesn't hold, is left out.
+func AssembleTemplates(syncDir string, include []string, data TemplateData) (string, []TemplateError, error) {
+	claudeMdDir := filepath.Join(syncDir, claudeMdSubdir)
+	var sections []Section
+	var errs []TemplateError
+
+	for _, name := range include {
+		content, err := ReadFragment(claudeMdDir, name)
+		if err != nil {
+			return "", nil, err
+		}
+		rendered, err := RenderFragment(name, content, data)
+		if err != nil {
+			errs = append(errs, TemplateError{Fragment: name, Err: err})
+			rendered = content
+		}
+		if strings.TrimSpace(rendered) == "" {
+			continue
+		}
+		sections = append(sections, fragmentSection(rendered))
+	}
+
+	return Assemble(sections), errs, nil
+}
@@ -0,0 +1,149 @@
+package claudemd_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	toolsFragment = "## Tools\n{{if eq .OS \"darwin\"}}Install packages with `brew`.{{else}}Install packages with `apt`.{{end}}"
+	macPreamble   = "{{if eq .OS \"darwin\"}}Mac only.{{end}}"
+)
+
+func TestRenderFragment(t *testing.T) {
+	data := claudemd.TemplateData{
+		Hostname: "laptop",
+		OS:       "linux",
+		Profile:  "work",
+		Env:      map[string]string{"TEAM": "platform"},
+		Vars:     map[string]any{"editor": "nvim"},
+	}
+
+	t.Run("machine facts and vars", func(t *testing.T) {
+		out, err := claudemd.RenderFragment("about", "## About\n{{.Hostname}} {{.Profile}} {{.Env.TEAM}} {{.Vars.editor}}", data)
+		require.NoError(t, err)
+		assert.Equal(t, "## About\nlaptop work platform nvim", out)
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		out, err := claudemd.RenderFragment("tools", toolsFragment, data)
+		require.NoError(t, err)
+		assert.Equal(t, "## Tools\nInstall packages with `apt`.", out)
+
+		data.OS = "darwin"
+		out, err = claudemd.RenderFragment("tools", toolsFragment, data)
+		require.NoError(t, err)
+		assert.Equal(t, "## Tools\nInstall packages with `brew`.", out)
+	})
+
+	t.Run("unset env renders empty", func(t *testing.T) {
+		out, err := claudemd.RenderFragment("env", "team={{.Env.MISSING}}", data)
+		require.NoError(t, err)
+		assert.Equal(t, "team=", out)
+	})
+
+	t.Run("no template actions", func(t *testing.T) {
+		out, err := claudemd.RenderFragment("plain", "## Plain\n{ not a template }", data)
+		require.NoError(t, err)
+		assert.Equal(t, "## Plain\n{ not a template }", out)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := claudemd.RenderFragment("broken", "## Broken\n{{if .OS}}", data)
+		assert.Error(t, err)
+	})
+}
+
+func TestAssembleTemplates(t *testing.T) {
+	t.Run("renders each fragment", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, toolsFragment+"\n## Plain\nPlain content")
+		require.NoError(t, err)
+
+		assembled, errs, err := claudemd.AssembleTemplates(syncDir, []string{"tools", "plain"}, claudemd.TemplateData{OS: "darwin"})
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "## Tools\nInstall packages with `brew`.\n## Plain\nPlain content", assembled)
+	})
+
+	t.Run("fragment rendering to nothing is left out", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, macPreamble+"\n## Keep\nKept")
+		require.NoError(t, err)
+
+		assembled, errs, err := claudemd.AssembleTemplates(syncDir, []string{"_preamble", "keep"}, claudemd.TemplateData{OS: "linux"})
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "## Keep\nKept", assembled)
+	})
+
+	t.Run("template error includes fragment verbatim", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, "## Broken\n{{if .OS}}unterminated\n## Plain\nPlain content")
+		require.NoError(t, err)
+
+		assembled, errs, err := claudemd.AssembleTemplates(syncDir, []string{"broken", "plain"}, claudemd.TemplateData{})
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "broken", errs[0].Fragment)
+		assert.Contains(t, errs[0].Error(), "broken: ")
+		assert.Equal(t, "## Broken\n{{if .OS}}unterminated\n## Plain\nPlain content", assembled)
+	})
+
+	t.Run("missing fragment error", func(t *testing.T) {
+		_, _, err := claudemd.AssembleTemplates(t.TempDir(), []string{"nonexistent"}, claudemd.TemplateData{})
+		assert.Error(t, err)
+	})
+}
+
+func TestReconcileRendered(t *testing.T) {
+	data := claudemd.TemplateData{OS: "linux"}
+
+	t.Run("rendered template is unchanged", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, toolsFragment)
+		require.NoError(t, err)
+
+		assembled, _, err := claudemd.AssembleTemplates(syncDir, []string{"tools"}, data)
+		require.NoError(t, err)
+
+		result, err := claudemd.ReconcileRendered(syncDir, assembled, data)
+		require.NoError(t, err)
+		assert.Empty(t, result.Updated)
+		assert.Empty(t, result.TemplateEdited)
+
+		stored, err := claudemd.ReadFragment(filepath.Join(syncDir, "claude-md"), "tools")
+		require.NoError(t, err)
+		assert.Equal(t, toolsFragment, stored)
+	})
+
+	t.Run("edited template is reported, not overwritten", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, toolsFragment)
+		require.NoError(t, err)
+
+		result, err := claudemd.ReconcileRendered(syncDir, "## Tools\nInstall packages with `dnf`.", data)
+		require.NoError(t, err)
+		assert.Empty(t, result.Updated)
+		assert.Equal(t, []string{"tools"}, result.TemplateEdited)
+
+		stored, err := claudemd.ReadFragment(filepath.Join(syncDir, "claude-md"), "tools")
+		require.NoError(t, err)
+		assert.Equal(t, toolsFragment, stored)
+	})
+
+	t.Run("template rendering to nothing isn't deleted", func(t *testing.T) {
+		syncDir := t.TempDir()
+		_, err := claudemd.ImportClaudeMD(syncDir, macPreamble+"\n## Keep\nKept")
+		require.NoError(t, err)
+
+		result, err := claudemd.ReconcileRendered(syncDir, "## Keep\nKept", data)
+		require.NoError(t, err)
+		assert.Empty(t, result.Deleted)
+		assert.Empty(t, result.Updated)
+	})
+}
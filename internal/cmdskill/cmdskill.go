@@ -8,12 +8,14 @@ import (
 	"strings"
 )
 
-// ItemType distinguishes commands from skills.
+// ItemType distinguishes commands, skills, agents and output styles.
 type ItemType int
 
 const (
 	TypeCommand ItemType = iota
 	TypeSkill
+	TypeAgent
+	TypeOutputStyle
 )
 
 // Source identifies where an item was discovered.
@@ -25,9 +27,11 @@ const (
 	SourceProject
 )
 
-// Item represents a single command (.md file) or skill (SKILL.md directory).
+// Item represents a single command (.md file), skill (SKILL.md directory),
+// subagent definition (.md file under agents/) or output style (.md file
+// under output-styles/).
 type Item struct {
-	Name        string   // filename without extension for commands, dir name for skills
+	Name        string   // filename without extension for commands, agents and styles, dir name for skills
 	Type        ItemType
 	Source      Source
 	SourceLabel string // plugin name, "global", or project path
@@ -41,12 +45,19 @@ type Item struct {
 // Format:
 //   - cmd:global:review-pr
 //   - skill:global:brainstorming
+//   - agent:global:code-reviewer
+//   - style:global:explanatory-terse
 //   - cmd:plugin:commit-commands:commit
 //   - cmd:project:myproject:deploy
 func (i Item) Key() string {
 	prefix := "cmd"
-	if i.Type == TypeSkill {
+	switch i.Type {
+	case TypeSkill:
 		prefix = "skill"
+	case TypeAgent:
+		prefix = "agent"
+	case TypeOutputStyle:
+		prefix = "style"
 	}
 
 	switch i.Source {
@@ -107,7 +118,7 @@ type pluginInstallJSON struct {
 	Version     string `json:"version"`
 }
 
-// ScanPlugins walks the plugin cache to find commands and skills from installed plugins.
+// ScanPlugins walks the plugin cache to find commands, skills, agents and output styles from installed plugins.
 // It uses installed_plugins.json to determine which plugins/versions are active.
 func ScanPlugins(claudeDir string) ([]Item, error) {
 	ipPath := filepath.Join(claudeDir, "plugins", "installed_plugins.json")
@@ -156,12 +167,27 @@ func ScanPlugins(claudeDir string) ([]Item, error) {
 			continue
 		}
 		items = append(items, skills...)
+
+		// Scan agents/ directory.
+		agents, err := scanAgentsDir(installPath, SourcePlugin, pluginName)
+		if err != nil {
+			continue
+		}
+		items = append(items, agents...)
+
+		// Scan output-styles/ directory.
+		styles, err := scanOutputStylesDir(installPath, SourcePlugin, pluginName)
+		if err != nil {
+			continue
+		}
+		items = append(items, styles...)
 	}
 
 	return items, nil
 }
 
-// ScanGlobal walks claudeDir/commands/ and claudeDir/skills/ for user-created items.
+// ScanGlobal walks claudeDir/commands/, claudeDir/skills/, claudeDir/agents/
+// and claudeDir/output-styles/ for user-created items.
 func ScanGlobal(claudeDir string) ([]Item, error) {
 	var items []Item
 
@@ -177,10 +203,23 @@ func ScanGlobal(claudeDir string) ([]Item, error) {
 	}
 	items = append(items, skills...)
 
+	agents, err := scanAgentsDir(claudeDir, SourceGlobal, "global")
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, agents...)
+
+	styles, err := scanOutputStylesDir(claudeDir, SourceGlobal, "global")
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, styles...)
+
 	return items, nil
 }
 
-// ScanProject walks dir/.claude/commands/ and dir/.claude/skills/ for project-local items.
+// ScanProject walks dir/.claude/commands/, dir/.claude/skills/, dir/.claude/agents/
+// and dir/.claude/output-styles/ for project-local items.
 func ScanProject(projectDir string) ([]Item, error) {
 	claudeDir := filepath.Join(projectDir, ".claude")
 	label := filepath.Base(projectDir)
@@ -199,18 +238,44 @@ func ScanProject(projectDir string) ([]Item, error) {
 	}
 	items = append(items, skills...)
 
+	agents, err := scanAgentsDir(claudeDir, SourceProject, label)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, agents...)
+
+	styles, err := scanOutputStylesDir(claudeDir, SourceProject, label)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, styles...)
+
 	return items, nil
 }
 
 // scanCommandsDir reads .md files from dir/commands/.
 func scanCommandsDir(dir string, source Source, label string) ([]Item, error) {
-	commandsDir := filepath.Join(dir, "commands")
-	entries, err := os.ReadDir(commandsDir)
+	return scanMarkdownDir(filepath.Join(dir, "commands"), TypeCommand, source, label)
+}
+
+// scanAgentsDir reads subagent .md files from dir/agents/.
+func scanAgentsDir(dir string, source Source, label string) ([]Item, error) {
+	return scanMarkdownDir(filepath.Join(dir, "agents"), TypeAgent, source, label)
+}
+
+// scanOutputStylesDir reads output style .md files from dir/output-styles/.
+func scanOutputStylesDir(dir string, source Source, label string) ([]Item, error) {
+	return scanMarkdownDir(filepath.Join(dir, "output-styles"), TypeOutputStyle, source, label)
+}
+
+// scanMarkdownDir reads the .md files directly in dir as items of type t.
+func scanMarkdownDir(dir string, t ItemType, source Source, label string) ([]Item, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("reading commands dir %s: %w", commandsDir, err)
+		return nil, fmt.Errorf("reading %s dir %s: %w", filepath.Base(dir), dir, err)
 	}
 
 	var items []Item
@@ -219,7 +284,7 @@ func scanCommandsDir(dir string, source Source, label string) ([]Item, error) {
 			continue
 		}
 
-		filePath := filepath.Join(commandsDir, entry.Name())
+		filePath := filepath.Join(dir, entry.Name())
 		content, err := os.ReadFile(filePath)
 		if err != nil {
 			continue // skip unreadable files
@@ -230,7 +295,7 @@ func scanCommandsDir(dir string, source Source, label string) ([]Item, error) {
 
 		items = append(items, Item{
 			Name:        name,
-			Type:        TypeCommand,
+			Type:        t,
 			Source:      source,
 			SourceLabel: label,
 			FilePath:    filePath,
@@ -139,6 +139,16 @@ func TestKey(t *testing.T) {
 			item: Item{Name: "onboard", Type: TypeSkill, Source: SourceProject, SourceLabel: "myproject"},
 			want: "skill:project:myproject:onboard",
 		},
+		{
+			name: "global agent",
+			item: Item{Name: "code-reviewer", Type: TypeAgent, Source: SourceGlobal, SourceLabel: "global"},
+			want: "agent:global:code-reviewer",
+		},
+		{
+			name: "plugin agent",
+			item: Item{Name: "planner", Type: TypeAgent, Source: SourcePlugin, SourceLabel: "superpowers"},
+			want: "agent:plugin:superpowers:planner",
+		},
 	}
 
 	for _, tt := range tests {
@@ -234,6 +244,46 @@ func TestScanGlobal_MissingDirs(t *testing.T) {
 	assert.Empty(t, items)
 }
 
+func TestScanGlobal_Agents(t *testing.T) {
+	claudeDir := t.TempDir()
+	agentsDir := filepath.Join(claudeDir, "agents")
+	require.NoError(t, os.MkdirAll(agentsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "code-reviewer.md"), []byte(`---
+name: code-reviewer
+description: Reviews diffs for bugs
+tools: Read, Grep
+---
+You review code.`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "notes.txt"), []byte("ignore me"), 0644))
+
+	items, err := ScanGlobal(claudeDir)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, TypeAgent, items[0].Type)
+	assert.Equal(t, "code-reviewer", items[0].Name)
+	assert.Equal(t, "Reviews diffs for bugs", items[0].Description)
+	assert.Equal(t, "agent:global:code-reviewer", items[0].Key())
+}
+
+func TestScanGlobal_OutputStyles(t *testing.T) {
+	claudeDir := t.TempDir()
+	stylesDir := filepath.Join(claudeDir, "output-styles")
+	require.NoError(t, os.MkdirAll(stylesDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(stylesDir, "terse.md"), []byte(`---
+name: Terse
+description: Short answers, no preamble
+---
+Answer in as few words as possible.`), 0644))
+
+	items, err := ScanGlobal(claudeDir)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, TypeOutputStyle, items[0].Type)
+	assert.Equal(t, "terse", items[0].Name)
+	assert.Equal(t, "Short answers, no preamble", items[0].Description)
+	assert.Equal(t, "style:global:terse", items[0].Key())
+}
+
 // ─── ScanProject ───────────────────────────────────────────────────────────
 
 func TestScanProject(t *testing.T) {
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/ruminaider/claude-sync/internal/claudemd"
 )
 
 // Hash key constants for managed surfaces.
-// MCP is excluded: it uses additive merge, so user-added servers are safe.
+// MCP servers are tracked per server in AppliedHashes.MCP instead, since
+// users legitimately add their own servers to the same .mcp.json files.
 const (
 	HashKeySettings    = "settings"
 	HashKeyClaudeMD    = "claude-md"
@@ -23,8 +25,32 @@ const HashKeyMemoryPrefix = "memory:"
 // AppliedHashes tracks content hashes of files last written by pull.
 // Used to detect local modifications before overwriting.
 type AppliedHashes struct {
-	Hashes map[string]string `json:"hashes"`
-	path   string
+	Hashes      map[string]string   `json:"hashes"`
+	Permissions *ManagedPermissions `json:"permissions,omitempty"`
+	// MCP maps an .mcp.json path to the servers pull wrote there, keyed by
+	// server name with the hash of the server JSON as written.
+	MCP map[string]map[string]string `json:"mcp,omitempty"`
+	// Contents keeps the full content last written for keys set with
+	// SetContent, as the base for three-way merging local edits.
+	Contents map[string]string `json:"contents,omitempty"`
+	path     string
+}
+
+// ManagedPermissions records the permission rules that pull added to
+// settings.json. Rules the user wrote by hand are never recorded, so exact
+// sync mode can remove rules dropped from config without touching them.
+type ManagedPermissions struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// PermissionLedger returns the recorded permission rules, creating an empty
+// ledger if none has been stored yet.
+func (h *AppliedHashes) PermissionLedger() *ManagedPermissions {
+	if h.Permissions == nil {
+		h.Permissions = &ManagedPermissions{}
+	}
+	return h.Permissions
 }
 
 // LoadAppliedHashes reads .applied-hashes.json from syncDir.
@@ -66,6 +92,22 @@ func (h *AppliedHashes) Set(key, content string) {
 	h.Hashes[key] = claudemd.ContentHash(content)
 }
 
+// SetContent records content under key like Set, and keeps the content
+// itself so a later pull can merge local edits against it.
+func (h *AppliedHashes) SetContent(key, content string) {
+	h.Set(key, content)
+	if h.Contents == nil {
+		h.Contents = make(map[string]string)
+	}
+	h.Contents[key] = content
+}
+
+// Content returns the content last recorded for key with SetContent.
+func (h *AppliedHashes) Content(key string) (string, bool) {
+	content, ok := h.Contents[key]
+	return content, ok
+}
+
 // IsLocallyModified returns true if the file at filePath has been modified
 // since pull last wrote it. Returns false if no hash is stored (first pull)
 // or the file doesn't exist. Returns true on read errors (conservative: assume
@@ -84,3 +126,67 @@ func (h *AppliedHashes) IsLocallyModified(key, filePath string) bool {
 	}
 	return claudemd.ContentHash(string(currentData)) != storedHash
 }
+
+// IsContentModified returns true if content differs from what was last
+// recorded under key. Returns false if nothing is recorded.
+func (h *AppliedHashes) IsContentModified(key, content string) bool {
+	storedHash, ok := h.Hashes[key]
+	return ok && claudemd.ContentHash(content) != storedHash
+}
+
+// SetMCPServer records that pull wrote server name with value raw to the
+// .mcp.json at mcpPath.
+func (h *AppliedHashes) SetMCPServer(mcpPath, name string, raw json.RawMessage) {
+	if h.MCP == nil {
+		h.MCP = make(map[string]map[string]string)
+	}
+	if h.MCP[mcpPath] == nil {
+		h.MCP[mcpPath] = make(map[string]string)
+	}
+	h.MCP[mcpPath][name] = mcpServerHash(raw)
+}
+
+// ForgetMCPServer stops tracking server name in the .mcp.json at mcpPath.
+func (h *AppliedHashes) ForgetMCPServer(mcpPath, name string) {
+	servers, ok := h.MCP[mcpPath]
+	if !ok {
+		return
+	}
+	delete(servers, name)
+	if len(servers) == 0 {
+		delete(h.MCP, mcpPath)
+	}
+}
+
+// ManagedMCPServers returns the sorted names of servers pull wrote to the
+// .mcp.json at mcpPath.
+func (h *AppliedHashes) ManagedMCPServers(mcpPath string) []string {
+	names := make([]string, 0, len(h.MCP[mcpPath]))
+	for name := range h.MCP[mcpPath] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsMCPServerModified returns true if current differs from the value pull last
+// wrote for server name at mcpPath. Returns false if pull never wrote it.
+func (h *AppliedHashes) IsMCPServerModified(mcpPath, name string, current json.RawMessage) bool {
+	stored, ok := h.MCP[mcpPath][name]
+	if !ok {
+		return false
+	}
+	return mcpServerHash(current) != stored
+}
+
+// mcpServerHash hashes a server's JSON after normalizing it, so whitespace
+// and key order differences from re-serialization don't count as edits.
+func mcpServerHash(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err == nil {
+		if normalized, err := json.Marshal(v); err == nil {
+			raw = normalized
+		}
+	}
+	return claudemd.ContentHash(string(raw))
+}
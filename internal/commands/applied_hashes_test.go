@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -24,6 +25,45 @@ func TestAppliedHashes_LoadSave(t *testing.T) {
 	assert.Equal(t, h.Hashes["settings"], h2.Hashes["settings"])
 }
 
+func TestAppliedHashes_PermissionLedgerRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	h, err := commands.LoadAppliedHashes(dir)
+	require.NoError(t, err)
+	ledger := h.PermissionLedger()
+	ledger.Allow = []string{"Bash(git:*)"}
+	ledger.Deny = []string{"WebFetch"}
+	require.NoError(t, h.Save())
+
+	h2, err := commands.LoadAppliedHashes(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash(git:*)"}, h2.PermissionLedger().Allow)
+	assert.Equal(t, []string{"WebFetch"}, h2.PermissionLedger().Deny)
+}
+
+func TestAppliedHashes_MCPServerTracking(t *testing.T) {
+	dir := t.TempDir()
+	mcpPath := filepath.Join(dir, ".mcp.json")
+
+	h, err := commands.LoadAppliedHashes(dir)
+	require.NoError(t, err)
+	h.SetMCPServer(mcpPath, "memory", json.RawMessage(`{"command":"npx","args":["-y"]}`))
+	require.NoError(t, h.Save())
+
+	h2, err := commands.LoadAppliedHashes(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"memory"}, h2.ManagedMCPServers(mcpPath))
+
+	// Re-serialized JSON with different whitespace/key order is not an edit.
+	assert.False(t, h2.IsMCPServerModified(mcpPath, "memory", json.RawMessage(`{ "args": ["-y"], "command": "npx" }`)))
+	assert.True(t, h2.IsMCPServerModified(mcpPath, "memory", json.RawMessage(`{"command":"npx"}`)))
+	assert.False(t, h2.IsMCPServerModified(mcpPath, "untracked", json.RawMessage(`{}`)))
+
+	h2.ForgetMCPServer(mcpPath, "memory")
+	assert.Empty(t, h2.ManagedMCPServers(mcpPath))
+	assert.Empty(t, h2.MCP)
+}
+
 func TestAppliedHashes_IsLocallyModified_NoStoredHash(t *testing.T) {
 	dir := t.TempDir()
 	h, err := commands.LoadAppliedHashes(dir)
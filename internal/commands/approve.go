@@ -3,10 +3,15 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/ruminaider/claude-sync/internal/approval"
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/claudecode"
-	"github.com/ruminaider/claude-sync/internal/sliceutil"
+	"github.com/ruminaider/claude-sync/internal/config"
 )
 
 // ApproveResult holds the result of applying pending changes.
@@ -14,67 +19,158 @@ type ApproveResult struct {
 	PermissionsApplied bool
 	MCPApplied         []string
 	HooksApplied       []string
+	Rejected           []string // keys of the items rejected
+	Remaining          int      // items left pending
+}
+
+// PendingHookChanges compares the hooks awaiting approval with those in
+// settings.json. Each changed event carries its before/after commands and
+// the risks found in the commands approving would install.
+func PendingHookChanges(claudeDir string, pending approval.PendingChanges) []approval.HookChange {
+	if len(pending.Hooks) == 0 {
+		return nil
+	}
+	var current map[string]json.RawMessage
+	if settings, err := claudecode.ReadSettings(claudeDir); err == nil {
+		if raw, ok := settings["hooks"]; ok {
+			json.Unmarshal(raw, &current)
+		}
+	}
+	// Approve replaces only the pending events, so only they can change.
+	before := make(map[string]json.RawMessage, len(pending.Hooks))
+	for event := range pending.Hooks {
+		if raw, ok := current[event]; ok {
+			before[event] = raw
+		}
+	}
+	return approval.CompareHooks(before, pending.Hooks)
 }
 
 // Approve reads pending-changes.yaml, applies each pending change, then clears the file.
 func Approve(claudeDir, syncDir string) (*ApproveResult, error) {
+	return ApproveItems(claudeDir, syncDir, ApproveOptions{All: true})
+}
+
+// ApproveOptions selects which pending changes to accept and which to
+// reject. Keys are item keys ("mcp.github", "hooks.PreToolUse",
+// "permissions.allow.Bash(git *)") or groups of them ("mcp").
+type ApproveOptions struct {
+	Accept []string
+	Reject []string
+	All    bool // accept every item not in Reject
+}
+
+// ApproveItems applies the accepted pending changes and remembers the
+// rejected ones so pull stops proposing them until they change upstream.
+// Both are recorded in the audit log. Items neither accepted nor
+// rejected stay pending.
+func ApproveItems(claudeDir, syncDir string, opts ApproveOptions) (*ApproveResult, error) {
 	pending, err := approval.ReadPending(syncDir)
 	if err != nil {
 		return nil, fmt.Errorf("reading pending changes: %w", err)
 	}
 
-	if pending.IsEmpty() {
+	items := pending.Items()
+	if len(items) == 0 {
 		return nil, fmt.Errorf("no pending changes to approve")
 	}
 
-	result := &ApproveResult{}
-
-	// Apply pending permissions.
-	if pending.Permissions != nil && (len(pending.Permissions.Allow) > 0 || len(pending.Permissions.Deny) > 0) {
-		settings, err := claudecode.ReadSettings(claudeDir)
-		if err != nil {
-			settings = make(map[string]json.RawMessage)
+	rejected, err := approval.MatchItems(items, opts.Reject)
+	if err != nil {
+		return nil, err
+	}
+	accepted := pending.Without(rejected).Items()
+	if !opts.All {
+		if accepted, err = approval.MatchItems(accepted, opts.Accept); err != nil {
+			return nil, err
 		}
+	}
 
-		// Read existing permissions.
-		var existingPerms struct {
-			Allow []string `json:"allow"`
-			Deny  []string `json:"deny"`
-		}
-		if permRaw, ok := settings["permissions"]; ok {
-			json.Unmarshal(permRaw, &existingPerms)
+	result, err := applyPending(claudeDir, syncDir, pending.Only(accepted))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range rejected {
+		result.Rejected = append(result.Rejected, item.Key)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	rejections, err := approval.ReadRejections(syncDir)
+	if err != nil {
+		return nil, err
+	}
+	entry := audit.Entry{Command: "approve", Proposed: pending.Commit}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	for _, item := range accepted {
+		rejections.Forget(item)
+		entry.Items = append(entry.Items, audit.Item{Action: "applied", Category: item.Category, Name: item.Name, Hash: item.Hash()})
+	}
+	for _, item := range rejected {
+		rejections.Reject(item, now)
+		entry.Items = append(entry.Items, audit.Item{Action: "rejected", Category: item.Category, Name: item.Name, Hash: item.Hash()})
+	}
+	ensureGitignorePatterns(syncDir, []string{"rejected-changes.yaml"})
+	if err := approval.WriteRejections(syncDir, rejections); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(syncDir, entry); err != nil {
+		return nil, err
+	}
+
+	rest := pending.Without(append(accepted, rejected...))
+	if len(rest.Items()) == 0 {
+		if err := approval.ClearPending(syncDir); err != nil {
+			return nil, fmt.Errorf("clearing pending: %w", err)
 		}
+	} else if err := approval.WritePending(syncDir, rest); err != nil {
+		return nil, fmt.Errorf("updating pending: %w", err)
+	}
+	result.Remaining = len(rest.Items())
 
-		// Additive merge.
-		mergedAllow := sliceutil.AppendUnique(existingPerms.Allow, pending.Permissions.Allow)
-		mergedDeny := sliceutil.AppendUnique(existingPerms.Deny, pending.Permissions.Deny)
+	return result, nil
+}
 
-		permData, err := json.Marshal(map[string]any{
-			"allow": mergedAllow,
-			"deny":  mergedDeny,
-		})
-		if err == nil {
-			settings["permissions"] = json.RawMessage(permData)
-			if err := claudecode.WriteSettings(claudeDir, settings); err != nil {
-				return nil, fmt.Errorf("applying permissions: %w", err)
-			}
-			result.PermissionsApplied = true
+// applyPending writes the given pending changes to the Claude directory.
+func applyPending(claudeDir, syncDir string, pending approval.PendingChanges) (*ApproveResult, error) {
+	result := &ApproveResult{}
+
+	// Applied items are recorded as managed so later pulls can remove them
+	// once they leave config.
+	hashes, _ := LoadAppliedHashes(syncDir)
+
+	// Apply pending permissions (additive).
+	if pending.Permissions != nil && (len(pending.Permissions.Allow) > 0 || len(pending.Permissions.Deny) > 0) {
+		perms := config.Permissions{
+			Allow: pending.Permissions.Allow,
+			Deny:  pending.Permissions.Deny,
+		}
+		if _, _, err := applyPermissions(claudeDir, perms, hashes.PermissionLedger(), false); err != nil {
+			return nil, fmt.Errorf("applying permissions: %w", err)
 		}
+		result.PermissionsApplied = true
 	}
 
 	// Apply pending MCP servers.
 	if len(pending.MCP) > 0 {
 		existing, _ := claudecode.ReadMCPConfig(claudeDir)
+		if existing == nil {
+			existing = make(map[string]json.RawMessage)
+		}
 		for k, v := range pending.MCP {
 			existing[k] = v
 		}
 		if err := claudecode.WriteMCPConfig(claudeDir, existing); err != nil {
 			return nil, fmt.Errorf("applying MCP config: %w", err)
 		}
+		mcpPath := filepath.Join(claudeDir, ".mcp.json")
 		result.MCPApplied = make([]string, 0, len(pending.MCP))
-		for k := range pending.MCP {
+		for k, v := range pending.MCP {
+			hashes.SetMCPServer(mcpPath, k, v)
 			result.MCPApplied = append(result.MCPApplied, k)
 		}
+		sort.Strings(result.MCPApplied)
 	}
 
 	// Apply pending hooks.
@@ -96,6 +192,7 @@ func Approve(claudeDir, syncDir string) (*ApproveResult, error) {
 			existingHooks[k] = v
 			result.HooksApplied = append(result.HooksApplied, k)
 		}
+		sort.Strings(result.HooksApplied)
 
 		hooksData, err := json.Marshal(existingHooks)
 		if err == nil {
@@ -106,9 +203,8 @@ func Approve(claudeDir, syncDir string) (*ApproveResult, error) {
 		}
 	}
 
-	// Clear pending changes.
-	if err := approval.ClearPending(syncDir); err != nil {
-		return nil, fmt.Errorf("clearing pending: %w", err)
+	if err := hashes.Save(); err != nil {
+		return nil, fmt.Errorf("saving applied hashes: %w", err)
 	}
 
 	return result, nil
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/ruminaider/claude-sync/internal/approval"
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/claudecode"
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/stretchr/testify/assert"
@@ -148,3 +149,77 @@ func TestApproveClears(t *testing.T) {
 	_, err = os.Stat(filepath.Join(syncDir, "pending-changes.yaml"))
 	assert.True(t, os.IsNotExist(err))
 }
+
+func TestApproveItems_AcceptsAndRejectsIndividually(t *testing.T) {
+	claudeDir, syncDir := setupApproveEnv(t)
+
+	pending := approval.PendingChanges{
+		Commit: "abc123",
+		Permissions: &approval.PendingPermissions{
+			Allow: []string{"Bash(git *)"},
+		},
+		MCP: map[string]json.RawMessage{
+			"github": json.RawMessage(`{"command":"gh-mcp"}`),
+		},
+		Hooks: map[string]json.RawMessage{
+			"PreToolUse": json.RawMessage(`[{"hooks":[{"type":"command","command":"curl -s https://x.example"}]}]`),
+		},
+	}
+	require.NoError(t, approval.WritePending(syncDir, pending))
+
+	result, err := commands.ApproveItems(claudeDir, syncDir, commands.ApproveOptions{
+		Accept: []string{"mcp.github"},
+		Reject: []string{"hooks.PreToolUse"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github"}, result.MCPApplied)
+	assert.Empty(t, result.HooksApplied)
+	assert.False(t, result.PermissionsApplied)
+	assert.Equal(t, []string{"hooks.PreToolUse"}, result.Rejected)
+	assert.Equal(t, 1, result.Remaining)
+
+	// The hook wasn't installed.
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	assert.NotContains(t, settings, "hooks")
+
+	// Only the permission rule is left pending.
+	left, err := approval.ReadPending(syncDir)
+	require.NoError(t, err)
+	require.Len(t, left.Items(), 1)
+	assert.Equal(t, "permissions.allow.Bash(git *)", left.Items()[0].Key)
+
+	// The rejection is remembered and both decisions are audited.
+	rejections, err := approval.ReadRejections(syncDir)
+	require.NoError(t, err)
+	assert.True(t, rejections.Rejected(pending.Items()[2]))
+
+	logged, err := audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, logged, 1)
+	assert.Equal(t, "approve", logged[0].Command)
+	assert.Equal(t, "abc123", logged[0].Proposed)
+	assert.NotEmpty(t, logged[0].User)
+	assert.Equal(t, []audit.Item{
+		{Action: "applied", Category: "mcp", Name: "github", Hash: pending.Items()[1].Hash()},
+		{Action: "rejected", Category: "hooks", Name: "PreToolUse", Hash: pending.Items()[2].Hash()},
+	}, logged[0].Items)
+	assert.NoFileExists(t, filepath.Join(syncDir, "approval-audit.jsonl"))
+}
+
+func TestApproveItems_UnknownKey(t *testing.T) {
+	claudeDir, syncDir := setupApproveEnv(t)
+
+	require.NoError(t, approval.WritePending(syncDir, approval.PendingChanges{
+		MCP: map[string]json.RawMessage{"github": json.RawMessage(`{"command":"gh-mcp"}`)},
+	}))
+
+	_, err := commands.ApproveItems(claudeDir, syncDir, commands.ApproveOptions{Accept: []string{"mcp.gitlab"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"mcp.gitlab"`)
+
+	// Nothing was applied or cleared.
+	p, err := approval.ReadPending(syncDir)
+	require.NoError(t, err)
+	assert.Contains(t, p.MCP, "github")
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ruminaider/claude-sync/internal/audit"
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/ruminaider/claude-sync/internal/subscriptions"
+)
+
+// recordAudit stamps e with the time, the sync repo's HEAD and the
+// subscription commits, and appends it to the audit log.
+func recordAudit(syncDir string, e audit.Entry) error {
+	e.Time = time.Now().UTC()
+	if head, err := git.RevParse(syncDir, "HEAD"); err == nil {
+		e.Commit = head
+	}
+	if state, err := subscriptions.ReadState(syncDir); err == nil {
+		for name, sub := range state.Subscriptions {
+			if sub.CommitSHA == "" {
+				continue
+			}
+			if e.Subscriptions == nil {
+				e.Subscriptions = make(map[string]string)
+			}
+			e.Subscriptions[name] = sub.CommitSHA
+		}
+	}
+	ensureGitignorePatterns(syncDir, []string{audit.FileName})
+	return audit.Append(syncDir, e)
+}
+
+// pullAuditEntry lists what a pull applied, skipped, removed and deferred.
+func pullAuditEntry(r *PullResult) audit.Entry {
+	e := audit.Entry{Command: "pull", Profile: strings.Join(r.ActiveProfiles, ", "), Snapshot: r.Snapshot}
+	add := func(action, category string, names []string, reason string) {
+		for _, name := range names {
+			e.Items = append(e.Items, audit.Item{Action: action, Category: category, Name: name, Reason: reason})
+		}
+	}
+	flag := func(action, category string, set bool, reason string) {
+		if set {
+			e.Items = append(e.Items, audit.Item{Action: action, Category: category, Reason: reason})
+		}
+	}
+
+	add("applied", "plugins", r.Installed, "")
+	add("skipped", "plugins", r.Failed, "install failed")
+	add("applied", "settings", r.SettingsApplied, "")
+	flag("skipped", "settings", r.SettingsSkipped, "local modification")
+	add("skipped", "settings", r.SettingsConflicts, "conflicts with a local change")
+	add("applied", "hooks", r.HooksApplied, "")
+	add("skipped", "hooks", r.HooksSkipped, "script not found")
+	add("applied", "permissions", r.PermissionsAdded.Allow, "allow")
+	add("applied", "permissions", r.PermissionsAdded.Deny, "deny")
+	add("removed", "permissions", r.PermissionsRemoved.Allow, "allow rule no longer in config")
+	add("removed", "permissions", r.PermissionsRemoved.Deny, "deny rule no longer in config")
+	flag("applied", "claude_md", r.ClaudeMDAssembled, "")
+	flag("skipped", "claude_md", r.ClaudeMDSkipped, "local modification")
+	add("skipped", "claude_md", r.ClaudeMDTemplateErrors, "template error, included as-is")
+	add("applied", "mcp", r.MCPApplied, "")
+	for _, path := range sortedMapKeys(r.MCPProjectApplied) {
+		add("applied", "mcp", r.MCPProjectApplied[path], path)
+	}
+	for _, path := range sortedMapKeys(r.MCPRemoved) {
+		add("removed", "mcp", r.MCPRemoved[path], "no longer in config")
+	}
+	for _, path := range sortedMapKeys(r.MCPSkipped) {
+		add("skipped", "mcp", r.MCPSkipped[path], "local modification")
+	}
+	flag("applied", "keybindings", r.KeybindingsApplied, "")
+	flag("skipped", "keybindings", r.KeybindingsSkipped, "local modification")
+	add("applied", "commands", r.CommandsRestored, "")
+	add("skipped", "commands", r.CommandsSkipped, "local modification")
+	add("applied", "skills", r.SkillsRestored, "")
+	add("skipped", "skills", r.SkillsSkipped, "local modification")
+	add("applied", "agents", r.AgentsRestored, "")
+	add("skipped", "agents", r.AgentsSkipped, "local modification")
+	add("applied", "output_styles", r.OutputStylesRestored, "")
+	add("skipped", "output_styles", r.OutputStylesSkipped, "local modification")
+	add("applied", "scripts", r.ScriptsRestored, "")
+	add("skipped", "scripts", r.ScriptsSkipped, "local modification")
+	add("applied", "memory", r.MemoryWritten, "")
+	add("skipped", "memory", r.MemorySkipped, "local modification")
+	add("skipped", "project_memory", r.ProjectMemoryPending, "not checked out on this machine")
+	for _, category := range r.SkippedCategories {
+		flag("skipped", category, true, "skipped in user preferences")
+	}
+	for _, c := range r.PendingHighRisk {
+		e.Items = append(e.Items, audit.Item{Action: "pending", Category: c.Category, Reason: c.Description})
+	}
+	for _, u := range r.UntrustedCommits {
+		e.Items = append(e.Items, audit.Item{Action: "skipped", Category: "signing", Name: u.Source, Reason: u.Reason()})
+		for _, dir := range u.Projects {
+			e.Items = append(e.Items, audit.Item{Action: "skipped", Category: "project", Name: dir, Reason: u.Source + ": " + u.Reason()})
+		}
+	}
+	return e
+}
+
+func sortedMapKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/claudecode"
 	"github.com/ruminaider/claude-sync/internal/claudemd"
 	"github.com/ruminaider/claude-sync/internal/config"
@@ -23,6 +24,10 @@ type AutoCommitResult struct {
 	Changed       bool
 	CommitMessage string
 	FilesChanged  []string
+	// TemplateEdited lists templated CLAUDE.md fragments whose rendered
+	// section was edited locally. They aren't committed; see
+	// TemplateEditedWarning.
+	TemplateEdited []string
 }
 
 // AutoCommitOptions configures profile-aware auto-commit behavior.
@@ -66,15 +71,19 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 	var changes []string
 	var stagedFiles []string
 	configChanged := false
+	entry := audit.Entry{Command: "auto-commit"}
+	var templateEdited []string
 
 	// Check CLAUDE.md changes (skipped entirely in manual mode).
 	if claudeMDMode != config.AutoCommitManual {
 		claudeMDPath := filepath.Join(claudeDir, "CLAUDE.md")
 		if claudeMDData, err := os.ReadFile(claudeMDPath); err == nil {
-			reconcileResult, err := claudemd.Reconcile(syncDir, string(claudeMDData))
+			reconcileResult, err := claudemd.ReconcileRendered(syncDir, string(claudeMDData), loadClaudeMDTemplateData(syncDir, cfg))
 			if err == nil {
+				templateEdited = reconcileResult.TemplateEdited
 				if len(reconcileResult.Updated) > 0 {
 					changes = append(changes, "update "+strings.Join(reconcileResult.Updated, ", "))
+					entry.Items = appendAuditItems(entry.Items, "changed", "claude_md", reconcileResult.Updated)
 					stagedFiles = append(stagedFiles, "claude-md")
 				}
 				if len(reconcileResult.New) > 0 && claudeMDMode == config.AutoCommitAll {
@@ -87,6 +96,7 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 						claudemd.WriteFragment(claudeMdDir, names[i], s.Content)
 					}
 					changes = append(changes, "add "+strings.Join(names, ", "))
+					entry.Items = appendAuditItems(entry.Items, "added", "claude_md", names)
 					stagedFiles = append(stagedFiles, "claude-md")
 					// Update config.yaml to include new fragments.
 					for _, name := range names {
@@ -118,6 +128,7 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 			}
 			if len(reconcileResult.Updated) > 0 {
 				changes = append(changes, "update memory "+strings.Join(reconcileResult.Updated, ", "))
+				entry.Items = appendAuditItems(entry.Items, "changed", "memory", reconcileResult.Updated)
 				stagedFiles = append(stagedFiles, "memory")
 			}
 			if len(reconcileResult.New) > 0 && memoryMode == config.AutoCommitAll {
@@ -129,6 +140,7 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 					}
 				}
 				changes = append(changes, "add memory "+strings.Join(names, ", "))
+				entry.Items = appendAuditItems(entry.Items, "added", "memory", names)
 				stagedFiles = append(stagedFiles, "memory")
 				for _, name := range names {
 					cfg.Memory.Include = append(cfg.Memory.Include, name)
@@ -141,19 +153,27 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 		}
 	}
 
+	// Compare against what pull applies on this machine; entries another
+	// machine's when: selector kept out of the local files stay as they are.
+	machineCfg, _ := cfg.ForMachine(config.CurrentMachine())
+
 	// Check settings changes.
 	settingsRaw, settErr := claudecode.ReadSettings(claudeDir)
 	if settErr == nil && cfg.Settings != nil {
-		for key, val := range cfg.Settings {
+		dec := SecretDecrypter()
+		for key, val := range machineCfg.Settings {
 			if raw, ok := settingsRaw[key]; ok {
 				var current any
 				json.Unmarshal(raw, &current)
+				current = normalizeScriptSetting(key, current)
+				current = keepEncryptedSetting(current, val, dec)
 				currentJSON, _ := json.Marshal(current)
 				cfgJSON, _ := json.Marshal(val)
 				if string(currentJSON) != string(cfgJSON) {
 					cfg.Settings[key] = current
 					configChanged = true
 					changes = append(changes, "update setting "+key)
+					entry.Items = appendAuditItems(entry.Items, "changed", "settings", []string{key})
 				}
 			}
 		}
@@ -162,20 +182,23 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 	// Check MCP changes.
 	currentMCP, mcpErr := claudecode.ReadMCPConfig(claudeDir)
 	if mcpErr == nil && len(currentMCP) > 0 {
-		if !jsonMapsEqual(currentMCP, cfg.MCP) {
+		currentMCP = keepSecretReferences(currentMCP, cfg.MCP, SecretResolver(syncDir))
+		currentMCP = keepEncryptedMCPValues(currentMCP, cfg.MCP, SecretDecrypter())
+		if !jsonMapsEqual(currentMCP, machineCfg.MCP) {
 			// Strip secrets and normalize paths before writing to config.
 			if secrets := DetectMCPSecrets(currentMCP); len(secrets) > 0 {
 				currentMCP = ReplaceSecrets(currentMCP, secrets)
 			}
 			currentMCP = NormalizeMCPPaths(currentMCP)
-			cfg.MCP = currentMCP
+			cfg.MCP = restoreFilteredMCP(currentMCP, cfg.MCP, machineCfg.MCP)
 			configChanged = true
 			changes = append(changes, "update MCP servers")
+			entry.Items = append(entry.Items, audit.Item{Action: "changed", Category: "mcp"})
 		}
 	}
 
 	if len(changes) == 0 {
-		return &AutoCommitResult{}, nil
+		return &AutoCommitResult{TemplateEdited: templateEdited}, nil
 	}
 
 	// Write updated config if needed.
@@ -203,21 +226,16 @@ func AutoCommit(claudeDir, syncDir string) (*AutoCommitResult, error) {
 		}
 	}
 
-	for _, f := range deduped {
-		if err := git.Add(syncDir, f); err != nil {
-			return nil, fmt.Errorf("staging %s: %w", f, err)
-		}
-	}
-
 	commitMsg := "auto: " + strings.Join(changes, ", ")
-	if err := git.Commit(syncDir, commitMsg); err != nil {
-		return nil, fmt.Errorf("committing: %w", err)
+	if err := commitAutoChanges(syncDir, deduped, commitMsg, entry); err != nil {
+		return nil, err
 	}
 
 	return &AutoCommitResult{
-		Changed:       true,
-		CommitMessage: commitMsg,
-		FilesChanged:  deduped,
+		Changed:        true,
+		CommitMessage:  commitMsg,
+		FilesChanged:   deduped,
+		TemplateEdited: templateEdited,
 	}, nil
 }
 
@@ -238,10 +256,14 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 			}
 		}
 	}
-	// Fallback: active-profile file.
+	// Fallback: active-profile file. With several active profiles, changes
+	// are written to the last (highest-precedence) one and compared against
+	// all of them merged in order.
+	activeNames := []string{profileName}
 	if !opts.ForceBase && profileName == "" {
-		if active, err := profiles.ReadActiveProfile(opts.SyncDir); err == nil && active != "" {
-			profileName = active
+		if active, err := profiles.ReadActiveProfiles(opts.SyncDir); err == nil && len(active) > 0 {
+			activeNames = active
+			profileName = active[len(active)-1]
 		}
 	}
 
@@ -267,9 +289,26 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 		profile = profiles.Profile{}
 	}
 
-	// Compute effective config (base + profile merged) for comparison.
-	effectiveSettings := profiles.MergeSettings(cfg.Settings, profile)
-	effectiveMCP := profiles.MergeMCP(cfg.MCP, profile)
+	// Compute effective config (base + resolved profile chain) for
+	// comparison. Changes are still written to the profile's own overlay,
+	// measured against what it inherits.
+	// Both are filtered for this machine, as pull does, so entries whose
+	// when: selector excludes it aren't taken as removed locally.
+	machine := config.CurrentMachine()
+	machineCfg, _ := cfg.ForMachine(machine)
+	effectiveProfile := profile
+	inheritedMCP := machineCfg.MCP
+	if resolved, _, err := profiles.ResolveProfiles(opts.SyncDir, activeNames); err == nil {
+		effectiveProfile = resolved.Profile
+		// Inherited is only meaningful when profileName is applied last.
+		if resolved.Chain[len(resolved.Chain)-1] == profileName {
+			inherited, _ := resolved.Inherited.ForMachine(machine)
+			inheritedMCP = profiles.MergeMCP(machineCfg.MCP, inherited)
+		}
+	}
+	effectiveProfile, _ = effectiveProfile.ForMachine(machine)
+	effectiveSettings := profiles.MergeSettings(machineCfg.Settings, effectiveProfile)
+	effectiveMCP := profiles.MergeMCP(machineCfg.MCP, effectiveProfile)
 
 	// Read user preferences for auto-commit mode.
 	prefsData, prefsErr := os.ReadFile(filepath.Join(opts.SyncDir, "user-preferences.yaml"))
@@ -288,16 +327,20 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 	var stagedFiles []string
 	profileChanged := false
 	configChanged := false
+	entry := audit.Entry{Command: "auto-commit", Profile: profileName}
+	var templateEdited []string
 
 	// Check CLAUDE.md changes — these still go to base config (fragments are shared).
 	// Skipped entirely in manual mode.
 	if claudeMDMode != config.AutoCommitManual {
 		claudeMDPath := filepath.Join(opts.ClaudeDir, "CLAUDE.md")
 		if claudeMDData, err := os.ReadFile(claudeMDPath); err == nil {
-			reconcileResult, err := claudemd.Reconcile(opts.SyncDir, string(claudeMDData))
+			reconcileResult, err := claudemd.ReconcileRendered(opts.SyncDir, string(claudeMDData), loadClaudeMDTemplateData(opts.SyncDir, cfg))
 			if err == nil {
+				templateEdited = reconcileResult.TemplateEdited
 				if len(reconcileResult.Updated) > 0 {
 					changes = append(changes, "update "+strings.Join(reconcileResult.Updated, ", "))
+					entry.Items = appendAuditItems(entry.Items, "changed", "claude_md", reconcileResult.Updated)
 					stagedFiles = append(stagedFiles, "claude-md")
 				}
 				if len(reconcileResult.New) > 0 && claudeMDMode == config.AutoCommitAll {
@@ -309,6 +352,7 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 						claudemd.WriteFragment(claudeMdDir, names[i], s.Content)
 					}
 					changes = append(changes, "add "+strings.Join(names, ", "))
+					entry.Items = appendAuditItems(entry.Items, "added", "claude_md", names)
 					stagedFiles = append(stagedFiles, "claude-md")
 					for _, name := range names {
 						cfg.ClaudeMD.Include = append(cfg.ClaudeMD.Include, name)
@@ -339,6 +383,7 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 			}
 			if len(reconcileResult.Updated) > 0 {
 				changes = append(changes, "update memory "+strings.Join(reconcileResult.Updated, ", "))
+				entry.Items = appendAuditItems(entry.Items, "changed", "memory", reconcileResult.Updated)
 				stagedFiles = append(stagedFiles, "memory")
 			}
 			if len(reconcileResult.New) > 0 && memoryModeCtx == config.AutoCommitAll {
@@ -350,6 +395,7 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 					}
 				}
 				changes = append(changes, "add memory "+strings.Join(names, ", "))
+				entry.Items = appendAuditItems(entry.Items, "added", "memory", names)
 				stagedFiles = append(stagedFiles, "memory")
 				for _, name := range names {
 					cfg.Memory.Include = append(cfg.Memory.Include, name)
@@ -365,10 +411,13 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 	// Check settings changes against effective config.
 	settingsRaw, settErr := claudecode.ReadSettings(opts.ClaudeDir)
 	if settErr == nil && effectiveSettings != nil {
+		dec := SecretDecrypter()
 		for key, val := range effectiveSettings {
 			if raw, ok := settingsRaw[key]; ok {
 				var current any
 				json.Unmarshal(raw, &current)
+				current = normalizeScriptSetting(key, current)
+				current = keepEncryptedSetting(current, val, dec)
 				currentJSON, _ := json.Marshal(current)
 				cfgJSON, _ := json.Marshal(val)
 				if string(currentJSON) != string(cfgJSON) {
@@ -379,6 +428,7 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 					profile.Settings[key] = current
 					profileChanged = true
 					changes = append(changes, "update setting "+key)
+					entry.Items = appendAuditItems(entry.Items, "changed", "settings", []string{key})
 				}
 			}
 		}
@@ -387,6 +437,8 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 	// Check MCP changes against effective config.
 	currentMCP, mcpErr := claudecode.ReadMCPConfig(opts.ClaudeDir)
 	if mcpErr == nil && len(currentMCP) > 0 {
+		currentMCP = keepSecretReferences(currentMCP, effectiveMCP, SecretResolver(opts.SyncDir))
+		currentMCP = keepEncryptedMCPValues(currentMCP, effectiveMCP, SecretDecrypter())
 		if !jsonMapsEqual(currentMCP, effectiveMCP) {
 			// Compute the delta: new/changed servers go to profile.Add,
 			// removed servers go to profile.Remove.
@@ -397,7 +449,7 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 			}
 			// Add servers that are new or changed vs base.
 			for name, val := range currentMCP {
-				baseVal, inBase := cfg.MCP[name]
+				baseVal, inBase := inheritedMCP[name]
 				if !inBase || string(baseVal) != string(val) {
 					newAdd[name] = val
 				}
@@ -410,7 +462,7 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 			}
 			for name := range effectiveMCP {
 				if _, inCurrent := currentMCP[name]; !inCurrent {
-					if _, inBase := cfg.MCP[name]; inBase && !removeSet[name] {
+					if _, inBase := inheritedMCP[name]; inBase && !removeSet[name] {
 						newRemove = append(newRemove, name)
 					}
 					// Also remove from profile adds if it was there.
@@ -437,11 +489,12 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 			profile.MCP.Remove = dedupedRemove
 			profileChanged = true
 			changes = append(changes, "update MCP servers")
+			entry.Items = append(entry.Items, audit.Item{Action: "changed", Category: "mcp"})
 		}
 	}
 
 	if len(changes) == 0 {
-		return &AutoCommitResult{}, nil
+		return &AutoCommitResult{TemplateEdited: templateEdited}, nil
 	}
 
 	// Write updated base config if CLAUDE.md fragments changed.
@@ -483,20 +536,46 @@ func AutoCommitWithContext(opts AutoCommitOptions) (*AutoCommitResult, error) {
 		}
 	}
 
-	for _, f := range deduped {
-		if err := git.Add(opts.SyncDir, f); err != nil {
-			return nil, fmt.Errorf("staging %s: %w", f, err)
-		}
-	}
-
 	commitMsg := "auto(" + profileName + "): " + strings.Join(changes, ", ")
-	if err := git.Commit(opts.SyncDir, commitMsg); err != nil {
-		return nil, fmt.Errorf("committing: %w", err)
+	if err := commitAutoChanges(opts.SyncDir, deduped, commitMsg, entry); err != nil {
+		return nil, err
 	}
 
 	return &AutoCommitResult{
-		Changed:       true,
-		CommitMessage: commitMsg,
-		FilesChanged:  deduped,
+		Changed:        true,
+		CommitMessage:  commitMsg,
+		FilesChanged:   deduped,
+		TemplateEdited: templateEdited,
 	}, nil
 }
+
+// commitAutoChanges stages files and commits them as message, recording the
+// attempt, with its error if it fails, in the audit log.
+func commitAutoChanges(syncDir string, files []string, message string, entry audit.Entry) error {
+	err := func() error {
+		for _, f := range files {
+			if err := git.Add(syncDir, f); err != nil {
+				return fmt.Errorf("staging %s: %w", f, err)
+			}
+		}
+		if err := git.Commit(syncDir, message); err != nil {
+			return fmt.Errorf("committing: %w", err)
+		}
+		return nil
+	}()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if auditErr := recordAudit(syncDir, entry); auditErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", auditErr)
+	}
+	return err
+}
+
+// appendAuditItems appends an item for each name.
+func appendAuditItems(items []audit.Item, action, category string, names []string) []audit.Item {
+	for _, name := range names {
+		items = append(items, audit.Item{Action: action, Category: category, Name: name})
+	}
+	return items
+}
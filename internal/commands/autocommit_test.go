@@ -8,10 +8,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/claudemd"
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/config"
 	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/ruminaider/claude-sync/internal/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -90,6 +92,46 @@ func TestAutoCommit_SettingsChanged(t *testing.T) {
 	cfg, err := config.Parse(cfgData)
 	require.NoError(t, err)
 	assert.Equal(t, "light", cfg.Settings["theme"])
+
+	// The commit is recorded in the audit log.
+	entries, err := audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "auto-commit", entries[0].Command)
+	assert.Empty(t, entries[0].Error)
+	assert.Equal(t, []audit.Item{{Action: "changed", Category: "settings", Name: "theme"}}, entries[0].Items)
+}
+
+func TestAutoCommit_ReportsEditedTemplateFragment(t *testing.T) {
+	claudeDir, syncDir := setupAutoCommitEnv(t)
+
+	_, err := claudemd.ImportClaudeMD(syncDir, "## Tools\nInstall packages with {{.Vars.pm}}.\n")
+	require.NoError(t, err)
+	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(cfgData)
+	require.NoError(t, err)
+	cfg.ClaudeMD.Include = []string{"tools"}
+	cfg.Vars = map[string]any{"pm": "apt"}
+	newCfgData, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), newCfgData, 0644))
+	exec.Command("git", "-C", syncDir, "add", ".").Run()
+	exec.Command("git", "-C", syncDir, "commit", "-m", "Add claude-md").Run()
+
+	// Edit the rendered text rather than the template.
+	os.WriteFile(filepath.Join(claudeDir, "CLAUDE.md"), []byte("## Tools\nInstall packages with dnf.\n"), 0644)
+
+	result, err := commands.AutoCommit(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.False(t, result.Changed)
+	assert.Equal(t, []string{"tools"}, result.TemplateEdited)
+	assert.Contains(t, commands.TemplateEditedWarning(result.TemplateEdited), "Edit claude-md/tools.md in the sync repo")
+
+	scan, err := commands.PushScan(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tools"}, scan.TemplateEdited)
+	assert.Nil(t, scan.ChangedClaudeMD)
 }
 
 func TestAutoCommit_ClaudeMDChanged(t *testing.T) {
@@ -271,6 +313,43 @@ func TestAutoCommitWithContext_ProfileAware_MCPToProfile(t *testing.T) {
 	assert.False(t, hasEvvyDB, "evvy-db should not be in base config")
 }
 
+func TestAutoCommitWithContext_MultipleActiveProfiles(t *testing.T) {
+	claudeDir, syncDir := setupAutoCommitEnv(t)
+
+	profilesDir := filepath.Join(syncDir, "profiles")
+	require.NoError(t, os.MkdirAll(profilesDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(profilesDir, "backend.yaml"), []byte("settings:\n  theme: light\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(profilesDir, "reviewer.yaml"), []byte(""), 0644))
+	require.NoError(t, profiles.WriteActiveProfiles(syncDir, []string{"backend", "reviewer"}))
+	exec.Command("git", "-C", syncDir, "add", ".").Run()
+	exec.Command("git", "-C", syncDir, "commit", "-m", "Add profiles").Run()
+
+	writeTheme := func(theme string) {
+		data, _ := json.Marshal(map[string]string{"theme": theme})
+		require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), data, 0644))
+	}
+
+	// Matches the merged profiles: nothing to commit.
+	writeTheme("light")
+	result, err := commands.AutoCommitWithContext(commands.AutoCommitOptions{ClaudeDir: claudeDir, SyncDir: syncDir})
+	require.NoError(t, err)
+	assert.False(t, result.Changed)
+
+	// A new value is written to the last (highest-precedence) profile.
+	writeTheme("solarized")
+	result, err = commands.AutoCommitWithContext(commands.AutoCommitOptions{ClaudeDir: claudeDir, SyncDir: syncDir})
+	require.NoError(t, err)
+	assert.True(t, result.Changed)
+	assert.Contains(t, result.CommitMessage, "auto(reviewer):")
+
+	reviewer, err := profiles.ReadProfile(syncDir, "reviewer")
+	require.NoError(t, err)
+	assert.Equal(t, "solarized", reviewer.Settings["theme"])
+	backend, err := profiles.ReadProfile(syncDir, "backend")
+	require.NoError(t, err)
+	assert.Equal(t, "light", backend.Settings["theme"])
+}
+
 func TestAutoCommitWithContext_NoProject_FallsBackToBase(t *testing.T) {
 	claudeDir, syncDir := setupAutoCommitEnv(t)
 
@@ -568,3 +647,101 @@ func TestAutoCommit_ManualModeSkipsAllClaudeMD(t *testing.T) {
 	// Manual mode: NO claude-md changes committed at all.
 	assert.False(t, result.Changed, "manual mode should not auto-commit any CLAUDE.md changes")
 }
+
+func TestAutoCommit_KeepsEntriesFilteredOutByWhen(t *testing.T) {
+	t.Setenv("CLAUDE_SYNC_TEST_VPN", "")
+	claudeDir, syncDir := setupAutoCommitEnv(t)
+	configYAML := `version: "1.0.0"
+settings:
+  theme: dark
+  apiKeyHelper:
+    value: vpn-key-helper.sh
+    when: {env: CLAUDE_SYNC_TEST_VPN}
+mcp:
+  shared:
+    command: shared
+  vpn-db:
+    command: db
+    when: {env: CLAUDE_SYNC_TEST_VPN}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(configYAML), 0644))
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-am", "add selectors").Run())
+
+	// This machine has its own apiKeyHelper and only the shared server.
+	settingsData, _ := json.Marshal(map[string]any{"theme": "dark", "apiKeyHelper": "local-helper.sh"})
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), settingsData, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".mcp.json"), []byte(`{"mcpServers":{"shared":{"command":"shared"}}}`), 0644))
+
+	result, err := commands.AutoCommit(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.False(t, result.Changed)
+
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".mcp.json"), []byte(`{"mcpServers":{"shared":{"command":"shared-v2"}}}`), 0644))
+	result, err = commands.AutoCommit(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.True(t, result.Changed)
+
+	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(cfgData)
+	require.NoError(t, err)
+	vpn := config.Selector{Env: config.StringList{"CLAUDE_SYNC_TEST_VPN"}}
+	assert.Equal(t, "vpn-key-helper.sh", cfg.Settings["apiKeyHelper"])
+	assert.Equal(t, vpn, cfg.When[config.WhenKey(config.WhenSettings, "apiKeyHelper")])
+	assert.JSONEq(t, `{"command":"shared-v2"}`, string(cfg.MCP["shared"]))
+	assert.JSONEq(t, `{"command":"db"}`, string(cfg.MCP["vpn-db"]))
+	assert.Equal(t, vpn, cfg.When[config.WhenKey(config.WhenMCP, "vpn-db")])
+}
+
+func TestAutoCommit_KeepsTeamSecretsEncrypted(t *testing.T) {
+	t.Setenv("CLAUDE_SYNC_IDENTITY", filepath.Join(t.TempDir(), "identity.txt"))
+	key, err := commands.SecretsPublicKey()
+	require.NoError(t, err)
+	encToken, err := secrets.Encrypt("tok-123", map[string]string{"me": key})
+	require.NoError(t, err)
+	encSetting, err := secrets.Encrypt("s3cret", map[string]string{"me": key})
+	require.NoError(t, err)
+
+	for _, withProfile := range []bool{false, true} {
+		t.Run(fmt.Sprintf("profile=%v", withProfile), func(t *testing.T) {
+			claudeDir, syncDir := setupAutoCommitEnv(t)
+			cfg := config.Config{
+				Version: "1.0.0",
+				Settings: map[string]any{
+					"theme": "dark",
+					"env":   map[string]any{"INTERNAL_TOKEN": encSetting},
+				},
+				MCP: map[string]json.RawMessage{
+					"internal-api": json.RawMessage(`{"command":"internal-mcp","env":{"TOKEN":"` + encToken + `"}}`),
+				},
+			}
+			cfgData, err := config.Marshal(cfg)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), cfgData, 0644))
+			require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-am", "share secrets").Run())
+
+			// What pull writes: the decrypted values, plus a local edit.
+			settingsData, _ := json.Marshal(map[string]any{"theme": "light", "env": map[string]any{"INTERNAL_TOKEN": "s3cret"}})
+			require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), settingsData, 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".mcp.json"),
+				[]byte(`{"mcpServers":{"internal-api":{"command":"internal-mcp-v2","env":{"TOKEN":"tok-123"}}}}`), 0644))
+
+			var result *commands.AutoCommitResult
+			if withProfile {
+				require.NoError(t, profiles.WriteActiveProfile(syncDir, "work"))
+				result, err = commands.AutoCommitWithContext(commands.AutoCommitOptions{ClaudeDir: claudeDir, SyncDir: syncDir})
+			} else {
+				result, err = commands.AutoCommit(claudeDir, syncDir)
+			}
+			require.NoError(t, err)
+			require.True(t, result.Changed)
+			assert.Contains(t, result.CommitMessage, "update setting theme")
+			assert.NotContains(t, result.CommitMessage, "update setting env")
+
+			out, err := exec.Command("git", "-C", syncDir, "show", "HEAD").CombinedOutput()
+			require.NoError(t, err)
+			assert.NotContains(t, string(out), "tok-123")
+			assert.NotContains(t, string(out), "s3cret")
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/ruminaider/claude-sync/internal/config"
+)
+
+// claudeMDLocallyModified reports whether the CLAUDE.md at path was edited
+// since pull last wrote it. When the file has managed region markers only
+// the region counts, and an empty region is never treated as edited.
+func claudeMDLocallyModified(hashes *AppliedHashes, path string) bool {
+	if data, err := os.ReadFile(path); err == nil {
+		if r, ok := claudemd.SplitRegions(string(data)); ok {
+			return r.Managed != "" && hashes.IsContentModified(HashKeyClaudeMD, r.Managed)
+		}
+	}
+	return hashes.IsLocallyModified(HashKeyClaudeMD, path)
+}
+
+// writeClaudeMD writes assembled to the CLAUDE.md at path. When the file
+// has managed region markers only the region is replaced, keeping the
+// hand-written content around it.
+func writeClaudeMD(path, assembled string) error {
+	content := assembled
+	if data, err := os.ReadFile(path); err == nil {
+		if r, ok := claudemd.SplitRegions(string(data)); ok {
+			r.Managed = assembled
+			content = r.Join()
+		}
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// UnmanagedClaudeMDSections returns the hand-written sections outside the
+// managed region of ~/.claude/CLAUDE.md. Returns an error if the file has no
+// managed region.
+func UnmanagedClaudeMDSections(claudeDir string) ([]claudemd.Section, error) {
+	r, err := readClaudeMDRegions(claudeDir)
+	if err != nil {
+		return nil, err
+	}
+	return append(splitHandWritten(r.Before), splitHandWritten(r.After)...), nil
+}
+
+// PromoteClaudeMD turns hand-written sections outside the managed region of
+// ~/.claude/CLAUDE.md into fragments included in config.yaml, and moves them
+// into the region so the next pull owns them. With no names, every section
+// outside the region is promoted. Returns the new fragment names.
+func PromoteClaudeMD(claudeDir, syncDir string, names []string) ([]string, error) {
+	r, err := readClaudeMDRegions(claudeDir)
+	if err != nil {
+		return nil, err
+	}
+	before := splitHandWritten(r.Before)
+	after := splitHandWritten(r.After)
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var promoted []claudemd.Section
+	var promotedNames []string
+	for _, sec := range append(append([]claudemd.Section{}, before...), after...) {
+		name := claudemd.SectionFragmentName(sec)
+		if len(names) > 0 && !wanted[name] {
+			continue
+		}
+		delete(wanted, name)
+		promoted = append(promoted, sec)
+		promotedNames = append(promotedNames, name)
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("no section %q outside the managed region of CLAUDE.md", name)
+		}
+	}
+	if len(promoted) == 0 {
+		return nil, nil
+	}
+
+	claudeMdDir := filepath.Join(syncDir, "claude-md")
+	manifest, err := claudemd.ReadManifest(claudeMdDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range promotedNames {
+		if _, exists := manifest.Fragments[name]; exists {
+			return nil, fmt.Errorf("fragment %q already exists in sync repo; rename the section or remove the fragment first", name)
+		}
+	}
+
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(claudeMdDir, 0755); err != nil {
+		return nil, err
+	}
+	for i, sec := range promoted {
+		name := promotedNames[i]
+		if err := claudemd.WriteFragment(claudeMdDir, name, sec.Content); err != nil {
+			return nil, err
+		}
+		manifest.Fragments[name] = claudemd.FragmentMeta{
+			Header:      sec.Header,
+			ContentHash: claudemd.ContentHash(sec.Content),
+			Group:       sec.Group,
+		}
+		manifest.Order = append(manifest.Order, name)
+	}
+	if err := claudemd.WriteManifest(claudeMdDir, manifest); err != nil {
+		return nil, err
+	}
+
+	cfg.ClaudeMD.Include = append(cfg.ClaudeMD.Include, promotedNames...)
+	newData, err := config.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := os.WriteFile(cfgPath, newData, 0644); err != nil {
+		return nil, fmt.Errorf("writing config: %w", err)
+	}
+
+	// Move the sections into the region. If the region still holds what pull
+	// last wrote, record the new region so the next pull doesn't take the
+	// move for a local edit.
+	hashes, _ := LoadAppliedHashes(syncDir)
+	regionEdited := r.Managed != "" && hashes.IsContentModified(HashKeyClaudeMD, r.Managed)
+	isPromoted := make(map[string]bool, len(promotedNames))
+	for _, name := range promotedNames {
+		isPromoted[name] = true
+	}
+	r.Before = withoutSections(r.Before, before, isPromoted, false)
+	r.After = withoutSections(r.After, after, isPromoted, true)
+	if r.Managed != "" {
+		r.Managed += "\n"
+	}
+	r.Managed += claudemd.Assemble(promoted)
+	if err := os.WriteFile(filepath.Join(claudeDir, "CLAUDE.md"), []byte(r.Join()), 0644); err != nil {
+		return nil, fmt.Errorf("writing CLAUDE.md: %w", err)
+	}
+	if !regionEdited {
+		hashes.Set(HashKeyClaudeMD, r.Managed)
+		if err := hashes.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return promotedNames, nil
+}
+
+// readClaudeMDRegions reads ~/.claude/CLAUDE.md and splits it at its
+// managed region markers.
+func readClaudeMDRegions(claudeDir string) (claudemd.Regions, error) {
+	data, err := os.ReadFile(filepath.Join(claudeDir, "CLAUDE.md"))
+	if err != nil {
+		return claudemd.Regions{}, fmt.Errorf("reading CLAUDE.md: %w", err)
+	}
+	r, ok := claudemd.SplitRegions(string(data))
+	if !ok {
+		return claudemd.Regions{}, fmt.Errorf("CLAUDE.md has no managed region; add %s and %s lines around the content claude-sync manages", claudemd.RegionBegin, claudemd.RegionEnd)
+	}
+	return r, nil
+}
+
+// splitHandWritten splits text outside the managed region into sections,
+// dropping blank ones such as the newline after the end marker.
+func splitHandWritten(text string) []claudemd.Section {
+	var sections []claudemd.Section
+	for _, sec := range claudemd.Split(text) {
+		if strings.TrimSpace(sec.Content) != "" {
+			sections = append(sections, sec)
+		}
+	}
+	return sections
+}
+
+// withoutSections returns the hand-written text of one side of the managed
+// region with the promoted sections removed. Text without any promoted
+// section is returned unchanged.
+func withoutSections(text string, sections []claudemd.Section, promoted map[string]bool, afterRegion bool) string {
+	var kept []claudemd.Section
+	for _, sec := range sections {
+		if !promoted[claudemd.SectionFragmentName(sec)] {
+			kept = append(kept, sec)
+		}
+	}
+	if len(kept) == len(sections) {
+		return text
+	}
+	rest := claudemd.Assemble(kept)
+	switch {
+	case rest == "" && afterRegion:
+		return "\n"
+	case rest == "":
+		return ""
+	case afterRegion:
+		return "\n" + rest + "\n"
+	default:
+		return rest + "\n"
+	}
+}
@@ -0,0 +1,149 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/claudecode"
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupClaudeMDRegionEnv(t *testing.T) (claudeDir, syncDir string) {
+	t.Helper()
+	claudeDir = t.TempDir()
+	require.NoError(t, claudecode.Bootstrap(claudeDir))
+	syncDir = filepath.Join(t.TempDir(), ".claude-sync")
+
+	claudeMdDir := filepath.Join(syncDir, "claude-md")
+	require.NoError(t, os.MkdirAll(claudeMdDir, 0755))
+	_, err := claudemd.ImportClaudeMD(syncDir, "## Introduction\nWelcome.")
+	require.NoError(t, err)
+
+	configYAML := "version: \"1.0.0\"\nplugins:\n  upstream: []\nclaude_md:\n  include:\n    - introduction\n"
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(configYAML), 0644))
+	return claudeDir, syncDir
+}
+
+func writeIntroFragment(t *testing.T, syncDir, content string) {
+	t.Helper()
+	require.NoError(t, claudemd.WriteFragment(filepath.Join(syncDir, "claude-md"), "introduction", content))
+}
+
+func TestPull_ClaudeMDManagedRegion(t *testing.T) {
+	claudeDir, syncDir := setupClaudeMDRegionEnv(t)
+	claudeMDPath := filepath.Join(claudeDir, "CLAUDE.md")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	// Wrap what pull wrote in markers and add notes around it.
+	data, err := os.ReadFile(claudeMDPath)
+	require.NoError(t, err)
+	withNotes := "# Mine\nPersonal notes\n" + claudemd.RegionBegin + "\n" + string(data) + "\n" + claudemd.RegionEnd + "\n## Scratch\nTODO\n"
+	require.NoError(t, os.WriteFile(claudeMDPath, []byte(withNotes), 0644))
+
+	// Upstream changes the fragment: only the region is rewritten.
+	writeIntroFragment(t, syncDir, "## Introduction\nWelcome back.")
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.True(t, result.ClaudeMDAssembled)
+	assert.False(t, result.ClaudeMDSkipped, "edits outside the region don't block pull")
+
+	data, err = os.ReadFile(claudeMDPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Mine\nPersonal notes\n"+claudemd.RegionBegin+"\n## Introduction\nWelcome back.\n"+claudemd.RegionEnd+"\n## Scratch\nTODO\n", string(data))
+
+	// Editing inside the region is a local modification.
+	edited := "# Mine\nPersonal notes\n" + claudemd.RegionBegin + "\n## Introduction\nEdited here.\n" + claudemd.RegionEnd + "\n"
+	require.NoError(t, os.WriteFile(claudeMDPath, []byte(edited), 0644))
+	writeIntroFragment(t, syncDir, "## Introduction\nWelcome again.")
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.True(t, result.ClaudeMDSkipped)
+
+	data, err = os.ReadFile(claudeMDPath)
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(data))
+}
+
+func TestPull_ClaudeMDEmptyRegionIsFilled(t *testing.T) {
+	claudeDir, syncDir := setupClaudeMDRegionEnv(t)
+	claudeMDPath := filepath.Join(claudeDir, "CLAUDE.md")
+
+	// A hand-written file from before claude-sync, with an empty region.
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	content := "My notes\n" + claudemd.RegionBegin + "\n" + claudemd.RegionEnd + "\n"
+	require.NoError(t, os.WriteFile(claudeMDPath, []byte(content), 0644))
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.True(t, result.ClaudeMDAssembled)
+
+	data, err := os.ReadFile(claudeMDPath)
+	require.NoError(t, err)
+	assert.Equal(t, "My notes\n"+claudemd.RegionBegin+"\n## Introduction\nWelcome.\n"+claudemd.RegionEnd+"\n", string(data))
+}
+
+func TestPromoteClaudeMD(t *testing.T) {
+	claudeDir, syncDir := setupClaudeMDRegionEnv(t)
+	claudeMDPath := filepath.Join(claudeDir, "CLAUDE.md")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	content := "## Build Notes\nUse make.\n" + claudemd.RegionBegin + "\n## Introduction\nWelcome.\n" + claudemd.RegionEnd + "\n## Scratch\nTODO\n"
+	require.NoError(t, os.WriteFile(claudeMDPath, []byte(content), 0644))
+
+	sections, err := commands.UnmanagedClaudeMDSections(claudeDir)
+	require.NoError(t, err)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "Build Notes", sections[0].Header)
+	assert.Equal(t, "Scratch", sections[1].Header)
+
+	// Push ignores what's outside the region.
+	scan, err := commands.PushScan(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.Nil(t, scan.ChangedClaudeMD)
+
+	names, err := commands.PromoteClaudeMD(claudeDir, syncDir, []string{"build-notes"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build-notes"}, names)
+
+	fragment, err := claudemd.ReadFragment(filepath.Join(syncDir, "claude-md"), "build-notes")
+	require.NoError(t, err)
+	assert.Equal(t, "## Build Notes\nUse make.", fragment)
+
+	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(cfgData)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"introduction", "build-notes"}, cfg.ClaudeMD.Include)
+
+	data, err := os.ReadFile(claudeMDPath)
+	require.NoError(t, err)
+	assert.Equal(t, claudemd.RegionBegin+"\n## Introduction\nWelcome.\n## Build Notes\nUse make.\n"+claudemd.RegionEnd+"\n## Scratch\nTODO\n", string(data))
+
+	// The next pull owns the promoted section and keeps the rest.
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.False(t, result.ClaudeMDSkipped)
+	data, err = os.ReadFile(claudeMDPath)
+	require.NoError(t, err)
+	assert.Equal(t, claudemd.RegionBegin+"\n## Introduction\nWelcome.\n## Build Notes\nUse make.\n"+claudemd.RegionEnd+"\n## Scratch\nTODO\n", string(data))
+
+	_, err = commands.PromoteClaudeMD(claudeDir, syncDir, []string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestPromoteClaudeMD_NoRegion(t *testing.T) {
+	claudeDir, syncDir := setupClaudeMDRegionEnv(t)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "CLAUDE.md"), []byte("## Notes\nMine"), 0644))
+
+	_, err := commands.PromoteClaudeMD(claudeDir, syncDir, nil)
+	assert.ErrorContains(t, err, "no managed region")
+}
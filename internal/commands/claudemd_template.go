@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/profiles"
+)
+
+// claudeMDTemplateData returns what CLAUDE.md fragments are rendered with on
+// this machine. Vars from config are overlaid by the active profile's, then
+// by the user's own preferences. Env holds only the variables the user's
+// preferences list under template_env, so a shared fragment can't copy
+// credentials from the environment into CLAUDE.md.
+func claudeMDTemplateData(cfg config.Config, activeProfile *profiles.Profile, activeNames []string, prefs config.UserPreferences) claudemd.TemplateData {
+	machine := config.CurrentMachine()
+	vars := cfg.Vars
+	if activeProfile != nil {
+		vars = profiles.MergeVars(vars, *activeProfile)
+	}
+	if len(prefs.Vars) > 0 {
+		vars = profiles.MergeVars(vars, profiles.Profile{Vars: prefs.Vars})
+	}
+	env := make(map[string]string)
+	for _, name := range prefs.TemplateEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			env[name] = v
+		}
+	}
+	return claudemd.TemplateData{
+		Hostname: machine.Hostname,
+		OS:       machine.GOOS,
+		Profile:  strings.Join(activeNames, ","),
+		Env:      env,
+		Vars:     vars,
+	}
+}
+
+// loadClaudeMDTemplateData is claudeMDTemplateData with the active profiles
+// and user preferences read from syncDir.
+func loadClaudeMDTemplateData(syncDir string, cfg config.Config) claudemd.TemplateData {
+	activeNames, _ := profiles.ReadActiveProfiles(syncDir)
+	var activeProfile *profiles.Profile
+	if len(activeNames) > 0 {
+		if p, _, err := readActiveProfiles(syncDir, activeNames); err == nil {
+			p, _ = p.ForMachine(config.CurrentMachine())
+			activeProfile = &p
+		}
+	}
+	prefs := config.DefaultUserPreferences()
+	if data, err := os.ReadFile(filepath.Join(syncDir, "user-preferences.yaml")); err == nil {
+		prefs, _ = config.ParseUserPreferences(data)
+	}
+	return claudeMDTemplateData(cfg, activeProfile, activeNames, prefs)
+}
+
+// TemplateEditedWarning tells the user that the named templated CLAUDE.md
+// fragments were edited in their rendered form, which push and auto-commit
+// leave alone, and where to make the edit instead. Returns "" for none.
+func TemplateEditedWarning(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	files := make([]string, len(names))
+	for i, name := range names {
+		files[i] = "claude-md/" + name + ".md"
+	}
+	return fmt.Sprintf("CLAUDE.md sections rendered from templates were edited and not synced: %s. Edit %s in the sync repo instead.",
+		strings.Join(names, ", "), strings.Join(files, ", "))
+}
+
+// templateErrorStrings formats template errors for display.
+func templateErrorStrings(errs []claudemd.TemplateError) []string {
+	var out []string
+	for _, e := range errs {
+		out = append(out, e.Error())
+	}
+	return out
+}
@@ -1,19 +1,29 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/ruminaider/claude-sync/internal/claudecode"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/ruminaider/claude-sync/internal/secrets"
+	"github.com/ruminaider/claude-sync/internal/sliceutil"
 	"go.yaml.in/yaml/v3"
 )
 
 // PendingConflict describes a single unresolved merge conflict.
 type PendingConflict struct {
 	Timestamp   string          `yaml:"timestamp"`
+	Source      string          `yaml:"source,omitempty"` // file the conflict is in, e.g. "settings.json"
 	Key         string          `yaml:"key"`
 	LocalValue  json.RawMessage `yaml:"local_value,omitempty"`
 	RemoteValue json.RawMessage `yaml:"remote_value,omitempty"`
@@ -24,10 +34,13 @@ type PendingConflictFile struct {
 	Conflicts []PendingConflict `yaml:"conflicts"`
 }
 
+// conflictsDir holds pending conflicts under the sync dir. It is
+// gitignored: conflicts are between this machine's files and the repo.
 const conflictsDir = "conflicts"
 
 // SaveConflicts writes pending conflicts to the sync dir.
 func SaveConflicts(syncDir string, conflicts []PendingConflict) error {
+	ensureGitignorePatterns(syncDir, []string{conflictsDir + "/"})
 	dir := filepath.Join(syncDir, conflictsDir)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
@@ -41,6 +54,33 @@ func SaveConflicts(syncDir string, conflicts []PendingConflict) error {
 	return os.WriteFile(filepath.Join(dir, ts+".yaml"), data, 0644)
 }
 
+// AddConflicts saves the conflicts not already pending, so a pull that
+// meets the same conflict again doesn't record it twice. Returns how many
+// were added.
+func AddConflicts(syncDir string, conflicts []PendingConflict) (int, error) {
+	existing, err := ListPendingConflicts(syncDir)
+	if err != nil {
+		return 0, err
+	}
+	var added []PendingConflict
+	for _, c := range conflicts {
+		if !slices.ContainsFunc(existing, func(e PendingConflict) bool {
+			return e.Source == c.Source && e.Key == c.Key && bytes.Equal(e.RemoteValue, c.RemoteValue)
+		}) {
+			added = append(added, c)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	// Rewrite everything as one file: files are named by the second, so a
+	// second file saved in the same second would replace the first.
+	if err := DiscardConflicts(syncDir); err != nil {
+		return 0, err
+	}
+	return len(added), SaveConflicts(syncDir, append(existing, added...))
+}
+
 // HasPendingConflicts checks if there are unresolved conflicts.
 func HasPendingConflicts(syncDir string) bool {
 	dir := filepath.Join(syncDir, conflictsDir)
@@ -95,9 +135,143 @@ func DiscardConflicts(syncDir string) error {
 	return os.RemoveAll(dir)
 }
 
-// ResolveConflict applies a resolution choice and removes the conflict file.
-// choice: "local" keeps local value, "remote" keeps remote value.
-func ResolveConflict(syncDir string, index int, choice string) error {
+// Conflict resolution choices.
+const (
+	ResolveLocal  = "local"
+	ResolveRemote = "remote"
+	ResolveBoth   = "both" // list-valued keys only: local items, then remote ones
+)
+
+// CanKeepBoth reports whether both sides are lists, so ResolveBoth applies.
+func (c PendingConflict) CanKeepBoth() bool {
+	var local, remote []json.RawMessage
+	return json.Unmarshal(c.LocalValue, &local) == nil && json.Unmarshal(c.RemoteValue, &remote) == nil
+}
+
+// Value returns the value choice resolves the conflict to. A nil value
+// means the key is removed.
+func (c PendingConflict) Value(choice string) (json.RawMessage, error) {
+	switch choice {
+	case ResolveLocal:
+		return c.LocalValue, nil
+	case ResolveRemote:
+		return c.RemoteValue, nil
+	case ResolveBoth:
+		var local, remote []json.RawMessage
+		if json.Unmarshal(c.LocalValue, &local) != nil || json.Unmarshal(c.RemoteValue, &remote) != nil {
+			return nil, fmt.Errorf("%s: both sides must be lists to keep both", c.Key)
+		}
+		union := local
+		for _, item := range remote {
+			if !slices.ContainsFunc(union, func(u json.RawMessage) bool { return jsonEquivalent(u, item) }) {
+				union = append(union, item)
+			}
+		}
+		return json.Marshal(union)
+	}
+	return nil, fmt.Errorf("unknown resolution %q (use local, remote or both)", choice)
+}
+
+// ConflictLine is one row of a side-by-side view of a conflict.
+type ConflictLine struct {
+	Local   string
+	Remote  string
+	Changed bool
+}
+
+// SideBySide lays the local and remote values out as YAML, line by line,
+// with the lines they have in common aligned.
+func (c PendingConflict) SideBySide() []ConflictLine {
+	local, remote := conflictValueLines(c.LocalValue), conflictValueLines(c.RemoteValue)
+
+	// Longest common subsequence of lines, filled from the end.
+	lcs := make([][]int, len(local)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(remote)+1)
+	}
+	for i := len(local) - 1; i >= 0; i-- {
+		for j := len(remote) - 1; j >= 0; j-- {
+			if local[i] == remote[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var rows []ConflictLine
+	var removed, added []string
+	flush := func() {
+		for k := 0; k < max(len(removed), len(added)); k++ {
+			row := ConflictLine{Changed: true}
+			if k < len(removed) {
+				row.Local = removed[k]
+			}
+			if k < len(added) {
+				row.Remote = added[k]
+			}
+			rows = append(rows, row)
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(local) || j < len(remote) {
+		switch {
+		case i < len(local) && j < len(remote) && local[i] == remote[j]:
+			flush()
+			rows = append(rows, ConflictLine{Local: local[i], Remote: remote[j]})
+			i++
+			j++
+		case j == len(remote) || (i < len(local) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, local[i])
+			i++
+		default:
+			added = append(added, remote[j])
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+// conflictValueLines renders a conflict value as YAML lines.
+func conflictValueLines(raw json.RawMessage) []string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return []string{"(not set)"}
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return []string{string(raw)}
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return []string{string(raw)}
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+// ResolveConflict resolves conflict index with choice (ResolveLocal,
+// ResolveRemote or ResolveBoth); see ResolveConflictValue.
+func ResolveConflict(claudeDir, syncDir string, index int, choice string) error {
+	conflicts, err := ListPendingConflicts(syncDir)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(conflicts) {
+		return fmt.Errorf("conflict index %d out of range (0-%d)", index, len(conflicts)-1)
+	}
+	value, err := conflicts[index].Value(choice)
+	if err != nil {
+		return err
+	}
+	return ResolveConflictValue(claudeDir, syncDir, index, value)
+}
+
+// ResolveConflictValue writes value for the conflicting key into
+// config.yaml and, for conflicts in settings.json, into
+// claudeDir/settings.json, then removes the conflict. A nil value removes
+// the key.
+func ResolveConflictValue(claudeDir, syncDir string, index int, value json.RawMessage) error {
 	conflicts, err := ListPendingConflicts(syncDir)
 	if err != nil {
 		return err
@@ -105,6 +279,19 @@ func ResolveConflict(syncDir string, index int, choice string) error {
 	if index < 0 || index >= len(conflicts) {
 		return fmt.Errorf("conflict index %d out of range (0-%d)", index, len(conflicts)-1)
 	}
+	c := conflicts[index]
+	if string(value) == "null" {
+		value = nil
+	}
+
+	if err := writeConfigValue(syncDir, c.Key, value); err != nil {
+		return err
+	}
+	if c.Source == "settings.json" {
+		if err := writeSettingsValue(claudeDir, c.Key, value); err != nil {
+			return err
+		}
+	}
 
 	// Remove the resolved conflict and re-save remaining
 	remaining := append(conflicts[:index], conflicts[index+1:]...)
@@ -118,3 +305,400 @@ func ResolveConflict(syncDir string, index int, choice string) error {
 	}
 	return nil
 }
+
+// writeConfigValue sets key ("settings.<name>", "hooks.<event>",
+// "mcp.<server>", "permissions.allow" or "permissions.deny") in the sync
+// repo. A key an active profile sets is written to that profile rather
+// than config.yaml. Strings where the old value held a team secret are
+// encrypted to the recipients, and paths under this machine's home
+// directory are written as ~/ paths; a value that still names the home
+// directory is refused.
+func writeConfigValue(syncDir, key string, value json.RawMessage) error {
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	section, name, _ := strings.Cut(key, ".")
+	if value, err = portableValue(key, value); err != nil {
+		return err
+	}
+	origins, err := activeProfileOrigins(syncDir)
+	if err != nil {
+		return err
+	}
+	if profile := keyOrigin(origins, section, name); profile != "" {
+		return writeProfileValue(syncDir, profile, cfg, key, value)
+	}
+
+	switch {
+	case section == "settings" && name != "":
+		if value == nil {
+			delete(cfg.Settings, name)
+			break
+		}
+		v, err := sharedSettingValue(key, cfg.Settings[name], value, cfg.Recipients)
+		if err != nil {
+			return err
+		}
+		if cfg.Settings == nil {
+			cfg.Settings = make(map[string]any)
+		}
+		cfg.Settings[name] = v
+	case section == "hooks" && name != "":
+		cfg.Hooks = setRaw(cfg.Hooks, name, value)
+	case section == "mcp" && name != "":
+		if value, err = sharedRawValue(key, cfg.MCP[name], value, cfg.Recipients); err != nil {
+			return err
+		}
+		cfg.MCP = setRaw(cfg.MCP, name, value)
+	case key == "permissions.allow" || key == "permissions.deny":
+		var rules []string
+		if value != nil {
+			if err := json.Unmarshal(value, &rules); err != nil {
+				return fmt.Errorf("%s must be a list of rules: %w", key, err)
+			}
+		}
+		// Rules active profiles add stay in their profiles.
+		rules = slices.DeleteFunc(rules, func(r string) bool {
+			return origins[profiles.ResolvedItem{Section: "permissions", Op: name, Item: r}.Key()] != ""
+		})
+		if name == "allow" {
+			cfg.Permissions.Allow = rules
+		} else {
+			cfg.Permissions.Deny = rules
+		}
+	default:
+		return fmt.Errorf("don't know where %q goes in config.yaml", key)
+	}
+
+	newCfgData, err := config.MarshalV2(cfg)
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := os.WriteFile(cfgPath, newCfgData, 0644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// activeProfileOrigins returns the Origins of the active profiles resolved
+// together, or nil when none is active.
+func activeProfileOrigins(syncDir string) (map[string]string, error) {
+	names, err := profiles.ReadActiveProfiles(syncDir)
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	available, err := profiles.ListProfiles(syncDir)
+	if err != nil {
+		return nil, err
+	}
+	names = slices.DeleteFunc(names, func(n string) bool { return !slices.Contains(available, n) })
+	if len(names) == 0 {
+		return nil, nil
+	}
+	resolved, _, err := profiles.ResolveProfiles(syncDir, names)
+	if err != nil {
+		return nil, fmt.Errorf("resolving profiles: %w", err)
+	}
+	return resolved.Origins, nil
+}
+
+// keyOrigin returns the profile that sets the settings key, hook event or
+// MCP server name, or "" when it comes from config.yaml.
+func keyOrigin(origins map[string]string, section, name string) string {
+	var ops []string
+	switch section {
+	case "settings":
+		ops = []string{"set"}
+	case "hooks", "mcp":
+		ops = []string{"add", "remove"}
+	}
+	for _, op := range ops {
+		if origin := origins[profiles.ResolvedItem{Section: section, Op: op, Item: name}.Key()]; origin != "" {
+			return origin
+		}
+	}
+	return ""
+}
+
+// writeProfileValue sets key in profiles/<profile>.yaml. For a hook or MCP
+// server, a nil value removes it, also from config.yaml's if it is there.
+func writeProfileValue(syncDir, profile string, cfg config.Config, key string, value json.RawMessage) error {
+	p, err := profiles.ReadProfile(syncDir, profile)
+	if err != nil {
+		return err
+	}
+	section, name, _ := strings.Cut(key, ".")
+	setAddRemove := func(add map[string]json.RawMessage, remove []string, inConfig bool) (map[string]json.RawMessage, []string) {
+		add = setRaw(add, name, value)
+		if value == nil && inConfig {
+			return add, sliceutil.AppendUnique(remove, []string{name})
+		}
+		return add, sliceutil.RemoveAll(remove, []string{name})
+	}
+	switch section {
+	case "settings":
+		if value == nil {
+			delete(p.Settings, name)
+			break
+		}
+		v, err := sharedSettingValue(key, p.Settings[name], value, cfg.Recipients)
+		if err != nil {
+			return err
+		}
+		if p.Settings == nil {
+			p.Settings = make(map[string]any)
+		}
+		p.Settings[name] = v
+	case "hooks":
+		_, inConfig := cfg.Hooks[name]
+		p.Hooks.Add, p.Hooks.Remove = setAddRemove(p.Hooks.Add, p.Hooks.Remove, inConfig)
+	case "mcp":
+		if value, err = sharedRawValue(key, p.MCP.Add[name], value, cfg.Recipients); err != nil {
+			return err
+		}
+		_, inConfig := cfg.MCP[name]
+		p.MCP.Add, p.MCP.Remove = setAddRemove(p.MCP.Add, p.MCP.Remove, inConfig)
+	default:
+		return fmt.Errorf("don't know where %q goes in profile %q", key, profile)
+	}
+
+	data, err := profiles.MarshalProfile(p)
+	if err != nil {
+		return fmt.Errorf("marshaling profile %q: %w", profile, err)
+	}
+	if err := os.WriteFile(filepath.Join(syncDir, "profiles", profile+".yaml"), data, 0644); err != nil {
+		return fmt.Errorf("writing profile %q: %w", profile, err)
+	}
+	return nil
+}
+
+// portableValue returns value with the paths the key's commands run
+// written as ~/ paths. Any other string naming this machine's home
+// directory is an error: it wouldn't work on another machine.
+func portableValue(key string, value json.RawMessage) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	section, name, _ := strings.Cut(key, ".")
+	switch section {
+	case "settings":
+		var v any
+		if err := json.Unmarshal(value, &v); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		settings, _ := NormalizeScriptPaths(map[string]any{name: v}, nil)
+		data, err := json.Marshal(settings[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		value = data
+	case "hooks":
+		_, hooks := NormalizeScriptPaths(nil, map[string]json.RawMessage{name: value})
+		value = hooks[name]
+	case "mcp":
+		value = NormalizeMCPPaths(map[string]json.RawMessage{name: value})[name]
+	}
+
+	home := os.Getenv("HOME")
+	if home == "" {
+		return value, nil
+	}
+	var v any
+	if err := json.Unmarshal(value, &v); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	var found string
+	mapSettingStrings(v, key, func(where, s string) (string, bool) {
+		if found == "" && strings.Contains(s, home+"/") {
+			found = where
+		}
+		return s, true
+	})
+	if found != "" {
+		return nil, fmt.Errorf("%s names this machine's home directory (%s); use a ~/ path", found, home)
+	}
+	return value, nil
+}
+
+// sharedSettingValue decodes value for config, encrypting each string that
+// replaces a team secret in old so the secret isn't written in plaintext.
+// Strings that are already ciphertext are kept.
+func sharedSettingValue(key string, old any, value json.RawMessage, recipients map[string]string) (any, error) {
+	var v any
+	if err := json.Unmarshal(value, &v); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	secretAt := make(map[string]bool)
+	mapSettingStrings(old, key, func(where, s string) (string, bool) {
+		if secrets.IsEncrypted(s) {
+			secretAt[where] = true
+		}
+		return s, true
+	})
+	if len(secretAt) == 0 {
+		return v, nil
+	}
+	var encErr error
+	v = mapSettingStrings(v, key, func(where, s string) (string, bool) {
+		if !secretAt[where] || secrets.IsEncrypted(s) || encErr != nil {
+			return s, true
+		}
+		enc, err := secrets.Encrypt(s, recipients)
+		if err != nil {
+			encErr = fmt.Errorf("encrypting %s: %w", where, err)
+			return s, true
+		}
+		return enc, true
+	})
+	return v, encErr
+}
+
+// sharedRawValue is sharedSettingValue for a raw JSON value.
+func sharedRawValue(key string, old, value json.RawMessage, recipients map[string]string) (json.RawMessage, error) {
+	if value == nil || old == nil {
+		return value, nil
+	}
+	var oldValue any
+	if err := json.Unmarshal(old, &oldValue); err != nil {
+		return value, nil
+	}
+	v, err := sharedSettingValue(key, oldValue, value, recipients)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// writeSettingsValue sets key ("settings.<name>" or "hooks.<event>") in
+// claudeDir/settings.json. value is in config form (see toConfigForm): team
+// secrets are decrypted and ~/ paths expanded before writing.
+func writeSettingsValue(claudeDir, key string, value json.RawMessage) error {
+	settings, err := claudecode.ReadSettings(claudeDir)
+	if err != nil {
+		return err
+	}
+	section, name, _ := strings.Cut(key, ".")
+	switch section {
+	case "settings":
+		if value != nil {
+			file, err := toSettingsForm(map[string]json.RawMessage{name: value}, SecretDecrypter())
+			if err != nil {
+				return err
+			}
+			if value = file[name]; value == nil {
+				return fmt.Errorf("%s: can't decrypt the team secret it holds", key)
+			}
+		}
+		settings = setRaw(settings, name, value)
+	case "hooks":
+		if value != nil {
+			_, hooks := ExpandScriptPaths(nil, map[string]json.RawMessage{name: value})
+			value = hooks[name]
+		}
+		hooks := hookEvents(settings)
+		hooks = setRaw(hooks, name, value)
+		if len(hooks) == 0 {
+			delete(settings, "hooks")
+			break
+		}
+		data, err := json.Marshal(hooks)
+		if err != nil {
+			return fmt.Errorf("marshaling hooks: %w", err)
+		}
+		settings["hooks"] = data
+	default:
+		return fmt.Errorf("don't know where %q goes in settings.json", key)
+	}
+	return claudecode.WriteSettings(claudeDir, settings)
+}
+
+// setRaw sets or, for a nil value, deletes m[key], allocating m as needed.
+func setRaw(m map[string]json.RawMessage, key string, value json.RawMessage) map[string]json.RawMessage {
+	if value == nil {
+		delete(m, key)
+		return m
+	}
+	if m == nil {
+		m = make(map[string]json.RawMessage)
+	}
+	m[key] = value
+	return m
+}
+
+// conflictEditHeader starts the file EditConflictFile writes.
+const conflictEditHeader = "# Resolve %s%s.\n# Edit the value below (YAML or JSON) and save. Delete everything to remove the key.\n#\n"
+
+// WriteConflictEditFile writes a temporary YAML file for editing a merged
+// value: the local and remote values as comments, then value. Read it
+// back with ReadConflictEditFile.
+func WriteConflictEditFile(c PendingConflict, value json.RawMessage) (string, error) {
+	var b strings.Builder
+	where := ""
+	if c.Source != "" {
+		where = " in " + c.Source
+	}
+	fmt.Fprintf(&b, conflictEditHeader, c.Key, where)
+	for _, side := range []struct {
+		label string
+		raw   json.RawMessage
+	}{{"Local", c.LocalValue}, {"Remote", c.RemoteValue}} {
+		fmt.Fprintf(&b, "# %s:\n", side.label)
+		for _, line := range conflictValueLines(side.raw) {
+			fmt.Fprintf(&b, "#   %s\n", line)
+		}
+	}
+	b.WriteString("\n")
+	if len(value) > 0 && string(value) != "null" {
+		b.WriteString(strings.Join(conflictValueLines(value), "\n") + "\n")
+	}
+
+	f, err := os.CreateTemp("", "claude-sync-conflict-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// ReadConflictEditFile parses a file written by WriteConflictEditFile
+// after editing. An empty document gives a nil value.
+func ReadConflictEditFile(path string) (json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("parsing edited value: %w", err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// EditorCommand returns the command that opens path in $VISUAL, $EDITOR
+// or vi. The variables may carry arguments, as in "code --wait".
+func EditorCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("VISUAL"))
+	if len(args) == 0 {
+		args = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd
+}
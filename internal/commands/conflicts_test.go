@@ -2,9 +2,15 @@ package commands_test
 
 import (
 	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
+	"github.com/ruminaider/claude-sync/internal/claudecode"
 	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/profiles"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -56,20 +62,276 @@ func TestHasPendingConflicts_Empty(t *testing.T) {
 }
 
 func TestResolveConflict(t *testing.T) {
+	claudeDir := t.TempDir()
 	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(`version: "1.0.0"
+# Team defaults.
+settings:
+  model: opus
+permissions:
+  allow:
+    - Read
+`), 0644))
 
 	conflicts := []commands.PendingConflict{
-		{Key: "a", LocalValue: json.RawMessage(`"1"`), RemoteValue: json.RawMessage(`"2"`)},
-		{Key: "b", LocalValue: json.RawMessage(`"3"`), RemoteValue: json.RawMessage(`"4"`)},
+		{Key: "settings.model", LocalValue: json.RawMessage(`"opus"`), RemoteValue: json.RawMessage(`"sonnet"`)},
+		{Key: "permissions.allow", LocalValue: json.RawMessage(`["Read","Edit"]`), RemoteValue: json.RawMessage(`["Read","Bash(ls *)"]`)},
 	}
 	commands.SaveConflicts(syncDir, conflicts)
 
-	err := commands.ResolveConflict(syncDir, 0, "local")
+	err := commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveRemote)
 	require.NoError(t, err)
 
 	remaining, _ := commands.ListPendingConflicts(syncDir)
-	assert.Len(t, remaining, 1)
-	assert.Equal(t, "b", remaining[0].Key)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "permissions.allow", remaining[0].Key)
+
+	require.NoError(t, commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveBoth))
+	assert.False(t, commands.HasPendingConflicts(syncDir))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Team defaults.", "comments survive the rewrite")
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "sonnet", cfg.Settings["model"])
+	assert.Equal(t, []string{"Read", "Edit", "Bash(ls *)"}, cfg.Permissions.Allow)
+}
+
+func TestResolveConflict_OutOfRange(t *testing.T) {
+	syncDir := t.TempDir()
+	err := commands.ResolveConflict(t.TempDir(), syncDir, 0, commands.ResolveLocal)
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestResolveConflictValue_SettingsJSON(t *testing.T) {
+	claudeDir := t.TempDir()
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\nsettings:\n  model: sonnet\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"model":"haiku","theme":"dark"}`), 0644))
+
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{{
+		Source:      "settings.json",
+		Key:         "settings.model",
+		LocalValue:  json.RawMessage(`"haiku"`),
+		RemoteValue: json.RawMessage(`"sonnet"`),
+	}})
+
+	require.NoError(t, commands.ResolveConflictValue(claudeDir, syncDir, 0, json.RawMessage(`"opus"`)))
+	assert.False(t, commands.HasPendingConflicts(syncDir))
+
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"opus"`, string(settings["model"]))
+	assert.JSONEq(t, `"dark"`, string(settings["theme"]))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "opus", cfg.Settings["model"])
+}
+
+func TestResolveConflictValue_NilRemovesKey(t *testing.T) {
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\nsettings:\n  model: sonnet\n  theme: dark\n"), 0644))
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{{Key: "settings.theme", RemoteValue: json.RawMessage(`"dark"`)}})
+
+	require.NoError(t, commands.ResolveConflict(t.TempDir(), syncDir, 0, commands.ResolveLocal))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.Settings, "theme")
+	assert.Equal(t, "sonnet", cfg.Settings["model"])
+}
+
+func TestResolveConflictValue_UnknownKey(t *testing.T) {
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\n"), 0644))
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{{Key: "test", LocalValue: json.RawMessage(`"a"`)}})
+
+	err := commands.ResolveConflict(t.TempDir(), syncDir, 0, commands.ResolveLocal)
+	assert.ErrorContains(t, err, "don't know where")
+	assert.True(t, commands.HasPendingConflicts(syncDir), "the conflict stays pending")
+}
+
+func TestResolveConflictValue_KeepsTeamSecretsEncrypted(t *testing.T) {
+	claudeDir := t.TempDir()
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\nsettings:\n  env:\n    REGION: us\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{}`), 0644))
+	require.NoError(t, exec.Command("git", "init", "-q", syncDir).Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.email", "test@test.com").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.name", "Test").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-qm", "init").Run())
+	t.Setenv("CLAUDE_SYNC_IDENTITY", filepath.Join(t.TempDir(), "alice.txt"))
+	key, err := commands.SecretsPublicKey()
+	require.NoError(t, err)
+	_, err = commands.SecretsAddRecipient(syncDir, "alice", key)
+	require.NoError(t, err)
+	target, err := commands.ParseSecretTarget("settings.env.TOKEN")
+	require.NoError(t, err)
+	require.NoError(t, commands.SecretsShare(syncDir, target, "old-token"))
+
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{{
+		Source:     "settings.json",
+		Key:        "settings.env",
+		LocalValue: json.RawMessage(`{"REGION":"eu","TOKEN":"new-token"}`),
+	}})
+	require.NoError(t, commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveLocal))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "new-token")
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	env := cfg.Settings["env"].(map[string]any)
+	assert.Equal(t, "eu", env["REGION"])
+	plain, err := commands.SecretDecrypter().Decrypt(env["TOKEN"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "new-token", plain)
+
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"REGION":"eu","TOKEN":"new-token"}`, string(settings["env"]))
+}
+
+func TestResolveConflictValue_WritesProfileKeysToTheirProfile(t *testing.T) {
+	claudeDir := t.TempDir()
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\nsettings:\n  model: sonnet\n  theme: dark\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(syncDir, "profiles"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "work.yaml"), []byte("settings:\n  model: opus\n"), 0644))
+	require.NoError(t, profiles.WriteActiveProfile(syncDir, "work"))
+
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{
+		{Source: "settings.json", Key: "settings.model", LocalValue: json.RawMessage(`"haiku"`)},
+		{Source: "settings.json", Key: "settings.theme", LocalValue: json.RawMessage(`"light"`)},
+	})
+	require.NoError(t, commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveLocal))
+	require.NoError(t, commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveLocal))
+
+	work, err := profiles.ReadProfile(syncDir, "work")
+	require.NoError(t, err)
+	assert.Equal(t, "haiku", work.Settings["model"], "the profile's key is written to the profile")
+	assert.NotContains(t, work.Settings, "theme")
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "sonnet", cfg.Settings["model"], "config.yaml keeps the base value")
+	assert.Equal(t, "light", cfg.Settings["theme"])
+}
+
+func TestResolveConflictValue_HomePaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	claudeDir := t.TempDir()
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{}`), 0644))
+
+	// A script path under $HOME is written as a ~/ path, and expanded again
+	// in settings.json.
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{{
+		Source:     "settings.json",
+		Key:        "settings.statusLine",
+		LocalValue: json.RawMessage(`{"type":"command","command":"` + home + `/.claude/statusline.sh"}`),
+	}})
+	require.NoError(t, commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveLocal))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), home)
+	cfg, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"type": "command", "command": "~/.claude/statusline.sh"}, cfg.Settings["statusLine"])
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"command","command":"`+home+`/.claude/statusline.sh"}`, string(settings["statusLine"]))
+
+	// Any other string naming the home directory is refused.
+	commands.SaveConflicts(syncDir, []commands.PendingConflict{{
+		Source:     "settings.json",
+		Key:        "settings.env",
+		LocalValue: json.RawMessage(`{"TOOLS":"` + home + `/bin"}`),
+	}})
+	err = commands.ResolveConflict(claudeDir, syncDir, 0, commands.ResolveLocal)
+	assert.ErrorContains(t, err, "settings.env.TOOLS names this machine's home directory")
+	assert.True(t, commands.HasPendingConflicts(syncDir), "the conflict stays pending")
+	data, err = os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), home)
+}
+
+func TestPendingConflict_Value(t *testing.T) {
+	lists := commands.PendingConflict{
+		LocalValue:  json.RawMessage(`["a","b"]`),
+		RemoteValue: json.RawMessage(`["b","c"]`),
+	}
+	assert.True(t, lists.CanKeepBoth())
+	both, err := lists.Value(commands.ResolveBoth)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["a","b","c"]`, string(both))
+
+	scalars := commands.PendingConflict{LocalValue: json.RawMessage(`"a"`), RemoteValue: json.RawMessage(`"b"`)}
+	assert.False(t, scalars.CanKeepBoth())
+	_, err = scalars.Value(commands.ResolveBoth)
+	assert.Error(t, err)
+	local, err := scalars.Value(commands.ResolveLocal)
+	require.NoError(t, err)
+	assert.Equal(t, `"a"`, string(local))
+	_, err = scalars.Value("theirs")
+	assert.Error(t, err)
+}
+
+func TestPendingConflict_SideBySide(t *testing.T) {
+	c := commands.PendingConflict{
+		LocalValue:  json.RawMessage(`["Read","Edit","Write"]`),
+		RemoteValue: json.RawMessage(`["Read","Bash(ls *)","Write"]`),
+	}
+	assert.Equal(t, []commands.ConflictLine{
+		{Local: "- Read", Remote: "- Read"},
+		{Local: "- Edit", Remote: "- Bash(ls *)", Changed: true},
+		{Local: "- Write", Remote: "- Write"},
+	}, c.SideBySide())
+
+	removed := commands.PendingConflict{LocalValue: json.RawMessage(`"plan"`)}
+	assert.Equal(t, []commands.ConflictLine{
+		{Local: "plan", Remote: "(not set)", Changed: true},
+	}, removed.SideBySide())
+}
+
+func TestConflictEditFile_RoundTrip(t *testing.T) {
+	c := commands.PendingConflict{
+		Source:      "settings.json",
+		Key:         "settings.env",
+		LocalValue:  json.RawMessage(`{"A":"1"}`),
+		RemoteValue: json.RawMessage(`{"B":"2"}`),
+	}
+	path, err := commands.WriteConflictEditFile(c, c.LocalValue)
+	require.NoError(t, err)
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "settings.env in settings.json")
+	assert.Contains(t, string(data), "#   B: \"2\"")
+
+	value, err := commands.ReadConflictEditFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"A":"1"}`, string(value))
+
+	// Saving an empty document removes the key.
+	require.NoError(t, os.WriteFile(path, []byte("# nothing\n"), 0644))
+	value, err = commands.ReadConflictEditFile(path)
+	require.NoError(t, err)
+	assert.Nil(t, value)
 }
 
 func TestPushBlockedByConflicts(t *testing.T) {
@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/audit"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/ruminaider/claude-sync/internal/project"
+)
+
+// rollbackPaths are the sync repo paths Rollback restores: the config, the
+// profiles and their selection rules, and CLAUDE.md and memory fragments.
+var rollbackPaths = []string{"config.yaml", "profiles", project.RulesFileName, "claude-md", "memory"}
+
+// historyCategories is the display order of HistoryChange categories.
+var historyCategories = []string{"plugins", "hooks", "permissions", "mcp", "settings", "claude_md", "memory", "profiles", "other"}
+
+// HistoryChange summarizes what one commit changed in a single category.
+type HistoryChange struct {
+	Category string
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// String renders the change as e.g. "plugins: +foo@bar, -baz@bar".
+func (c HistoryChange) String() string {
+	var parts []string
+	for _, s := range c.Added {
+		parts = append(parts, "+"+s)
+	}
+	for _, s := range c.Removed {
+		parts = append(parts, "-"+s)
+	}
+	for _, s := range c.Modified {
+		parts = append(parts, "~"+s)
+	}
+	return c.Category + ": " + strings.Join(parts, ", ")
+}
+
+// HistoryEntry is a sync repo commit with a categorized summary of its changes.
+type HistoryEntry struct {
+	git.LogEntry
+	Changes []HistoryChange
+}
+
+// History returns up to limit commits to the sync repo, newest first.
+func History(syncDir string, limit int) ([]HistoryEntry, error) {
+	if !git.IsRepo(syncDir) {
+		return nil, fmt.Errorf("claude-sync not initialized. Run 'claude-sync init' or 'claude-sync join <url>'")
+	}
+	commits, err := git.Log(syncDir, limit)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]HistoryEntry, 0, len(commits))
+	for _, c := range commits {
+		changes, err := summarizeCommit(syncDir, c.SHA)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, HistoryEntry{LogEntry: c, Changes: changes})
+	}
+	return entries, nil
+}
+
+// RollbackResult describes the outcome of Rollback.
+type RollbackResult struct {
+	Target    git.LogEntry
+	Committed bool // false if the config already matched Target
+	Changes   []HistoryChange
+}
+
+// Rollback restores config.yaml, profiles and fragments to their state at
+// ref and records that as a new commit, so the rollback itself can be
+// pushed or undone. It does not touch ~/.claude; callers re-run pull.
+func Rollback(syncDir, ref string) (*RollbackResult, error) {
+	if !git.IsRepo(syncDir) {
+		return nil, fmt.Errorf("claude-sync not initialized. Run 'claude-sync init' or 'claude-sync join <url>'")
+	}
+	target, err := git.ShowCommit(syncDir, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if git.HasStagedChanges(syncDir) {
+		return nil, fmt.Errorf("sync repo has staged changes; commit or unstage them before rolling back")
+	}
+	for _, p := range rollbackPaths {
+		if git.HasUncommittedChanges(syncDir, p) {
+			return nil, fmt.Errorf("%s has uncommitted changes; run 'claude-sync push' or discard them before rolling back", p)
+		}
+	}
+
+	if err := git.RestorePaths(syncDir, target.SHA, rollbackPaths...); err != nil {
+		return nil, err
+	}
+	result := &RollbackResult{Target: target}
+	if !git.HasStagedChanges(syncDir) {
+		return result, nil
+	}
+
+	msg := fmt.Sprintf("Roll back to %s: %s", target.ShortSHA(), target.Subject)
+	if err := git.Commit(syncDir, msg); err != nil {
+		return nil, fmt.Errorf("committing rollback: %w", err)
+	}
+	result.Committed = true
+	result.Changes, _ = summarizeCommit(syncDir, "HEAD")
+
+	entry := audit.Entry{Command: "rollback"}
+	for _, c := range result.Changes {
+		for _, name := range c.Added {
+			entry.Items = append(entry.Items, audit.Item{Action: "added", Category: c.Category, Name: name})
+		}
+		for _, name := range c.Removed {
+			entry.Items = append(entry.Items, audit.Item{Action: "removed", Category: c.Category, Name: name})
+		}
+		for _, name := range c.Modified {
+			entry.Items = append(entry.Items, audit.Item{Action: "changed", Category: c.Category, Name: name})
+		}
+	}
+	if err := recordAudit(syncDir, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+	return result, nil
+}
+
+// summarizeCommit groups the files a commit touched into categories. For
+// config.yaml the old and new configs are compared item by item.
+func summarizeCommit(syncDir, ref string) ([]HistoryChange, error) {
+	files, err := git.CommitChanges(syncDir, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[string]*HistoryChange)
+	get := func(category string) *HistoryChange {
+		if c, ok := byCategory[category]; ok {
+			return c
+		}
+		c := &HistoryChange{Category: category}
+		byCategory[category] = c
+		return c
+	}
+
+	for _, f := range files {
+		switch {
+		case f.Path == "config.yaml":
+			diffConfigs(configAt(syncDir, ref+"^"), configAt(syncDir, ref), get)
+		case strings.HasPrefix(f.Path, "profiles/"):
+			recordFileChange(get("profiles"), f, strings.TrimSuffix(path.Base(f.Path), ".yaml"))
+		case strings.HasPrefix(f.Path, "claude-md/"):
+			if path.Base(f.Path) == "manifest.yaml" {
+				continue
+			}
+			recordFileChange(get("claude_md"), f, strings.TrimSuffix(strings.TrimPrefix(f.Path, "claude-md/"), ".md"))
+		case strings.HasPrefix(f.Path, "memory/"):
+			recordFileChange(get("memory"), f, strings.TrimSuffix(strings.TrimPrefix(f.Path, "memory/"), ".md"))
+		default:
+			recordFileChange(get("other"), f, f.Path)
+		}
+	}
+
+	var changes []HistoryChange
+	for _, category := range historyCategories {
+		c, ok := byCategory[category]
+		if !ok || len(c.Added)+len(c.Removed)+len(c.Modified) == 0 {
+			continue
+		}
+		changes = append(changes, *c)
+	}
+	return changes, nil
+}
+
+// configAt parses config.yaml at ref, returning an empty config if it
+// doesn't exist there (e.g. the parent of the first commit) or is invalid.
+func configAt(syncDir, ref string) config.Config {
+	data, err := git.ShowFile(syncDir, ref, "config.yaml")
+	if err != nil {
+		return config.Config{}
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return config.Config{}
+	}
+	return cfg
+}
+
+func recordFileChange(c *HistoryChange, f git.FileChange, name string) {
+	switch f.Status {
+	case "A":
+		c.Added = append(c.Added, name)
+	case "D":
+		c.Removed = append(c.Removed, name)
+	default:
+		c.Modified = append(c.Modified, name)
+	}
+}
+
+// diffConfigs records item-level differences between two configs.
+func diffConfigs(before, after config.Config, get func(string) *HistoryChange) {
+	plugins := get("plugins")
+	diffList(plugins, before.AllPluginKeys(), after.AllPluginKeys(), "")
+	for key, v := range after.Pinned {
+		if old, ok := before.Pinned[key]; ok && old != v {
+			plugins.Modified = append(plugins.Modified, fmt.Sprintf("%s (pinned %s -> %s)", key, old, v))
+		}
+	}
+
+	diffRaw(get("hooks"), before.Hooks, after.Hooks)
+	diffList(get("permissions"), before.Permissions.Allow, after.Permissions.Allow, "allow ")
+	diffList(get("permissions"), before.Permissions.Deny, after.Permissions.Deny, "deny ")
+	diffRaw(get("mcp"), before.MCP, after.MCP)
+	diffRaw(get("settings"), marshalValues(before.Settings), marshalValues(after.Settings))
+	diffList(get("claude_md"), before.ClaudeMD.Include, after.ClaudeMD.Include, "")
+}
+
+func diffList(c *HistoryChange, before, after []string, prefix string) {
+	for _, s := range after {
+		if !slices.Contains(before, s) && !slices.Contains(c.Added, prefix+s) {
+			c.Added = append(c.Added, prefix+s)
+		}
+	}
+	for _, s := range before {
+		if !slices.Contains(after, s) && !slices.Contains(c.Removed, prefix+s) {
+			c.Removed = append(c.Removed, prefix+s)
+		}
+	}
+}
+
+func diffRaw(c *HistoryChange, before, after map[string]json.RawMessage) {
+	for _, k := range sortedKeys(after) {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, k)
+		case !bytes.Equal(old, after[k]):
+			c.Modified = append(c.Modified, k)
+		}
+	}
+	for _, k := range sortedKeys(before) {
+		if _, ok := after[k]; !ok {
+			c.Removed = append(c.Removed, k)
+		}
+	}
+}
+
+// marshalValues JSON-encodes each value so settings can be compared with diffRaw.
+func marshalValues(m map[string]any) map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		data, _ := json.Marshal(v)
+		out[k] = data
+	}
+	return out
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/audit"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHistoryRepo creates a sync repo with three commits:
+// 1. base config with one plugin and a work profile
+// 2. a second plugin, a hook, a permission and a CLAUDE.md fragment
+// 3. the work profile removed
+func setupHistoryRepo(t *testing.T) string {
+	t.Helper()
+	syncDir := t.TempDir()
+	require.NoError(t, git.Init(syncDir))
+
+	commit := func(msg string) {
+		t.Helper()
+		require.NoError(t, git.Add(syncDir, "-A"))
+		require.NoError(t, git.Commit(syncDir, msg))
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(syncDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	write(".gitignore", "audit.jsonl\n")
+	write("config.yaml", "version: \"1.0.0\"\nplugins:\n  upstream:\n    - a@m\n")
+	write("profiles/work.yaml", "settings:\n  model: opus\n")
+	commit("Initial config")
+
+	write("config.yaml", `version: "1.0.0"
+plugins:
+  upstream:
+    - a@m
+    - b@m
+hooks:
+  PreToolUse: "guard"
+permissions:
+  allow:
+    - Bash(ls)
+claude_md:
+  include:
+    - style
+`)
+	write("claude-md/style.md", "## Style\n")
+	commit("Add b, guard hook and style")
+
+	require.NoError(t, os.Remove(filepath.Join(syncDir, "profiles", "work.yaml")))
+	commit("Drop work profile")
+	return syncDir
+}
+
+func TestHistory(t *testing.T) {
+	syncDir := setupHistoryRepo(t)
+
+	entries, err := commands.History(syncDir, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "Drop work profile", entries[0].Subject)
+	require.Len(t, entries[0].Changes, 1)
+	assert.Equal(t, "profiles: -work", entries[0].Changes[0].String())
+
+	var got []string
+	for _, c := range entries[1].Changes {
+		got = append(got, c.String())
+	}
+	assert.Equal(t, []string{
+		"plugins: +b@m",
+		"hooks: +PreToolUse",
+		"permissions: +allow Bash(ls)",
+		"claude_md: +style",
+	}, got)
+
+	assert.Equal(t, "Initial config", entries[2].Subject)
+	assert.Equal(t, "plugins: +a@m", entries[2].Changes[0].String())
+
+	limited, err := commands.History(syncDir, 1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
+
+func TestRollback(t *testing.T) {
+	syncDir := setupHistoryRepo(t)
+	entries, err := commands.History(syncDir, 0)
+	require.NoError(t, err)
+	initial := entries[2]
+
+	result, err := commands.Rollback(syncDir, initial.SHA)
+	require.NoError(t, err)
+	assert.True(t, result.Committed)
+	assert.Equal(t, initial.SHA, result.Target.SHA)
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "b@m")
+	assert.FileExists(t, filepath.Join(syncDir, "profiles", "work.yaml"))
+	assert.NoFileExists(t, filepath.Join(syncDir, "claude-md", "style.md"))
+
+	head, err := git.ShowCommit(syncDir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "Roll back to "+initial.ShortSHA()+": Initial config", head.Subject)
+	clean, _ := git.IsClean(syncDir)
+	assert.True(t, clean)
+
+	logged, err := audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, logged, 1)
+	assert.Equal(t, "rollback", logged[0].Command)
+	assert.Equal(t, head.SHA, logged[0].Commit)
+	assert.Contains(t, logged[0].Items, audit.Item{Action: "removed", Category: "plugins", Name: "b@m"})
+
+	t.Run("already at target", func(t *testing.T) {
+		result, err := commands.Rollback(syncDir, initial.SHA)
+		require.NoError(t, err)
+		assert.False(t, result.Committed)
+	})
+
+	t.Run("refuses with uncommitted changes", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\n"), 0644))
+		_, err := commands.Rollback(syncDir, "HEAD~1")
+		assert.ErrorContains(t, err, "config.yaml has uncommitted changes")
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := commands.Rollback(syncDir, "no-such-ref")
+		assert.Error(t, err)
+	})
+}
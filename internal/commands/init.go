@@ -44,21 +44,24 @@ type InitScanResult struct {
 
 // InitResult describes how plugins were categorized during init.
 type InitResult struct {
-	Upstream            []string // portable marketplace plugins
-	AutoForked          []string // non-portable plugins copied into sync repo
-	ExcludedPlugins     []string // plugins excluded by user selection
-	RemotePushed        bool     // whether the initial commit was pushed to a remote
-	IncludedSettings    []string // settings keys written to config
-	IncludedHooks       []string // hook names written to config
-	ProfileNames        []string // profiles created
-	ActiveProfile       string   // profile activated on this machine
-	PermissionsIncluded bool     // whether permissions were included
-	ClaudeMDFragments   []string // CLAUDE.md fragment names created
-	MCPIncluded         []string // MCP server names included
-	KeybindingsIncluded bool     // whether keybindings were included
-	CommandsIncluded    int
-	SkillsIncluded      int
-	MemoryImported      int
+	Upstream             []string // portable marketplace plugins
+	AutoForked           []string // non-portable plugins copied into sync repo
+	ExcludedPlugins      []string // plugins excluded by user selection
+	RemotePushed         bool     // whether the initial commit was pushed to a remote
+	IncludedSettings     []string // settings keys written to config
+	IncludedHooks        []string // hook names written to config
+	ProfileNames         []string // profiles created
+	ActiveProfile        string   // profile activated on this machine
+	PermissionsIncluded  bool     // whether permissions were included
+	ClaudeMDFragments    []string // CLAUDE.md fragment names created
+	MCPIncluded          []string // MCP server names included
+	KeybindingsIncluded  bool     // whether keybindings were included
+	CommandsIncluded     int
+	SkillsIncluded       int
+	AgentsIncluded       int
+	OutputStylesIncluded int
+	ScriptsIncluded      []string // ~/ paths of scripts settings and hooks run, copied into the sync repo
+	MemoryImported       int
 }
 
 // InitOptions configures what Init includes in the sync config.
@@ -80,6 +83,8 @@ type InitOptions struct {
 	Keybindings       map[string]any              // keybindings to include
 	Commands          []string                    // selected command keys to include
 	Skills            []string                    // selected skill keys to include
+	Agents            []string                    // selected subagent keys to include
+	OutputStyles      []string                    // selected output style keys to include
 	MemoryIncludes    []string                    // selected memory fragment names to include
 	// Extra* fields carry config-only values through sections where
 	// buildAndWriteConfig re-reads from local files. Other sections (hooks,
@@ -89,6 +94,7 @@ type InitOptions struct {
 	ExtraForked       []string                    // config-only forked plugin names to preserve
 	ExtraSettings     map[string]any              // config-only setting values to preserve
 	ExtraMarketplaces map[string]config.MarketplaceSource // config-only marketplace sources to preserve
+	ExtraVars         map[string]any              // CLAUDE.md template vars to preserve
 }
 
 // Fields from settings.json that should NOT be synced.
@@ -209,7 +215,7 @@ func InitScan(claudeDir string) (*InitScanResult, error) {
 		result.Keybindings = kb
 	}
 
-	// Scan commands and skills
+	// Scan commands, skills and agents
 	cs, csErr := cmdskill.ScanAll(claudeDir, nil)
 	if csErr == nil {
 		result.CommandsSkills = cs
@@ -251,7 +257,7 @@ func Init(opts InitOptions) (*InitResult, error) {
 		return nil, err
 	}
 
-	gitignore := "user-preferences.yaml\n.last_fetch\nplugins/.claude-plugin/\nactive-profile\npending-changes.yaml\nplugin-sources.yaml\n.applied-hashes.json\n__pycache__/\n*.pyc\n"
+	gitignore := "user-preferences.yaml\n.last_fetch\nplugins/.claude-plugin/\nactive-profile\npending-changes.yaml\nplugin-sources.yaml\n.applied-hashes.json\nsnapshots/\nsecrets.age\n.trusted-signers\nrejected-changes.yaml\naudit.jsonl\nconflicts/\n__pycache__/\n*.pyc\n"
 	if err := os.WriteFile(filepath.Join(syncDir, ".gitignore"), []byte(gitignore), 0644); err != nil {
 		return nil, fmt.Errorf("writing .gitignore: %w", err)
 	}
@@ -356,7 +362,7 @@ func Update(opts InitOptions) (*InitResult, error) {
 	os.WriteFile(filepath.Join(pluginsDir, ".gitkeep"), []byte{}, 0644)
 
 	// Ensure .gitignore has patterns added in later versions.
-	ensureGitignorePatterns(syncDir, []string{"__pycache__/", "*.pyc", "plugin-sources.yaml", ".applied-hashes.json"})
+	ensureGitignorePatterns(syncDir, []string{"__pycache__/", "*.pyc", "plugin-sources.yaml", ".applied-hashes.json", "snapshots/", "secrets.age"})
 
 	if len(forkedNames) > 0 {
 		if err := forkedplugins.RegisterLocalMarketplace(opts.ClaudeDir, syncDir); err != nil {
@@ -369,7 +375,7 @@ func Update(opts InitOptions) (*InitResult, error) {
 
 // buildAndWriteConfig contains the shared config-building logic used by both
 // Init and Update. It categorizes plugins, filters settings/hooks, writes
-// config.yaml, imports CLAUDE.md, writes profiles, and copies commands/skills.
+// config.yaml, imports CLAUDE.md, writes profiles, and copies commands/skills/agents.
 func buildAndWriteConfig(opts InitOptions) (*InitResult, []string, error) {
 	claudeDir := opts.ClaudeDir
 	syncDir := opts.SyncDir
@@ -559,6 +565,15 @@ func buildAndWriteConfig(opts InitOptions) (*InitResult, []string, error) {
 	}
 	sort.Strings(result.IncludedHooks)
 
+	// Write script paths as ~/ so they resolve on other machines, and bring
+	// the scripts themselves along.
+	cfgSettings, cfgHooks = NormalizeScriptPaths(cfgSettings, cfgHooks)
+	scripts, err := copyScriptAssets(syncDir, ScriptAssets(cfgSettings, cfgHooks))
+	if err != nil {
+		return nil, nil, fmt.Errorf("copying scripts: %w", err)
+	}
+	result.ScriptsIncluded = scripts
+
 	// Collect unique marketplace IDs from upstream plugins to detect custom
 	// marketplaces that need entries in the config's marketplaces section.
 	mktIDs := make(map[string]bool)
@@ -605,7 +620,11 @@ func buildAndWriteConfig(opts InitOptions) (*InitResult, []string, error) {
 		Keybindings:  opts.Keybindings,
 		Commands:     opts.Commands,
 		Skills:       opts.Skills,
+		Agents:       opts.Agents,
+		OutputStyles: opts.OutputStyles,
+		Scripts:      scripts,
 		Marketplaces: customMkts,
+		Vars:         opts.ExtraVars,
 	}
 
 	cfgData, err := config.Marshal(cfg)
@@ -695,8 +714,8 @@ func buildAndWriteConfig(opts InitOptions) (*InitResult, []string, error) {
 		result.KeybindingsIncluded = true
 	}
 
-	// Copy selected commands and skills to sync directory.
-	if len(opts.Commands) > 0 || len(opts.Skills) > 0 {
+	// Copy selected commands, skills, agents and output styles to sync directory.
+	if len(opts.Commands) > 0 || len(opts.Skills) > 0 || len(opts.Agents) > 0 || len(opts.OutputStyles) > 0 {
 		cs, csErr := cmdskill.ScanAll(claudeDir, nil)
 		if csErr == nil {
 			selectedSet := make(map[string]bool)
@@ -706,6 +725,12 @@ func buildAndWriteConfig(opts InitOptions) (*InitResult, []string, error) {
 			for _, k := range opts.Skills {
 				selectedSet[k] = true
 			}
+			for _, k := range opts.Agents {
+				selectedSet[k] = true
+			}
+			for _, k := range opts.OutputStyles {
+				selectedSet[k] = true
+			}
 			for _, item := range cs.Items {
 				if !selectedSet[item.Key()] {
 					continue
@@ -731,6 +756,24 @@ func buildAndWriteConfig(opts InitOptions) (*InitResult, []string, error) {
 					if err := copyDir(srcDir, dstDir); err == nil {
 						result.SkillsIncluded++
 					}
+				case cmdskill.TypeAgent:
+					dstDir := filepath.Join(syncDir, "agents")
+					os.MkdirAll(dstDir, 0755)
+					dstPath := filepath.Join(dstDir, filepath.Base(item.FilePath))
+					data, err := os.ReadFile(item.FilePath)
+					if err == nil {
+						os.WriteFile(dstPath, data, 0644)
+						result.AgentsIncluded++
+					}
+				case cmdskill.TypeOutputStyle:
+					dstDir := filepath.Join(syncDir, "output-styles")
+					os.MkdirAll(dstDir, 0755)
+					dstPath := filepath.Join(dstDir, filepath.Base(item.FilePath))
+					data, err := os.ReadFile(item.FilePath)
+					if err == nil {
+						os.WriteFile(dstPath, data, 0644)
+						result.OutputStylesIncluded++
+					}
 				}
 			}
 		}
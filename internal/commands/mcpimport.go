@@ -14,6 +14,7 @@ import (
 	"github.com/ruminaider/claude-sync/internal/config"
 	"github.com/ruminaider/claude-sync/internal/git"
 	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/ruminaider/claude-sync/internal/secrets"
 )
 
 // DetectedSecret represents a secret value found in an MCP server's env config.
@@ -85,15 +86,15 @@ func detectSecrets(serverName string, raw json.RawMessage) []DetectedSecret {
 		return nil
 	}
 
-	var secrets []DetectedSecret
+	var found []DetectedSecret
 	for key, value := range cfg.Env {
-		// Skip values already templated as ${VAR}
-		if isTemplated(value) {
+		// Skip values already templated as ${VAR} or encrypted as team secrets
+		if isTemplated(value) || secrets.IsEncrypted(value) {
 			continue
 		}
 
 		if reason := classifySecret(key, value); reason != "" {
-			secrets = append(secrets, DetectedSecret{
+			found = append(found, DetectedSecret{
 				ServerName: serverName,
 				EnvKey:     key,
 				Value:      value,
@@ -101,7 +102,7 @@ func detectSecrets(serverName string, raw json.RawMessage) []DetectedSecret {
 			})
 		}
 	}
-	return secrets
+	return found
 }
 
 // classifySecret returns a reason string if the key/value pair looks like a secret, or "" if not.
@@ -449,6 +450,14 @@ func transformMCPPaths(servers map[string]json.RawMessage, transform func(string
 // them from the environment. Returns resolved configs and a list of warnings
 // for any unresolved variables.
 func ResolveMCPEnvVars(servers map[string]json.RawMessage) (map[string]json.RawMessage, []string) {
+	return ResolveMCPSecrets(servers, secrets.Env{}, nil)
+}
+
+// ResolveMCPSecrets is ResolveMCPEnvVars with a caller-supplied resolver,
+// e.g. the chain built by SecretResolver. Env values that are team secrets
+// (ENC[age:...]) are decrypted with dec; with a nil dec, or when decryption
+// fails, they are left encrypted and reported as warnings.
+func ResolveMCPSecrets(servers map[string]json.RawMessage, resolver secrets.Resolver, dec secrets.Decrypter) (map[string]json.RawMessage, []string) {
 	if len(servers) == 0 {
 		return servers, nil
 	}
@@ -480,13 +489,32 @@ func ResolveMCPEnvVars(servers map[string]json.RawMessage) (map[string]json.RawM
 			if !ok {
 				continue
 			}
+			if secrets.IsEncrypted(strVal) {
+				if dec == nil {
+					warnings = append(warnings, fmt.Sprintf("%s: %s is encrypted and no identity is available", name, key))
+					continue
+				}
+				plain, err := dec.Decrypt(strVal)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s: decrypting %s: %v", name, key, err))
+					continue
+				}
+				env[key] = plain
+				changed = true
+				continue
+			}
 			resolved := envVarRefPattern.ReplaceAllStringFunc(strVal, func(match string) string {
 				varName := match[2 : len(match)-1] // strip ${ and }
-				if envVal, ok := os.LookupEnv(varName); ok {
+				secretVal, ok, err := resolver.Resolve(varName)
+				if ok {
 					changed = true
-					return envVal
+					return secretVal
+				}
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s: resolving %s (used by %s): %v", name, varName, key, err))
+				} else {
+					warnings = append(warnings, fmt.Sprintf("%s: secret %s not set (used by %s)", name, varName, key))
 				}
-				warnings = append(warnings, fmt.Sprintf("%s: env var %s not set (used by %s)", name, varName, key))
 				return match // leave unresolved
 			})
 			env[key] = resolved
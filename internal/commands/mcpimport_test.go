@@ -449,6 +449,41 @@ func TestResolveMCPEnvVars_EmptyInput(t *testing.T) {
 	assert.Empty(t, warnings)
 }
 
+func TestResolveMCPSecrets_StoreThenCommandThenEnv(t *testing.T) {
+	syncDir := t.TempDir()
+	t.Setenv("CLAUDE_SYNC_IDENTITY", filepath.Join(t.TempDir(), "identity.txt"))
+	t.Setenv("FROM_STORE", "env-loses")
+	t.Setenv("FROM_ENV", "env-value")
+
+	require.NoError(t, commands.SecretsSet(syncDir, "FROM_STORE", "store-value"))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "user-preferences.yaml"),
+		[]byte("sync_mode: union\nsecret_command: \"test {name} = FROM_CMD && echo cmd-value\"\n"), 0644))
+
+	serverData, _ := json.Marshal(map[string]any{
+		"command": "npx",
+		"env": map[string]string{
+			"A": "${FROM_STORE}",
+			"B": "${FROM_CMD}",
+			"C": "${FROM_ENV}",
+			"D": "${NOWHERE_XYZZY}",
+		},
+	})
+	servers := map[string]json.RawMessage{"s": json.RawMessage(serverData)}
+
+	resolved, warnings := commands.ResolveMCPSecrets(servers, commands.SecretResolver(syncDir), nil)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "NOWHERE_XYZZY")
+
+	var cfg struct {
+		Env map[string]string `json:"env"`
+	}
+	require.NoError(t, json.Unmarshal(resolved["s"], &cfg))
+	assert.Equal(t, "store-value", cfg.Env["A"])
+	assert.Equal(t, "cmd-value", cfg.Env["B"])
+	assert.Equal(t, "env-value", cfg.Env["C"])
+	assert.Equal(t, "${NOWHERE_XYZZY}", cfg.Env["D"])
+}
+
 // --- Config Round-Trip for MCPMeta ---
 
 func TestConfigRoundTrip_MCPMeta(t *testing.T) {
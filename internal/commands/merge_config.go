@@ -62,7 +62,7 @@ func MergeExisting(scan *InitScanResult, cfg *config.Config, existingProfiles ma
 		mergeMCP(scan, cfg.MCP)
 		mergeKeybindings(scan, cfg.Keybindings)
 		mergeClaudeMDFragments(scan, cfg.ClaudeMD.Include, syncDir)
-		mergeCommandsSkills(scan, cfg.Commands, cfg.Skills, syncDir)
+		mergeCommandsSkills(scan, cfg.Commands, cfg.Skills, cfg.Agents, cfg.OutputStyles, syncDir)
 		mergeMemory(scan, cfg.Memory.Include)
 	}
 
@@ -96,7 +96,7 @@ func MergeExisting(scan *InitScanResult, cfg *config.Config, existingProfiles ma
 		mergeMCP(scan, profile.MCP.Add)
 		mergeKeybindings(scan, profile.Keybindings.Override)
 		mergeClaudeMDFragments(scan, profile.ClaudeMD.Add, syncDir)
-		mergeCommandsSkills(scan, profile.Commands.Add, profile.Skills.Add, syncDir)
+		mergeCommandsSkills(scan, profile.Commands.Add, profile.Skills.Add, profile.Agents.Add, profile.OutputStyles.Add, syncDir)
 		mergeMemory(scan, profile.Memory.Add)
 	}
 }
@@ -278,13 +278,15 @@ func mergeClaudeMDFragments(scan *InitScanResult, fragments []string, syncDir st
 	}
 }
 
-// mergeCommandsSkills injects commands and skills that are missing from the
-// scan. It reads content from the sync dir when available, falling back to a
-// placeholder when the file doesn't exist locally.
-func mergeCommandsSkills(scan *InitScanResult, commands []string, skills []string, syncDir string) {
-	allKeys := make([]string, 0, len(commands)+len(skills))
+// mergeCommandsSkills injects commands, skills, agents and output styles that are missing
+// from the scan. It reads content from the sync dir when available, falling
+// back to a placeholder when the file doesn't exist locally.
+func mergeCommandsSkills(scan *InitScanResult, commands, skills, agents, styles []string, syncDir string) {
+	allKeys := make([]string, 0, len(commands)+len(skills)+len(agents)+len(styles))
 	allKeys = append(allKeys, commands...)
 	allKeys = append(allKeys, skills...)
+	allKeys = append(allKeys, agents...)
+	allKeys = append(allKeys, styles...)
 	if len(allKeys) == 0 {
 		return
 	}
@@ -304,7 +306,7 @@ func mergeCommandsSkills(scan *InitScanResult, commands []string, skills []strin
 			continue
 		}
 
-		// Parse key: "cmd:global:name" or "skill:global:name"
+		// Parse key: "cmd:global:name", "skill:global:name", "agent:global:name" or "style:global:name"
 		parts := strings.SplitN(key, ":", 3)
 		if len(parts) < 3 {
 			continue
@@ -325,6 +327,12 @@ func mergeCommandsSkills(scan *InitScanResult, commands []string, skills []strin
 		case "skill":
 			itemType = cmdskill.TypeSkill
 			filePath = filepath.Join(syncDir, "skills", name, "SKILL.md")
+		case "agent":
+			itemType = cmdskill.TypeAgent
+			filePath = filepath.Join(syncDir, "agents", name+".md")
+		case "style":
+			itemType = cmdskill.TypeOutputStyle
+			filePath = filepath.Join(syncDir, "output-styles", name+".md")
 		default:
 			continue
 		}
@@ -15,6 +15,9 @@ type PullPreviewResult struct {
 	ClaudeMDChanged bool
 	CommandsChanged bool
 	SkillsChanged   bool
+	AgentsChanged   bool
+	StylesChanged   bool
+	ScriptsChanged  bool
 	MemoryChanged   bool
 	NothingToChange bool
 }
@@ -59,7 +62,13 @@ func PullPreview(syncDir string) (*PullPreviewResult, error) {
 			result.CommandsChanged = true
 		case strings.HasPrefix(f, "skills/"):
 			result.SkillsChanged = true
-		case strings.HasPrefix(f, "memory/"):
+		case strings.HasPrefix(f, "agents/"):
+			result.AgentsChanged = true
+		case strings.HasPrefix(f, "output-styles/"):
+			result.StylesChanged = true
+		case strings.HasPrefix(f, scriptsDirName+"/"):
+			result.ScriptsChanged = true
+		case strings.HasPrefix(f, "memory/"), strings.HasPrefix(f, projectMemoryDirName+"/"):
 			result.MemoryChanged = true
 		case strings.HasPrefix(f, "profiles/"):
 			result.SettingsChanged = true
@@ -91,6 +100,15 @@ func FormatPullPreview(p *PullPreviewResult) string {
 	if p.SkillsChanged {
 		changes = append(changes, "skills")
 	}
+	if p.AgentsChanged {
+		changes = append(changes, "agents")
+	}
+	if p.StylesChanged {
+		changes = append(changes, "output styles")
+	}
+	if p.ScriptsChanged {
+		changes = append(changes, "scripts")
+	}
 	if p.MemoryChanged {
 		changes = append(changes, "memory")
 	}
@@ -148,12 +166,33 @@ func PushPreviewSummary(scan *PushScanResult) string {
 	if scan.ChangedSkills {
 		parts = append(parts, "skills changed")
 	}
+	if scan.ChangedAgents {
+		parts = append(parts, "agents changed")
+	}
+	if scan.ChangedOutputStyles {
+		parts = append(parts, "output styles changed")
+	}
+	if scan.ChangedScripts {
+		parts = append(parts, "scripts changed")
+	}
+	if len(scan.ChangedProjectMemory) > 0 {
+		parts = append(parts, fmt.Sprintf("memory changed for %d project(s)", len(scan.ChangedProjectMemory)))
+		for _, id := range scan.ChangedProjectMemory {
+			parts = append(parts, fmt.Sprintf("  ~ %s", id))
+		}
+	}
 	if len(scan.OrphanedCommands) > 0 {
 		parts = append(parts, fmt.Sprintf("%d orphaned command(s) to remove", len(scan.OrphanedCommands)))
 	}
 	if len(scan.OrphanedSkills) > 0 {
 		parts = append(parts, fmt.Sprintf("%d orphaned skill(s) to remove", len(scan.OrphanedSkills)))
 	}
+	if len(scan.OrphanedAgents) > 0 {
+		parts = append(parts, fmt.Sprintf("%d orphaned agent(s) to remove", len(scan.OrphanedAgents)))
+	}
+	if len(scan.OrphanedOutputStyles) > 0 {
+		parts = append(parts, fmt.Sprintf("%d orphaned output style(s) to remove", len(scan.OrphanedOutputStyles)))
+	}
 	if scan.DirtyWorkingTree {
 		parts = append(parts, "uncommitted config changes")
 	}
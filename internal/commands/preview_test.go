@@ -105,6 +105,7 @@ func TestPushPreviewSummary_ConfigChanges(t *testing.T) {
 		ChangedKeybindings: true,
 		ChangedCommands:    true,
 		ChangedSkills:      true,
+		ChangedAgents:      true,
 	}
 	result := commands.PushPreviewSummary(scan)
 	assert.Contains(t, result, "permissions changed")
@@ -113,17 +114,20 @@ func TestPushPreviewSummary_ConfigChanges(t *testing.T) {
 	assert.Contains(t, result, "keybindings changed")
 	assert.Contains(t, result, "commands changed")
 	assert.Contains(t, result, "skills changed")
+	assert.Contains(t, result, "agents changed")
 }
 
 func TestPushPreviewSummary_OrphansAndDirty(t *testing.T) {
 	scan := &commands.PushScanResult{
 		OrphanedCommands: []string{"old-cmd"},
 		OrphanedSkills:   []string{"old-skill"},
+		OrphanedAgents:   []string{"old-agent"},
 		DirtyWorkingTree: true,
 	}
 	result := commands.PushPreviewSummary(scan)
 	assert.Contains(t, result, "1 orphaned command(s)")
 	assert.Contains(t, result, "1 orphaned skill(s)")
+	assert.Contains(t, result, "1 orphaned agent(s)")
 	assert.Contains(t, result, "uncommitted config changes")
 }
 
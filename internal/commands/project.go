@@ -98,10 +98,18 @@ type ResolvedConfig struct {
 	Settings    map[string]any
 	ClaudeMD    []string
 	MCP         map[string]json.RawMessage
+	// ProfileConflicts lists items the active profiles disagree on.
+	ProfileConflicts []profiles.Conflict
+	// ClaudeMDData is what the ClaudeMD fragments are rendered with.
+	ClaudeMDData claudemd.TemplateData
 }
 
-// ResolveWithProfile merges base config with the specified profile (or active profile if empty).
+// ResolveWithProfile merges base config with the specified profile (or the
+// active profiles, in order, if empty). Entries whose when: selector doesn't
+// match this machine are left out.
 func ResolveWithProfile(cfg config.Config, syncDir, profileName string) ResolvedConfig {
+	machine := config.CurrentMachine()
+	cfg, _ = cfg.ForMachine(machine)
 	rc := ResolvedConfig{
 		Hooks:       copyHooks(cfg.Hooks),
 		Permissions: cfg.Permissions,
@@ -110,12 +118,17 @@ func ResolveWithProfile(cfg config.Config, syncDir, profileName string) Resolved
 		MCP:         copyHooks(cfg.MCP), // same type: map[string]json.RawMessage
 	}
 
+	names := []string{profileName}
 	if profileName == "" {
-		profileName, _ = profiles.ReadActiveProfile(syncDir)
+		names, _ = profiles.ReadActiveProfiles(syncDir)
 	}
 
-	if profileName != "" {
-		if p, err := profiles.ReadProfile(syncDir, profileName); err == nil {
+	var activeProfile *profiles.Profile
+	if len(names) > 0 {
+		if resolved, conflicts, err := profiles.ResolveProfiles(syncDir, names); err == nil {
+			p, _ := resolved.Profile.ForMachine(machine)
+			activeProfile = &p
+			rc.ProfileConflicts = conflicts
 			rc.Hooks = profiles.MergeHooks(rc.Hooks, p)
 			rc.Permissions = profiles.MergePermissions(rc.Permissions, p)
 			rc.Settings = profiles.MergeSettings(rc.Settings, p)
@@ -124,6 +137,12 @@ func ResolveWithProfile(cfg config.Config, syncDir, profileName string) Resolved
 		}
 	}
 
+	prefs := config.DefaultUserPreferences()
+	if data, err := os.ReadFile(filepath.Join(syncDir, "user-preferences.yaml")); err == nil {
+		prefs, _ = config.ParseUserPreferences(data)
+	}
+	rc.ClaudeMDData = claudeMDTemplateData(cfg, activeProfile, names, prefs)
+
 	return rc
 }
 
@@ -249,10 +268,10 @@ func ApplyProjectSettings(projectDir string, resolved ResolvedConfig, pcfg proje
 				includes = filtered
 			}
 			if len(includes) > 0 {
-				assembled, asmErr := claudemd.AssembleFromDir(syncDir, includes)
+				assembled, _, asmErr := claudemd.AssembleTemplates(syncDir, includes, resolved.ClaudeMDData)
 				if asmErr == nil && assembled != "" {
 					claudeMDPath := filepath.Join(projectDir, ".claude", "CLAUDE.md")
-					os.WriteFile(claudeMDPath, []byte(assembled), 0644)
+					writeClaudeMD(claudeMDPath, assembled)
 				}
 			}
 		case "mcp":
@@ -264,6 +283,14 @@ func ApplyProjectSettings(projectDir string, resolved ResolvedConfig, pcfg proje
 				delete(finalMCP, name)
 			}
 			if len(finalMCP) > 0 {
+				// Resolve ${VAR} references and team secrets as pull does
+				// for the global .mcp.json.
+				var warnings []string
+				finalMCP = ExpandMCPPaths(finalMCP)
+				finalMCP, warnings = ResolveMCPSecrets(finalMCP, SecretResolver(syncDir), SecretDecrypter())
+				for _, w := range warnings {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+				}
 				mcpPath := filepath.Join(projectDir, ".mcp.json")
 				if err := claudecode.WriteMCPConfigFile(mcpPath, finalMCP); err != nil {
 					return fmt.Errorf("writing .mcp.json: %w", err)
@@ -48,6 +48,38 @@ func TestProjectInit_ClaudeMDFragments(t *testing.T) {
 	assert.Contains(t, string(claudeMD), "Always write tests first")
 }
 
+func TestProjectInit_ClaudeMDTemplates(t *testing.T) {
+	projectDir, syncDir := setupProjectTestEnv(t)
+
+	cfg := config.Config{
+		Version:  "1.0.0",
+		ClaudeMD: config.ClaudeMDConfig{Include: []string{"stack"}},
+		Vars:     map[string]any{"db": "mysql"},
+	}
+	data, err := config.MarshalV2(cfg)
+	require.NoError(t, err)
+	os.WriteFile(filepath.Join(syncDir, "config.yaml"), data, 0644)
+	os.MkdirAll(filepath.Join(syncDir, "profiles"), 0755)
+	os.WriteFile(filepath.Join(syncDir, "profiles", "backend.yaml"), []byte("vars:\n  db: postgres\n"), 0644)
+
+	claudeMdDir := filepath.Join(syncDir, "claude-md")
+	os.MkdirAll(claudeMdDir, 0755)
+	os.WriteFile(filepath.Join(claudeMdDir, "stack.md"), []byte("## Stack\n\n{{.Profile}} uses {{.Vars.db}}.\n"), 0644)
+	os.WriteFile(filepath.Join(claudeMdDir, "manifest.yaml"), []byte("fragments:\n  - stack\n"), 0644)
+
+	_, err = commands.ProjectInit(commands.ProjectInitOptions{
+		ProjectDir:    projectDir,
+		SyncDir:       syncDir,
+		Profile:       "backend",
+		ProjectedKeys: []string{"claude_md"},
+	})
+	require.NoError(t, err)
+
+	claudeMD, err := os.ReadFile(filepath.Join(projectDir, ".claude", "CLAUDE.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(claudeMD), "backend uses postgres.")
+}
+
 func TestProjectInit_ClaudeMDOverrides(t *testing.T) {
 	projectDir, syncDir := setupProjectTestEnv(t)
 
@@ -10,6 +10,7 @@ import (
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/config"
 	"github.com/ruminaider/claude-sync/internal/project"
+	"github.com/ruminaider/claude-sync/internal/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -123,3 +124,34 @@ func TestProjectInit_MCPEmptyNoFile(t *testing.T) {
 	_, err = os.Stat(mcpPath)
 	assert.True(t, os.IsNotExist(err), ".mcp.json should not exist when no MCP servers are configured")
 }
+
+func TestProjectInit_MCPResolvesSecrets(t *testing.T) {
+	projectDir, syncDir := setupProjectTestEnv(t)
+	t.Setenv("CLAUDE_SYNC_IDENTITY", filepath.Join(t.TempDir(), "identity.txt"))
+	t.Setenv("PROJECT_DB", "postgres://db.internal/app")
+	key, err := commands.SecretsPublicKey()
+	require.NoError(t, err)
+	enc, err := secrets.Encrypt("tok-123", map[string]string{"me": key})
+	require.NoError(t, err)
+
+	cfg := config.Config{
+		Version: "1.0.0",
+		MCP: map[string]json.RawMessage{
+			"app-db": json.RawMessage(`{"command":"db-server","env":{"DATABASE_URL":"${PROJECT_DB}","TOKEN":"` + enc + `"}}`),
+		},
+	}
+	data, err := config.MarshalV2(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), data, 0644))
+
+	_, err = commands.ProjectInit(commands.ProjectInitOptions{
+		ProjectDir:    projectDir,
+		SyncDir:       syncDir,
+		ProjectedKeys: []string{"mcp"},
+	})
+	require.NoError(t, err)
+
+	mcp, err := claudecode.ReadMCPConfigFile(filepath.Join(projectDir, ".mcp.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"command":"db-server","env":{"DATABASE_URL":"postgres://db.internal/app","TOKEN":"tok-123"}}`, string(mcp["app-db"]))
+}
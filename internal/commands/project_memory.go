@@ -0,0 +1,332 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/ruminaider/claude-sync/internal/memory"
+	"github.com/ruminaider/claude-sync/internal/paths"
+)
+
+// projectMemoryDirName is the sync repo directory holding project memory,
+// one subdirectory per project identity.
+const projectMemoryDirName = "project-memory"
+
+// HashKeyProjectMemoryPrefix is the prefix for per-project memory file hash
+// keys. The project's local slug follows, so each checkout is tracked apart.
+const HashKeyProjectMemoryPrefix = "project-memory:"
+
+// LocalProject is a project checked out on this machine.
+type LocalProject struct {
+	Identity  string // stable across machines, see ProjectIdentity
+	Dir       string // the directory Claude Code was started in
+	MemoryDir string // ~/.claude/projects/<slug>/memory
+}
+
+// ProjectIdentity names the project at dir the same way on every machine:
+// its origin remote in host/path form, followed by "#<path>" when dir is
+// below the repository root (github.com/acme/mono#services/web). Returns ""
+// when dir isn't in a git repository with an origin remote.
+func ProjectIdentity(dir string) string {
+	root, err := git.TopLevel(dir)
+	if err != nil {
+		return ""
+	}
+	remote := originRemote(root)
+	if remote == "" {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return remote
+	}
+	return remote + "#" + filepath.ToSlash(rel)
+}
+
+// projectMemorySyncDir returns where a project's memory is stored in the
+// sync repo.
+func projectMemorySyncDir(syncDir, identity string) string {
+	return filepath.Join(syncDir, projectMemoryDirName, filepath.FromSlash(identity))
+}
+
+// localProjectFor returns the local checkout of the project at dir. Its
+// memory directory is derived from this machine's slug for dir.
+func localProjectFor(claudeDir, dir string) (LocalProject, bool) {
+	identity := ProjectIdentity(dir)
+	if identity == "" {
+		return LocalProject{}, false
+	}
+	return LocalProject{
+		Identity:  identity,
+		Dir:       dir,
+		MemoryDir: filepath.Join(claudeDir, "projects", paths.ProjectSlug(dir), "memory"),
+	}, true
+}
+
+// ScanLocalProjects lists the projects Claude Code has been used in on this
+// machine that have a stable identity, sorted by directory. The slug
+// directories under claudeDir/projects can't be mapped back to a path, so
+// each project's directory is read from its session transcripts.
+func ScanLocalProjects(claudeDir string) []LocalProject {
+	entries, err := os.ReadDir(filepath.Join(claudeDir, "projects"))
+	if err != nil {
+		return nil
+	}
+	var projects []LocalProject
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := transcriptCwd(filepath.Join(claudeDir, "projects", e.Name()))
+		if dir == "" {
+			continue
+		}
+		if p, ok := localProjectFor(claudeDir, dir); ok {
+			projects = append(projects, p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Dir < projects[j].Dir })
+	return projects
+}
+
+// transcriptCwd returns the working directory recorded in the session
+// transcripts in projectDir, or "" if none records one that still exists.
+func transcriptCwd(projectDir string) string {
+	matches, _ := filepath.Glob(filepath.Join(projectDir, "*.jsonl"))
+	for _, path := range matches {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		r := bufio.NewReader(f)
+		var cwd string
+		for i := 0; i < 50 && cwd == ""; i++ {
+			line, err := r.ReadBytes('\n')
+			var entry struct {
+				Cwd string `json:"cwd"`
+			}
+			if json.Unmarshal(line, &entry) == nil {
+				cwd = entry.Cwd
+			}
+			if err != nil {
+				break
+			}
+		}
+		f.Close()
+		if cwd == "" {
+			continue
+		}
+		if info, err := os.Stat(cwd); err == nil && info.IsDir() {
+			return cwd
+		}
+	}
+	return ""
+}
+
+// syncedLocalProjects returns the local checkouts of the listed projects.
+// The current directory counts too, so a project's memory arrives before
+// Claude Code first records a session for it.
+func syncedLocalProjects(claudeDir string, identities []string) []LocalProject {
+	if len(identities) == 0 {
+		return nil
+	}
+	listed := make(map[string]bool, len(identities))
+	for _, id := range identities {
+		listed[id] = true
+	}
+	var projects []LocalProject
+	seen := make(map[string]bool)
+	add := func(p LocalProject) {
+		if listed[p.Identity] && !seen[p.MemoryDir] {
+			seen[p.MemoryDir] = true
+			projects = append(projects, p)
+		}
+	}
+	for _, p := range ScanLocalProjects(claudeDir) {
+		add(p)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if p, ok := localProjectFor(claudeDir, cwd); ok {
+			add(p)
+		}
+	}
+	return projects
+}
+
+// changedProjectMemory returns the listed projects whose local memory
+// differs from the sync repo. Updated fragments are written to the sync
+// repo as memory.Reconcile does for global memory; new and deleted ones are
+// left for PushApply.
+func changedProjectMemory(claudeDir, syncDir string, identities []string) ([]string, error) {
+	changed := make(map[string]bool)
+	for _, p := range syncedLocalProjects(claudeDir, identities) {
+		if changed[p.Identity] {
+			continue
+		}
+		if _, err := os.Stat(p.MemoryDir); err != nil {
+			continue
+		}
+		result, err := memory.Reconcile(p.MemoryDir, projectMemorySyncDir(syncDir, p.Identity))
+		if err != nil {
+			return nil, fmt.Errorf("scanning memory for %s: %w", p.Identity, err)
+		}
+		if len(result.Updated) > 0 || len(result.New) > 0 || len(result.Deleted) > 0 {
+			changed[p.Identity] = true
+		}
+	}
+	ids := make([]string, 0, len(changed))
+	for id := range changed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// pushProjectMemory mirrors the local memory of each project into the sync
+// repo. With several checkouts of one project, the first found wins.
+func pushProjectMemory(claudeDir, syncDir string, identities []string) error {
+	done := make(map[string]bool)
+	for _, p := range syncedLocalProjects(claudeDir, identities) {
+		if done[p.Identity] {
+			continue
+		}
+		if _, err := os.Stat(p.MemoryDir); err != nil {
+			continue
+		}
+		if _, err := memory.Sync(p.MemoryDir, projectMemorySyncDir(syncDir, p.Identity)); err != nil {
+			return fmt.Errorf("syncing memory for %s: %w", p.Identity, err)
+		}
+		done[p.Identity] = true
+	}
+	return nil
+}
+
+// restoreProjectMemory writes each listed project's synced memory to every
+// local checkout of it, under that checkout's slug. Returns the projects
+// with no checkout here yet.
+func restoreProjectMemory(claudeDir, syncDir string, identities []string, hashes *AppliedHashes, force bool, result *PullResult) ([]string, error) {
+	found := make(map[string]bool)
+	for _, p := range syncedLocalProjects(claudeDir, identities) {
+		syncMemDir := projectMemorySyncDir(syncDir, p.Identity)
+		manifest, err := memory.ReadManifest(syncMemDir)
+		if err != nil {
+			return nil, fmt.Errorf("reading memory manifest for %s: %w", p.Identity, err)
+		}
+		found[p.Identity] = true
+		if len(manifest.Order) == 0 {
+			continue
+		}
+		slug := filepath.Base(filepath.Dir(p.MemoryDir))
+		if err := applyMemoryFragments(p.MemoryDir, syncMemDir, manifest.Order, HashKeyProjectMemoryPrefix+slug+"/", hashes, force, result); err != nil {
+			return nil, fmt.Errorf("applying memory for %s: %w", p.Identity, err)
+		}
+	}
+	var missing []string
+	for _, id := range identities {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// ProjectMemoryAdd starts syncing the memory of the project at dir: it
+// copies the project's memory into the sync repo and lists the project
+// under memory.projects in config.yaml. Returns the project's identity.
+func ProjectMemoryAdd(claudeDir, syncDir, dir string) (string, *memory.ReconcileResult, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	p, ok := localProjectFor(claudeDir, abs)
+	if !ok {
+		return "", nil, fmt.Errorf("%s is not in a git repository with an origin remote", abs)
+	}
+	if _, err := os.Stat(p.MemoryDir); err != nil {
+		return "", nil, fmt.Errorf("no Claude Code memory for %s (expected %s)", abs, p.MemoryDir)
+	}
+
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return "", nil, err
+	}
+
+	result, err := memory.Sync(p.MemoryDir, projectMemorySyncDir(syncDir, p.Identity))
+	if err != nil {
+		return "", nil, err
+	}
+	if !containsString(cfg.Memory.Projects, p.Identity) {
+		cfg.Memory.Projects = append(cfg.Memory.Projects, p.Identity)
+		newData, err := config.Marshal(cfg)
+		if err != nil {
+			return "", nil, fmt.Errorf("marshaling config: %w", err)
+		}
+		if err := os.WriteFile(cfgPath, newData, 0644); err != nil {
+			return "", nil, fmt.Errorf("writing config: %w", err)
+		}
+	}
+	return p.Identity, result, nil
+}
+
+// ProjectMemoryRemove stops syncing a project's memory. With purge, its
+// stored memory is deleted from the sync repo too.
+func ProjectMemoryRemove(syncDir, identity string, purge bool) error {
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return err
+	}
+	if !containsString(cfg.Memory.Projects, identity) {
+		return fmt.Errorf("project %q is not synced", identity)
+	}
+	var kept []string
+	for _, id := range cfg.Memory.Projects {
+		if id != identity {
+			kept = append(kept, id)
+		}
+	}
+	cfg.Memory.Projects = kept
+	newData, err := config.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := os.WriteFile(cfgPath, newData, 0644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if purge {
+		// Remove files only: another project's directory may nest inside.
+		dir := projectMemorySyncDir(syncDir, identity)
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return fmt.Errorf("deleting project memory: %w", err)
+			}
+		}
+	}
+	return nil
+}
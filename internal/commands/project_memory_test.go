@@ -0,0 +1,146 @@
+package commands_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkoutWithRemote creates a git repository at dir with an origin remote.
+func checkoutWithRemote(t *testing.T, dir, remote string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, exec.Command("git", "init", dir).Run())
+	require.NoError(t, exec.Command("git", "-C", dir, "remote", "add", "origin", remote).Run())
+}
+
+// openInClaude records a session for dir under claudeDir/projects the way
+// Claude Code does, and returns the project's memory directory.
+func openInClaude(t *testing.T, claudeDir, dir string) string {
+	t.Helper()
+	projectDir := filepath.Join(claudeDir, "projects", paths.ProjectSlug(dir))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "memory"), 0755))
+	transcript := `{"type":"summary","summary":"hi"}` + "\n" + `{"type":"user","cwd":"` + dir + `","message":{}}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "session.jsonl"), []byte(transcript), 0644))
+	return filepath.Join(projectDir, "memory")
+}
+
+func TestProjectIdentity(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "api")
+	checkoutWithRemote(t, repo, "git@github.com:acme/api.git")
+	sub := filepath.Join(repo, "services", "web")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	assert.Equal(t, "github.com/acme/api", commands.ProjectIdentity(repo))
+	assert.Equal(t, "github.com/acme/api#services/web", commands.ProjectIdentity(sub))
+
+	noRemote := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", noRemote).Run())
+	assert.Empty(t, commands.ProjectIdentity(noRemote))
+	assert.Empty(t, commands.ProjectIdentity(t.TempDir()))
+}
+
+func TestScanLocalProjects(t *testing.T) {
+	claudeDir := t.TempDir()
+	repo := filepath.Join(t.TempDir(), "api")
+	checkoutWithRemote(t, repo, "https://github.com/acme/api")
+	memDir := openInClaude(t, claudeDir, repo)
+
+	// A project whose directory is gone is ignored.
+	require.NoError(t, os.MkdirAll(filepath.Join(claudeDir, "projects", "-gone"), 0755))
+
+	projects := commands.ScanLocalProjects(claudeDir)
+	require.Len(t, projects, 1)
+	assert.Equal(t, "github.com/acme/api", projects[0].Identity)
+	assert.Equal(t, repo, projects[0].Dir)
+	assert.Equal(t, memDir, projects[0].MemoryDir)
+}
+
+func TestProjectMemory_PushAndPullAcrossCheckouts(t *testing.T) {
+	claudeDir, syncDir := setupCommandSkillEnv(t)
+
+	// Machine A keeps the project at one path...
+	claudeDirA := t.TempDir()
+	repoA := filepath.Join(t.TempDir(), "work", "api")
+	checkoutWithRemote(t, repoA, "git@github.com:acme/api.git")
+	memA := openInClaude(t, claudeDirA, repoA)
+	require.NoError(t, os.WriteFile(filepath.Join(memA, "build.md"), []byte("---\nname: build\ntype: project\n---\nRun make."), 0644))
+
+	identity, result, err := commands.ProjectMemoryAdd(claudeDirA, syncDir, repoA)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/acme/api", identity)
+	assert.Len(t, result.New, 1)
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", "-A").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "add project memory").Run())
+
+	// ...and machine B at another.
+	repoB := filepath.Join(t.TempDir(), "src", "acme-api")
+	checkoutWithRemote(t, repoB, "https://github.com/acme/api.git")
+	memB := openInClaude(t, claudeDir, repoB)
+
+	pullResult, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, pullResult.ProjectMemoryPending)
+	data, err := os.ReadFile(filepath.Join(memB, "build.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nname: build\ntype: project\n---\nRun make.", string(data))
+	assert.FileExists(t, filepath.Join(memB, "MEMORY.md"))
+
+	// Claude adds a memory on machine B; push picks it up.
+	require.NoError(t, os.WriteFile(filepath.Join(memB, "deploy.md"), []byte("---\nname: deploy\ntype: project\n---\nUse the staging script."), 0644))
+	scan, err := commands.PushScan(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/acme/api"}, scan.ChangedProjectMemory)
+
+	require.NoError(t, commands.PushApply(commands.PushApplyOptions{
+		ClaudeDir:           claudeDir,
+		SyncDir:             syncDir,
+		UpdateProjectMemory: scan.ChangedProjectMemory,
+		Message:             "project memory",
+	}))
+	assert.FileExists(t, filepath.Join(syncDir, "project-memory", "github.com", "acme", "api", "deploy.md"))
+
+	scan, err = commands.PushScan(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.Empty(t, scan.ChangedProjectMemory)
+}
+
+func TestProjectMemory_PendingWithoutCheckout(t *testing.T) {
+	claudeDir, syncDir := setupCommandSkillEnv(t)
+
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	data, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cfgPath, append(data, "memory:\n  projects:\n    - github.com/acme/elsewhere\n"...), 0644))
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-am", "list project").Run())
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/acme/elsewhere"}, result.ProjectMemoryPending)
+}
+
+func TestProjectMemoryRemove(t *testing.T) {
+	claudeDir := t.TempDir()
+	syncDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\n"), 0644))
+	repo := filepath.Join(t.TempDir(), "api")
+	checkoutWithRemote(t, repo, "https://github.com/acme/api")
+	mem := openInClaude(t, claudeDir, repo)
+	require.NoError(t, os.WriteFile(filepath.Join(mem, "notes.md"), []byte("notes"), 0644))
+
+	identity, _, err := commands.ProjectMemoryAdd(claudeDir, syncDir, repo)
+	require.NoError(t, err)
+	require.NoError(t, commands.ProjectMemoryRemove(syncDir, identity, true))
+
+	data, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), identity)
+	assert.NoFileExists(t, filepath.Join(syncDir, "project-memory", "github.com", "acme", "api", "notes.md"))
+	assert.Error(t, commands.ProjectMemoryRemove(syncDir, identity, false))
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/ruminaider/claude-sync/internal/project"
+)
+
+// ProjectRulesResult describes how profile-rules.yaml applies to a directory.
+type ProjectRulesResult struct {
+	Dir         string // project root the rules were evaluated against
+	Remote      string // normalized origin remote, empty if none
+	Evaluations []project.RuleEvaluation
+	Match       *project.ProfileRule // first matching rule, nil if none matched
+}
+
+// ProjectRulesTest evaluates profile-rules.yaml against the project root
+// containing dir. It is an error for the matching rule to name a profile
+// that doesn't exist.
+func ProjectRulesTest(syncDir, dir string) (*ProjectRulesResult, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root := findProjectRoot(abs)
+	if root == "" {
+		root = abs
+	}
+
+	rules, err := project.ReadProfileRules(syncDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProjectRulesResult{Dir: root, Remote: originRemote(root)}
+	result.Evaluations = project.EvaluateRules(rules, root, result.Remote)
+	for _, e := range result.Evaluations {
+		if !e.Matched {
+			continue
+		}
+		rule := e.Rule
+		if rule.Profile != "" {
+			available, _ := profiles.ListProfiles(syncDir)
+			if !slices.Contains(available, rule.Profile) {
+				return nil, fmt.Errorf("%s: rule %q selects unknown profile %q", project.RulesFileName, rule, rule.Profile)
+			}
+		}
+		result.Match = &rule
+		break
+	}
+	return result, nil
+}
+
+// originRemote returns dir's origin remote in normalized host/path form,
+// or "" if dir isn't a git repository with an origin.
+func originRemote(dir string) string {
+	url, err := git.RemoteURL(dir, "origin")
+	if err != nil {
+		return ""
+	}
+	return project.NormalizeRemote(url)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/git"
 	"github.com/ruminaider/claude-sync/internal/project"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -222,3 +223,34 @@ func TestProjectPush_NoDrift(t *testing.T) {
 	assert.Equal(t, 0, result.NewPermissions)
 	assert.Equal(t, 0, result.NewHooks)
 }
+
+func TestProjectRulesTest(t *testing.T) {
+	projectDir, syncDir := setupProjectTestEnv(t)
+	require.NoError(t, git.Init(projectDir))
+	require.NoError(t, git.RemoteAdd(projectDir, "origin", "git@github.com:acme/api.git"))
+	require.NoError(t, os.MkdirAll(filepath.Join(syncDir, "profiles"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "acme.yaml"), []byte("settings:\n  model: opus\n"), 0644))
+
+	rules := "rules:\n  - path: /nowhere/**\n    profile: other\n  - remote: github.com/acme/*\n    profile: acme\n    keys: [hooks, mcp]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, project.RulesFileName), []byte(rules), 0644))
+
+	// Evaluated against the project root, not the subdirectory passed in.
+	sub := filepath.Join(projectDir, "src")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	result, err := commands.ProjectRulesTest(syncDir, sub)
+	require.NoError(t, err)
+	assert.Equal(t, projectDir, result.Dir)
+	assert.Equal(t, "github.com/acme/api", result.Remote)
+	require.Len(t, result.Evaluations, 2)
+	assert.False(t, result.Evaluations[0].Matched)
+	require.NotNil(t, result.Match)
+	assert.Equal(t, "acme", result.Match.Profile)
+	assert.Equal(t, []string{"hooks", "mcp"}, result.Match.ProjectedKeys())
+
+	t.Run("unknown profile", func(t *testing.T) {
+		rules := "rules:\n  - remote: github.com/acme/*\n    profile: missing\n"
+		require.NoError(t, os.WriteFile(filepath.Join(syncDir, project.RulesFileName), []byte(rules), 0644))
+		_, err := commands.ProjectRulesTest(syncDir, projectDir)
+		assert.ErrorContains(t, err, `unknown profile "missing"`)
+	})
+}
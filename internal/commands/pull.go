@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/ruminaider/claude-sync/internal/approval"
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/bundled"
 	"github.com/ruminaider/claude-sync/internal/sliceutil"
 	"github.com/ruminaider/claude-sync/internal/claudecode"
@@ -24,6 +25,7 @@ import (
 	"github.com/ruminaider/claude-sync/internal/plugins"
 	"github.com/ruminaider/claude-sync/internal/profiles"
 	"github.com/ruminaider/claude-sync/internal/project"
+	"github.com/ruminaider/claude-sync/internal/signing"
 	"github.com/ruminaider/claude-sync/internal/subscriptions"
 	csync "github.com/ruminaider/claude-sync/internal/sync"
 )
@@ -38,19 +40,36 @@ type PullResult struct {
 	Failed                 []string
 	SettingsApplied        []string
 	HooksApplied           []string
-	HooksSkipped           []string // hooks skipped due to missing script files
+	HooksSkipped           []string // hooks and settings skipped due to missing script files
 	SkippedCategories      []string
-	ActiveProfile          string // active profile applied (empty = base only)
+	ActiveProfiles         []string // active profiles applied, in order (empty = base only)
+	ProfileConflicts       []profiles.Conflict // items active profiles disagree on
+	FilteredByWhen         []config.FilteredEntry // entries whose when: selector doesn't match this machine
+	Snapshot               string                 // ID of the snapshot of local files taken before applying
 	PermissionsApplied     bool
+	PermissionsAdded       config.Permissions // rules this pull added to settings.json
+	PermissionsRemoved     config.Permissions // managed rules removed in exact sync mode
 	ClaudeMDAssembled      bool
 	MCPApplied             []string
 	MCPEnvWarnings         []string // unresolved ${VAR} references
+	SecretWarnings         []string // team secrets in settings that couldn't be decrypted
+	SubscriptionWarnings   []string // subscriptions that couldn't be fetched, stale or skipped
+	UntrustedCommits       []UntrustedSource // sources whose hooks, MCP servers and permissions weren't applied
 	MCPProjectApplied      map[string][]string // project path -> server names written there
+	MCPToRemove            map[string][]string // .mcp.json path -> managed servers pull will remove (dry run)
+	MCPRemoved             map[string][]string // .mcp.json path -> managed servers removed
+	MCPSkipped             map[string][]string // .mcp.json path -> servers left alone due to local edits
 	KeybindingsApplied     bool
-	CommandsRestored           int
-	CommandsSkipped            int
-	SkillsRestored             int
-	SkillsSkipped              int
+	CommandsRestored           []string
+	CommandsSkipped            []string
+	SkillsRestored             []string
+	SkillsSkipped              []string
+	AgentsRestored             []string
+	AgentsSkipped              []string
+	OutputStylesRestored       []string
+	OutputStylesSkipped        []string
+	ScriptsRestored            []string
+	ScriptsSkipped             []string
 	PendingHighRisk            []approval.Change
 	Updated                    []string // plugins refreshed due to version mismatch
 	UpdateFailed               []string // plugins that failed to refresh
@@ -60,15 +79,20 @@ type PullResult struct {
 	ProjectUnmanagedDetected   bool     // CWD has settings.local.json but no .claude-sync.yaml
 	ProjectInitEligible        bool     // detected project root has no .claude-sync.yaml and profiles exist
 	ProjectInitDir             string   // suggested directory for project init (project root, not CWD)
+	ProjectRuleApplied         *project.ProfileRule // profile-rules.yaml entry that initialized ProjectInitDir
 	AvailableProfiles          []string // profile names from sync dir (set when ProjectInitEligible)
 	DuplicatePlugins           []plugins.Duplicate // unresolved duplicate plugins (auto mode)
 	EnabledPluginsReconciled   []string // plugins whose enabledPlugins entry was restored
-	SettingsSkipped            bool     // settings.json had local modifications
+	SettingsSkipped            bool     // settings.json had local modifications and couldn't be merged
+	SettingsMerged             bool     // settings.json had local modifications, merged with the incoming settings
+	SettingsConflicts          []string // keys both sides changed; kept local and recorded in conflicts/
 	ClaudeMDSkipped            bool     // CLAUDE.md had local modifications
+	ClaudeMDTemplateErrors     []string // fragments that failed to render, included verbatim
 	KeybindingsSkipped         bool     // keybindings.json had local modifications
-	MemoryWritten              int
-	MemorySkipped              int
+	MemoryWritten              []string // memory fragments written
+	MemorySkipped              []string // memory fragments left alone due to local edits
 	MemoryTotal                int
+	ProjectMemoryPending       []string // synced projects not checked out on this machine
 }
 
 // PullOptions configures pull behavior.
@@ -98,7 +122,17 @@ type PullOptions struct {
 	Version string
 }
 
+// PullDryRun reports what pull would apply from the local sync repo without
+// fetching or changing anything. Marketplaces pull would register aren't, so
+// one that only exists as a directory on disk may be listed as undefined.
 func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
+	return planPull(claudeDir, syncDir, false)
+}
+
+// planPull works out what pull applies. With register set it first
+// registers the marketplaces the plugins need, which writes
+// known_marketplaces.json and may clone repos; PullDryRun doesn't.
+func planPull(claudeDir, syncDir string, register bool) (*PullResult, error) {
 	if _, err := os.Stat(syncDir); os.IsNotExist(err) {
 		return nil, fmt.Errorf("claude-sync not initialized. Run 'claude-sync init' or 'claude-sync join <url>'")
 	}
@@ -111,9 +145,11 @@ func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	machine := config.CurrentMachine()
+	cfg, filtered := cfg.ForMachine(machine)
 
 	// Register declared custom marketplaces before any plugin operations.
-	if len(cfg.Marketplaces) > 0 {
+	if register && len(cfg.Marketplaces) > 0 {
 		if err := marketplace.EnsureRegistered(claudeDir, cfg.Marketplaces); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to register marketplaces: %v\n", err)
 		}
@@ -121,12 +157,14 @@ func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
 
 	// Register local marketplace if forked plugins exist, otherwise clean up stale entry.
 	forks, _ := plugins.ListForkedPlugins(syncDir)
-	if len(forks) > 0 {
-		if err := plugins.RegisterLocalMarketplace(claudeDir, syncDir); err != nil {
-			return nil, fmt.Errorf("registering local marketplace: %w", err)
+	if register {
+		if len(forks) > 0 {
+			if err := plugins.RegisterLocalMarketplace(claudeDir, syncDir); err != nil {
+				return nil, fmt.Errorf("registering local marketplace: %w", err)
+			}
+		} else {
+			_ = plugins.UnregisterLocalMarketplace(claudeDir)
 		}
-	} else {
-		_ = plugins.UnregisterLocalMarketplace(claudeDir)
 	}
 
 	// Build complete desired list from all categories.
@@ -146,13 +184,22 @@ func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
 		allDesired = append(allDesired, bundledKey)
 	}
 
-	// Apply active profile to desired plugins.
-	activeName, _ := profiles.ReadActiveProfile(syncDir)
-	if activeName != "" {
-		p, err := profiles.ReadProfile(syncDir, activeName)
-		if err == nil {
-			allDesired = profiles.MergePlugins(allDesired, p)
+	// Apply active profiles to desired plugins.
+	activeNames, _ := profiles.ReadActiveProfiles(syncDir)
+	mcpServers := cfg.MCP
+	var profileConflicts []profiles.Conflict
+	var activeProfile *profiles.Profile
+	if len(activeNames) > 0 {
+		p, conflicts, err := readActiveProfiles(syncDir, activeNames)
+		if err != nil {
+			return nil, err
 		}
+		p, profileFiltered := p.ForMachine(machine)
+		filtered = append(filtered, profileFiltered...)
+		profileConflicts = conflicts
+		activeProfile = &p
+		allDesired = profiles.MergePlugins(allDesired, p)
+		mcpServers = profiles.MergeMCP(mcpServers, p)
 	}
 
 	// Auto-register marketplaces referenced by plugins but not in config.yaml.
@@ -160,10 +207,12 @@ func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
 	// (called next) catches anything not resolved.
 	// NOTE: Side effects (writes known_marketplaces.json, may clone repos)
 	// mirror the existing EnsureRegistered call above.
-	registered, regErr := marketplace.AutoRegisterFromPlugins(claudeDir, allDesired, cfg.Marketplaces)
-	if regErr != nil {
-		fmt.Fprintf(os.Stderr, "Warning: auto-registering marketplaces (registered %d): %v\n",
-			len(registered), regErr)
+	if register {
+		registered, regErr := marketplace.AutoRegisterFromPlugins(claudeDir, allDesired, cfg.Marketplaces)
+		if regErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: auto-registering marketplaces (registered %d): %v\n",
+				len(registered), regErr)
+		}
 	}
 
 	// Check for plugins referencing undefined marketplaces.
@@ -193,7 +242,9 @@ func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
 		Synced:                diff.Synced,
 		Untracked:             diff.Untracked,
 		EffectiveDesired:      effectiveDesired,
-		ActiveProfile:         activeName,
+		ActiveProfiles:        activeNames,
+		ProfileConflicts:      profileConflicts,
+		FilteredByWhen:        filtered,
 		UndefinedMarketplaces: undefinedMkts,
 	}
 
@@ -202,6 +253,26 @@ func PullDryRun(claudeDir, syncDir string) (*PullResult, error) {
 		result.Untracked = nil
 	}
 
+	// Preview managed MCP servers that are no longer in the resolved config.
+	if !prefs.ShouldSkip(config.CategoryMCP) {
+		if hashes, err := LoadAppliedHashes(syncDir); err == nil && len(hashes.MCP) > 0 {
+			globalServers, projectServers := partitionMCPServers(mcpServers, cfg.MCPMeta, nil)
+			result.MCPToRemove = planMCPRemovals(claudeDir, globalServers, projectServers, hashes)
+		}
+	}
+
+	// Render CLAUDE.md fragments to surface template errors before pull.
+	includes := cfg.ClaudeMD.Include
+	if activeProfile != nil {
+		includes = profiles.MergeClaudeMD(includes, *activeProfile)
+	}
+	if len(includes) > 0 {
+		data := claudeMDTemplateData(cfg, activeProfile, activeNames, prefs)
+		if _, tmplErrs, err := claudemd.AssembleTemplates(syncDir, includes, data); err == nil {
+			result.ClaudeMDTemplateErrors = templateErrorStrings(tmplErrs)
+		}
+	}
+
 	return result, nil
 }
 
@@ -214,18 +285,73 @@ func Pull(claudeDir, syncDir string, quiet bool) (*PullResult, error) {
 	})
 }
 
+// readActiveProfiles resolves the active profiles, in order, including
+// everything they extend. Active profiles whose file is missing are ignored,
+// matching the behavior before inheritance existed; a broken chain (cycle,
+// unknown parent) is an error.
+func readActiveProfiles(syncDir string, names []string) (profiles.Profile, []profiles.Conflict, error) {
+	available, err := profiles.ListProfiles(syncDir)
+	if err != nil {
+		return profiles.Profile{}, nil, err
+	}
+	var present []string
+	for _, name := range names {
+		if slices.Contains(available, name) {
+			present = append(present, name)
+		}
+	}
+	if len(present) == 0 {
+		return profiles.Profile{}, nil, nil
+	}
+
+	resolved, conflicts, err := profiles.ResolveProfiles(syncDir, present)
+	if err != nil {
+		return profiles.Profile{}, nil, fmt.Errorf("resolving profiles: %w", err)
+	}
+	return resolved.Profile, conflicts, nil
+}
+
+// PullWithOptions pulls the sync repo and applies it. Every run, including
+// one that fails part way, is recorded in the audit log.
 func PullWithOptions(opts PullOptions) (*PullResult, error) {
+	if _, err := os.Stat(opts.SyncDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("claude-sync not initialized. Run 'claude-sync init' or 'claude-sync join <url>'")
+	}
+
+	result, err := pullWithOptions(opts)
+	entry := audit.Entry{Command: "pull"}
+	if result != nil {
+		entry = pullAuditEntry(result)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if auditErr := recordAudit(opts.SyncDir, entry); auditErr != nil && !opts.Quiet {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", auditErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pullWithOptions does the work of PullWithOptions. On error it returns
+// what was applied so far, if anything, for the audit log.
+func pullWithOptions(opts PullOptions) (*PullResult, error) {
 	claudeDir := opts.ClaudeDir
 	syncDir := opts.SyncDir
 	quiet := opts.Quiet
 
-	if _, err := os.Stat(syncDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("claude-sync not initialized. Run 'claude-sync init' or 'claude-sync join <url>'")
-	}
-
-	// In auto mode, push any unpushed commits before pulling.
+	// In auto mode, push any unpushed commits, such as auto-commits, before
+	// pulling. Best-effort: a failure is only recorded in the audit log.
 	if opts.Auto && git.HasRemote(syncDir, "origin") && git.HasUnpushedCommits(syncDir) {
-		_ = git.Push(syncDir) // best-effort push before pull
+		entry := audit.Entry{Command: "push", Items: []audit.Item{{Action: "pushed", Category: "commits", Reason: "unpushed commits, before auto pull"}}}
+		if err := git.Push(syncDir); err != nil {
+			entry.Error = err.Error()
+		}
+		if err := recordAudit(syncDir, entry); err != nil && !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+		}
 	}
 
 	if git.HasRemote(syncDir, "origin") {
@@ -240,10 +366,30 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 		}
 	}
 
+	// With signing configured, only apply hooks, MCP servers and
+	// permissions from commits signed by an allowed key.
+	policy, err := signingPolicy(syncDir)
+	if err != nil {
+		return nil, fmt.Errorf("signature verification: %w", err)
+	}
+	var untrusted []UntrustedSource
+	if policy != nil {
+		u, err := verifyConfigRepo(syncDir, policy)
+		if err != nil {
+			return nil, fmt.Errorf("signature verification: %w", err)
+		}
+		if u != nil {
+			untrusted = append(untrusted, *u)
+		}
+	}
+	repoUntrusted := len(untrusted) > 0
+
 	// Fetch and merge subscriptions before computing plugin diff.
-	if err := pullSubscriptions(syncDir, opts.Auto, quiet); err != nil && !quiet {
+	subWarnings, subUntrusted, err := pullSubscriptions(syncDir, opts.Auto, quiet, policy)
+	if err != nil && !quiet {
 		fmt.Fprintf(os.Stderr, "Warning: subscription pull failed: %v\n", err)
 	}
+	untrusted = append(untrusted, subUntrusted...)
 
 	// Detect duplicate plugins before computing diff.
 	var unresolvedDupes []plugins.Duplicate
@@ -272,16 +418,30 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 		}
 	}
 
-	result, err := PullDryRun(claudeDir, syncDir)
+	result, err := planPull(claudeDir, syncDir, true)
 	if err != nil {
 		return nil, err
 	}
+	// MCPToRemove is a dry-run preview; MCPRemoved records what was removed.
+	result.MCPToRemove = nil
+	result.SubscriptionWarnings = subWarnings
+	result.UntrustedCommits = untrusted
 
 	appliedHashes, hashLoadErr := LoadAppliedHashes(syncDir)
 	if hashLoadErr != nil && !quiet {
 		fmt.Fprintf(os.Stderr, "Warning: %v — local modification protection may not work correctly\n", hashLoadErr)
 	}
 
+	// Snapshot local files before anything is written so the previous state
+	// can be brought back with 'claude-sync snapshot restore'.
+	if snap, snapErr := takePullSnapshot(claudeDir, syncDir, opts.ProjectDir, appliedHashes); snapErr != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: failed to snapshot local files: %v\n", snapErr)
+		}
+	} else {
+		result.Snapshot = snap.ID
+	}
+
 	for _, plugin := range result.ToInstall {
 		if !quiet {
 			fmt.Printf("  Installing %s...\n", plugin)
@@ -368,12 +528,16 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 	if err == nil {
 		cfg, err := config.Parse(cfgData)
 		if err == nil {
-			// Merge active profile into config before applying.
-			activeName, _ := profiles.ReadActiveProfile(syncDir)
+			machine := config.CurrentMachine()
+			cfg, _ = cfg.ForMachine(machine)
+
+			// Merge active profiles into config before applying.
+			activeNames, _ := profiles.ReadActiveProfiles(syncDir)
 			var activeProfile *profiles.Profile
-			if activeName != "" {
-				p, pErr := profiles.ReadProfile(syncDir, activeName)
+			if len(activeNames) > 0 {
+				p, _, pErr := readActiveProfiles(syncDir, activeNames)
 				if pErr == nil {
+					p, _ = p.ForMachine(machine)
 					activeProfile = &p
 					cfg.Settings = profiles.MergeSettings(cfg.Settings, p)
 					cfg.Hooks = profiles.MergeHooks(cfg.Hooks, p)
@@ -390,25 +554,72 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 				result.SkippedCategories = append(result.SkippedCategories, string(config.CategoryHooks))
 			}
 
-			// Determine which high-risk items to skip in auto mode.
-			skipHooks := opts.Auto
-			skipPermissions := opts.Auto
-			skipMCP := opts.Auto
+			// Decrypt team secrets (ENC[age:...] values) with this machine's
+			// identity. Merging with local edits works on the settings as
+			// config.yaml holds them, encrypted and with ~/ paths.
+			decrypter := SecretDecrypter()
+			configSettings, configHooks := undecryptableDropped(cfg.Settings, decrypter), cfg.Hooks
+			cfg.Settings, result.SecretWarnings = decryptSettings(cfg.Settings, decrypter)
+
+			// Determine which high-risk items to skip: all of them in auto
+			// mode, or when HEAD isn't signed by an allowed key.
+			skipHooks := opts.Auto || repoUntrusted
+			skipPermissions := opts.Auto || repoUntrusted
+			skipMCP := opts.Auto || repoUntrusted
 
 			// Clean up legacy session lifecycle hooks now handled by the plugin.
 			if cleanErr := CleanupLegacyHooks(claudeDir); cleanErr != nil && !quiet {
 				fmt.Fprintf(os.Stderr, "Warning: failed to clean up legacy hooks: %v\n", cleanErr)
 			}
 
+			// Restore the scripts settings and hooks run before applying
+			// them, so they aren't skipped as missing, then point their
+			// ~/ paths at this machine's home directory. Scripts are code,
+			// so they wait for a reviewed pull like hooks do, and only those
+			// the settings and hooks being applied run are restored.
+			if len(cfg.Scripts) > 0 && !skipHooks {
+				result.ScriptsRestored, result.ScriptsSkipped = restoreScripts(syncDir, cfg.Scripts, ScriptAssets(cfg.Settings, cfg.Hooks), appliedHashes, opts.Force)
+			}
+			cfg.Settings, cfg.Hooks = ExpandScriptPaths(cfg.Settings, cfg.Hooks)
+
 			// Apply settings and hooks (hooks skipped in auto mode).
+			// If settings.json was edited since the last pull, merge the
+			// edits with the incoming settings rather than overwriting them.
 			settingsPath := filepath.Join(claudeDir, "settings.json")
+			settingsCfg := cfg
+			if skipHooks {
+				settingsCfg.Hooks = nil
+			}
 			if !opts.Force && appliedHashes.IsLocallyModified(HashKeySettings, settingsPath) {
-				result.SettingsSkipped = true
-			} else {
-				settingsCfg := cfg
-				if skipHooks {
-					settingsCfg.Hooks = nil
+				var merged *settingsMergeResult
+				if base, ok := appliedHashes.Content(HashKeySettings); ok {
+					mergeCfg := settingsCfg
+					mergeCfg.Settings = configSettings
+					if !skipHooks {
+						mergeCfg.Hooks = configHooks
+					}
+					var mergeErr error
+					merged, mergeErr = mergeLocalSettings(claudeDir, base, mergeCfg, decrypter)
+					if mergeErr != nil && !quiet {
+						fmt.Fprintf(os.Stderr, "Warning: failed to merge settings: %v\n", mergeErr)
+					}
 				}
+				if merged == nil {
+					result.SettingsSkipped = true
+				} else {
+					result.SettingsMerged = true
+					result.SettingsApplied = merged.applied
+					result.HooksApplied = merged.hooksApplied
+					result.HooksSkipped = merged.hooksSkipped
+					appliedHashes.SetContent(HashKeySettings, merged.remote)
+					if _, err := AddConflicts(syncDir, merged.conflicts); err != nil && !quiet {
+						fmt.Fprintf(os.Stderr, "Warning: failed to record settings conflicts: %v\n", err)
+					}
+					for _, c := range merged.conflicts {
+						result.SettingsConflicts = append(result.SettingsConflicts, c.Key)
+					}
+				}
+			} else {
 				applied, hookNames, skippedHooks, applyErr := ApplySettings(claudeDir, settingsCfg)
 				result.HooksSkipped = skippedHooks // always propagate skip info
 				if applyErr != nil {
@@ -419,18 +630,35 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 					result.SettingsApplied = applied
 					result.HooksApplied = hookNames
 					if data, err := os.ReadFile(settingsPath); err == nil {
-						appliedHashes.Set(HashKeySettings, string(data))
+						appliedHashes.SetContent(HashKeySettings, string(data))
 					} else if !quiet {
 						fmt.Fprintf(os.Stderr, "Warning: could not read back %s for hash tracking: %v\n", settingsPath, err)
 					}
 				}
 			}
 
-			// Apply permissions (additive merge, skipped in auto mode).
-			if !skipPermissions && (len(cfg.Permissions.Allow) > 0 || len(cfg.Permissions.Deny) > 0) {
+			// Apply permissions (skipped in auto mode). Union mode merges
+			// additively; exact mode also removes rules pull previously wrote
+			// that are no longer in config.
+			exactSync := prefs.SyncMode == "exact"
+			ledger := appliedHashes.PermissionLedger()
+			hasManaged := len(ledger.Allow) > 0 || len(ledger.Deny) > 0
+			if !skipPermissions && (len(cfg.Permissions.Allow) > 0 || len(cfg.Permissions.Deny) > 0 || (exactSync && hasManaged)) {
 				if !prefs.ShouldSkip(config.CategoryPermissions) {
-					if applyPermissions(claudeDir, cfg.Permissions) == nil {
-						result.PermissionsApplied = true
+					added, removed, permErr := applyPermissions(claudeDir, cfg.Permissions, ledger, exactSync)
+					if permErr == nil {
+						result.PermissionsApplied = len(cfg.Permissions.Allow) > 0 || len(cfg.Permissions.Deny) > 0
+						result.PermissionsAdded = added
+						result.PermissionsRemoved = removed
+						// Permissions live in settings.json; re-record its hash so
+						// the next pull doesn't mistake this write for a local edit.
+						if !result.SettingsSkipped && !result.SettingsMerged {
+							if data, err := os.ReadFile(settingsPath); err == nil {
+								appliedHashes.SetContent(HashKeySettings, string(data))
+							}
+						}
+					} else if !quiet {
+						fmt.Fprintf(os.Stderr, "Warning: failed to apply permissions: %v\n", permErr)
 					}
 				}
 			}
@@ -442,16 +670,16 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 			}
 			if len(includes) > 0 {
 				claudeMDPath := filepath.Join(claudeDir, "CLAUDE.md")
-				if !opts.Force && appliedHashes.IsLocallyModified(HashKeyClaudeMD, claudeMDPath) {
+				if !opts.Force && claudeMDLocallyModified(appliedHashes, claudeMDPath) {
 					result.ClaudeMDSkipped = true
 				} else {
-					assembled, asmErr := claudemd.AssembleFromDir(syncDir, includes)
+					data := claudeMDTemplateData(cfg, activeProfile, activeNames, prefs)
+					assembled, tmplErrs, asmErr := claudemd.AssembleTemplates(syncDir, includes, data)
+					result.ClaudeMDTemplateErrors = templateErrorStrings(tmplErrs)
 					if asmErr == nil && assembled != "" {
-						if os.WriteFile(claudeMDPath, []byte(assembled), 0644) == nil {
+						if writeClaudeMD(claudeMDPath, assembled) == nil {
 							result.ClaudeMDAssembled = true
-							if data, err := os.ReadFile(claudeMDPath); err == nil {
-								appliedHashes.Set(HashKeyClaudeMD, string(data))
-							}
+							appliedHashes.Set(HashKeyClaudeMD, assembled)
 						}
 					}
 				}
@@ -465,87 +693,88 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 			result.MemoryTotal = len(memIncludes)
 			if len(memIncludes) > 0 {
 				syncMemDir := filepath.Join(syncDir, "memory")
-				if err := applyMemoryFragments(paths.ClaudeMemoryDir(), syncMemDir, memIncludes, appliedHashes, opts.Force, result); err != nil {
-					return nil, fmt.Errorf("applying memory fragments: %w", err)
+				if err := applyMemoryFragments(paths.ClaudeMemoryDir(), syncMemDir, memIncludes, HashKeyMemoryPrefix, appliedHashes, opts.Force, result); err != nil {
+					return result, fmt.Errorf("applying memory fragments: %w", err)
 				}
 				if instances, ok := paths.CCSInstances(); ok {
 					for _, inst := range instances {
 						// CCS is best-effort; don't fail pull
-						_ = applyMemoryFragments(paths.CCSInstanceMemoryDir(inst), syncMemDir, memIncludes, appliedHashes, opts.Force, result)
+						_ = applyMemoryFragments(paths.CCSInstanceMemoryDir(inst), syncMemDir, memIncludes, HashKeyMemoryPrefix, appliedHashes, opts.Force, result)
 					}
 				}
 			}
 
-			// Apply MCP servers (additive merge, skipped in auto mode).
+			// Apply project memory to each local checkout of a synced project.
+			if len(cfg.Memory.Projects) > 0 {
+				pending, err := restoreProjectMemory(claudeDir, syncDir, cfg.Memory.Projects, appliedHashes, opts.Force, result)
+				if err != nil {
+					return result, err
+				}
+				result.ProjectMemoryPending = pending
+			}
+
+			// Apply MCP servers (ownership-tracked merge, skipped in auto mode).
 			mcpServers := cfg.MCP
 			if activeProfile != nil {
 				mcpServers = profiles.MergeMCP(mcpServers, *activeProfile)
 			}
 
-			// Expand ~/... paths and resolve ${VAR} env var references before writing.
+			// Expand ~/... paths and resolve ${VAR} references from the secret
+			// store, secret_command and the environment before writing.
 			if len(mcpServers) > 0 {
 				mcpServers = ExpandMCPPaths(mcpServers)
-				mcpServers, result.MCPEnvWarnings = ResolveMCPEnvVars(mcpServers)
+				mcpServers, result.MCPEnvWarnings = ResolveMCPSecrets(mcpServers, SecretResolver(syncDir), decrypter)
 			}
 
-			if !skipMCP && len(mcpServers) > 0 {
-				if !prefs.ShouldSkip(config.CategoryMCP) {
-					// Partition servers by destination using MCPMeta.
-					globalServers := make(map[string]json.RawMessage)
-					projectServers := make(map[string]map[string]json.RawMessage) // path -> servers
-
-					for name, raw := range mcpServers {
-						meta, hasMeta := cfg.MCPMeta[name]
-						if hasMeta && meta.SourceProject != "" {
-							targetPath := meta.SourceProject
-							if opts.MCPTargetResolver != nil {
-								targetPath = opts.MCPTargetResolver(name, targetPath)
-							}
-							if targetPath != "" {
-								if projectServers[targetPath] == nil {
-									projectServers[targetPath] = make(map[string]json.RawMessage)
-								}
-								projectServers[targetPath][name] = raw
-								continue
-							}
-						}
-						globalServers[name] = raw
+			if !skipMCP && !prefs.ShouldSkip(config.CategoryMCP) {
+				globalServers, projectServers := partitionMCPServers(mcpServers, cfg.MCPMeta, opts.MCPTargetResolver)
+
+				// Write each target file, removing servers pull wrote earlier
+				// that are no longer in the resolved set. Files pull wrote to
+				// before are revisited even if nothing targets them now.
+				globalPath := filepath.Join(claudeDir, ".mcp.json")
+				targets := map[string]map[string]json.RawMessage{globalPath: globalServers}
+				projectForPath := make(map[string]string)
+				for projectPath, servers := range projectServers {
+					mcpPath := filepath.Join(projectPath, ".mcp.json")
+					targets[mcpPath] = servers
+					projectForPath[mcpPath] = projectPath
+				}
+				for mcpPath := range appliedHashes.MCP {
+					if _, ok := targets[mcpPath]; !ok {
+						targets[mcpPath] = nil
 					}
+				}
 
-					// Write global servers (additive merge — no hash protection
-					// since users legitimately add their own servers to .mcp.json).
-					if len(globalServers) > 0 {
-						existing, _ := claudecode.ReadMCPConfig(claudeDir)
-						for k, v := range globalServers {
-							existing[k] = v
+				for mcpPath, servers := range targets {
+					applied, removed, skipped, mcpErr := applyMCPFile(mcpPath, servers, appliedHashes, opts.Force)
+					if mcpErr != nil {
+						if !quiet {
+							fmt.Fprintf(os.Stderr, "Warning: failed to apply MCP servers to %s: %v\n", mcpPath, mcpErr)
 						}
-						if claudecode.WriteMCPConfig(claudeDir, existing) == nil {
-							result.MCPApplied = make([]string, 0, len(globalServers))
-							for k := range globalServers {
-								result.MCPApplied = append(result.MCPApplied, k)
+						continue
+					}
+					if len(applied) > 0 {
+						if projectPath, ok := projectForPath[mcpPath]; ok {
+							if result.MCPProjectApplied == nil {
+								result.MCPProjectApplied = make(map[string][]string)
 							}
-							sort.Strings(result.MCPApplied)
+							result.MCPProjectApplied[projectPath] = applied
+						} else {
+							result.MCPApplied = applied
 						}
 					}
-
-					// Write project-scoped servers.
-					if len(projectServers) > 0 {
-						result.MCPProjectApplied = make(map[string][]string)
-						for projectPath, servers := range projectServers {
-							mcpPath := filepath.Join(projectPath, ".mcp.json")
-							existing, _ := claudecode.ReadMCPConfigFile(mcpPath)
-							for k, v := range servers {
-								existing[k] = v
-							}
-							if claudecode.WriteMCPConfigFile(mcpPath, existing) == nil {
-								names := make([]string, 0, len(servers))
-								for k := range servers {
-									names = append(names, k)
-								}
-								sort.Strings(names)
-								result.MCPProjectApplied[projectPath] = names
-							}
+					if len(removed) > 0 {
+						if result.MCPRemoved == nil {
+							result.MCPRemoved = make(map[string][]string)
+						}
+						result.MCPRemoved[mcpPath] = removed
+					}
+					if len(skipped) > 0 {
+						if result.MCPSkipped == nil {
+							result.MCPSkipped = make(map[string][]string)
 						}
+						result.MCPSkipped[mcpPath] = skipped
 					}
 				}
 			}
@@ -579,27 +808,65 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 				effectiveSkills = profiles.MergeSkills(effectiveSkills, *activeProfile)
 			}
 			if len(effectiveCommands) > 0 || len(effectiveSkills) > 0 {
-				cr, cs, sr, ss := restoreCommandsSkills(claudeDir, syncDir, effectiveCommands, effectiveSkills)
-				result.CommandsRestored = cr
-				result.CommandsSkipped = cs
-				result.SkillsRestored = sr
-				result.SkillsSkipped = ss
+				result.CommandsRestored, result.CommandsSkipped, result.SkillsRestored, result.SkillsSkipped = restoreCommandsSkills(claudeDir, syncDir, effectiveCommands, effectiveSkills)
+			}
+			effectiveAgents := cfg.Agents
+			if activeProfile != nil {
+				effectiveAgents = profiles.MergeAgents(effectiveAgents, *activeProfile)
+			}
+			if len(effectiveAgents) > 0 {
+				result.AgentsRestored, result.AgentsSkipped = restoreAgents(claudeDir, syncDir, effectiveAgents)
+			}
+			effectiveStyles := cfg.OutputStyles
+			if activeProfile != nil {
+				effectiveStyles = profiles.MergeOutputStyles(effectiveStyles, *activeProfile)
+			}
+			if len(effectiveStyles) > 0 {
+				result.OutputStylesRestored, result.OutputStylesSkipped = restoreMDFiles(
+					filepath.Join(syncDir, "output-styles"), filepath.Join(claudeDir, "output-styles"), effectiveStyles)
 			}
 
-			// In auto mode, write high-risk items to pending.
+			// In auto mode, write high-risk items to pending. Items the user
+			// rejected are left out until their value changes upstream.
 			if opts.Auto {
-				changes := approval.ConfigChanges{
-					Settings:       cfg.Settings,
-					HasHookChanges: len(cfg.Hooks) > 0,
+				pending := approval.PendingChanges{
+					PendingSince: time.Now().UTC().Format(time.RFC3339),
 				}
 				if len(cfg.Permissions.Allow) > 0 || len(cfg.Permissions.Deny) > 0 {
-					changes.Permissions = &approval.PermissionChanges{
+					pending.Permissions = &approval.PendingPermissions{
 						Allow: cfg.Permissions.Allow,
 						Deny:  cfg.Permissions.Deny,
 					}
 				}
 				if len(mcpServers) > 0 {
-					changes.HasMCPChanges = true
+					pending.MCP = mcpServers
+				}
+				if len(cfg.Hooks) > 0 {
+					pending.Hooks = cfg.Hooks
+				}
+				if head, err := git.RevParse(syncDir, "HEAD"); err == nil {
+					pending.Commit = head
+				}
+				if rejections, err := approval.ReadRejections(syncDir); err == nil {
+					pending = rejections.Filter(pending)
+				}
+
+				changes := approval.ConfigChanges{
+					Settings:       cfg.Settings,
+					HasHookChanges: len(pending.Hooks) > 0,
+					Hooks:          pending.Hooks,
+					HasMCPChanges:  len(pending.MCP) > 0,
+				}
+				if p := pending.Permissions; p != nil {
+					changes.Permissions = &approval.PermissionChanges{
+						Allow: p.Allow,
+						Deny:  p.Deny,
+					}
+				}
+				for _, item := range pending.Items() {
+					if item.Category == "mcp" {
+						changes.MCPServers = append(changes.MCPServers, item.Name)
+					}
 				}
 				if len(kbConfig) > 0 {
 					changes.Keybindings = true
@@ -611,22 +878,6 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 				classified := approval.Classify(changes)
 
 				if len(classified.HighRisk) > 0 {
-					pending := approval.PendingChanges{
-						PendingSince: time.Now().UTC().Format(time.RFC3339),
-					}
-					if len(cfg.Permissions.Allow) > 0 || len(cfg.Permissions.Deny) > 0 {
-						pending.Permissions = &approval.PendingPermissions{
-							Allow: cfg.Permissions.Allow,
-							Deny:  cfg.Permissions.Deny,
-						}
-					}
-					if len(mcpServers) > 0 {
-						pending.MCP = mcpServers
-					}
-					if len(cfg.Hooks) > 0 {
-						pending.Hooks = cfg.Hooks
-					}
-
 					_ = approval.WritePending(syncDir, pending)
 					result.PendingHighRisk = classified.HighRisk
 				}
@@ -656,6 +907,12 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 			cfgData2, _ := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
 			cfg2, _ := config.Parse(cfgData2)
 			resolved := ResolveWithProfile(cfg2, syncDir, pcfg.Profile)
+			// An untrusted HEAD's hooks, MCP servers and permissions reach
+			// the project no more than the global settings.
+			if repoUntrusted {
+				pcfg.ProjectedKeys = withoutHighRiskKeys(pcfg.ProjectedKeys)
+				result.UntrustedCommits[0].Projects = append(result.UntrustedCommits[0].Projects, projectDir)
+			}
 			if applyErr := ApplyProjectSettings(projectDir, resolved, pcfg, syncDir); applyErr == nil {
 				result.ProjectSettingsApplied = true
 			}
@@ -674,9 +931,35 @@ func PullWithOptions(opts PullOptions) (*PullResult, error) {
 						result.ProjectUnmanagedDetected = true
 					}
 
+					// A matching profile-rules.yaml entry initializes the
+					// project without prompting.
+					rules, rulesErr := ProjectRulesTest(syncDir, projectRoot)
+					if rulesErr != nil && !quiet {
+						fmt.Fprintf(os.Stderr, "Warning: %v\n", rulesErr)
+					}
+					if rulesErr == nil && rules.Match != nil && repoUntrusted {
+						// profile-rules.yaml is part of the untrusted HEAD.
+						result.UntrustedCommits[0].Projects = append(result.UntrustedCommits[0].Projects, projectRoot)
+					} else if rulesErr == nil && rules.Match != nil {
+						_, initErr := ProjectInit(ProjectInitOptions{
+							ProjectDir:    projectRoot,
+							SyncDir:       syncDir,
+							Profile:       rules.Match.Profile,
+							ProjectedKeys: rules.Match.ProjectedKeys(),
+							Yes:           true,
+						})
+						if initErr == nil {
+							result.ProjectRuleApplied = rules.Match
+							result.ProjectInitDir = projectRoot
+							result.ProjectSettingsApplied = true
+						} else if !quiet {
+							fmt.Fprintf(os.Stderr, "Warning: applying %s to %s: %v\n", project.RulesFileName, projectRoot, initErr)
+						}
+					}
+
 					// If profiles exist, this directory is eligible for project init.
 					profileNames, _ := profiles.ListProfiles(syncDir)
-					if len(profileNames) > 0 {
+					if len(profileNames) > 0 && result.ProjectRuleApplied == nil {
 						result.ProjectInitEligible = true
 						result.ProjectInitDir = projectRoot
 						result.AvailableProfiles = profileNames
@@ -825,11 +1108,32 @@ func ApplySettings(claudeDir string, cfg config.Config) ([]string, []string, []s
 		settings = make(map[string]json.RawMessage)
 	}
 
+	settingsApplied, hooksApplied, hooksSkipped, err := applySettingsTo(settings, cfg)
+	if err != nil {
+		return settingsApplied, nil, hooksSkipped, err
+	}
+
+	if err := claudecode.WriteSettings(claudeDir, settings); err != nil {
+		return nil, nil, nil, fmt.Errorf("writing settings: %w", err)
+	}
+	return settingsApplied, hooksApplied, hooksSkipped, nil
+}
+
+// applySettingsTo sets cfg's settings and hooks in the parsed settings.json
+// map. Settings and hooks whose scripts are missing are skipped.
+func applySettingsTo(settings map[string]json.RawMessage, cfg config.Config) ([]string, []string, []string, error) {
 	var settingsApplied []string
+	var hooksSkipped []string
 	for key, val := range cfg.Settings {
 		if excludedSettingsFields[key] {
 			continue
 		}
+		if missing := missingSettingScripts(key, val); len(missing) > 0 {
+			for _, m := range missing {
+				hooksSkipped = append(hooksSkipped, fmt.Sprintf("setting %q: script not found: %s", key, m))
+			}
+			continue
+		}
 		data, err := json.Marshal(val)
 		if err != nil {
 			continue
@@ -839,7 +1143,6 @@ func ApplySettings(claudeDir string, cfg config.Config) ([]string, []string, []s
 	}
 
 	var hooksApplied []string
-	var hooksSkipped []string
 	if len(cfg.Hooks) > 0 {
 		var existingHooks map[string]json.RawMessage
 		if hooksRaw, ok := settings["hooks"]; ok {
@@ -850,7 +1153,7 @@ func ApplySettings(claudeDir string, cfg config.Config) ([]string, []string, []s
 		}
 
 		for hookName, hookData := range cfg.Hooks {
-			missing, parseErr := findMissingHookScripts(hookData)
+			missing, parseErr := approval.MissingHookScripts(hookData)
 			if parseErr != nil {
 				hooksSkipped = append(hooksSkipped, fmt.Sprintf("hook %q: %v", hookName, parseErr))
 				continue
@@ -872,83 +1175,12 @@ func ApplySettings(claudeDir string, cfg config.Config) ([]string, []string, []s
 		settings["hooks"] = json.RawMessage(hooksData)
 	}
 
-	if err := claudecode.WriteSettings(claudeDir, settings); err != nil {
-		return nil, nil, nil, fmt.Errorf("writing settings: %w", err)
-	}
-
 	sort.Strings(settingsApplied)
 	sort.Strings(hooksApplied)
 	sort.Strings(hooksSkipped)
 	return settingsApplied, hooksApplied, hooksSkipped, nil
 }
 
-// scriptInterpreters are command prefixes that indicate the next argument is a script file.
-var scriptInterpreters = map[string]bool{
-	"bash": true, "sh": true, "zsh": true,
-	"python": true, "python3": true,
-	"ruby": true, "perl": true, "node": true,
-}
-
-// hookEntry represents a single hook entry in the Claude hook JSON format.
-type hookEntry struct {
-	Hooks []struct {
-		Command string `json:"command"`
-	} `json:"hooks"`
-}
-
-// findMissingHookScripts inspects a hook's JSON data for commands that reference
-// external script files. Returns paths of scripts that don't exist on disk.
-// Returns an error if the hook JSON is malformed.
-func findMissingHookScripts(hookData json.RawMessage) ([]string, error) {
-	var entries []hookEntry
-	if err := json.Unmarshal(hookData, &entries); err != nil {
-		return nil, fmt.Errorf("malformed hook JSON: %w", err)
-	}
-	var missing []string
-	for _, entry := range entries {
-		for _, h := range entry.Hooks {
-			path := extractScriptPath(h.Command)
-			if path == "" {
-				continue
-			}
-			expanded := expandHome(path)
-			if _, err := os.Stat(expanded); err != nil {
-				if os.IsNotExist(err) {
-					missing = append(missing, path)
-				} else {
-					return nil, fmt.Errorf("checking script %s: %w", path, err)
-				}
-			}
-		}
-	}
-	return missing, nil
-}
-
-// extractScriptPath returns the script file path from a command string like
-// "bash ~/.claude/hooks/foo.sh" or "python3 /path/to/script.py".
-// Returns empty string if the command doesn't reference an external script.
-func extractScriptPath(command string) string {
-	parts := strings.Fields(command)
-	if len(parts) < 2 {
-		return ""
-	}
-	if !scriptInterpreters[parts[0]] {
-		return ""
-	}
-	// Skip flags (e.g. -e, -x) to find the first positional argument.
-	for _, arg := range parts[1:] {
-		if strings.HasPrefix(arg, "-") {
-			continue
-		}
-		path := strings.Trim(arg, `"'`)
-		if strings.HasPrefix(path, "/") || strings.HasPrefix(path, "~/") {
-			return path
-		}
-		return ""
-	}
-	return ""
-}
-
 // expandHome replaces a leading ~/ with the user's home directory.
 func expandHome(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -961,8 +1193,12 @@ func expandHome(path string) string {
 	return path
 }
 
-// applyPermissions additively merges permissions into settings.json.
-func applyPermissions(claudeDir string, perms config.Permissions) error {
+// applyPermissions merges permissions into settings.json and records the rules
+// it introduces in ledger. When exact is true, rules recorded in ledger that
+// are no longer in perms are removed; rules the user added by hand are never
+// in the ledger and so are never removed. Returns the rules added to and
+// removed from settings.json.
+func applyPermissions(claudeDir string, perms config.Permissions, ledger *ManagedPermissions, exact bool) (added, removed config.Permissions, err error) {
 	settings, err := claudecode.ReadSettings(claudeDir)
 	if err != nil {
 		settings = make(map[string]json.RawMessage)
@@ -976,76 +1212,220 @@ func applyPermissions(claudeDir string, perms config.Permissions) error {
 		json.Unmarshal(permRaw, &existingPerms)
 	}
 
-	mergedAllow := sliceutil.AppendUnique(existingPerms.Allow, perms.Allow)
-	mergedDeny := sliceutil.AppendUnique(existingPerms.Deny, perms.Deny)
+	mergedAllow, managedAllow, removedAllow := mergeManagedRules(existingPerms.Allow, perms.Allow, ledger.Allow, exact)
+	mergedDeny, managedDeny, removedDeny := mergeManagedRules(existingPerms.Deny, perms.Deny, ledger.Deny, exact)
 
 	permData, err := json.Marshal(map[string]any{
 		"allow": mergedAllow,
 		"deny":  mergedDeny,
 	})
 	if err != nil {
-		return fmt.Errorf("marshaling permissions: %w", err)
+		return added, removed, fmt.Errorf("marshaling permissions: %w", err)
 	}
 	settings["permissions"] = json.RawMessage(permData)
 
-	return claudecode.WriteSettings(claudeDir, settings)
+	if err := claudecode.WriteSettings(claudeDir, settings); err != nil {
+		return added, removed, err
+	}
+
+	ledger.Allow = managedAllow
+	ledger.Deny = managedDeny
+	added = config.Permissions{
+		Allow: sliceutil.AppendUnique(nil, sliceutil.RemoveAll(perms.Allow, existingPerms.Allow)),
+		Deny:  sliceutil.AppendUnique(nil, sliceutil.RemoveAll(perms.Deny, existingPerms.Deny)),
+	}
+	return added, config.Permissions{Allow: removedAllow, Deny: removedDeny}, nil
 }
 
-// restoreCommandsSkills copies command .md files and skill directories from
-// syncDir to claudeDir, filtered by the effective key lists.
-// Tracks content hashes to avoid overwriting locally-modified files.
-// Returns (restored, skipped) counts for commands and skills.
-func restoreCommandsSkills(claudeDir, syncDir string, commandKeys, skillKeys []string) (cmdsRestored, cmdsSkipped, skillsRestored, skillsSkipped int) {
-	// Build set of command filenames from keys (e.g. "cmd:global:review-pr" → "review-pr.md").
-	cmdNames := make(map[string]bool)
-	for _, k := range commandKeys {
-		parts := strings.Split(k, ":")
-		if len(parts) >= 2 {
-			cmdNames[parts[len(parts)-1]+".md"] = true
+// mergeManagedRules merges desired rules into existing. A rule is recorded as
+// managed only if pull introduced it (it was not already present). When exact
+// is true, previously managed rules missing from desired are removed.
+// Returns (merged, managed, removed). merged is never nil so settings.json
+// always receives a JSON array.
+func mergeManagedRules(existing, desired, managed []string, exact bool) (merged, newManaged, removed []string) {
+	desiredSet := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		desiredSet[r] = true
+	}
+	existingSet := make(map[string]bool, len(existing))
+	for _, r := range existing {
+		existingSet[r] = true
+	}
+
+	if exact {
+		for _, r := range managed {
+			if !desiredSet[r] && existingSet[r] {
+				removed = append(removed, r)
+			}
 		}
 	}
 
-	// Copy commands with local-modification protection.
-	srcCmdsDir := filepath.Join(syncDir, "commands")
-	if entries, err := os.ReadDir(srcCmdsDir); err == nil {
-		dstCmdsDir := filepath.Join(claudeDir, "commands")
-		os.MkdirAll(dstCmdsDir, 0755)
+	merged = sliceutil.AppendUnique(sliceutil.RemoveAll(existing, removed), desired)
+	if merged == nil {
+		merged = []string{}
+	}
 
-		cmdHashes, _ := claudecode.ReadContentHashes(dstCmdsDir)
+	mergedSet := make(map[string]bool, len(merged))
+	for _, r := range merged {
+		mergedSet[r] = true
+	}
+	// Keep ledger entries still present in settings.json, then record rules
+	// this pull introduced.
+	var candidates []string
+	candidates = append(candidates, managed...)
+	for _, r := range desired {
+		if !existingSet[r] {
+			candidates = append(candidates, r)
+		}
+	}
+	seen := make(map[string]bool, len(candidates))
+	for _, r := range candidates {
+		if seen[r] || !mergedSet[r] {
+			continue
+		}
+		seen[r] = true
+		newManaged = append(newManaged, r)
+	}
+	return merged, newManaged, removed
+}
 
-		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
-				continue
+// partitionMCPServers splits servers into global servers and project-scoped
+// servers keyed by project path, using MCPMeta.SourceProject. resolver, if
+// non-nil, confirms or redirects each project path; an empty result sends the
+// server to the global file instead.
+func partitionMCPServers(servers map[string]json.RawMessage, meta map[string]config.MCPServerMeta, resolver func(serverName, suggestedPath string) string) (map[string]json.RawMessage, map[string]map[string]json.RawMessage) {
+	globalServers := make(map[string]json.RawMessage)
+	projectServers := make(map[string]map[string]json.RawMessage) // path -> servers
+
+	for name, raw := range servers {
+		m, hasMeta := meta[name]
+		if hasMeta && m.SourceProject != "" {
+			targetPath := m.SourceProject
+			if resolver != nil {
+				targetPath = resolver(name, targetPath)
 			}
-			if len(cmdNames) > 0 && !cmdNames[entry.Name()] {
+			if targetPath != "" {
+				if projectServers[targetPath] == nil {
+					projectServers[targetPath] = make(map[string]json.RawMessage)
+				}
+				projectServers[targetPath][name] = raw
 				continue
 			}
-			srcPath := filepath.Join(srcCmdsDir, entry.Name())
-			dstPath := filepath.Join(dstCmdsDir, entry.Name())
+		}
+		globalServers[name] = raw
+	}
+	return globalServers, projectServers
+}
 
-			srcData, err := os.ReadFile(srcPath)
-			if err != nil {
+// applyMCPFile merges desired servers into the .mcp.json at mcpPath and
+// removes servers pull previously wrote there that are no longer desired.
+// Servers edited locally since pull wrote them are neither overwritten nor
+// removed unless force is set; a locally edited server that is no longer
+// desired is handed over to the user and dropped from tracking. The file is
+// only written if something changed. Returns sorted applied, removed and
+// skipped server names.
+func applyMCPFile(mcpPath string, desired map[string]json.RawMessage, hashes *AppliedHashes, force bool) (applied, removed, skipped []string, err error) {
+	managed := hashes.ManagedMCPServers(mcpPath)
+	if len(desired) == 0 && len(managed) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	existing, err := claudecode.ReadMCPConfigFile(mcpPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	changed := false
+	for name, raw := range desired {
+		if current, ok := existing[name]; ok && !force && hashes.IsMCPServerModified(mcpPath, name, current) {
+			skipped = append(skipped, name)
+			continue
+		}
+		existing[name] = raw
+		applied = append(applied, name)
+		changed = true
+	}
+
+	var forget []string
+	for _, name := range managed {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		current, ok := existing[name]
+		if !ok {
+			forget = append(forget, name)
+			continue
+		}
+		if !force && hashes.IsMCPServerModified(mcpPath, name, current) {
+			skipped = append(skipped, name)
+			forget = append(forget, name)
+			continue
+		}
+		delete(existing, name)
+		removed = append(removed, name)
+		forget = append(forget, name)
+		changed = true
+	}
+
+	if changed {
+		if err := claudecode.WriteMCPConfigFile(mcpPath, existing); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	for _, name := range applied {
+		hashes.SetMCPServer(mcpPath, name, existing[name])
+	}
+	for _, name := range forget {
+		hashes.ForgetMCPServer(mcpPath, name)
+	}
+
+	sort.Strings(applied)
+	sort.Strings(removed)
+	sort.Strings(skipped)
+	return applied, removed, skipped, nil
+}
+
+// planMCPRemovals returns, per .mcp.json path, the managed servers that pull
+// would remove because they are no longer in the resolved config and have not
+// been edited locally.
+func planMCPRemovals(claudeDir string, globalServers map[string]json.RawMessage, projectServers map[string]map[string]json.RawMessage, hashes *AppliedHashes) map[string][]string {
+	desired := map[string]map[string]json.RawMessage{
+		filepath.Join(claudeDir, ".mcp.json"): globalServers,
+	}
+	for projectPath, servers := range projectServers {
+		desired[filepath.Join(projectPath, ".mcp.json")] = servers
+	}
+
+	var plan map[string][]string
+	for mcpPath := range hashes.MCP {
+		existing, err := claudecode.ReadMCPConfigFile(mcpPath)
+		if err != nil {
+			continue
+		}
+		for _, name := range hashes.ManagedMCPServers(mcpPath) {
+			if _, ok := desired[mcpPath][name]; ok {
 				continue
 			}
-
-			// Check if local file was modified since last sync.
-			if localData, err := os.ReadFile(dstPath); err == nil {
-				localHash := claudemd.ContentHash(string(localData))
-				if storedHash, ok := cmdHashes.Hashes[entry.Name()]; ok && localHash != storedHash {
-					// Local file was modified — skip overwrite.
-					cmdsSkipped++
-					continue
-				}
+			current, ok := existing[name]
+			if !ok || hashes.IsMCPServerModified(mcpPath, name, current) {
+				continue
 			}
-
-			if os.WriteFile(dstPath, srcData, 0644) == nil {
-				cmdHashes.Hashes[entry.Name()] = claudemd.ContentHash(string(srcData))
-				cmdsRestored++
+			if plan == nil {
+				plan = make(map[string][]string)
 			}
+			plan[mcpPath] = append(plan[mcpPath], name)
 		}
-
-		claudecode.WriteContentHashes(dstCmdsDir, cmdHashes)
 	}
+	return plan
+}
+
+// restoreCommandsSkills copies command .md files and skill directories from
+// syncDir to claudeDir, filtered by the effective key lists.
+// Tracks content hashes to avoid overwriting locally-modified files.
+// Returns the restored and skipped command files and skill names.
+func restoreCommandsSkills(claudeDir, syncDir string, commandKeys, skillKeys []string) (cmdsRestored, cmdsSkipped, skillsRestored, skillsSkipped []string) {
+	cmdsRestored, cmdsSkipped = restoreMDFiles(filepath.Join(syncDir, "commands"), filepath.Join(claudeDir, "commands"), commandKeys)
 
 	// Build set of skill names from keys (e.g. "skill:global:brainstorming" → "brainstorming").
 	skillNames := make(map[string]bool)
@@ -1078,21 +1458,21 @@ func restoreCommandsSkills(claudeDir, syncDir string, commandKeys, skillKeys []s
 			if err != nil {
 				// Can't hash source; copy without tracking.
 				if copyDir(srcDir, dstDir) == nil {
-					skillsRestored++
+					skillsRestored = append(skillsRestored, entry.Name())
 				}
 				continue
 			}
 
 			if localHash, err := claudemd.DirContentHash(dstDir); err == nil {
 				if storedHash, ok := skillHashes.Hashes[entry.Name()]; ok && localHash != storedHash {
-					skillsSkipped++
+					skillsSkipped = append(skillsSkipped, entry.Name())
 					continue
 				}
 			}
 
 			if copyDir(srcDir, dstDir) == nil {
 				skillHashes.Hashes[entry.Name()] = srcHash
-				skillsRestored++
+				skillsRestored = append(skillsRestored, entry.Name())
 			}
 		}
 
@@ -1102,6 +1482,70 @@ func restoreCommandsSkills(claudeDir, syncDir string, commandKeys, skillKeys []s
 	return
 }
 
+// restoreAgents copies subagent .md files from syncDir/agents to
+// claudeDir/agents, with the same local-modification protection as
+// commands. Returns the restored and skipped file names.
+func restoreAgents(claudeDir, syncDir string, agentKeys []string) (restored, skipped []string) {
+	return restoreMDFiles(filepath.Join(syncDir, "agents"), filepath.Join(claudeDir, "agents"), agentKeys)
+}
+
+// restoreMDFiles copies the .md files in srcDir named by keys (e.g.
+// "cmd:global:review-pr" → "review-pr.md"; all files when keys is empty) to
+// dstDir. A file whose content no longer matches the hash recorded when it
+// was last restored was edited locally and is skipped. Returns the restored
+// and skipped file names.
+func restoreMDFiles(srcDir, dstDir string, keys []string) (restored, skipped []string) {
+	names := make(map[string]bool)
+	for _, k := range keys {
+		parts := strings.Split(k, ":")
+		if len(parts) >= 2 {
+			names[parts[len(parts)-1]+".md"] = true
+		}
+	}
+
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, nil
+	}
+	os.MkdirAll(dstDir, 0755)
+
+	hashes, _ := claudecode.ReadContentHashes(dstDir)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		if len(names) > 0 && !names[entry.Name()] {
+			continue
+		}
+		srcPath := filepath.Join(srcDir, entry.Name())
+		dstPath := filepath.Join(dstDir, entry.Name())
+
+		srcData, err := os.ReadFile(srcPath)
+		if err != nil {
+			continue
+		}
+
+		// Check if local file was modified since last sync.
+		if localData, err := os.ReadFile(dstPath); err == nil {
+			localHash := claudemd.ContentHash(string(localData))
+			if storedHash, ok := hashes.Hashes[entry.Name()]; ok && localHash != storedHash {
+				// Local file was modified — skip overwrite.
+				skipped = append(skipped, entry.Name())
+				continue
+			}
+		}
+
+		if os.WriteFile(dstPath, srcData, 0644) == nil {
+			hashes.Hashes[entry.Name()] = claudemd.ContentHash(string(srcData))
+			restored = append(restored, entry.Name())
+		}
+	}
+
+	claudecode.WriteContentHashes(dstDir, hashes)
+	return restored, skipped
+}
+
 
 // detectStalePlugins returns synced plugin keys whose cache is out of date.
 // For directory-based marketplaces, it compares content hashes of the source
@@ -1333,45 +1777,84 @@ func updatePluginContentHashes(claudeDir string, keys []string) {
 	_ = claudecode.WritePluginContentHashes(claudeDir, pch)
 }
 
+// autoSubscriptionTimeout bounds each subscription fetch in auto mode, which
+// runs from the SessionStart hook with 5s for the whole pull.
+const autoSubscriptionTimeout = 3 * time.Second
+
 // pullSubscriptions fetches all subscriptions and merges their items into the
 // local config.yaml. In auto mode, only previously-accepted items are applied;
 // new items are queued for the next interactive pull. In interactive mode,
 // all resolved items are applied (the approval TUI is handled by the CLI layer).
-func pullSubscriptions(syncDir string, auto, quiet bool) error {
+//
+// Subscriptions that can't be fetched are merged from the last good clone if
+// there is one, and left out otherwise; either way a warning is returned.
+func pullSubscriptions(syncDir string, auto, quiet bool, policy *signing.Policy) ([]string, []UntrustedSource, error) {
 	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
 	if err != nil {
-		return nil // no config = nothing to do
+		return nil, nil, nil // no config = nothing to do
 	}
 	cfg, err := config.Parse(cfgData)
 	if err != nil || len(cfg.Subscriptions) == 0 {
-		return nil
+		return nil, nil, nil
 	}
 
 	state, _ := subscriptions.ReadState(syncDir)
+	lock, err := subscriptions.ReadLock(syncDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var untrusted []UntrustedSource
 
-	// Fetch all subscriptions.
-	results := subscriptions.FetchAll(syncDir, cfg.Subscriptions, state)
+	// Fetch all subscriptions. Locked ones stay on their commit.
+	var warnings []string
+	var newlyLocked []string
+	usable := make(map[string]config.SubscriptionEntry, len(cfg.Subscriptions))
+	fetchOpts := subscriptions.FetchOptions{}
+	if auto {
+		fetchOpts.Timeout = autoSubscriptionTimeout
+	}
+	results := subscriptions.FetchAll(syncDir, cfg.Subscriptions, state, lock, fetchOpts)
 	for _, r := range results {
-		if r.Error != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "Warning: subscription %q: %v\n", r.Name, r.Error)
-			}
+		switch {
+		case r.Stale:
+			warnings = append(warnings, fmt.Sprintf("subscription %q: %v; using the copy fetched %s ago",
+				r.Name, r.Error, formatAge(time.Since(r.LastFetched))))
+		case r.Error != nil:
+			warnings = append(warnings, fmt.Sprintf("subscription %q skipped: %v", r.Name, r.Error))
 			continue
+		default:
+			// Update state with new SHA.
+			subscriptions.UpdateState(&state, r.Name, r.CommitSHA, nil)
+			// A subscription switched to lock: true is locked where it is now.
+			if cfg.Subscriptions[r.Name].Lock && lock.Commit(r.Name) == "" {
+				lock.Set(r.Name, r.CommitSHA)
+				newlyLocked = append(newlyLocked, r.Name)
+			}
+		}
+		sub := cfg.Subscriptions[r.Name]
+		if policy != nil {
+			v, err := signing.Verify(subscriptions.SubDir(syncDir, r.Name), "HEAD", policy)
+			if err != nil {
+				v = signing.Verification{Commit: r.CommitSHA, Status: signing.Invalid}
+			}
+			if !v.Trusted() {
+				untrusted = append(untrusted, UntrustedSource{Source: "subscription " + r.Name, Verification: v})
+				sub = withoutHighRisk(sub)
+			}
 		}
-		// Update state with new SHA.
-		subscriptions.UpdateState(&state, r.Name, r.CommitSHA, nil)
+		usable[r.Name] = sub
 	}
 
 	// Merge all subscription items.
-	merged, conflicts, err := subscriptions.MergeAll(syncDir, cfg.Subscriptions, cfg)
+	merged, conflicts, err := subscriptions.MergeAll(syncDir, usable, cfg)
 	if err != nil {
-		return fmt.Errorf("merging subscriptions: %w", err)
+		return warnings, untrusted, fmt.Errorf("merging subscriptions: %w", err)
 	}
 	if len(conflicts) > 0 {
 		if !quiet {
 			fmt.Fprintf(os.Stderr, "\n%s\n", subscriptions.FormatConflicts(conflicts))
 		}
-		return fmt.Errorf("%d subscription conflict(s) found", len(conflicts))
+		return warnings, untrusted, fmt.Errorf("%d subscription conflict(s) found", len(conflicts))
 	}
 
 	// Apply merged items to local config.
@@ -1380,16 +1863,39 @@ func pullSubscriptions(syncDir string, auto, quiet bool) error {
 	// Write updated config.
 	newData, err := config.Marshal(cfg)
 	if err != nil {
-		return fmt.Errorf("marshaling config after subscription merge: %w", err)
+		return warnings, untrusted, fmt.Errorf("marshaling config after subscription merge: %w", err)
 	}
 	if err := os.WriteFile(filepath.Join(syncDir, "config.yaml"), newData, 0644); err != nil {
-		return fmt.Errorf("writing config after subscription merge: %w", err)
+		return warnings, untrusted, fmt.Errorf("writing config after subscription merge: %w", err)
 	}
 
 	// Save state.
 	subscriptions.WriteState(syncDir, state)
 
-	return nil
+	if len(newlyLocked) > 0 {
+		if err := subscriptions.WriteLock(syncDir, lock); err != nil {
+			return warnings, untrusted, err
+		}
+		_ = git.Add(syncDir, "subscriptions.lock")
+		if err := git.Commit(syncDir, "Lock subscriptions: "+strings.Join(newlyLocked, ", ")); err != nil {
+			warnings = append(warnings, fmt.Sprintf("committing subscriptions.lock: %v", err))
+		}
+	}
+
+	return warnings, untrusted, nil
+}
+
+// formatAge renders d coarsely, e.g. "3d", "5h" or "12m".
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return "<1m"
 }
 
 // findProjectRoot walks up from dir looking for .git/ or .claude/ to identify
@@ -1420,7 +1926,7 @@ func findProjectRoot(dir string) string {
 	}
 }
 
-func applyMemoryFragments(targetDir, syncMemDir string, includes []string, hashes *AppliedHashes, force bool, result *PullResult) error {
+func applyMemoryFragments(targetDir, syncMemDir string, includes []string, hashKeyPrefix string, hashes *AppliedHashes, force bool, result *PullResult) error {
 	if err := os.MkdirAll(targetDir, 0755); err != nil {
 		return fmt.Errorf("creating memory target dir %s: %w", targetDir, err)
 	}
@@ -1430,16 +1936,16 @@ func applyMemoryFragments(targetDir, syncMemDir string, includes []string, hashe
 			return fmt.Errorf("reading memory fragment %q: %w", name, err)
 		}
 		targetPath := filepath.Join(targetDir, name+".md")
-		hashKey := HashKeyMemoryPrefix + name
+		hashKey := hashKeyPrefix + name
 		if !force && hashes.IsLocallyModified(hashKey, targetPath) {
-			result.MemorySkipped++
+			result.MemorySkipped = sliceutil.AppendUnique(result.MemorySkipped, []string{name})
 			continue
 		}
 		if err := os.WriteFile(targetPath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("writing memory fragment %q: %w", name, err)
 		}
 		hashes.Set(hashKey, content)
-		result.MemoryWritten++
+		result.MemoryWritten = sliceutil.AppendUnique(result.MemoryWritten, []string{name})
 	}
 	if err := memory.RegenerateIndex(targetDir); err != nil {
 		return fmt.Errorf("regenerating memory index: %w", err)
@@ -5,21 +5,40 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/ruminaider/claude-sync/internal/approval"
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/claudecode"
 	"github.com/ruminaider/claude-sync/internal/claudemd"
 	"github.com/ruminaider/claude-sync/internal/commands"
 	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/git"
 	"github.com/ruminaider/claude-sync/internal/marketplace"
 	"github.com/ruminaider/claude-sync/internal/plugins"
 	"github.com/ruminaider/claude-sync/internal/profiles"
 	"github.com/ruminaider/claude-sync/internal/project"
+	"github.com/ruminaider/claude-sync/internal/signing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+func TestPull_DryRun_DoesNotRegisterMarketplaces(t *testing.T) {
+	claudeDir, syncDir := setupStatusEnv(t)
+	manifest := filepath.Join(syncDir, "plugins", "my-fork", ".claude-plugin", "plugin.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(manifest), 0755))
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"name":"my-fork"}`), 0644))
+	mktsPath := filepath.Join(claudeDir, "plugins", "known_marketplaces.json")
+	before, _ := os.ReadFile(mktsPath)
+
+	_, err := commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+
+	after, _ := os.ReadFile(mktsPath)
+	assert.Equal(t, string(before), string(after), "dry run must not register the forks marketplace")
+}
+
 func TestPull_DryRun_NothingToInstall(t *testing.T) {
 	claudeDir, syncDir := setupStatusEnv(t)
 
@@ -143,6 +162,10 @@ func TestPull_RegistersLocalMarketplace(t *testing.T) {
 	result, err := commands.PullDryRun(claudeDir, syncDir)
 	require.NoError(t, err)
 
+	// Pull itself registers the marketplace; the dry run above doesn't.
+	_, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
 	// Verify known_marketplaces.json contains "claude-sync-forks" entry
 	mkts, err := claudecode.ReadMarketplaces(claudeDir)
 	require.NoError(t, err)
@@ -664,7 +687,7 @@ plugins:
 
 	assert.Contains(t, result.EffectiveDesired, "context7@claude-plugins-official")
 	assert.Contains(t, result.EffectiveDesired, "extra-tool@some-marketplace")
-	assert.Equal(t, "work", result.ActiveProfile)
+	assert.Equal(t, []string{"work"}, result.ActiveProfiles)
 }
 
 func TestPull_AppliesProfilePluginRemoves(t *testing.T) {
@@ -698,7 +721,7 @@ plugins:
 
 	assert.Contains(t, result.EffectiveDesired, "context7@claude-plugins-official")
 	assert.NotContains(t, result.EffectiveDesired, "beads@beads-marketplace")
-	assert.Equal(t, "work", result.ActiveProfile)
+	assert.Equal(t, []string{"work"}, result.ActiveProfiles)
 }
 
 func TestPull_NoActiveProfile_BaseOnly(t *testing.T) {
@@ -724,7 +747,102 @@ plugins:
 	assert.Contains(t, result.EffectiveDesired, "context7@claude-plugins-official")
 	assert.Contains(t, result.EffectiveDesired, "beads@beads-marketplace")
 	assert.NotContains(t, result.EffectiveDesired, "extra-tool@some-marketplace")
-	assert.Equal(t, "", result.ActiveProfile)
+	assert.Empty(t, result.ActiveProfiles)
+}
+
+func TestPull_AppliesExtendedProfileChain(t *testing.T) {
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+    - beads@beads-marketplace
+`
+	profile := profiles.Profile{
+		Extends: []string{"work"},
+		Plugins: profiles.ProfilePlugins{
+			Add: []string{"pager@some-marketplace"},
+		},
+	}
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "work-oncall", profile, true)
+
+	parent := "plugins:\n  add: [extra-tool@some-marketplace]\n  remove: [beads@beads-marketplace]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "work.yaml"), []byte(parent), 0644))
+
+	result, err := commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+
+	assert.Contains(t, result.EffectiveDesired, "context7@claude-plugins-official")
+	assert.Contains(t, result.EffectiveDesired, "extra-tool@some-marketplace")
+	assert.Contains(t, result.EffectiveDesired, "pager@some-marketplace")
+	assert.NotContains(t, result.EffectiveDesired, "beads@beads-marketplace")
+}
+
+func TestPull_MultipleActiveProfilesMergeInOrder(t *testing.T) {
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+    - beads@beads-marketplace
+`
+	backend := profiles.Profile{
+		Plugins: profiles.ProfilePlugins{
+			Add: []string{"db-tools@some-marketplace", "lint@some-marketplace"},
+		},
+	}
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "backend", backend, false)
+
+	reviewer := "plugins:\n  add: [audit@some-marketplace]\n  remove: [lint@some-marketplace, beads@beads-marketplace]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "security-reviewer.yaml"), []byte(reviewer), 0644))
+	require.NoError(t, profiles.WriteActiveProfiles(syncDir, []string{"backend", "security-reviewer"}))
+
+	result, err := commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"backend", "security-reviewer"}, result.ActiveProfiles)
+	assert.Contains(t, result.EffectiveDesired, "db-tools@some-marketplace")
+	assert.Contains(t, result.EffectiveDesired, "audit@some-marketplace")
+	assert.NotContains(t, result.EffectiveDesired, "lint@some-marketplace")
+	assert.NotContains(t, result.EffectiveDesired, "beads@beads-marketplace")
+
+	// lint is added by one active profile and removed by the other; beads
+	// only comes from the base config, so removing it is not a conflict.
+	require.Len(t, result.ProfileConflicts, 1)
+	assert.Equal(t, "lint@some-marketplace", result.ProfileConflicts[0].Item)
+	assert.Equal(t, "security-reviewer", result.ProfileConflicts[0].Winner)
+}
+
+func TestResolveWithProfile_MultipleActiveProfiles(t *testing.T) {
+	syncDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(syncDir, "profiles"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "a.yaml"), []byte("permissions:\n  add_allow: [\"Bash(ls)\"]\nsettings:\n  model: sonnet\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "b.yaml"), []byte("permissions:\n  add_allow: [\"Bash(git *)\"]\nsettings:\n  model: opus\n"), 0644))
+	require.NoError(t, profiles.WriteActiveProfiles(syncDir, []string{"a", "b"}))
+
+	rc := commands.ResolveWithProfile(config.Config{}, syncDir, "")
+	assert.Equal(t, []string{"Bash(ls)", "Bash(git *)"}, rc.Permissions.Allow)
+	assert.Equal(t, "opus", rc.Settings["model"])
+	require.Len(t, rc.ProfileConflicts, 1)
+	assert.Equal(t, "model", rc.ProfileConflicts[0].Item)
+
+	// An explicit profile name (e.g. from a project config) ignores the active list.
+	rc = commands.ResolveWithProfile(config.Config{}, syncDir, "a")
+	assert.Equal(t, "sonnet", rc.Settings["model"])
+	assert.Empty(t, rc.ProfileConflicts)
+}
+
+func TestPull_ProfileInheritanceCycleIsError(t *testing.T) {
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+`
+	profile := profiles.Profile{Extends: []string{"work"}}
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "personal", profile, true)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "work.yaml"), []byte("extends: [personal]\n"), 0644))
+
+	_, err := commands.PullDryRun(claudeDir, syncDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "personal -> work -> personal")
 }
 
 func TestPull_ProfileAddOverridesExcluded(t *testing.T) {
@@ -753,7 +871,7 @@ plugins:
 		"base upstream plugin should be in desired")
 	assert.Contains(t, result.EffectiveDesired, "excluded-tool@some-marketplace",
 		"profile plugins.add should override global excluded")
-	assert.Equal(t, "work", result.ActiveProfile)
+	assert.Equal(t, []string{"work"}, result.ActiveProfiles)
 }
 
 func TestPull_ProfileAddOverridesExcluded_Reconciliation(t *testing.T) {
@@ -826,6 +944,128 @@ permissions:
 	assert.Equal(t, []string{"Bash", "Network"}, mergedPerms.Deny)
 }
 
+// setupPermissionsEnv creates a sync dir whose config.yaml grants allowRules,
+// with settings.json pre-populated with a hand-added rule.
+func setupPermissionsEnv(t *testing.T, syncMode string, allowRules ...string) (claudeDir, syncDir string) {
+	t.Helper()
+
+	claudeDir = t.TempDir()
+	require.NoError(t, claudecode.Bootstrap(claudeDir))
+	require.NoError(t, claudecode.WriteSettings(claudeDir, map[string]json.RawMessage{
+		"permissions": json.RawMessage(`{"allow":["Read(./docs/**)"]}`),
+	}))
+
+	syncDir = filepath.Join(t.TempDir(), ".claude-sync")
+	require.NoError(t, os.MkdirAll(syncDir, 0755))
+	writePermissionsConfig(t, syncDir, allowRules...)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "user-preferences.yaml"), []byte("sync_mode: "+syncMode+"\n"), 0644))
+	require.NoError(t, exec.Command("git", "init", syncDir).Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.email", "test@test.com").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.name", "Test").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "init").Run())
+
+	return claudeDir, syncDir
+}
+
+func writePermissionsConfig(t *testing.T, syncDir string, allowRules ...string) {
+	t.Helper()
+	cfg := config.Config{Version: "1.0.0", Permissions: config.Permissions{Allow: allowRules}}
+	data, err := config.MarshalV2(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), data, 0644))
+}
+
+func readAllowRules(t *testing.T, claudeDir string) []string {
+	t.Helper()
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	var perms struct {
+		Allow []string `json:"allow"`
+	}
+	require.NoError(t, json.Unmarshal(settings["permissions"], &perms))
+	return perms.Allow
+}
+
+func TestPull_ExactModeRemovesDroppedPermissions(t *testing.T) {
+	claudeDir, syncDir := setupPermissionsEnv(t, "exact", "Bash(rm:*)", "Bash(git:*)")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Read(./docs/**)", "Bash(rm:*)", "Bash(git:*)"}, readAllowRules(t, claudeDir))
+
+	// Team drops the dangerous rule from config.
+	writePermissionsConfig(t, syncDir, "Bash(git:*)")
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash(rm:*)"}, result.PermissionsRemoved.Allow)
+	assert.Equal(t, []string{"Read(./docs/**)", "Bash(git:*)"}, readAllowRules(t, claudeDir),
+		"hand-added rule must survive; dropped managed rule must be removed")
+}
+
+func TestPull_ExactModeRemovesAllWhenConfigEmpty(t *testing.T) {
+	claudeDir, syncDir := setupPermissionsEnv(t, "exact", "Bash(rm:*)")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	writePermissionsConfig(t, syncDir)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash(rm:*)"}, result.PermissionsRemoved.Allow)
+	assert.False(t, result.PermissionsApplied)
+	assert.Equal(t, []string{"Read(./docs/**)"}, readAllowRules(t, claudeDir))
+}
+
+func TestPull_ExactModeKeepsPreexistingRuleDroppedFromConfig(t *testing.T) {
+	// The user had the rule before config ever declared it, so pull does not
+	// own it and must not remove it.
+	claudeDir, syncDir := setupPermissionsEnv(t, "exact", "Read(./docs/**)")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	writePermissionsConfig(t, syncDir)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.PermissionsRemoved.Allow)
+	assert.Equal(t, []string{"Read(./docs/**)"}, readAllowRules(t, claudeDir))
+}
+
+func TestPull_UnionModeKeepsDroppedPermissions(t *testing.T) {
+	claudeDir, syncDir := setupPermissionsEnv(t, "union", "Bash(rm:*)")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	writePermissionsConfig(t, syncDir, "Bash(git:*)")
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.PermissionsRemoved.Allow)
+	assert.Equal(t, []string{"Read(./docs/**)", "Bash(rm:*)", "Bash(git:*)"}, readAllowRules(t, claudeDir))
+
+	// The ledger still owns the dropped rule, so switching to exact removes it.
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "user-preferences.yaml"), []byte("sync_mode: exact\n"), 0644))
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash(rm:*)"}, result.PermissionsRemoved.Allow)
+}
+
+func TestPull_PermissionsDoNotTripSettingsProtection(t *testing.T) {
+	claudeDir, syncDir := setupPermissionsEnv(t, "exact", "Bash(git:*)")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.False(t, result.SettingsSkipped, "pull's own permission write must not count as a local edit")
+}
+
 func TestPullClaudeMDAssembly(t *testing.T) {
 	claudeDir := t.TempDir()
 	require.NoError(t, claudecode.Bootstrap(claudeDir))
@@ -866,6 +1106,63 @@ claude_md:
 	assert.Contains(t, string(data), "## Rules")
 }
 
+func TestPullClaudeMDTemplates(t *testing.T) {
+	claudeDir := t.TempDir()
+	require.NoError(t, claudecode.Bootstrap(claudeDir))
+
+	syncDir := filepath.Join(t.TempDir(), ".claude-sync")
+	require.NoError(t, os.MkdirAll(filepath.Join(syncDir, "profiles"), 0755))
+
+	claudeMdDir := filepath.Join(syncDir, "claude-md")
+	require.NoError(t, os.MkdirAll(claudeMdDir, 0755))
+	require.NoError(t, claudemd.WriteFragment(claudeMdDir, "tools",
+		"## Tools\n{{if eq .OS \"darwin\"}}Use `brew`.{{else}}Use `apt`.{{end}}"))
+	require.NoError(t, claudemd.WriteFragment(claudeMdDir, "team",
+		"## Team\n{{.Env.TEAM}}{{.Env.SECRET_TOKEN}} on {{.Profile}}, editor {{.Vars.editor}}, shell {{.Vars.shell}}, tz {{.Vars.tz}}"))
+	require.NoError(t, claudemd.WriteFragment(claudeMdDir, "broken", "## Broken\n{{if .OS}}unterminated"))
+
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream: []
+claude_md:
+  include:
+    - tools
+    - team
+    - broken
+vars:
+  editor: vim
+  shell: bash
+  tz: UTC
+`
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(configYAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "work.yaml"), []byte("vars:\n  editor: nvim\n  shell: zsh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "user-preferences.yaml"), []byte("vars:\n  shell: fish\ntemplate_env: [TEAM]\n"), 0644))
+	require.NoError(t, profiles.WriteActiveProfile(syncDir, "work"))
+	t.Setenv("TEAM", "platform")
+	t.Setenv("SECRET_TOKEN", "s3cret") // not in template_env, so not exposed
+
+	dry, err := commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+	require.Len(t, dry.ClaudeMDTemplateErrors, 1)
+	assert.Contains(t, dry.ClaudeMDTemplateErrors[0], "broken: ")
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.True(t, result.ClaudeMDAssembled)
+	assert.Len(t, result.ClaudeMDTemplateErrors, 1)
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "CLAUDE.md"))
+	require.NoError(t, err)
+	if runtime.GOOS == "darwin" {
+		assert.Contains(t, string(data), "Use `brew`.")
+	} else {
+		assert.Contains(t, string(data), "Use `apt`.")
+	}
+	assert.Contains(t, string(data), "platform on work, editor nvim, shell fish, tz UTC")
+	assert.NotContains(t, string(data), "s3cret")
+	assert.Contains(t, string(data), "{{if .OS}}unterminated", "broken fragment is included verbatim")
+}
+
 func TestPullMCPApply(t *testing.T) {
 	claudeDir := t.TempDir()
 	require.NoError(t, claudecode.Bootstrap(claudeDir))
@@ -902,6 +1199,148 @@ mcp:
 	assert.Contains(t, string(data), "memory")
 }
 
+// setupMCPEnv creates a git-backed sync dir with the given config.yaml.
+func setupMCPEnv(t *testing.T, configYAML string) (claudeDir, syncDir string) {
+	t.Helper()
+
+	claudeDir = t.TempDir()
+	require.NoError(t, claudecode.Bootstrap(claudeDir))
+
+	syncDir = filepath.Join(t.TempDir(), ".claude-sync")
+	require.NoError(t, os.MkdirAll(syncDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(configYAML), 0644))
+	require.NoError(t, exec.Command("git", "init", syncDir).Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.email", "test@test.com").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.name", "Test").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "init").Run())
+
+	return claudeDir, syncDir
+}
+
+const twoMCPServersYAML = `version: "1.0.0"
+mcp:
+  memory:
+    command: npx
+  legacy:
+    command: old-server
+`
+
+const oneMCPServerYAML = `version: "1.0.0"
+mcp:
+  memory:
+    command: npx
+`
+
+func TestPullMCP_RemovesRetiredServer(t *testing.T) {
+	claudeDir, syncDir := setupMCPEnv(t, twoMCPServersYAML)
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	// The user adds a server of their own.
+	servers, err := claudecode.ReadMCPConfig(claudeDir)
+	require.NoError(t, err)
+	servers["mine"] = json.RawMessage(`{"command":"my-server"}`)
+	require.NoError(t, claudecode.WriteMCPConfig(claudeDir, servers))
+
+	// The team retires "legacy".
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(oneMCPServerYAML), 0644))
+
+	dry, err := commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+	mcpPath := filepath.Join(claudeDir, ".mcp.json")
+	assert.Equal(t, map[string][]string{mcpPath: {"legacy"}}, dry.MCPToRemove)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{mcpPath: {"legacy"}}, result.MCPRemoved)
+	assert.Nil(t, result.MCPToRemove, "the dry-run preview doesn't carry over into the applied result")
+
+	servers, err = claudecode.ReadMCPConfig(claudeDir)
+	require.NoError(t, err)
+	assert.Contains(t, servers, "memory")
+	assert.Contains(t, servers, "mine", "user-added server must survive")
+	assert.NotContains(t, servers, "legacy")
+}
+
+func TestPullMCP_ProfileRemoveDropsServer(t *testing.T) {
+	claudeDir, syncDir := setupMCPEnv(t, twoMCPServersYAML)
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	data, err := profiles.MarshalProfile(profiles.Profile{MCP: profiles.ProfileMCP{Remove: []string{"legacy"}}})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(syncDir, "profiles"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "profiles", "work.yaml"), data, 0644))
+	require.NoError(t, profiles.WriteActiveProfile(syncDir, "work"))
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, result.MCPRemoved[filepath.Join(claudeDir, ".mcp.json")])
+}
+
+func TestPullMCP_LocallyEditedServerProtected(t *testing.T) {
+	claudeDir, syncDir := setupMCPEnv(t, twoMCPServersYAML)
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	// The user tweaks both managed servers.
+	servers, err := claudecode.ReadMCPConfig(claudeDir)
+	require.NoError(t, err)
+	servers["memory"] = json.RawMessage(`{"command":"npx","args":["--verbose"]}`)
+	servers["legacy"] = json.RawMessage(`{"command":"old-server","args":["--patched"]}`)
+	require.NoError(t, claudecode.WriteMCPConfig(claudeDir, servers))
+
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(oneMCPServerYAML), 0644))
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	mcpPath := filepath.Join(claudeDir, ".mcp.json")
+	assert.Empty(t, result.MCPRemoved)
+	assert.Equal(t, []string{"legacy", "memory"}, result.MCPSkipped[mcpPath])
+
+	servers, err = claudecode.ReadMCPConfig(claudeDir)
+	require.NoError(t, err)
+	assert.Contains(t, string(servers["memory"]), "--verbose")
+	assert.Contains(t, string(servers["legacy"]), "--patched")
+
+	// The edited retired server now belongs to the user: no further reports.
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"memory"}, result.MCPSkipped[mcpPath])
+}
+
+func TestPullMCP_RemovesRetiredProjectServer(t *testing.T) {
+	projectDir := t.TempDir()
+	configYAML := `version: "1.0.0"
+mcp:
+  db:
+    command: db-server
+mcp_metadata:
+  db:
+    source_project: ` + projectDir + `
+`
+	claudeDir, syncDir := setupMCPEnv(t, configYAML)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, result.MCPProjectApplied[projectDir])
+
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: \"1.0.0\"\n"), 0644))
+
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	mcpPath := filepath.Join(projectDir, ".mcp.json")
+	assert.Equal(t, []string{"db"}, result.MCPRemoved[mcpPath])
+
+	servers, err := claudecode.ReadMCPConfigFile(mcpPath)
+	require.NoError(t, err)
+	assert.Empty(t, servers)
+}
+
 func TestPullKeybindings(t *testing.T) {
 	claudeDir := t.TempDir()
 	require.NoError(t, claudecode.Bootstrap(claudeDir))
@@ -997,6 +1436,62 @@ keybindings:
 	assert.NotEmpty(t, pending.MCP)
 }
 
+func TestPullAutoModeSkipsRejectedChanges(t *testing.T) {
+	claudeDir := t.TempDir()
+	require.NoError(t, claudecode.Bootstrap(claudeDir))
+
+	syncDir := filepath.Join(t.TempDir(), ".claude-sync")
+	require.NoError(t, os.MkdirAll(syncDir, 0755))
+	writeConfig := func(hook string) {
+		configYAML := `version: "1.0.0"
+plugins:
+  upstream: []
+hooks:
+  PreCompact: "` + hook + `"
+mcp:
+  memory:
+    command: npx
+`
+		require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(configYAML), 0644))
+	}
+	writeConfig("bd prime")
+	require.NoError(t, exec.Command("git", "init", syncDir).Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.email", "test@test.com").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "config", "user.name", "Test").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "init").Run())
+
+	pull := func() approval.PendingChanges {
+		t.Helper()
+		_, err := commands.PullWithOptions(commands.PullOptions{
+			ClaudeDir: claudeDir,
+			SyncDir:   syncDir,
+			Quiet:     true,
+			Auto:      true,
+		})
+		require.NoError(t, err)
+		pending, err := approval.ReadPending(syncDir)
+		require.NoError(t, err)
+		return pending
+	}
+
+	pending := pull()
+	require.Contains(t, pending.Hooks, "PreCompact")
+	_, err := commands.ApproveItems(claudeDir, syncDir, commands.ApproveOptions{Reject: []string{"hooks.PreCompact"}})
+	require.NoError(t, err)
+
+	// The rejected hook isn't proposed again while it is unchanged.
+	pending = pull()
+	assert.NotContains(t, pending.Hooks, "PreCompact")
+	assert.Contains(t, pending.MCP, "memory")
+
+	// Once it changes upstream, it is.
+	writeConfig("bd prime --quiet")
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-am", "change hook").Run())
+	pending = pull()
+	assert.Contains(t, pending.Hooks, "PreCompact")
+}
+
 func TestAppendUniqueStrings(t *testing.T) {
 	// Test via the exported PullWithOptions to ensure the helper works.
 	// We test the behavior indirectly through permissions merge.
@@ -1441,8 +1936,8 @@ func TestRestoreSkipsLocallyModifiedCommand(t *testing.T) {
 	// First pull — restores commands and creates hash file.
 	result, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 2, result.CommandsRestored)
-	assert.Equal(t, 0, result.CommandsSkipped)
+	assert.Len(t, result.CommandsRestored, 2)
+	assert.Empty(t, result.CommandsSkipped)
 
 	// Modify a command locally (simulates agent editing a command).
 	localCmd := filepath.Join(claudeDir, "commands", "review-pr.md")
@@ -1451,8 +1946,8 @@ func TestRestoreSkipsLocallyModifiedCommand(t *testing.T) {
 	// Second pull — should skip the modified command, overwrite the unmodified one.
 	result, err = commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 1, result.CommandsRestored, "unmodified command should be restored")
-	assert.Equal(t, 1, result.CommandsSkipped, "locally modified command should be skipped")
+	assert.Equal(t, []string{"deploy.md"}, result.CommandsRestored, "unmodified command should be restored")
+	assert.Equal(t, []string{"review-pr.md"}, result.CommandsSkipped, "locally modified command should be skipped")
 
 	// Verify the local modification was preserved.
 	data, err := os.ReadFile(localCmd)
@@ -1466,8 +1961,8 @@ func TestRestoreSkipsLocallyModifiedSkill(t *testing.T) {
 	// First pull — restores skills and creates hash file.
 	result, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 1, result.SkillsRestored)
-	assert.Equal(t, 0, result.SkillsSkipped)
+	assert.Len(t, result.SkillsRestored, 1)
+	assert.Empty(t, result.SkillsSkipped)
 
 	// Modify skill SKILL.md locally.
 	localSkill := filepath.Join(claudeDir, "skills", "brainstorming", "SKILL.md")
@@ -1476,8 +1971,8 @@ func TestRestoreSkipsLocallyModifiedSkill(t *testing.T) {
 	// Second pull — should skip the modified skill.
 	result, err = commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 0, result.SkillsRestored, "modified skill should not be restored")
-	assert.Equal(t, 1, result.SkillsSkipped, "locally modified skill should be skipped")
+	assert.Empty(t, result.SkillsRestored, "modified skill should not be restored")
+	assert.Len(t, result.SkillsSkipped, 1, "locally modified skill should be skipped")
 
 	// Verify local modification preserved.
 	data, err := os.ReadFile(localSkill)
@@ -1485,6 +1980,38 @@ func TestRestoreSkipsLocallyModifiedSkill(t *testing.T) {
 	assert.Contains(t, string(data), "Locally modified skill")
 }
 
+func TestRestoreAgents(t *testing.T) {
+	claudeDir, syncDir := setupCommandSkillEnv(t)
+
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	data, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cfgPath, append(data, "agents:\n  - agent:global:code-reviewer\n"...), 0644))
+	agentsDir := filepath.Join(syncDir, "agents")
+	require.NoError(t, os.MkdirAll(agentsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "code-reviewer.md"), []byte("---\nname: code-reviewer\n---\nOriginal"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "unlisted.md"), []byte("Not in config"), 0644))
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "add agents").Run())
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Len(t, result.AgentsRestored, 1)
+	localAgent := filepath.Join(claudeDir, "agents", "code-reviewer.md")
+	assert.FileExists(t, localAgent)
+	assert.NoFileExists(t, filepath.Join(claudeDir, "agents", "unlisted.md"))
+
+	// A locally edited agent is left alone.
+	require.NoError(t, os.WriteFile(localAgent, []byte("Locally modified agent"), 0644))
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.AgentsRestored)
+	assert.Len(t, result.AgentsSkipped, 1)
+	data, err = os.ReadFile(localAgent)
+	require.NoError(t, err)
+	assert.Equal(t, "Locally modified agent", string(data))
+}
+
 func TestRestoreOverwritesUnmodifiedCommand(t *testing.T) {
 	claudeDir, syncDir := setupCommandSkillEnv(t)
 
@@ -1501,8 +2028,8 @@ func TestRestoreOverwritesUnmodifiedCommand(t *testing.T) {
 	// Second pull — local was not modified, so remote update should overwrite.
 	result, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 2, result.CommandsRestored, "both unmodified commands should be restored")
-	assert.Equal(t, 0, result.CommandsSkipped)
+	assert.Len(t, result.CommandsRestored, 2, "both unmodified commands should be restored")
+	assert.Empty(t, result.CommandsSkipped)
 
 	// Verify remote content arrived.
 	data, err := os.ReadFile(filepath.Join(claudeDir, "commands", "review-pr.md"))
@@ -1516,10 +2043,10 @@ func TestRestoreHandlesNoHashFile(t *testing.T) {
 	// Pull with no prior hash file — should restore all + create hash file.
 	result, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 2, result.CommandsRestored)
-	assert.Equal(t, 0, result.CommandsSkipped)
-	assert.Equal(t, 1, result.SkillsRestored)
-	assert.Equal(t, 0, result.SkillsSkipped)
+	assert.Len(t, result.CommandsRestored, 2)
+	assert.Empty(t, result.CommandsSkipped)
+	assert.Len(t, result.SkillsRestored, 1)
+	assert.Empty(t, result.SkillsSkipped)
 
 	// Verify hash files were created.
 	cmdHashFile := filepath.Join(claudeDir, "commands", ".content_hashes.json")
@@ -1570,7 +2097,7 @@ settings:
 	return claudeDir, syncDir
 }
 
-func TestPull_SkipsLocallyModifiedSettings(t *testing.T) {
+func TestPull_KeepsLocallyModifiedSettings(t *testing.T) {
 	claudeDir, syncDir := setupSettingsEnv(t)
 
 	// First pull — should apply settings and record hash.
@@ -1583,11 +2110,13 @@ func TestPull_SkipsLocallyModifiedSettings(t *testing.T) {
 	settingsPath := filepath.Join(claudeDir, "settings.json")
 	os.WriteFile(settingsPath, []byte(`{"model": "my-custom-model"}`), 0644)
 
-	// Second pull — should skip settings due to local modification.
+	// Second pull — nothing changed upstream, so the merge keeps the edit.
 	result2, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.True(t, result2.SettingsSkipped)
+	assert.False(t, result2.SettingsSkipped)
+	assert.True(t, result2.SettingsMerged)
 	assert.Empty(t, result2.SettingsApplied)
+	assert.Empty(t, result2.SettingsConflicts)
 
 	// Verify local modification was preserved.
 	data, err := os.ReadFile(settingsPath)
@@ -1595,6 +2124,60 @@ func TestPull_SkipsLocallyModifiedSettings(t *testing.T) {
 	assert.Contains(t, string(data), "my-custom-model")
 }
 
+func TestPull_MergesLocallyModifiedSettings(t *testing.T) {
+	claudeDir, syncDir := setupSettingsEnv(t)
+	writeConfig := func(settings string) {
+		t.Helper()
+		configYAML := "version: \"1.0.0\"\nplugins:\n  upstream: []\nsettings:\n" + settings
+		require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte(configYAML), 0644))
+		require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-qam", "update settings").Run())
+	}
+	writeConfig("  model: opus\n  theme: dark\n  cleanupPeriodDays: 30\n")
+
+	_, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	// Locally: change theme and model, add a key of our own.
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	settings["theme"] = json.RawMessage(`"light"`)
+	settings["model"] = json.RawMessage(`"haiku"`)
+	settings["verbose"] = json.RawMessage(`true`)
+	require.NoError(t, claudecode.WriteSettings(claudeDir, settings))
+
+	// Upstream: change model (conflict) and cleanupPeriodDays (no conflict).
+	writeConfig("  model: sonnet\n  theme: dark\n  cleanupPeriodDays: 60\n")
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.True(t, result.SettingsMerged)
+	assert.False(t, result.SettingsSkipped)
+	assert.Equal(t, []string{"cleanupPeriodDays"}, result.SettingsApplied)
+	assert.Equal(t, []string{"settings.model"}, result.SettingsConflicts)
+
+	settings, err = claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	assert.JSONEq(t, `60`, string(settings["cleanupPeriodDays"]))
+	assert.JSONEq(t, `"light"`, string(settings["theme"]))
+	assert.JSONEq(t, `"haiku"`, string(settings["model"]), "conflicting key keeps the local value")
+	assert.JSONEq(t, `true`, string(settings["verbose"]))
+
+	conflicts, err := commands.ListPendingConflicts(syncDir)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "settings.json", conflicts[0].Source)
+	assert.Equal(t, "settings.model", conflicts[0].Key)
+	assert.JSONEq(t, `"haiku"`, string(conflicts[0].LocalValue))
+	assert.JSONEq(t, `"sonnet"`, string(conflicts[0].RemoteValue))
+
+	// Pulling again doesn't record the same conflict twice.
+	_, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	conflicts, err = commands.ListPendingConflicts(syncDir)
+	require.NoError(t, err)
+	assert.Len(t, conflicts, 1)
+}
+
 func TestPull_SkipsLocallyModifiedClaudeMD(t *testing.T) {
 	claudeDir := t.TempDir()
 	require.NoError(t, claudecode.Bootstrap(claudeDir))
@@ -1876,8 +2459,8 @@ func TestRestoreSkipsLocallyModifiedSkillCompanion(t *testing.T) {
 	// First pull: restores skills (including companion files) and creates hash file.
 	result, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 1, result.SkillsRestored)
-	assert.Equal(t, 0, result.SkillsSkipped)
+	assert.Len(t, result.SkillsRestored, 1)
+	assert.Empty(t, result.SkillsSkipped)
 
 	// Verify companion file was copied.
 	localReadme := filepath.Join(claudeDir, "skills", "brainstorming", "README.md")
@@ -1890,8 +2473,8 @@ func TestRestoreSkipsLocallyModifiedSkillCompanion(t *testing.T) {
 	// Second pull: should skip because the directory hash changed.
 	result, err = commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 0, result.SkillsRestored, "should not overwrite locally modified companion")
-	assert.Equal(t, 1, result.SkillsSkipped, "should skip skill with modified companion")
+	assert.Empty(t, result.SkillsRestored, "should not overwrite locally modified companion")
+	assert.Len(t, result.SkillsSkipped, 1, "should skip skill with modified companion")
 
 	// Local modification should still be present.
 	data, err := os.ReadFile(localReadme)
@@ -1905,7 +2488,7 @@ func TestRestoreUpdatesSkillCompanionFiles(t *testing.T) {
 	// First pull: restores skills.
 	result, err := commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 1, result.SkillsRestored)
+	assert.Len(t, result.SkillsRestored, 1)
 
 	// Update only the companion file in the sync dir (simulates remote change).
 	syncReadme := filepath.Join(syncDir, "skills", "brainstorming", "README.md")
@@ -1918,8 +2501,8 @@ func TestRestoreUpdatesSkillCompanionFiles(t *testing.T) {
 	// Second pull: should detect source dir changed and copy the update.
 	result, err = commands.Pull(claudeDir, syncDir, true)
 	require.NoError(t, err)
-	assert.Equal(t, 1, result.SkillsRestored, "should copy updated companion from sync dir")
-	assert.Equal(t, 0, result.SkillsSkipped)
+	assert.Len(t, result.SkillsRestored, 1, "should copy updated companion from sync dir")
+	assert.Empty(t, result.SkillsSkipped)
 
 	// Verify the updated companion file was copied.
 	localReadme := filepath.Join(claudeDir, "skills", "brainstorming", "README.md")
@@ -1927,3 +2510,303 @@ func TestRestoreUpdatesSkillCompanionFiles(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, string(data), "Updated remotely")
 }
+
+func TestPull_WhenSelectorsFilterEntries(t *testing.T) {
+	t.Setenv("CLAUDE_SYNC_TEST_VPN", "")
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+    - name: beads@beads-marketplace
+      when: {env: CLAUDE_SYNC_TEST_VPN}
+`
+	profile := profiles.Profile{
+		Plugins: profiles.ProfilePlugins{Add: []string{"mac-tools@some-marketplace"}},
+		When: map[string]config.Selector{
+			config.WhenKey(config.WhenPlugins, "mac-tools@some-marketplace"): {OS: config.StringList{"not-" + runtime.GOOS}},
+		},
+	}
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "work", profile, true)
+
+	result, err := commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.Contains(t, result.EffectiveDesired, "context7@claude-plugins-official")
+	assert.NotContains(t, result.EffectiveDesired, "beads@beads-marketplace")
+	assert.NotContains(t, result.EffectiveDesired, "mac-tools@some-marketplace")
+	require.Len(t, result.FilteredByWhen, 2)
+	assert.Equal(t, "beads@beads-marketplace", result.FilteredByWhen[0].Name)
+	assert.Equal(t, "mac-tools@some-marketplace", result.FilteredByWhen[1].Name)
+
+	t.Setenv("CLAUDE_SYNC_TEST_VPN", "1")
+	result, err = commands.PullDryRun(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.Contains(t, result.EffectiveDesired, "beads@beads-marketplace")
+	require.Len(t, result.FilteredByWhen, 1)
+}
+
+func TestPull_SnapshotsLocalFilesBeforeApplying(t *testing.T) {
+	claudeDir, syncDir := setupPullEnvWithSettingsAndHooks(t)
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+	require.NoFileExists(t, settingsPath)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Snapshot)
+	assert.FileExists(t, settingsPath)
+
+	m, statuses, err := commands.SnapshotShow(syncDir, result.Snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, "pull", m.Reason)
+	var settingsStatus string
+	for _, s := range statuses {
+		if s.Path == settingsPath {
+			settingsStatus = s.Status
+		}
+	}
+	assert.Equal(t, "created", settingsStatus)
+
+	// Restoring returns ~/.claude to its pre-pull state.
+	_, err = commands.SnapshotRestore(syncDir, result.Snapshot)
+	require.NoError(t, err)
+	assert.NoFileExists(t, settingsPath)
+}
+
+func TestPull_SubscriptionFallsBackToLastGoodClone(t *testing.T) {
+	teamDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(teamDir, "config.yaml"), []byte(`version: "1.0.0"
+settings:
+  theme: team-dark
+`), 0644))
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test"},
+		{"add", "."},
+		{"commit", "-m", "init"},
+	} {
+		require.NoError(t, exec.Command("git", append([]string{"-C", teamDir}, args...)...).Run())
+	}
+
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+subscriptions:
+  team:
+    url: ` + teamDir + `
+    categories:
+      settings: all
+  gone:
+    url: ` + filepath.Join(t.TempDir(), "missing") + `
+`
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "work", profiles.Profile{}, false)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	require.Len(t, result.SubscriptionWarnings, 1)
+	assert.Contains(t, result.SubscriptionWarnings[0], `subscription "gone" skipped`)
+
+	// The team repo becomes unreachable: its last good clone is still merged.
+	require.NoError(t, os.RemoveAll(teamDir))
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	require.Len(t, result.SubscriptionWarnings, 2)
+	assert.Contains(t, result.SubscriptionWarnings[1], `subscription "team"`)
+	assert.Contains(t, result.SubscriptionWarnings[1], "using the copy fetched <1m ago")
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "team-dark")
+}
+
+func TestPull_SignedCommitVerification(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	claudeDir, syncDir := setupPullEnvWithSettingsAndHooks(t)
+	key := filepath.Join(t.TempDir(), "alice")
+	require.NoError(t, exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).Run())
+	pub, err := os.ReadFile(key + ".pub")
+	require.NoError(t, err)
+
+	gitCommit := func(signed bool) {
+		t.Helper()
+		args := []string{"-C", syncDir}
+		if signed {
+			args = append(args, "-c", "gpg.format=ssh", "-c", "user.signingkey="+key)
+		}
+		args = append(args, "commit", "-q", "-m", "change")
+		if signed {
+			args = append(args, "-S")
+		}
+		require.NoError(t, exec.Command("git", "-C", syncDir, "add", "-A").Run())
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "allowed_signers"), []byte("alice@example.com "+string(pub)), 0644))
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	signedCfg := string(cfgData) + "signing:\n  allowed_signers: allowed_signers\n"
+	require.NoError(t, os.WriteFile(cfgPath, []byte(signedCfg), 0644))
+	gitCommit(false)
+
+	// A project that projects the config's hooks.
+	projectDir := t.TempDir()
+	require.NoError(t, project.WriteProjectConfig(projectDir, project.ProjectConfig{Version: "1.0.0", ProjectedKeys: []string{"hooks"}}))
+	projectSettings := filepath.Join(projectDir, ".claude", "settings.local.json")
+	pull := func() *commands.PullResult {
+		t.Helper()
+		result, err := commands.PullWithOptions(commands.PullOptions{ClaudeDir: claudeDir, SyncDir: syncDir, Quiet: true, ProjectDir: projectDir})
+		require.NoError(t, err)
+		return result
+	}
+
+	// Unsigned HEAD: settings apply, hooks don't, in the project either.
+	result := pull()
+	require.Len(t, result.UntrustedCommits, 1)
+	assert.Equal(t, "config repo", result.UntrustedCommits[0].Source)
+	assert.Equal(t, signing.Unsigned, result.UntrustedCommits[0].Status)
+	assert.Equal(t, []string{projectDir}, result.UntrustedCommits[0].Projects)
+	assert.Contains(t, result.SettingsApplied, "model")
+	assert.Empty(t, result.HooksApplied)
+	data, err := os.ReadFile(projectSettings)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hooks")
+
+	// Signed by an allowed key: everything applies.
+	gitCommit(true)
+	result = pull()
+	assert.Empty(t, result.UntrustedCommits)
+	assert.NotEmpty(t, result.HooksApplied)
+	data, err = os.ReadFile(projectSettings)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "hooks")
+
+	// An unsigned commit can't turn verification off.
+	require.NoError(t, os.WriteFile(cfgPath, cfgData, 0644))
+	gitCommit(false)
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	require.Len(t, result.UntrustedCommits, 1)
+	assert.Empty(t, result.HooksApplied)
+
+	// A signed one can.
+	gitCommit(true)
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.UntrustedCommits)
+	assert.NoFileExists(t, filepath.Join(syncDir, ".trusted-signers"))
+}
+
+func TestPull_SignedCommitVerification_AfterLockCommit(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	claudeDir, syncDir := setupPullEnvWithSettingsAndHooks(t)
+	key := filepath.Join(t.TempDir(), "alice")
+	require.NoError(t, exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).Run())
+	pub, err := os.ReadFile(key + ".pub")
+	require.NoError(t, err)
+	git := func(dir string, args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	signedCommit := func(dir string) {
+		t.Helper()
+		git(dir, "add", "-A")
+		git(dir, "-c", "gpg.format=ssh", "-c", "user.signingkey="+key, "commit", "-q", "-S", "-m", "change")
+	}
+
+	// A signed team subscription, locked on first pull.
+	teamDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(teamDir, "config.yaml"), []byte("version: \"1.0.0\"\nsettings:\n  theme: team-dark\n"), 0644))
+	git(teamDir, "init", "-q")
+	git(teamDir, "config", "user.email", "test@test.com")
+	git(teamDir, "config", "user.name", "Test")
+	signedCommit(teamDir)
+
+	// The config repo's remote holds a signed commit.
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	git(syncDir, "init", "-q", "--bare", remote)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "allowed_signers"), []byte("alice@example.com "+string(pub)), 0644))
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	cfgData = append(cfgData, "signing:\n  allowed_signers: allowed_signers\nsubscriptions:\n  team:\n    url: "+teamDir+"\n    lock: true\n    categories:\n      settings: all\n"...)
+	require.NoError(t, os.WriteFile(cfgPath, cfgData, 0644))
+	signedCommit(syncDir)
+	git(syncDir, "remote", "add", "origin", remote)
+	git(syncDir, "push", "-q", "-u", "origin", "HEAD")
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.UntrustedCommits)
+	head, err := exec.Command("git", "-C", syncDir, "log", "-1", "--format=%s").Output()
+	require.NoError(t, err)
+	require.Contains(t, string(head), "Lock subscriptions: team")
+
+	// The unsigned lock commit pull made itself doesn't make the repo untrusted.
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.UntrustedCommits)
+	assert.NotEmpty(t, result.HooksApplied)
+}
+
+func TestPull_RecordsAuditEntry(t *testing.T) {
+	claudeDir, syncDir := setupPullEnvWithSettingsAndHooks(t)
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	entries, err := audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, "pull", e.Command)
+	head, err := git.RevParse(syncDir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, e.Commit)
+	assert.Equal(t, result.Snapshot, e.Snapshot)
+	assert.Contains(t, e.Items, audit.Item{Action: "applied", Category: "settings", Name: "model"})
+	for _, hook := range result.HooksApplied {
+		assert.Contains(t, e.Items, audit.Item{Action: "applied", Category: "hooks", Name: hook})
+	}
+
+	// A pull that fails is recorded too, with its error.
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("version: [\n"), 0644))
+	_, err = commands.Pull(claudeDir, syncDir, true)
+	require.Error(t, err)
+	entries, err = audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "pull", entries[1].Command)
+	assert.NotEmpty(t, entries[1].Error)
+}
+
+func TestPull_AuditRecordsRulesAndFilesByName(t *testing.T) {
+	claudeDir, syncDir := setupCommandSkillEnv(t)
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	cfgData = append(cfgData, []byte("permissions:\n  allow:\n    - Bash(ls)\n    - Bash(git status)\n  deny:\n    - Bash(rm -rf /)\n")...)
+	require.NoError(t, os.WriteFile(cfgPath, cfgData, 0644))
+	// Bash(ls) is already allowed locally, so pull doesn't add it.
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"permissions":{"allow":["Bash(ls)"]}}`), 0644))
+
+	_, err = commands.Pull(claudeDir, syncDir, false)
+	require.NoError(t, err)
+
+	entries, err := audit.Read(syncDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	items := entries[0].Items
+	assert.Contains(t, items, audit.Item{Action: "applied", Category: "permissions", Name: "Bash(git status)", Reason: "allow"})
+	assert.Contains(t, items, audit.Item{Action: "applied", Category: "permissions", Name: "Bash(rm -rf /)", Reason: "deny"})
+	assert.NotContains(t, items, audit.Item{Action: "applied", Category: "permissions", Name: "Bash(ls)", Reason: "allow"})
+	assert.Contains(t, items, audit.Item{Action: "applied", Category: "commands", Name: "review-pr.md"})
+	assert.Contains(t, items, audit.Item{Action: "applied", Category: "commands", Name: "deploy.md"})
+	assert.Contains(t, items, audit.Item{Action: "applied", Category: "skills", Name: "brainstorming"})
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/ruminaider/claude-sync/internal/audit"
 	"github.com/ruminaider/claude-sync/internal/claudecode"
 	"github.com/ruminaider/claude-sync/internal/claudemd"
 	"github.com/ruminaider/claude-sync/internal/config"
@@ -19,30 +21,41 @@ import (
 )
 
 type PushScanResult struct {
-	AddedPlugins       []string
-	RemovedPlugins     []string
-	ChangedSettings    map[string]csync.SettingChange
-	ChangedPermissions bool
-	ChangedClaudeMD    *claudemd.ReconcileResult
-	ChangedMemory      *memory.ReconcileResult
-	ChangedMCP         bool
-	MCPSecrets         []DetectedSecret // secrets detected in MCP configs (will be auto-replaced)
-	ChangedKeybindings bool
-	ChangedCommands    bool
-	ChangedSkills      bool
-	OrphanedCommands   []string // command files in sync dir but not in config
-	OrphanedSkills     []string // skill dirs in sync dir but not in config
-	DirtyWorkingTree   bool     // sync repo has uncommitted changes (e.g. from config update)
+	AddedPlugins         []string
+	RemovedPlugins       []string
+	ChangedSettings      map[string]csync.SettingChange
+	ChangedPermissions   bool
+	ChangedClaudeMD      *claudemd.ReconcileResult
+	ChangedMemory        *memory.ReconcileResult
+	ChangedProjectMemory []string // identities of synced projects whose local memory changed
+	ChangedMCP           bool
+	MCPSecrets           []DetectedSecret // secrets detected in MCP configs (will be auto-replaced)
+	ChangedKeybindings   bool
+	ChangedCommands      bool
+	ChangedSkills        bool
+	ChangedAgents        bool
+	ChangedOutputStyles  bool
+	ChangedScripts       bool     // a script settings or hooks run differs from its sync repo copy
+	OrphanedCommands     []string // command files in sync dir but not in config
+	OrphanedSkills       []string // skill dirs in sync dir but not in config
+	OrphanedAgents       []string // agent files in sync dir but not in config
+	OrphanedOutputStyles []string // output style files in sync dir but not in config
+	DirtyWorkingTree     bool     // sync repo has uncommitted changes (e.g. from config update)
+	// TemplateEdited lists templated CLAUDE.md fragments whose rendered
+	// section was edited locally. Push leaves them alone, so they aren't a
+	// change; see TemplateEditedWarning.
+	TemplateEdited []string
 }
 
 func (r *PushScanResult) HasChanges() bool {
 	return len(r.AddedPlugins) > 0 || len(r.RemovedPlugins) > 0 ||
 		len(r.ChangedSettings) > 0 ||
 		r.ChangedPermissions || r.ChangedClaudeMD != nil ||
-		r.ChangedMemory != nil ||
+		r.ChangedMemory != nil || len(r.ChangedProjectMemory) > 0 ||
 		r.ChangedMCP || r.ChangedKeybindings ||
-		r.ChangedCommands || r.ChangedSkills ||
-		len(r.OrphanedCommands) > 0 || len(r.OrphanedSkills) > 0 ||
+		r.ChangedCommands || r.ChangedSkills || r.ChangedAgents || r.ChangedOutputStyles || r.ChangedScripts ||
+		len(r.OrphanedCommands) > 0 || len(r.OrphanedSkills) > 0 || len(r.OrphanedAgents) > 0 ||
+		len(r.OrphanedOutputStyles) > 0 ||
 		r.DirtyWorkingTree
 }
 
@@ -65,7 +78,11 @@ func PushScan(claudeDir, syncDir string) (*PushScanResult, error) {
 		return nil, err
 	}
 
-	diff := csync.ComputePluginDiff(cfg.AllPluginKeys(), plugins.PluginKeys())
+	// Compare against what pull applies on this machine, so entries whose
+	// when: selector excludes it aren't reported as removed.
+	machineCfg, _ := cfg.ForMachine(config.CurrentMachine())
+
+	diff := csync.ComputePluginDiff(machineCfg.AllPluginKeys(), plugins.PluginKeys())
 
 	// Build filter sets.
 	excludedSet := make(map[string]bool, len(cfg.Excluded))
@@ -133,8 +150,8 @@ func PushScan(claudeDir, syncDir string) (*PushScanResult, error) {
 		if permRaw, ok := settingsRaw["permissions"]; ok {
 			json.Unmarshal(permRaw, &currentPerms)
 		}
-		if !stringSlicesEqual(currentPerms.Allow, cfg.Permissions.Allow) ||
-			!stringSlicesEqual(currentPerms.Deny, cfg.Permissions.Deny) {
+		if !stringSlicesEqual(currentPerms.Allow, machineCfg.Permissions.Allow) ||
+			!stringSlicesEqual(currentPerms.Deny, machineCfg.Permissions.Deny) {
 			result.ChangedPermissions = true
 		}
 	}
@@ -142,7 +159,10 @@ func PushScan(claudeDir, syncDir string) (*PushScanResult, error) {
 	// Scan CLAUDE.md.
 	claudeMDPath := filepath.Join(claudeDir, "CLAUDE.md")
 	if claudeMDData, err := os.ReadFile(claudeMDPath); err == nil {
-		reconcileResult, err := claudemd.Reconcile(syncDir, string(claudeMDData))
+		reconcileResult, err := claudemd.ReconcileRendered(syncDir, string(claudeMDData), loadClaudeMDTemplateData(syncDir, cfg))
+		if err == nil {
+			result.TemplateEdited = reconcileResult.TemplateEdited
+		}
 		if err == nil && (len(reconcileResult.Updated) > 0 || len(reconcileResult.New) > 0 ||
 			len(reconcileResult.Deleted) > 0 || len(reconcileResult.Renamed) > 0) {
 			result.ChangedClaudeMD = reconcileResult
@@ -182,10 +202,18 @@ func PushScan(claudeDir, syncDir string) (*PushScanResult, error) {
 		}
 	}
 
+	// Scan project memory.
+	result.ChangedProjectMemory, err = changedProjectMemory(claudeDir, syncDir, cfg.Memory.Projects)
+	if err != nil {
+		return nil, err
+	}
+
 	// Scan MCP.
 	currentMCP, mcpErr := claudecode.ReadMCPConfig(claudeDir)
 	if mcpErr == nil {
-		if !jsonMapsEqual(currentMCP, cfg.MCP) {
+		currentMCP = keepSecretReferences(currentMCP, cfg.MCP, SecretResolver(syncDir))
+		currentMCP = keepEncryptedMCPValues(currentMCP, cfg.MCP, SecretDecrypter())
+		if !jsonMapsEqual(currentMCP, machineCfg.MCP) {
 			result.ChangedMCP = true
 			result.MCPSecrets = DetectMCPSecrets(currentMCP)
 		}
@@ -225,9 +253,43 @@ func PushScan(claudeDir, syncDir string) (*PushScanResult, error) {
 		result.ChangedSkills = true
 	}
 
-	// Detect orphaned commands/skills in sync dir that aren't in config.
+	// Scan agents — compare items listed in config, or check for uncommitted changes.
+	agentNames := extractNamesFromKeys(cfg.Agents)
+	if len(agentNames) > 0 {
+		result.ChangedAgents = filteredDirContentsDiffer(
+			filepath.Join(claudeDir, "agents"),
+			filepath.Join(syncDir, "agents"),
+			agentNames,
+		)
+	}
+	if !result.ChangedAgents && git.HasUncommittedChanges(syncDir, "agents") {
+		result.ChangedAgents = true
+	}
+
+	// Scan output styles — compare items listed in config, or check for uncommitted changes.
+	styleNames := extractNamesFromKeys(cfg.OutputStyles)
+	if len(styleNames) > 0 {
+		result.ChangedOutputStyles = filteredDirContentsDiffer(
+			filepath.Join(claudeDir, "output-styles"),
+			filepath.Join(syncDir, "output-styles"),
+			styleNames,
+		)
+	}
+	if !result.ChangedOutputStyles && git.HasUncommittedChanges(syncDir, "output-styles") {
+		result.ChangedOutputStyles = true
+	}
+
+	// Scan scripts run by settings and hooks.
+	result.ChangedScripts = scriptAssetsDiffer(syncDir, cfg.Scripts, ScriptAssets(cfg.Settings, cfg.Hooks))
+	if !result.ChangedScripts && git.HasUncommittedChanges(syncDir, scriptsDirName) {
+		result.ChangedScripts = true
+	}
+
+	// Detect orphaned commands/skills/agents/styles in sync dir that aren't in config.
 	result.OrphanedCommands = findOrphanedFiles(filepath.Join(syncDir, "commands"), cmdNames)
 	result.OrphanedSkills = findOrphanedDirs(filepath.Join(syncDir, "skills"), skillNames)
+	result.OrphanedAgents = findOrphanedFiles(filepath.Join(syncDir, "agents"), agentNames)
+	result.OrphanedOutputStyles = findOrphanedFiles(filepath.Join(syncDir, "output-styles"), styleNames)
 
 	// Check for uncommitted changes in the sync repo (e.g. from config update).
 	if clean, err := git.IsClean(syncDir); err == nil && !clean {
@@ -239,24 +301,30 @@ func PushScan(claudeDir, syncDir string) (*PushScanResult, error) {
 
 // PushApplyOptions configures what PushApply does.
 type PushApplyOptions struct {
-	ClaudeDir          string
-	SyncDir            string
-	AddPlugins         []string
-	RemovePlugins      []string
-	ExcludePlugins     []string // plugins to add to cfg.Excluded
-	ProfileTarget      string   // "" = base config, non-empty = profile name
-	Message            string
-	UpdatePermissions  bool
-	UpdateClaudeMD     bool
-	UpdateMCP          bool
-	UpdateKeybindings  bool
-	UpdateCommands     bool
-	UpdateSkills       bool
-	UpdateMemory       bool
-	OrphanedCommands   []string // command files to remove from sync dir
-	OrphanedSkills     []string // skill dirs to remove from sync dir
-	DirtyWorkingTree   bool     // sync repo has uncommitted changes to include
-	Force              bool     // force push (--force-with-lease)
+	ClaudeDir            string
+	SyncDir              string
+	AddPlugins           []string
+	RemovePlugins        []string
+	ExcludePlugins       []string // plugins to add to cfg.Excluded
+	ProfileTarget        string   // "" = base config, non-empty = profile name
+	Message              string
+	UpdatePermissions    bool
+	UpdateClaudeMD       bool
+	UpdateMCP            bool
+	UpdateKeybindings    bool
+	UpdateCommands       bool
+	UpdateSkills         bool
+	UpdateAgents         bool
+	UpdateOutputStyles   bool
+	UpdateScripts        bool
+	UpdateMemory         bool
+	UpdateProjectMemory  []string // identities of projects whose memory to push
+	OrphanedCommands     []string // command files to remove from sync dir
+	OrphanedSkills       []string // skill dirs to remove from sync dir
+	OrphanedAgents       []string // agent files to remove from sync dir
+	OrphanedOutputStyles []string // output style files to remove from sync dir
+	DirtyWorkingTree     bool     // sync repo has uncommitted changes to include
+	Force                bool     // force push (--force-with-lease)
 }
 
 func PushApply(opts PushApplyOptions) error {
@@ -273,6 +341,7 @@ func PushApply(opts PushApplyOptions) error {
 	if err != nil {
 		return err
 	}
+	machineCfg, _ := cfg.ForMachine(config.CurrentMachine())
 
 	if opts.ProfileTarget != "" {
 		// Route changes to a profile instead of base config.
@@ -337,6 +406,9 @@ func PushApply(opts PushApplyOptions) error {
 		if opts.UpdateMCP {
 			mcp, err := claudecode.ReadMCPConfig(opts.ClaudeDir)
 			if err == nil {
+				stored := profiles.MergeMCP(cfg.MCP, profile)
+				mcp = keepSecretReferences(mcp, stored, SecretResolver(opts.SyncDir))
+				mcp = keepEncryptedMCPValues(mcp, stored, SecretDecrypter())
 				mcpAdd := make(map[string]json.RawMessage)
 				for name, val := range mcp {
 					baseVal, inBase := cfg.MCP[name]
@@ -457,8 +529,8 @@ func PushApply(opts PushApplyOptions) error {
 				json.Unmarshal(permRaw, &perms)
 			}
 			cfg.Permissions = config.Permissions{
-				Allow: perms.Allow,
-				Deny:  perms.Deny,
+				Allow: restoreFilteredRules(perms.Allow, cfg.Permissions.Allow, machineCfg.Permissions.Allow),
+				Deny:  restoreFilteredRules(perms.Deny, cfg.Permissions.Deny, machineCfg.Permissions.Deny),
 			}
 		}
 	}
@@ -467,12 +539,15 @@ func PushApply(opts PushApplyOptions) error {
 	if opts.UpdateMCP {
 		mcp, err := claudecode.ReadMCPConfig(opts.ClaudeDir)
 		if err == nil {
-			// Strip secrets and normalize paths before writing to config.
+			// Restore store references pull resolved, then strip secrets
+			// and normalize paths before writing to config.
+			mcp = keepSecretReferences(mcp, cfg.MCP, SecretResolver(opts.SyncDir))
 			if secrets := DetectMCPSecrets(mcp); len(secrets) > 0 {
 				mcp = ReplaceSecrets(mcp, secrets)
 			}
 			mcp = NormalizeMCPPaths(mcp)
-			cfg.MCP = mcp
+			mcp = restoreFilteredMCP(mcp, cfg.MCP, machineCfg.MCP)
+			cfg.MCP = keepEncryptedMCPValues(mcp, cfg.MCP, SecretDecrypter())
 		}
 	}
 
@@ -494,13 +569,45 @@ func PushApply(opts PushApplyOptions) error {
 		syncCopySkills(opts.ClaudeDir, opts.SyncDir, extractNamesFromKeys(cfg.Skills))
 	}
 
-	// Remove orphaned commands/skills from sync dir.
+	// Update agents from current state (only items listed in config).
+	if opts.UpdateAgents {
+		syncCopyAgents(opts.ClaudeDir, opts.SyncDir, extractNamesFromKeys(cfg.Agents))
+	}
+
+	// Update output styles from current state (only items listed in config).
+	if opts.UpdateOutputStyles {
+		syncCopyMDFiles(filepath.Join(opts.ClaudeDir, "output-styles"), filepath.Join(opts.SyncDir, "output-styles"), extractNamesFromKeys(cfg.OutputStyles))
+	}
+
+	// Update the scripts settings and hooks run.
+	if opts.UpdateScripts {
+		scripts, err := copyScriptAssets(opts.SyncDir, ScriptAssets(cfg.Settings, cfg.Hooks))
+		if err != nil {
+			return err
+		}
+		cfg.Scripts = scripts
+	}
+
+	// Mirror the memory of changed projects, including new and deleted fragments.
+	if len(opts.UpdateProjectMemory) > 0 {
+		if err := pushProjectMemory(opts.ClaudeDir, opts.SyncDir, opts.UpdateProjectMemory); err != nil {
+			return err
+		}
+	}
+
+	// Remove orphaned commands/skills/agents/styles from sync dir.
 	for _, name := range opts.OrphanedCommands {
 		os.Remove(filepath.Join(opts.SyncDir, "commands", name+".md"))
 	}
 	for _, name := range opts.OrphanedSkills {
 		os.RemoveAll(filepath.Join(opts.SyncDir, "skills", name))
 	}
+	for _, name := range opts.OrphanedAgents {
+		os.Remove(filepath.Join(opts.SyncDir, "agents", name+".md"))
+	}
+	for _, name := range opts.OrphanedOutputStyles {
+		os.Remove(filepath.Join(opts.SyncDir, "output-styles", name+".md"))
+	}
 
 	// Always write config (excluded list may change even for profile-targeted pushes).
 	newData, err := config.Marshal(cfg)
@@ -549,11 +656,39 @@ func PushApply(opts PushApplyOptions) error {
 			}
 		}
 	}
+	if opts.UpdateAgents {
+		agentsDir := filepath.Join(opts.SyncDir, "agents")
+		if _, err := os.Stat(agentsDir); err == nil {
+			if err := git.Add(opts.SyncDir, "agents"); err != nil {
+				return fmt.Errorf("staging agents: %w", err)
+			}
+		}
+	}
+	if opts.UpdateOutputStyles {
+		stylesDir := filepath.Join(opts.SyncDir, "output-styles")
+		if _, err := os.Stat(stylesDir); err == nil {
+			if err := git.Add(opts.SyncDir, "output-styles"); err != nil {
+				return fmt.Errorf("staging output styles: %w", err)
+			}
+		}
+	}
+	if opts.UpdateScripts {
+		if _, err := os.Stat(filepath.Join(opts.SyncDir, scriptsDirName)); err == nil {
+			if err := git.Add(opts.SyncDir, "-A", scriptsDirName); err != nil {
+				return fmt.Errorf("staging scripts: %w", err)
+			}
+		}
+	}
 	if opts.UpdateMemory {
 		if err := git.Add(opts.SyncDir, "memory"); err != nil {
 			return fmt.Errorf("staging memory: %w", err)
 		}
 	}
+	if len(opts.UpdateProjectMemory) > 0 {
+		if err := git.Add(opts.SyncDir, "-A", projectMemoryDirName); err != nil {
+			return fmt.Errorf("staging project memory: %w", err)
+		}
+	}
 	// Stage orphan removals individually (the directories are already deleted).
 	for _, name := range opts.OrphanedCommands {
 		git.Add(opts.SyncDir, filepath.Join("commands", name+".md"))
@@ -561,6 +696,12 @@ func PushApply(opts PushApplyOptions) error {
 	for _, name := range opts.OrphanedSkills {
 		git.Add(opts.SyncDir, filepath.Join("skills", name))
 	}
+	for _, name := range opts.OrphanedAgents {
+		git.Add(opts.SyncDir, filepath.Join("agents", name+".md"))
+	}
+	for _, name := range opts.OrphanedOutputStyles {
+		git.Add(opts.SyncDir, filepath.Join("output-styles", name+".md"))
+	}
 	// Catch-all: stage any remaining uncommitted changes (e.g. from config update).
 	if opts.DirtyWorkingTree {
 		git.Add(opts.SyncDir, "-A")
@@ -571,6 +712,20 @@ func PushApply(opts PushApplyOptions) error {
 	if err := git.Commit(opts.SyncDir, message); err != nil {
 		return fmt.Errorf("committing: %w", err)
 	}
+	pushErr := pushCommitted(opts)
+
+	entry := pushAuditEntry(opts)
+	if pushErr != nil {
+		entry.Error = pushErr.Error()
+	}
+	if err := recordAudit(opts.SyncDir, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+	return pushErr
+}
+
+// pushCommitted pushes the sync repo, setting the upstream on first push.
+func pushCommitted(opts PushApplyOptions) error {
 	if git.HasRemote(opts.SyncDir, "origin") {
 		if !git.HasUpstream(opts.SyncDir) {
 			branch, err := git.CurrentBranch(opts.SyncDir)
@@ -598,6 +753,55 @@ func PushApply(opts PushApplyOptions) error {
 	return nil
 }
 
+// pushAuditEntry lists what a push sent from this machine.
+func pushAuditEntry(opts PushApplyOptions) audit.Entry {
+	e := audit.Entry{Command: "push", Profile: opts.ProfileTarget}
+	for _, p := range opts.AddPlugins {
+		e.Items = append(e.Items, audit.Item{Action: "pushed", Category: "plugins", Name: p})
+	}
+	for _, p := range opts.RemovePlugins {
+		e.Items = append(e.Items, audit.Item{Action: "removed", Category: "plugins", Name: p})
+	}
+	for _, p := range opts.ExcludePlugins {
+		e.Items = append(e.Items, audit.Item{Action: "removed", Category: "plugins", Name: p, Reason: "excluded"})
+	}
+	for _, u := range []struct {
+		set      bool
+		category string
+	}{
+		{opts.UpdatePermissions, "permissions"},
+		{opts.UpdateClaudeMD, "claude_md"},
+		{opts.UpdateMCP, "mcp"},
+		{opts.UpdateKeybindings, "keybindings"},
+		{opts.UpdateCommands, "commands"},
+		{opts.UpdateSkills, "skills"},
+		{opts.UpdateAgents, "agents"},
+		{opts.UpdateOutputStyles, "output_styles"},
+		{opts.UpdateScripts, "scripts"},
+		{opts.UpdateMemory, "memory"},
+	} {
+		if u.set {
+			e.Items = append(e.Items, audit.Item{Action: "pushed", Category: u.category})
+		}
+	}
+	for _, id := range opts.UpdateProjectMemory {
+		e.Items = append(e.Items, audit.Item{Action: "pushed", Category: "project_memory", Name: id})
+	}
+	for _, name := range opts.OrphanedCommands {
+		e.Items = append(e.Items, audit.Item{Action: "removed", Category: "commands", Name: name})
+	}
+	for _, name := range opts.OrphanedSkills {
+		e.Items = append(e.Items, audit.Item{Action: "removed", Category: "skills", Name: name})
+	}
+	for _, name := range opts.OrphanedAgents {
+		e.Items = append(e.Items, audit.Item{Action: "removed", Category: "agents", Name: name})
+	}
+	for _, name := range opts.OrphanedOutputStyles {
+		e.Items = append(e.Items, audit.Item{Action: "removed", Category: "output_styles", Name: name})
+	}
+	return e
+}
+
 func generateCommitMessage(added, removed []string, profileTarget string) string {
 	var parts []string
 	if len(added) > 0 {
@@ -729,7 +933,7 @@ func readDirMDFiles(dir string) map[string]string {
 }
 
 // extractNamesFromKeys parses the trailing name from key strings like
-// "cmd:global:review-plan" or "skill:global:termdock-ast".
+// "cmd:global:review-plan", "skill:global:termdock-ast" or "agent:global:reviewer".
 func extractNamesFromKeys(keys []string) map[string]bool {
 	names := make(map[string]bool)
 	for _, k := range keys {
@@ -747,7 +951,7 @@ func filteredDirContentsDiffer(a, b string, names map[string]bool) bool {
 	bFiles := readDirMDFiles(b)
 
 	for name := range names {
-		// For commands: name.md; for skills: name/SKILL.md.
+		// For commands and agents: name.md; for skills: name/SKILL.md.
 		cmdKey := name + ".md"
 		skillKey := name + "/SKILL.md"
 
@@ -769,11 +973,19 @@ func filteredDirContentsDiffer(a, b string, names map[string]bool) bool {
 
 // syncCopyCommands copies only the named .md files from claudeDir/commands/ to syncDir/commands/.
 func syncCopyCommands(claudeDir, syncDir string, names map[string]bool) {
+	syncCopyMDFiles(filepath.Join(claudeDir, "commands"), filepath.Join(syncDir, "commands"), names)
+}
+
+// syncCopyAgents copies only the named .md files from claudeDir/agents/ to syncDir/agents/.
+func syncCopyAgents(claudeDir, syncDir string, names map[string]bool) {
+	syncCopyMDFiles(filepath.Join(claudeDir, "agents"), filepath.Join(syncDir, "agents"), names)
+}
+
+// syncCopyMDFiles copies only the named .md files from srcDir to dstDir.
+func syncCopyMDFiles(srcDir, dstDir string, names map[string]bool) {
 	if len(names) == 0 {
 		return
 	}
-	srcDir := filepath.Join(claudeDir, "commands")
-	dstDir := filepath.Join(syncDir, "commands")
 
 	os.MkdirAll(dstDir, 0755)
 	for name := range names {
@@ -814,3 +1026,32 @@ func anyMapsEqual(a, b map[string]any) bool {
 	bJSON, _ := json.Marshal(b)
 	return string(aJSON) == string(bJSON)
 }
+
+// restoreFilteredMCP adds back the servers in stored that this machine's
+// when: selectors left out of applied. Pull never writes them locally, so
+// without this a push from this machine would delete them for everyone.
+func restoreFilteredMCP(current, stored, applied map[string]json.RawMessage) map[string]json.RawMessage {
+	for name, val := range stored {
+		if _, ok := applied[name]; ok {
+			continue
+		}
+		if _, ok := current[name]; ok {
+			continue
+		}
+		if current == nil {
+			current = make(map[string]json.RawMessage)
+		}
+		current[name] = val
+	}
+	return current
+}
+
+// restoreFilteredRules is restoreFilteredMCP for permission rules.
+func restoreFilteredRules(current, stored, applied []string) []string {
+	for _, r := range stored {
+		if !slices.Contains(applied, r) && !slices.Contains(current, r) {
+			current = append(current, r)
+		}
+	}
+	return current
+}
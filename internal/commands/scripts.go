@@ -0,0 +1,371 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/approval"
+	"github.com/ruminaider/claude-sync/internal/config"
+)
+
+// scriptsDirName is the sync repo directory holding script assets, laid out
+// by their path under the home directory (~/.claude/statusline.sh is stored
+// at scripts/.claude/statusline.sh).
+const scriptsDirName = "scripts"
+
+// HashKeyScriptPrefix is the prefix for per-script hash keys.
+const HashKeyScriptPrefix = "script:"
+
+// scriptSettings are the settings.json keys whose value runs a command.
+// statusLine holds the command in its "command" field; the others are the
+// command itself.
+var scriptSettings = []string{"statusLine", "apiKeyHelper", "awsAuthRefresh", "awsCredentialExport", "otelHeadersHelper"}
+
+// settingCommand returns the command a settings value runs, or "".
+func settingCommand(key string, val any) string {
+	if key == "statusLine" {
+		if m, ok := val.(map[string]any); ok {
+			cmd, _ := m["command"].(string)
+			return cmd
+		}
+		return ""
+	}
+	cmd, _ := val.(string)
+	return cmd
+}
+
+// commandScript returns the script file a command runs: the argument of an
+// interpreter ("bash ~/x.sh") or the program itself when it is a path
+// ("~/.claude/statusline.sh"). Returns "" for anything else.
+func commandScript(command string) string {
+	if path := approval.ScriptPath(command); path != "" {
+		return path
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	path := strings.Trim(fields[0], `"'`)
+	if strings.HasPrefix(path, "/") || strings.HasPrefix(path, "~/") {
+		return path
+	}
+	return ""
+}
+
+// ScriptAssets returns the ~/ paths of the scripts under the home directory
+// that settings and hooks run, sorted. Scripts elsewhere (/usr/local/bin, or
+// a ~/../ path that climbs out of the home directory) are assumed to be
+// installed by other means and aren't synced.
+func ScriptAssets(settings map[string]any, hooks map[string]json.RawMessage) []string {
+	seen := make(map[string]bool)
+	add := func(command string) {
+		path := normalizeHomePath(commandScript(command))
+		if config.ValidScriptPath(path) {
+			seen[path] = true
+		}
+	}
+	for _, key := range scriptSettings {
+		if val, ok := settings[key]; ok {
+			add(settingCommand(key, val))
+		}
+	}
+	for _, data := range hooks {
+		cmds, err := approval.HookCommands(data)
+		if err != nil {
+			continue
+		}
+		for _, cmd := range cmds {
+			add(cmd)
+		}
+	}
+	scripts := make([]string, 0, len(seen))
+	for path := range seen {
+		scripts = append(scripts, path)
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// missingSettingScripts returns the scripts a settings value runs that don't
+// exist on disk.
+func missingSettingScripts(key string, val any) []string {
+	path := commandScript(settingCommand(key, val))
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(expandHome(path)); os.IsNotExist(err) {
+		return []string{path}
+	}
+	return nil
+}
+
+// NormalizeScriptPaths replaces $HOME-prefixed paths with ~/ in the commands
+// settings and hooks run, so they work on machines with a different home
+// directory. Pair with ExpandScriptPaths on the read side.
+func NormalizeScriptPaths(settings map[string]any, hooks map[string]json.RawMessage) (map[string]any, map[string]json.RawMessage) {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return settings, hooks
+	}
+	return transformScriptPaths(settings, hooks, func(s string) string {
+		return replacePathPrefix(s, home+"/", "~/")
+	})
+}
+
+// ExpandScriptPaths replaces ~/ with the actual $HOME in the commands
+// settings and hooks run. Inverse of NormalizeScriptPaths.
+func ExpandScriptPaths(settings map[string]any, hooks map[string]json.RawMessage) (map[string]any, map[string]json.RawMessage) {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return settings, hooks
+	}
+	return transformScriptPaths(settings, hooks, func(s string) string {
+		return replacePathPrefix(s, "~/", home+"/")
+	})
+}
+
+// normalizeScriptSetting returns a settings value with the paths its
+// command runs normalized, so an expanded value on disk compares equal to
+// the ~/ form in config.
+func normalizeScriptSetting(key string, val any) any {
+	settings, _ := NormalizeScriptPaths(map[string]any{key: val}, nil)
+	return settings[key]
+}
+
+// transformScriptPaths applies transform to the command strings of the
+// script settings and of every hook. The inputs are not modified.
+func transformScriptPaths(settings map[string]any, hooks map[string]json.RawMessage, transform func(string) string) (map[string]any, map[string]json.RawMessage) {
+	var outSettings map[string]any
+	if settings != nil {
+		outSettings = make(map[string]any, len(settings))
+		for k, v := range settings {
+			outSettings[k] = v
+		}
+		for _, key := range scriptSettings {
+			val, ok := settings[key]
+			if !ok {
+				continue
+			}
+			switch v := val.(type) {
+			case string:
+				outSettings[key] = transform(v)
+			case map[string]any:
+				if cmd, ok := v["command"].(string); ok {
+					copied := make(map[string]any, len(v))
+					for k, x := range v {
+						copied[k] = x
+					}
+					copied["command"] = transform(cmd)
+					outSettings[key] = copied
+				}
+			}
+		}
+	}
+
+	var outHooks map[string]json.RawMessage
+	if hooks != nil {
+		outHooks = make(map[string]json.RawMessage, len(hooks))
+		for name, raw := range hooks {
+			outHooks[name] = raw
+			var v any
+			if json.Unmarshal(raw, &v) != nil {
+				continue
+			}
+			if !transformCommands(v, transform) {
+				continue
+			}
+			if data, err := json.Marshal(v); err == nil {
+				outHooks[name] = data
+			}
+		}
+	}
+	return outSettings, outHooks
+}
+
+// transformCommands rewrites every "command" string in a decoded hook in
+// place, reporting whether any changed.
+func transformCommands(v any, transform func(string) string) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if s, ok := x.(string); ok && k == "command" {
+				if t := transform(s); t != s {
+					v[k] = t
+					changed = true
+				}
+				continue
+			}
+			if transformCommands(x, transform) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, x := range v {
+			if transformCommands(x, transform) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// replacePathPrefix replaces from with to wherever it starts a word of a
+// command: at the start, or after whitespace, a quote or '='.
+func replacePathPrefix(command, from, to string) string {
+	var b strings.Builder
+	for i := 0; i < len(command); {
+		atWord := i == 0 || strings.ContainsRune(" \t\"'=", rune(command[i-1]))
+		if atWord && strings.HasPrefix(command[i:], from) {
+			b.WriteString(to)
+			i += len(from)
+			continue
+		}
+		b.WriteByte(command[i])
+		i++
+	}
+	return b.String()
+}
+
+// normalizeHomePath returns path with a leading $HOME/ replaced by ~/.
+func normalizeHomePath(path string) string {
+	home := os.Getenv("HOME")
+	if home != "" && strings.HasPrefix(path, home+"/") {
+		return "~/" + path[len(home)+1:]
+	}
+	return path
+}
+
+// scriptAssetPath returns where the script at a ~/ path is stored in the
+// sync repo. A path that would leave the home directory, and so the scripts
+// directory, is an error.
+func scriptAssetPath(syncDir, script string) (string, error) {
+	if !config.ValidScriptPath(script) {
+		return "", fmt.Errorf("script %q isn't a file under ~/", script)
+	}
+	return filepath.Join(syncDir, scriptsDirName, filepath.FromSlash(strings.TrimPrefix(script, "~/"))), nil
+}
+
+// copyScriptAssets copies the listed scripts into the sync repo, keeping
+// their file mode, and removes stored scripts that are no longer listed.
+// Returns the scripts copied; those missing locally are left out.
+func copyScriptAssets(syncDir string, scripts []string) ([]string, error) {
+	var copied []string
+	keep := make(map[string]bool, len(scripts))
+	for _, script := range scripts {
+		src := expandHome(script)
+		info, err := os.Stat(src)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return copied, fmt.Errorf("reading script %s: %w", script, err)
+		}
+		dst, err := scriptAssetPath(syncDir, script)
+		if err != nil {
+			return copied, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return copied, err
+		}
+		if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+			return copied, fmt.Errorf("writing script %s: %w", script, err)
+		}
+		os.Chmod(dst, info.Mode().Perm())
+		keep[dst] = true
+		copied = append(copied, script)
+	}
+
+	root := filepath.Join(syncDir, scriptsDirName)
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && !keep[path] {
+			os.Remove(path)
+		}
+		return nil
+	})
+	return copied, nil
+}
+
+// scriptAssetsDiffer reports whether any listed script differs from its copy
+// in the sync repo, or the set of scripts referenced has changed.
+func scriptAssetsDiffer(syncDir string, listed, referenced []string) bool {
+	if !stringSlicesEqual(listed, referenced) {
+		for _, script := range referenced {
+			if _, err := os.Stat(expandHome(script)); err == nil && !containsString(listed, script) {
+				return true
+			}
+		}
+		for _, script := range listed {
+			if !containsString(referenced, script) {
+				return true
+			}
+		}
+	}
+	for _, script := range listed {
+		local, err := os.ReadFile(expandHome(script))
+		if err != nil {
+			continue
+		}
+		src, err := scriptAssetPath(syncDir, script)
+		if err != nil {
+			continue
+		}
+		stored, err := os.ReadFile(src)
+		if err != nil || string(local) != string(stored) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreScripts copies the listed scripts from the sync repo to their
+// place under this machine's home directory. Only scripts in referenced,
+// those the applied settings and hooks run, are written, so config can't
+// plant an arbitrary file in the home directory. A script edited since pull
+// last wrote it is left alone unless force is set. Returns the restored and
+// skipped script paths.
+func restoreScripts(syncDir string, scripts, referenced []string, hashes *AppliedHashes, force bool) (restored, skipped []string) {
+	for _, script := range scripts {
+		if !containsString(referenced, script) {
+			continue
+		}
+		src, err := scriptAssetPath(syncDir, script)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(src)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			continue
+		}
+		dst := expandHome(script)
+		hashKey := HashKeyScriptPrefix + script
+		if current, err := os.ReadFile(dst); err == nil && string(current) == string(data) {
+			hashes.Set(hashKey, string(data))
+			continue
+		}
+		if !force && hashes.IsLocallyModified(hashKey, dst) {
+			skipped = append(skipped, script)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			continue
+		}
+		mode := info.Mode().Perm()
+		if err := os.WriteFile(dst, data, mode); err != nil {
+			continue
+		}
+		os.Chmod(dst, mode)
+		hashes.Set(hashKey, string(data))
+		restored = append(restored, script)
+	}
+	return restored, skipped
+}
@@ -0,0 +1,165 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptAssets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	settings := map[string]any{
+		"statusLine":   map[string]any{"type": "command", "command": home + "/.claude/statusline.sh"},
+		"apiKeyHelper": "~/bin/key-helper --profile work",
+		"model":        "opus",
+	}
+	hooks := map[string]json.RawMessage{
+		"PreToolUse":  config.ExpandHookCommand("bash ~/.claude/hooks/guard.sh"),
+		"SessionEnd":  config.ExpandHookCommand("claude-sync push --auto"),
+		"Stop":        config.ExpandHookCommand("/usr/local/bin/notify"),
+		"PostToolUse": config.ExpandHookCommand("bash ~/../outside/pwn.sh"),
+	}
+
+	assert.Equal(t, []string{
+		"~/.claude/hooks/guard.sh",
+		"~/.claude/statusline.sh",
+		"~/bin/key-helper",
+	}, commands.ScriptAssets(settings, hooks))
+}
+
+func TestNormalizeExpandScriptPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	settings := map[string]any{
+		"statusLine": map[string]any{"type": "command", "command": home + "/.claude/statusline.sh --short"},
+		"model":      "opus",
+	}
+	hooks := map[string]json.RawMessage{
+		"PreToolUse": config.ExpandHookCommand("bash " + home + "/.claude/hooks/guard.sh"),
+	}
+
+	normSettings, normHooks := commands.NormalizeScriptPaths(settings, hooks)
+	assert.Equal(t, "~/.claude/statusline.sh --short", normSettings["statusLine"].(map[string]any)["command"])
+	assert.Equal(t, "opus", normSettings["model"])
+	assert.Contains(t, string(normHooks["PreToolUse"]), `"bash ~/.claude/hooks/guard.sh"`)
+	assert.Equal(t, home+"/.claude/statusline.sh --short", settings["statusLine"].(map[string]any)["command"], "input is not modified")
+
+	expSettings, expHooks := commands.ExpandScriptPaths(normSettings, normHooks)
+	assert.Equal(t, settings["statusLine"], expSettings["statusLine"])
+	assert.Contains(t, string(expHooks["PreToolUse"]), home+"/.claude/hooks/guard.sh")
+}
+
+func TestPull_RestoresStatusLineScript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	claudeDir, syncDir := setupCommandSkillEnv(t)
+
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	data, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	data = append(data, `settings:
+  statusLine:
+    type: command
+    command: ~/.claude/statusline.sh
+scripts:
+  - ~/.claude/statusline.sh
+`...)
+	require.NoError(t, os.WriteFile(cfgPath, data, 0644))
+	stored := filepath.Join(syncDir, "scripts", ".claude", "statusline.sh")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stored), 0755))
+	require.NoError(t, os.WriteFile(stored, []byte("#!/bin/sh\necho ok\n"), 0755))
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "add statusline").Run())
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Len(t, result.ScriptsRestored, 1)
+	assert.Contains(t, result.SettingsApplied, "statusLine")
+
+	script := filepath.Join(home, ".claude", "statusline.sh")
+	info, err := os.Stat(script)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	settings := readSettingsJSON(t, claudeDir)
+	var statusLine map[string]any
+	require.NoError(t, json.Unmarshal(settings["statusLine"], &statusLine))
+	assert.Equal(t, script, statusLine["command"])
+
+	// A locally edited script is left alone.
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho mine\n"), 0755))
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.ScriptsRestored)
+	assert.Len(t, result.ScriptsSkipped, 1)
+	data, err = os.ReadFile(script)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho mine\n", string(data))
+}
+
+func TestPull_RestoresOnlyReferencedScripts(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "home")
+	require.NoError(t, os.MkdirAll(home, 0755))
+	t.Setenv("HOME", home)
+	claudeDir, syncDir := setupCommandSkillEnv(t)
+
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	data, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	data = append(data, `settings:
+  statusLine:
+    type: command
+    command: ~/.claude/statusline.sh
+scripts:
+  - ~/.claude/statusline.sh
+  - ~/.bashrc
+`...)
+	require.NoError(t, os.WriteFile(cfgPath, data, 0644))
+	for _, name := range []string{".claude/statusline.sh", ".bashrc"} {
+		stored := filepath.Join(syncDir, "scripts", filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(stored), 0755))
+		require.NoError(t, os.WriteFile(stored, []byte("#!/bin/sh\necho ok\n"), 0755))
+	}
+	require.NoError(t, exec.Command("git", "-C", syncDir, "add", ".").Run())
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-m", "add scripts").Run())
+
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Len(t, result.ScriptsRestored, 1)
+	assert.FileExists(t, filepath.Join(home, ".claude", "statusline.sh"))
+	assert.NoFileExists(t, filepath.Join(home, ".bashrc"), "no setting or hook runs it")
+
+	// A path that climbs out of the home directory is refused outright.
+	data = append(data, "  - ~/../outside/pwn.sh\n"...)
+	require.NoError(t, os.WriteFile(cfgPath, data, 0644))
+	require.NoError(t, exec.Command("git", "-C", syncDir, "commit", "-am", "add pwn").Run())
+	_, err = commands.Pull(claudeDir, syncDir, true)
+	assert.ErrorContains(t, err, "isn't a file under ~/")
+	assert.NoDirExists(t, filepath.Join(filepath.Dir(home), "outside"))
+}
+
+func TestApplySettings_SkipsMissingStatusLineScript(t *testing.T) {
+	claudeDir := setupApplySettingsEnv(t)
+	cfg := config.Config{
+		Settings: map[string]any{
+			"statusLine": map[string]any{"type": "command", "command": "/nonexistent/statusline.sh"},
+			"model":      "opus",
+		},
+	}
+	applied, _, skipped, err := commands.ApplySettings(claudeDir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"model"}, applied)
+	require.Len(t, skipped, 1)
+	assert.Contains(t, skipped[0], `setting "statusLine": script not found: /nonexistent/statusline.sh`)
+	assert.NotContains(t, readSettingsJSON(t, claudeDir), "statusLine")
+}
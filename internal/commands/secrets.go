@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/ruminaider/claude-sync/internal/secrets"
+)
+
+// secretStoreFileName is the local secret store under the sync dir. It is
+// gitignored: it is encrypted to this machine's identity only.
+const secretStoreFileName = "secrets.age"
+
+// SecretStore returns the local secret store for syncDir, encrypted to the
+// identity at paths.IdentityFile().
+func SecretStore(syncDir string) *secrets.Store {
+	return secrets.NewStore(filepath.Join(syncDir, secretStoreFileName), paths.IdentityFile())
+}
+
+// SecretsSet stores a secret in the local store.
+func SecretsSet(syncDir, name, value string) error {
+	ensureGitignorePatterns(syncDir, []string{secretStoreFileName})
+	return SecretStore(syncDir).Set(name, value)
+}
+
+// SecretsGet returns a secret from the local store.
+func SecretsGet(syncDir, name string) (string, bool, error) {
+	return SecretStore(syncDir).Get(name)
+}
+
+// SecretsList returns the names in the local store, sorted.
+func SecretsList(syncDir string) ([]string, error) {
+	return SecretStore(syncDir).Names()
+}
+
+// SecretsRemove deletes a secret from the local store, reporting whether it
+// was present.
+func SecretsRemove(syncDir, name string) (bool, error) {
+	return SecretStore(syncDir).Delete(name)
+}
+
+// SecretResolver returns the resolver pull uses for ${NAME} references: the
+// local store, then secret_command from user-preferences.yaml if set, then
+// the environment.
+func SecretResolver(syncDir string) secrets.Resolver {
+	chain := secrets.Chain{SecretStore(syncDir)}
+	if data, err := os.ReadFile(filepath.Join(syncDir, "user-preferences.yaml")); err == nil {
+		if prefs, err := config.ParseUserPreferences(data); err == nil && prefs.SecretCommand != "" {
+			chain = append(chain, secrets.Command{Template: prefs.SecretCommand})
+		}
+	}
+	return append(chain, secrets.Env{})
+}
+
+// keepSecretReferences puts ${NAME} references from stored back into
+// current wherever current holds exactly what pull resolved them to, so
+// re-capturing MCP config never commits a secret from the store or
+// renames its reference.
+func keepSecretReferences(current, stored map[string]json.RawMessage, resolver secrets.Resolver) map[string]json.RawMessage {
+	refs := make(map[string]string)
+	mapMCPEnvValues(stored, func(server, key, v string) string {
+		if envVarRefPattern.MatchString(v) {
+			refs[server+"\x00"+key] = v
+		}
+		return v
+	})
+	if len(refs) == 0 {
+		return current
+	}
+	return mapMCPEnvValues(current, func(server, key, v string) string {
+		ref, ok := refs[server+"\x00"+key]
+		if !ok || v == ref {
+			return v
+		}
+		if resolved, ok := resolveSecretReferences(ref, resolver); ok && resolved == v {
+			return ref
+		}
+		return v
+	})
+}
+
+// resolveSecretReferences replaces every ${NAME} in s with its value,
+// reporting false if any of them can't be resolved.
+func resolveSecretReferences(s string, resolver secrets.Resolver) (string, bool) {
+	ok := true
+	out := envVarRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		val, found, err := resolver.Resolve(match[2 : len(match)-1])
+		if !found || err != nil {
+			ok = false
+			return match
+		}
+		return val
+	})
+	return out, ok
+}
@@ -0,0 +1,464 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/ruminaider/claude-sync/internal/secrets"
+)
+
+// SecretDecrypter returns the decrypter pull uses for team secrets: the
+// identity at paths.IdentityFile().
+func SecretDecrypter() secrets.Decrypter {
+	return &secrets.IdentityDecrypter{Path: paths.IdentityFile()}
+}
+
+// SecretsPublicKey returns this machine's age public key, creating the
+// identity if needed. Team members add it to config.yaml recipients.
+func SecretsPublicKey() (string, error) {
+	id, err := secrets.LoadOrCreateIdentity(paths.IdentityFile())
+	if err != nil {
+		return "", err
+	}
+	return id.Recipient().String(), nil
+}
+
+// SecretTarget names a config.yaml value to encrypt: either an MCP server env
+// var ("mcp.<server>.env.<KEY>") or a settings key ("settings.<key>[.<key>...]").
+type SecretTarget struct {
+	Server  string
+	EnvKey  string
+	Setting []string
+}
+
+// ParseSecretTarget parses a target in the form accepted by SecretTarget.
+func ParseSecretTarget(s string) (SecretTarget, error) {
+	switch {
+	case strings.HasPrefix(s, "mcp."):
+		rest := strings.TrimPrefix(s, "mcp.")
+		i := strings.LastIndex(rest, ".env.")
+		if i <= 0 || i+len(".env.") == len(rest) {
+			break
+		}
+		return SecretTarget{Server: rest[:i], EnvKey: rest[i+len(".env."):]}, nil
+	case strings.HasPrefix(s, "settings."):
+		parts := strings.Split(strings.TrimPrefix(s, "settings."), ".")
+		for _, p := range parts {
+			if p == "" {
+				return SecretTarget{}, fmt.Errorf("invalid target %q", s)
+			}
+		}
+		return SecretTarget{Setting: parts}, nil
+	}
+	return SecretTarget{}, fmt.Errorf("invalid target %q: use mcp.<server>.env.<KEY> or settings.<key>", s)
+}
+
+func (t SecretTarget) String() string {
+	if t.Server != "" {
+		return "mcp." + t.Server + ".env." + t.EnvKey
+	}
+	return "settings." + strings.Join(t.Setting, ".")
+}
+
+// SecretsShare encrypts a config.yaml value to the team's recipients and
+// commits the result. If value is empty, the value currently in config.yaml
+// is encrypted in place.
+func SecretsShare(syncDir string, target SecretTarget, value string) error {
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return err
+	}
+
+	current, err := secretTargetValue(cfg, target)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		switch {
+		case current == "":
+			return fmt.Errorf("%s has no value in config.yaml; pass one to encrypt", target)
+		case secrets.IsEncrypted(current):
+			return fmt.Errorf("%s is already encrypted", target)
+		case isTemplated(current):
+			return fmt.Errorf("%s is a %s reference; pass the value to encrypt", target, current)
+		}
+		value = current
+	}
+
+	enc, err := secrets.Encrypt(value, cfg.Recipients)
+	if err != nil {
+		return err
+	}
+	if err := setSecretTargetValue(&cfg, target, enc); err != nil {
+		return err
+	}
+	return writeSecretsConfig(syncDir, cfg, fmt.Sprintf("Encrypt %s", target))
+}
+
+// SecretsRecipients returns the recipients declared in config.yaml.
+func SecretsRecipients(syncDir string) (map[string]string, error) {
+	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Recipients, nil
+}
+
+// SecretsAddRecipient adds or replaces a recipient and re-encrypts every
+// team secret so the new key can read it. The caller must be able to
+// decrypt every existing value. It returns the number of values
+// re-encrypted.
+func SecretsAddRecipient(syncDir, name, key string) (int, error) {
+	if _, err := secrets.ParseRecipient(key); err != nil {
+		return 0, err
+	}
+	return updateRecipients(syncDir, fmt.Sprintf("Add secrets recipient %s", name), func(r map[string]string) error {
+		r[name] = strings.TrimSpace(key)
+		return nil
+	})
+}
+
+// SecretsRemoveRecipient removes a recipient and re-encrypts every team
+// secret without their key. Values they could read before remain in git
+// history, so rotate anything they should no longer have.
+func SecretsRemoveRecipient(syncDir, name string) (int, error) {
+	return updateRecipients(syncDir, fmt.Sprintf("Remove secrets recipient %s", name), func(r map[string]string) error {
+		if _, ok := r[name]; !ok {
+			return fmt.Errorf("recipient %q not found", name)
+		}
+		delete(r, name)
+		return nil
+	})
+}
+
+func updateRecipients(syncDir, message string, update func(map[string]string) error) (int, error) {
+	cfgPath := filepath.Join(syncDir, "config.yaml")
+	cfgData, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return 0, fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.Recipients == nil {
+		cfg.Recipients = make(map[string]string)
+	}
+	if err := update(cfg.Recipients); err != nil {
+		return 0, err
+	}
+
+	n, err := reencryptConfig(&cfg, SecretDecrypter())
+	if err != nil {
+		return 0, err
+	}
+	return n, writeSecretsConfig(syncDir, cfg, message)
+}
+
+// reencryptConfig decrypts every team secret in cfg and encrypts it again to
+// cfg.Recipients.
+func reencryptConfig(cfg *config.Config, dec secrets.Decrypter) (int, error) {
+	var failed []string
+	n := 0
+	reencrypt := func(where, v string) string {
+		plain, err := dec.Decrypt(v)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", where, err))
+			return v
+		}
+		enc, err := secrets.Encrypt(plain, cfg.Recipients)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", where, err))
+			return v
+		}
+		n++
+		return enc
+	}
+
+	cfg.MCP = mapMCPEnvValues(cfg.MCP, func(server, key, v string) string {
+		if !secrets.IsEncrypted(v) {
+			return v
+		}
+		return reencrypt(SecretTarget{Server: server, EnvKey: key}.String(), v)
+	})
+	cfg.Settings, _ = mapSettingStrings(cfg.Settings, "settings", func(where, v string) (string, bool) {
+		if !secrets.IsEncrypted(v) {
+			return v, true
+		}
+		return reencrypt(where, v), true
+	}).(map[string]any)
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return 0, fmt.Errorf("can't re-encrypt team secrets:\n  %s", strings.Join(failed, "\n  "))
+	}
+	return n, nil
+}
+
+// decryptSettings decrypts team secrets in settings. Values that can't be
+// decrypted are dropped rather than applied as ciphertext, and reported.
+func decryptSettings(settings map[string]any, dec secrets.Decrypter) (map[string]any, []string) {
+	if len(settings) == 0 {
+		return settings, nil
+	}
+	var warnings []string
+	out, _ := mapSettingStrings(settings, "settings", func(where, v string) (string, bool) {
+		if !secrets.IsEncrypted(v) {
+			return v, true
+		}
+		plain, err := dec.Decrypt(v)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s not applied: %v", where, err))
+			return "", false
+		}
+		return plain, true
+	}).(map[string]any)
+	sort.Strings(warnings)
+	return out, warnings
+}
+
+// undecryptableDropped returns settings without the team secrets dec can't
+// decrypt, leaving the rest encrypted, so they are left out as
+// decryptSettings leaves them out.
+func undecryptableDropped(settings map[string]any, dec secrets.Decrypter) map[string]any {
+	if len(settings) == 0 {
+		return settings
+	}
+	out, _ := mapSettingStrings(settings, "settings", func(where, v string) (string, bool) {
+		if !secrets.IsEncrypted(v) {
+			return v, true
+		}
+		_, err := dec.Decrypt(v)
+		return v, err == nil
+	}).(map[string]any)
+	return out
+}
+
+// keepEncryptedMCPValues puts team secret ciphertext from stored back into
+// current wherever current holds the decrypted value or a ${KEY}
+// placeholder for it, so re-capturing MCP config never commits plaintext
+// or drops the encrypted value.
+func keepEncryptedMCPValues(current, stored map[string]json.RawMessage, dec secrets.Decrypter) map[string]json.RawMessage {
+	encrypted := make(map[string]string)
+	mapMCPEnvValues(stored, func(server, key, v string) string {
+		if secrets.IsEncrypted(v) {
+			encrypted[server+"\x00"+key] = v
+		}
+		return v
+	})
+	if len(encrypted) == 0 {
+		return current
+	}
+	return mapMCPEnvValues(current, func(server, key, v string) string {
+		enc, ok := encrypted[server+"\x00"+key]
+		if !ok {
+			return v
+		}
+		if v == "${"+key+"}" {
+			return enc
+		}
+		if plain, err := dec.Decrypt(enc); err == nil && plain == v {
+			return enc
+		}
+		return v
+	})
+}
+
+// keepEncryptedSetting is keepEncryptedMCPValues for one setting's value:
+// it puts ciphertext from stored back wherever current holds its decrypted
+// value.
+func keepEncryptedSetting(current, stored any, dec secrets.Decrypter) any {
+	encrypted := make(map[string]string)
+	mapSettingStrings(stored, "", func(where, v string) (string, bool) {
+		if secrets.IsEncrypted(v) {
+			encrypted[where] = v
+		}
+		return v, true
+	})
+	if len(encrypted) == 0 {
+		return current
+	}
+	return mapSettingStrings(current, "", func(where, v string) (string, bool) {
+		if enc, ok := encrypted[where]; ok {
+			if plain, err := dec.Decrypt(enc); err == nil && plain == v {
+				return enc, true
+			}
+		}
+		return v, true
+	})
+}
+
+// mapMCPEnvValues applies fn to every string env value of every server.
+func mapMCPEnvValues(servers map[string]json.RawMessage, fn func(server, key, value string) string) map[string]json.RawMessage {
+	if len(servers) == 0 {
+		return servers
+	}
+	result := make(map[string]json.RawMessage, len(servers))
+	for name, raw := range servers {
+		result[name] = raw
+		var cfg map[string]any
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			continue
+		}
+		env, ok := cfg["env"].(map[string]any)
+		if !ok {
+			continue
+		}
+		changed := false
+		for key, val := range env {
+			s, ok := val.(string)
+			if !ok {
+				continue
+			}
+			if t := fn(name, key, s); t != s {
+				env[key] = t
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if data, err := json.Marshal(cfg); err == nil {
+			result[name] = json.RawMessage(data)
+		}
+	}
+	return result
+}
+
+// mapSettingStrings applies fn to every string in a settings tree, returning
+// a copy. where is the dotted path of v. Strings for which fn returns false
+// are removed from their map or list.
+func mapSettingStrings(v any, where string, fn func(where, value string) (string, bool)) any {
+	switch t := v.(type) {
+	case string:
+		if s, keep := fn(where, t); keep {
+			return s
+		}
+		return nil
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, child := range t {
+			mapped := mapSettingStrings(child, where+"."+k, fn)
+			if mapped == nil && child != nil {
+				continue
+			}
+			out[k] = mapped
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(t))
+		for i, child := range t {
+			mapped := mapSettingStrings(child, fmt.Sprintf("%s[%d]", where, i), fn)
+			if mapped == nil && child != nil {
+				continue
+			}
+			out = append(out, mapped)
+		}
+		return out
+	}
+	return v
+}
+
+func secretTargetValue(cfg config.Config, target SecretTarget) (string, error) {
+	if target.Server != "" {
+		raw, ok := cfg.MCP[target.Server]
+		if !ok {
+			return "", fmt.Errorf("MCP server %q not found in config.yaml", target.Server)
+		}
+		var server struct {
+			Env map[string]any `json:"env"`
+		}
+		json.Unmarshal(raw, &server)
+		s, _ := server.Env[target.EnvKey].(string)
+		return s, nil
+	}
+
+	var node any = cfg.Settings
+	for _, k := range target.Setting {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return "", nil
+		}
+		node = m[k]
+	}
+	switch v := node.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("%s is not a string value", target)
+	}
+}
+
+func setSecretTargetValue(cfg *config.Config, target SecretTarget, value string) error {
+	if target.Server != "" {
+		var server map[string]any
+		if err := json.Unmarshal(cfg.MCP[target.Server], &server); err != nil {
+			return fmt.Errorf("parsing MCP server %q: %w", target.Server, err)
+		}
+		env, _ := server["env"].(map[string]any)
+		if env == nil {
+			env = make(map[string]any)
+		}
+		env[target.EnvKey] = value
+		server["env"] = env
+		data, err := json.Marshal(server)
+		if err != nil {
+			return err
+		}
+		cfg.MCP[target.Server] = json.RawMessage(data)
+		return nil
+	}
+
+	if cfg.Settings == nil {
+		cfg.Settings = make(map[string]any)
+	}
+	m := cfg.Settings
+	for _, k := range target.Setting[:len(target.Setting)-1] {
+		child, ok := m[k].(map[string]any)
+		if !ok {
+			if m[k] != nil {
+				return fmt.Errorf("%s: %s is not a mapping", target, k)
+			}
+			child = make(map[string]any)
+			m[k] = child
+		}
+		m = child
+	}
+	m[target.Setting[len(target.Setting)-1]] = value
+	return nil
+}
+
+func writeSecretsConfig(syncDir string, cfg config.Config, message string) error {
+	newData, err := config.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(syncDir, "config.yaml"), newData, 0644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := git.Add(syncDir, "config.yaml"); err != nil {
+		return fmt.Errorf("staging changes: %w", err)
+	}
+	if err := git.Commit(syncDir, message); err != nil {
+		return fmt.Errorf("committing: %w", err)
+	}
+	return nil
+}
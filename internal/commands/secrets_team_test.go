@@ -0,0 +1,211 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/claudecode"
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretTarget(t *testing.T) {
+	target, err := commands.ParseSecretTarget("mcp.internal.api.env.TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "internal.api", target.Server)
+	assert.Equal(t, "TOKEN", target.EnvKey)
+	assert.Equal(t, "mcp.internal.api.env.TOKEN", target.String())
+
+	target, err = commands.ParseSecretTarget("settings.env.INTERNAL_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env", "INTERNAL_TOKEN"}, target.Setting)
+
+	for _, bad := range []string{"mcp.server", "mcp.server.env.", "settings.", "settings.a..b", "hooks.x"} {
+		_, err := commands.ParseSecretTarget(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSecretsShare_PullDecryptsForRecipients(t *testing.T) {
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+mcp:
+  internal-api:
+    command: internal-mcp
+    env:
+      TOKEN: tok-123
+`
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "work", profiles.Profile{}, false)
+	keys := t.TempDir()
+	useIdentity := func(name string) string {
+		t.Helper()
+		t.Setenv("CLAUDE_SYNC_IDENTITY", filepath.Join(keys, name+".txt"))
+		key, err := commands.SecretsPublicKey()
+		require.NoError(t, err)
+		return key
+	}
+
+	aliceKey := useIdentity("alice")
+	bobKey := useIdentity("bob")
+	useIdentity("carol")
+
+	// Alice bootstraps the recipient list and encrypts two values.
+	useIdentity("alice")
+	n, err := commands.SecretsAddRecipient(syncDir, "alice", aliceKey)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	mcpTarget, err := commands.ParseSecretTarget("mcp.internal-api.env.TOKEN")
+	require.NoError(t, err)
+	require.NoError(t, commands.SecretsShare(syncDir, mcpTarget, ""))
+	settingTarget, err := commands.ParseSecretTarget("settings.env.INTERNAL_TOKEN")
+	require.NoError(t, err)
+	require.NoError(t, commands.SecretsShare(syncDir, settingTarget, "s3cret"))
+	assert.Error(t, commands.SecretsShare(syncDir, mcpTarget, ""), "already encrypted")
+
+	// Adding Bob re-encrypts both values to include him.
+	n, err = commands.SecretsAddRecipient(syncDir, "bob", bobKey)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(cfgData), "tok-123")
+	assert.NotContains(t, string(cfgData), "s3cret")
+	assert.Contains(t, string(cfgData), "ENC[age:")
+	assert.Contains(t, string(cfgData), bobKey)
+
+	// Bob's pull decrypts both.
+	useIdentity("bob")
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.SecretWarnings)
+	assert.Empty(t, result.MCPEnvWarnings)
+
+	settingsData, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	var settings struct {
+		Env map[string]string `json:"env"`
+	}
+	require.NoError(t, json.Unmarshal(settingsData, &settings))
+	assert.Equal(t, "s3cret", settings.Env["INTERNAL_TOKEN"])
+
+	mcpData, err := os.ReadFile(filepath.Join(claudeDir, ".mcp.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(mcpData), "tok-123")
+
+	// Re-capturing MCP config keeps the ciphertext instead of the plaintext.
+	scan, err := commands.PushScan(claudeDir, syncDir)
+	require.NoError(t, err)
+	assert.False(t, scan.ChangedMCP)
+
+	// Carol isn't a recipient: nothing is decrypted or applied.
+	useIdentity("carol")
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	require.Len(t, result.SecretWarnings, 1)
+	assert.Contains(t, result.SecretWarnings[0], "settings.env.INTERNAL_TOKEN")
+	require.Len(t, result.MCPEnvWarnings, 1)
+	assert.Contains(t, result.MCPEnvWarnings[0], "not encrypted to your key")
+
+	// Carol can't re-encrypt what she can't read.
+	_, err = commands.SecretsRemoveRecipient(syncDir, "bob")
+	assert.Error(t, err)
+}
+
+func TestPull_SettingsConflictsKeepSecretsEncrypted(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	script := filepath.Join(home, ".claude", "statusline.sh")
+	require.NoError(t, os.MkdirAll(filepath.Dir(script), 0755))
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n"), 0755))
+
+	configYAML := `version: "1.0.0"
+plugins:
+  upstream: []
+settings:
+  env:
+    REGION: us
+  statusLine:
+    type: command
+    command: ~/.claude/statusline.sh
+`
+	claudeDir, syncDir := setupPullEnvWithProfile(t, configYAML, "work", profiles.Profile{}, false)
+	require.NoError(t, os.WriteFile(filepath.Join(syncDir, ".gitignore"), nil, 0644))
+	t.Setenv("CLAUDE_SYNC_IDENTITY", filepath.Join(t.TempDir(), "alice.txt"))
+	key, err := commands.SecretsPublicKey()
+	require.NoError(t, err)
+	_, err = commands.SecretsAddRecipient(syncDir, "alice", key)
+	require.NoError(t, err)
+	target, err := commands.ParseSecretTarget("settings.env.INTERNAL_TOKEN")
+	require.NoError(t, err)
+	require.NoError(t, commands.SecretsShare(syncDir, target, "s3cret"))
+
+	_, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+
+	editLocal := func(key, value string) {
+		t.Helper()
+		settings, err := claudecode.ReadSettings(claudeDir)
+		require.NoError(t, err)
+		settings[key] = json.RawMessage(value)
+		require.NoError(t, claudecode.WriteSettings(claudeDir, settings))
+	}
+	editUpstream := func(old, new string) {
+		t.Helper()
+		cfgPath := filepath.Join(syncDir, "config.yaml")
+		data, err := os.ReadFile(cfgPath)
+		require.NoError(t, err)
+		require.Contains(t, string(data), old)
+		require.NoError(t, os.WriteFile(cfgPath, []byte(strings.Replace(string(data), old, new, 1)), 0644))
+	}
+	readConflicts := func() string {
+		t.Helper()
+		conflicts, err := commands.ListPendingConflicts(syncDir)
+		require.NoError(t, err)
+		var all string
+		for _, c := range conflicts {
+			all += string(c.LocalValue) + string(c.RemoteValue)
+		}
+		return all
+	}
+
+	// Both sides change statusLine; only upstream changes env. The env
+	// change is applied decrypted, the statusLine conflict is recorded with
+	// ~/ paths.
+	editLocal("statusLine", `{"type":"command","command":"`+script+` --local"}`)
+	editUpstream("REGION: us", "REGION: eu")
+	editUpstream("command: ~/.claude/statusline.sh", "command: ~/.claude/statusline.sh --team")
+	result, err := commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"settings.statusLine"}, result.SettingsConflicts)
+
+	settings, err := claudecode.ReadSettings(claudeDir)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"REGION":"eu","INTERNAL_TOKEN":"s3cret"}`, string(settings["env"]))
+	assert.JSONEq(t, `{"type":"command","command":"`+script+` --local"}`, string(settings["statusLine"]))
+	conflicts := readConflicts()
+	assert.Contains(t, conflicts, "~/.claude/statusline.sh --local")
+	assert.NotContains(t, conflicts, home)
+
+	// Both sides change env: the conflict keeps the team secret encrypted.
+	editLocal("env", `{"REGION":"ap","INTERNAL_TOKEN":"s3cret"}`)
+	editUpstream("REGION: eu", "REGION: sa")
+	result, err = commands.Pull(claudeDir, syncDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"settings.env"}, result.SettingsConflicts)
+	conflicts = readConflicts()
+	assert.Contains(t, conflicts, "ENC[age:")
+	assert.NotContains(t, conflicts, "s3cret")
+
+	gitignore, err := os.ReadFile(filepath.Join(syncDir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Contains(t, string(gitignore), "conflicts/")
+}
@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ruminaider/claude-sync/internal/claudecode"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/merge"
+	"github.com/ruminaider/claude-sync/internal/secrets"
+)
+
+// settingsMergeResult is the outcome of merging local settings.json edits
+// with the settings pull would have written.
+type settingsMergeResult struct {
+	applied      []string
+	hooksApplied []string
+	hooksSkipped []string
+	conflicts    []PendingConflict
+	remote       string // what pull would have written over base, the next base
+}
+
+// mergeLocalSettings three-way merges settings.json when it was edited
+// after pull last wrote base: the remote side is cfg applied to base, the
+// local side is the file as it is now. Changes on one side are kept; keys
+// both sides changed differently keep the local value and are returned as
+// conflicts. Permissions are left to applyPermissions.
+//
+// cfg holds settings as config.yaml does, with team secrets encrypted and
+// script paths under ~/. The merge runs in that form, so recorded conflicts
+// never hold a decrypted secret or this machine's home directory; only the
+// merged file is decrypted and expanded.
+func mergeLocalSettings(claudeDir, base string, cfg config.Config, dec secrets.Decrypter) (*settingsMergeResult, error) {
+	var baseMap map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(base), &baseMap); err != nil {
+		return nil, fmt.Errorf("parsing last applied settings: %w", err)
+	}
+	local, err := claudecode.ReadSettings(claudeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string]json.RawMessage, len(baseMap))
+	for k, v := range baseMap {
+		remote[k] = v
+	}
+	applied, hooksApplied, hooksSkipped, err := applySettingsTo(remote, cfg)
+	if err != nil {
+		return nil, err
+	}
+	remote, err = toConfigForm(remote, remote, dec)
+	if err != nil {
+		return nil, err
+	}
+	if baseMap, err = toConfigForm(baseMap, remote, dec); err != nil {
+		return nil, err
+	}
+	if local, err = toConfigForm(local, remote, dec); err != nil {
+		return nil, err
+	}
+
+	merged, conflicts, err := mergeSettingsMaps(baseMap, local, remote)
+	if err != nil {
+		return nil, err
+	}
+	if merged, err = toSettingsForm(merged, dec); err != nil {
+		return nil, err
+	}
+	if err := claudecode.WriteSettings(claudeDir, merged); err != nil {
+		return nil, err
+	}
+	// The next base is what pull would have written, as settings.json holds it.
+	remoteFile, err := toSettingsForm(remote, dec)
+	if err != nil {
+		return nil, err
+	}
+	remoteData, err := json.MarshalIndent(remoteFile, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling settings: %w", err)
+	}
+
+	// Report only what changed upstream and made it into the file.
+	result := &settingsMergeResult{hooksSkipped: hooksSkipped, conflicts: conflicts, remote: string(remoteData) + "\n"}
+	conflicted := func(key string) bool {
+		return slices.ContainsFunc(conflicts, func(c PendingConflict) bool { return c.Key == key })
+	}
+	for _, key := range applied {
+		if !jsonEquivalent(baseMap[key], remote[key]) && !conflicted("settings."+key) {
+			result.applied = append(result.applied, key)
+		}
+	}
+	baseHooks, remoteHooks := hookEvents(baseMap), hookEvents(remote)
+	for _, event := range hooksApplied {
+		if !bytes.Equal(baseHooks[event], remoteHooks[event]) && !conflicted("hooks."+event) {
+			result.hooksApplied = append(result.hooksApplied, event)
+		}
+	}
+	return result, nil
+}
+
+// toConfigForm returns a settings.json map in the form config.yaml holds
+// settings: script paths under $HOME become ~/ paths, and a string equal to
+// the decrypted team secret at the same place in remote becomes that
+// ciphertext.
+func toConfigForm(m, remote map[string]json.RawMessage, dec secrets.Decrypter) (map[string]json.RawMessage, error) {
+	settings, hooks := splitSettings(m)
+	settings, hooks = NormalizeScriptPaths(settings, hooks)
+
+	remoteSettings, _ := splitSettings(remote)
+	ciphertexts := make(map[string]string)
+	plaintexts := make(map[string]string)
+	mapSettingStrings(remoteSettings, "settings", func(where, v string) (string, bool) {
+		if secrets.IsEncrypted(v) {
+			if plain, err := dec.Decrypt(v); err == nil {
+				ciphertexts[where], plaintexts[where] = v, plain
+			}
+		}
+		return v, true
+	})
+	if len(ciphertexts) > 0 {
+		settings, _ = mapSettingStrings(settings, "settings", func(where, v string) (string, bool) {
+			if plain, ok := plaintexts[where]; ok && plain == v {
+				return ciphertexts[where], true
+			}
+			return v, true
+		}).(map[string]any)
+	}
+	return joinSettings(m, settings, hooks)
+}
+
+// toSettingsForm is the inverse of toConfigForm: it decrypts team secrets,
+// dropping those that can't be decrypted, and expands ~/ script paths.
+func toSettingsForm(m map[string]json.RawMessage, dec secrets.Decrypter) (map[string]json.RawMessage, error) {
+	settings, hooks := splitSettings(m)
+	settings, _ = decryptSettings(settings, dec)
+	settings, hooks = ExpandScriptPaths(settings, hooks)
+	return joinSettings(m, settings, hooks)
+}
+
+// splitSettings decodes the keys of a settings.json map other than hooks
+// and permissions, and the hooks by event.
+func splitSettings(m map[string]json.RawMessage) (map[string]any, map[string]json.RawMessage) {
+	settings := make(map[string]any, len(m))
+	for k, raw := range m {
+		if k == "hooks" || k == "permissions" {
+			continue
+		}
+		var v any
+		if json.Unmarshal(raw, &v) == nil {
+			settings[k] = v
+		}
+	}
+	return settings, hookEvents(m)
+}
+
+// joinSettings is the inverse of splitSettings, taking permissions from m.
+// Keys of m missing from settings are dropped.
+func joinSettings(m map[string]json.RawMessage, settings map[string]any, hooks map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	out := make(map[string]json.RawMessage, len(m))
+	for k, raw := range m {
+		if k != "hooks" {
+			out[k] = raw
+		}
+	}
+	for k := range m {
+		if k == "hooks" || k == "permissions" {
+			continue
+		}
+		v, ok := settings[k]
+		if !ok {
+			delete(out, k)
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling setting %q: %w", k, err)
+		}
+		out[k] = data
+	}
+	if len(hooks) > 0 {
+		data, err := json.Marshal(hooks)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling hooks: %w", err)
+		}
+		out["hooks"] = data
+	}
+	return out, nil
+}
+
+// jsonEquivalent reports whether a and b hold the same JSON value.
+func jsonEquivalent(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// mergeSettingsMaps merges parsed settings.json files key by key, and the
+// hooks key event by event. Conflicting keys keep the local value.
+func mergeSettingsMaps(base, local, remote map[string]json.RawMessage) (map[string]json.RawMessage, []PendingConflict, error) {
+	toValues := func(m map[string]json.RawMessage) map[string]any {
+		values := make(map[string]any, len(m))
+		for k, raw := range m {
+			if k == "hooks" || k == "permissions" {
+				continue
+			}
+			var v any
+			if json.Unmarshal(raw, &v) == nil {
+				values[k] = v
+			}
+		}
+		return values
+	}
+	mergedValues, settingConflicts := merge.MergeSettings(toValues(base), toValues(local), toValues(remote))
+
+	merged := make(map[string]json.RawMessage, len(mergedValues)+2)
+	for k, v := range mergedValues {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling setting %q: %w", k, err)
+		}
+		merged[k] = data
+	}
+	if perms, ok := local["permissions"]; ok {
+		merged["permissions"] = perms
+	}
+
+	mergedHooks, hookConflicts := merge.MergeHooks(hookEvents(base), hookEvents(local), hookEvents(remote))
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var conflicts []PendingConflict
+	for _, c := range settingConflicts {
+		if raw, ok := local[c.Key]; ok {
+			merged[c.Key] = raw
+		} else {
+			delete(merged, c.Key)
+		}
+		conflicts = append(conflicts, settingsConflict(now, "settings."+c.Key, local[c.Key], remote[c.Key]))
+	}
+	localHooks, remoteHooks := hookEvents(local), hookEvents(remote)
+	for _, c := range hookConflicts {
+		if raw, ok := localHooks[c.Key]; ok {
+			mergedHooks[c.Key] = raw
+		} else {
+			delete(mergedHooks, c.Key)
+		}
+		conflicts = append(conflicts, settingsConflict(now, "hooks."+c.Key, localHooks[c.Key], remoteHooks[c.Key]))
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+
+	if len(mergedHooks) > 0 {
+		data, err := json.Marshal(mergedHooks)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling hooks: %w", err)
+		}
+		merged["hooks"] = data
+	}
+	return merged, conflicts, nil
+}
+
+// hookEvents parses the hooks key of a settings.json map, compacting each
+// event so formatting differences don't read as changes.
+func hookEvents(settings map[string]json.RawMessage) map[string]json.RawMessage {
+	var events map[string]json.RawMessage
+	if raw, ok := settings["hooks"]; ok {
+		json.Unmarshal(raw, &events)
+	}
+	for event, raw := range events {
+		var buf bytes.Buffer
+		if json.Compact(&buf, raw) == nil {
+			events[event] = buf.Bytes()
+		}
+	}
+	return events
+}
+
+func settingsConflict(timestamp, key string, local, remote json.RawMessage) PendingConflict {
+	return PendingConflict{
+		Timestamp:   timestamp,
+		Source:      "settings.json",
+		Key:         key,
+		LocalValue:  local,
+		RemoteValue: remote,
+	}
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/git"
+	"github.com/ruminaider/claude-sync/internal/signing"
+)
+
+// trustedSignersFileName is the copy of the allow-list taken the last time
+// the config repo's HEAD verified. It is gitignored: it is what this machine
+// trusts, and an unsigned commit must not be able to change it.
+const trustedSignersFileName = ".trusted-signers"
+
+// UntrustedSource is the config repo or a subscription whose HEAD commit
+// isn't signed by an allowed key. Pull doesn't apply its hooks, MCP servers
+// or permissions.
+type UntrustedSource struct {
+	Source string // "config repo" or "subscription <name>"
+	signing.Verification
+	// Projects are the project directories whose projected hooks, MCP
+	// servers and permissions weren't applied either.
+	Projects []string
+}
+
+func (u UntrustedSource) String() string {
+	return u.Source + ": " + u.Reason()
+}
+
+// signingPolicy returns the allow-list commits are verified against, or nil
+// when config.yaml has no signing section. Once a commit has verified, the
+// trusted copy taken then is used rather than the repo's file, so an
+// unsigned commit can't edit the list or turn verification off. Until then
+// the repo's file is trusted as it is.
+func signingPolicy(syncDir string) (*signing.Policy, error) {
+	if data, err := os.ReadFile(filepath.Join(syncDir, trustedSignersFileName)); err == nil {
+		return signing.ParsePolicy(data)
+	}
+	data, err := readAllowedSigners(syncDir)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return signing.ParsePolicy(data)
+}
+
+// readAllowedSigners reads the allow-list config.yaml names, or returns nil
+// if it names none.
+func readAllowedSigners(syncDir string) ([]byte, error) {
+	cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+	if err != nil {
+		return nil, nil
+	}
+	cfg, err := config.Parse(cfgData)
+	if err != nil || cfg.Signing.AllowedSigners == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(syncDir, cfg.Signing.AllowedSigners))
+	if err != nil {
+		return nil, fmt.Errorf("reading allowed signers: %w", err)
+	}
+	return data, nil
+}
+
+// verifiedRevision returns the config repo commit whose signature decides
+// whether it is trusted: the newest upstream commit HEAD contains. Commits
+// made on this machine, by the user or by claude-sync itself (auto-commits,
+// subscription locks, merges), aren't signed but didn't come from the
+// remote either. Without an upstream, it is HEAD.
+func verifiedRevision(syncDir string) string {
+	if git.HasUpstream(syncDir) {
+		if base, err := git.MergeBase(syncDir, "HEAD", "@{u}"); err == nil {
+			return base
+		}
+	}
+	return "HEAD"
+}
+
+// verifyConfigRepo checks the config repo's latest upstream commit (see
+// verifiedRevision) against policy. When it verifies, the repo's allow-list
+// becomes the trusted copy, so signed changes to the list (or turning
+// verification off) take effect.
+func verifyConfigRepo(syncDir string, policy *signing.Policy) (*UntrustedSource, error) {
+	v, err := signing.Verify(syncDir, verifiedRevision(syncDir), policy)
+	if err != nil {
+		return nil, err
+	}
+	if !v.Trusted() {
+		return &UntrustedSource{Source: "config repo", Verification: v}, nil
+	}
+
+	trustedPath := filepath.Join(syncDir, trustedSignersFileName)
+	data, err := readAllowedSigners(syncDir)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		if err := os.Remove(trustedPath); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if _, err := signing.ParsePolicy(data); err != nil {
+		return nil, err
+	}
+	ensureGitignorePatterns(syncDir, []string{trustedSignersFileName})
+	return nil, os.WriteFile(trustedPath, data, 0644)
+}
+
+// withoutHighRiskKeys returns the projected keys other than hooks, mcp and
+// permissions.
+func withoutHighRiskKeys(keys []string) []string {
+	var out []string
+	for _, k := range keys {
+		if k != "hooks" && k != "mcp" && k != "permissions" {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// withoutHighRisk returns a copy of sub that selects no hooks, MCP servers
+// or permissions.
+func withoutHighRisk(sub config.SubscriptionEntry) config.SubscriptionEntry {
+	categories := make(map[string]any, len(sub.Categories)+3)
+	for k, v := range sub.Categories {
+		categories[k] = v
+	}
+	include := make(map[string][]string, len(sub.Include))
+	for k, v := range sub.Include {
+		include[k] = v
+	}
+	for _, cat := range []string{"hooks", "mcp", "permissions"} {
+		categories[cat] = "none"
+		delete(include, cat)
+	}
+	sub.Categories = categories
+	sub.Include = include
+	return sub
+}
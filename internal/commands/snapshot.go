@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/paths"
+	"github.com/ruminaider/claude-sync/internal/project"
+	"github.com/ruminaider/claude-sync/internal/snapshot"
+)
+
+// snapshotsDirName is the directory under the sync dir holding pre-pull
+// snapshots. It is gitignored: snapshots are per-machine.
+const snapshotsDirName = "snapshots"
+
+// SnapshotDir returns the snapshot root for syncDir.
+func SnapshotDir(syncDir string) string {
+	return filepath.Join(syncDir, snapshotsDirName)
+}
+
+// SnapshotList returns the snapshots for syncDir, newest first.
+func SnapshotList(syncDir string) ([]snapshot.Manifest, error) {
+	return snapshot.List(SnapshotDir(syncDir))
+}
+
+// SnapshotShow returns snapshot id and how each captured file differs from
+// the current disk state.
+func SnapshotShow(syncDir, id string) (*snapshot.Manifest, []snapshot.FileStatus, error) {
+	m, err := snapshot.Load(SnapshotDir(syncDir), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, m.Compare(), nil
+}
+
+// SnapshotRestore puts the files captured by snapshot id back in place.
+func SnapshotRestore(syncDir, id string) (*snapshot.RestoreResult, error) {
+	return snapshot.Restore(SnapshotDir(syncDir), id)
+}
+
+// takePullSnapshot captures every local file pull may write, then prunes
+// old snapshots down to the configured limit.
+func takePullSnapshot(claudeDir, syncDir, projectDir string, hashes *AppliedHashes) (*snapshot.Manifest, error) {
+	ensureGitignorePatterns(syncDir, []string{snapshotsDirName + "/"})
+
+	targets := []string{
+		filepath.Join(claudeDir, "settings.json"),
+		filepath.Join(claudeDir, "CLAUDE.md"),
+		filepath.Join(claudeDir, "keybindings.json"),
+		filepath.Join(claudeDir, ".mcp.json"),
+		filepath.Join(claudeDir, "commands"),
+		filepath.Join(claudeDir, "skills"),
+		filepath.Join(claudeDir, "agents"),
+		filepath.Join(claudeDir, "output-styles"),
+		paths.ClaudeMemoryDir(),
+	}
+	if instances, ok := paths.CCSInstances(); ok {
+		for _, inst := range instances {
+			targets = append(targets, filepath.Join(inst, "settings.json"), paths.CCSInstanceMemoryDir(inst))
+		}
+	}
+	if hashes != nil {
+		for mcpPath := range hashes.MCP {
+			targets = append(targets, mcpPath)
+		}
+	}
+	if cfgData, err := os.ReadFile(filepath.Join(syncDir, "config.yaml")); err == nil {
+		if cfg, err := config.Parse(cfgData); err == nil {
+			for _, meta := range cfg.MCPMeta {
+				if meta.SourceProject != "" {
+					targets = append(targets, filepath.Join(meta.SourceProject, ".mcp.json"))
+				}
+			}
+			for _, script := range cfg.Scripts {
+				targets = append(targets, expandHome(script))
+			}
+			for _, p := range syncedLocalProjects(claudeDir, cfg.Memory.Projects) {
+				targets = append(targets, p.MemoryDir)
+			}
+		}
+	}
+	if projectDir == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			projectDir = findProjectRoot(cwd)
+		}
+	}
+	if projectDir != "" {
+		targets = append(targets,
+			filepath.Join(projectDir, ".claude", "settings.local.json"),
+			filepath.Join(projectDir, ".claude", project.ConfigFileName),
+		)
+	}
+
+	root := SnapshotDir(syncDir)
+	m, err := snapshot.Take(root, targets, "pull")
+	if err != nil {
+		return nil, err
+	}
+
+	keep := snapshot.DefaultKeep
+	if prefsData, err := os.ReadFile(filepath.Join(syncDir, "user-preferences.yaml")); err == nil {
+		if prefs, err := config.ParseUserPreferences(prefsData); err == nil && prefs.Sync.SnapshotKeep > 0 {
+			keep = prefs.Sync.SnapshotKeep
+		}
+	}
+	if _, err := snapshot.Prune(root, keep); err != nil {
+		return m, err
+	}
+	return m, nil
+}
@@ -51,11 +51,11 @@ type ProjectInfo struct {
 // MenuState holds the detected state used to build the TUI menu.
 type MenuState struct {
 	// Existing fields
-	ConfigExists  bool
-	HasPending    bool
-	HasConflicts  bool
-	Profiles      []string
-	ActiveProfile string
+	ConfigExists   bool
+	HasPending     bool
+	HasConflicts   bool
+	Profiles       []string
+	ActiveProfiles []string // active profiles in application order
 
 	// Dashboard fields
 	ConfigRepo     string       // remote URL or repo shortname
@@ -109,10 +109,10 @@ func detectMenuStateWithPwd(claudeDir, syncDir, pwd string) MenuState {
 		state.Profiles = profileList
 	}
 
-	// Check active profile
-	active, err := profiles.ReadActiveProfile(syncDir)
+	// Check active profiles
+	active, err := profiles.ReadActiveProfiles(syncDir)
 	if err == nil {
-		state.ActiveProfile = active
+		state.ActiveProfiles = active
 	}
 
 	// Parse config.yaml for plugins, MCP count
@@ -49,7 +49,7 @@ func TestDetectMenuState_NotInitialized(t *testing.T) {
 	assert.False(t, state.HasPending)
 	assert.False(t, state.HasConflicts)
 	assert.Empty(t, state.Profiles)
-	assert.Empty(t, state.ActiveProfile)
+	assert.Empty(t, state.ActiveProfiles)
 }
 
 func TestDetectMenuState_Initialized(t *testing.T) {
@@ -86,7 +86,7 @@ func TestDetectMenuState_WithProfiles(t *testing.T) {
 	assert.True(t, state.ConfigExists)
 	assert.Contains(t, state.Profiles, "work")
 	assert.Contains(t, state.Profiles, "personal")
-	assert.Equal(t, "work", state.ActiveProfile)
+	assert.Equal(t, []string{"work"}, state.ActiveProfiles)
 }
 
 func TestDetectMenuState_WithPendingChanges(t *testing.T) {
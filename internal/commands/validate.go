@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/claudemd"
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/memory"
+	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/ruminaider/claude-sync/internal/project"
+	"github.com/ruminaider/claude-sync/internal/schema"
+	"go.yaml.in/yaml/v3"
+)
+
+// ValidationIssue is a problem found by ValidateSyncDir or ValidateFile.
+type ValidationIssue struct {
+	File string // as given, or relative to the sync directory
+	schema.Issue
+}
+
+func (i ValidationIssue) String() string {
+	return i.File + ":" + i.Issue.String()
+}
+
+// ValidateSyncDir checks every file in a config repo: config.yaml,
+// profiles/*.yaml, profile-rules.yaml and user-preferences.yaml. Besides
+// the schema, it checks what a schema can't see: hooks that look like JSON
+// but don't parse, profile removes that match nothing, include lists naming
+// fragments that don't exist, and rules naming missing profiles.
+func ValidateSyncDir(syncDir string) ([]ValidationIssue, error) {
+	if _, err := os.Stat(filepath.Join(syncDir, "config.yaml")); err != nil {
+		return nil, fmt.Errorf("no config.yaml in %s", syncDir)
+	}
+
+	files := []string{"config.yaml"}
+	names, err := profiles.ListProfiles(syncDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		files = append(files, filepath.Join("profiles", name+".yaml"))
+	}
+	for _, name := range []string{"profile-rules.yaml", "user-preferences.yaml"} {
+		if _, err := os.Stat(filepath.Join(syncDir, name)); err == nil {
+			files = append(files, name)
+		}
+	}
+
+	v := validator{syncDir: syncDir}
+	var issues []ValidationIssue
+	for _, file := range files {
+		kind, _ := schemaKindFor(file)
+		found, err := v.file(filepath.Join(syncDir, file), file, kind)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+// ValidateFile checks a single file, telling its kind from its name. Files
+// inside a config repo also get the checks that need the rest of the repo.
+func ValidateFile(path string) ([]ValidationIssue, error) {
+	kind, ok := schemaKindFor(path)
+	if !ok {
+		return nil, fmt.Errorf("%s: can't tell what kind of file this is; expected config.yaml, profiles/<name>.yaml, profile-rules.yaml, user-preferences.yaml or .claude-sync.yaml", path)
+	}
+	v := validator{}
+	switch kind {
+	case schema.Config, schema.ProfileRules:
+		v.syncDir = filepath.Dir(path)
+	case schema.Profile:
+		v.syncDir = filepath.Dir(filepath.Dir(path))
+	}
+	if v.syncDir != "" {
+		if _, err := os.Stat(filepath.Join(v.syncDir, "config.yaml")); err != nil {
+			v.syncDir = ""
+		}
+	}
+	return v.file(path, path, kind)
+}
+
+// schemaKindFor maps a file name to the schema it is checked against.
+func schemaKindFor(path string) (schema.Kind, bool) {
+	base := filepath.Base(path)
+	switch {
+	case base == "config.yaml":
+		return schema.Config, true
+	case base == "profile-rules.yaml":
+		return schema.ProfileRules, true
+	case base == "user-preferences.yaml":
+		return schema.UserPreferences, true
+	case base == project.ConfigFileName:
+		return schema.Project, true
+	case filepath.Base(filepath.Dir(path)) == "profiles" && strings.HasSuffix(base, ".yaml"):
+		return schema.Profile, true
+	}
+	return "", false
+}
+
+// validator runs the checks for one config repo. syncDir is empty when a
+// file is checked on its own, which skips the checks that need the repo.
+type validator struct {
+	syncDir string
+
+	cfg       *config.Config
+	cfgLoaded bool
+}
+
+func (v *validator) file(path, display string, kind schema.Kind) ([]ValidationIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	found, err := schema.Validate(kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra []schema.Issue
+	switch kind {
+	case schema.Config:
+		extra = v.checkConfig(data)
+	case schema.Profile:
+		extra = v.checkProfile(strings.TrimSuffix(filepath.Base(path), ".yaml"), data)
+	case schema.ProfileRules:
+		extra = v.checkRules(data)
+	}
+	// Parser errors usually repeat what the schema already said.
+	for _, issue := range extra {
+		if issue.Path == "" && len(found) > 0 {
+			continue
+		}
+		found = append(found, issue)
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Line != found[j].Line {
+			return found[i].Line < found[j].Line
+		}
+		return found[i].Column < found[j].Column
+	})
+
+	issues := make([]ValidationIssue, len(found))
+	for i, issue := range found {
+		issues[i] = ValidationIssue{File: display, Issue: issue}
+	}
+	return issues, nil
+}
+
+// config returns the repo's parsed config.yaml, or nil if there is no repo
+// or it doesn't parse.
+func (v *validator) config() *config.Config {
+	if v.cfgLoaded || v.syncDir == "" {
+		return v.cfg
+	}
+	v.cfgLoaded = true
+	data, err := os.ReadFile(filepath.Join(v.syncDir, "config.yaml"))
+	if err != nil {
+		return nil
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil
+	}
+	v.cfg = &cfg
+	return v.cfg
+}
+
+func (v *validator) checkConfig(data []byte) []schema.Issue {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return []schema.Issue{{Line: 1, Column: 1, Message: err.Error()}}
+	}
+
+	var raw map[string]any
+	_ = yaml.Unmarshal(data, &raw)
+	issues := checkHookValues(data, raw["hooks"], "hooks")
+
+	if v.syncDir != "" {
+		for i, name := range cfg.ClaudeMD.Include {
+			issues = append(issues, v.checkClaudeMDFragment(data, name, "claude_md", "include", strconv.Itoa(i))...)
+		}
+		for i, name := range cfg.Memory.Include {
+			issues = append(issues, v.checkMemoryFragment(data, name, "memory", "include", strconv.Itoa(i))...)
+		}
+	}
+	return issues
+}
+
+func (v *validator) checkProfile(name string, data []byte) []schema.Issue {
+	p, err := profiles.ParseProfile(data)
+	if err != nil {
+		return []schema.Issue{{Line: 1, Column: 1, Message: err.Error()}}
+	}
+
+	var raw map[string]any
+	_ = yaml.Unmarshal(data, &raw)
+	var issues []schema.Issue
+	if hooks, ok := raw["hooks"].(map[string]any); ok {
+		issues = append(issues, checkHookValues(data, hooks["add"], "hooks", "add")...)
+	}
+
+	if v.syncDir == "" {
+		return issues
+	}
+	for i, name := range p.ClaudeMD.Add {
+		issues = append(issues, v.checkClaudeMDFragment(data, name, "claude_md", "add", strconv.Itoa(i))...)
+	}
+	for i, name := range p.Memory.Add {
+		issues = append(issues, v.checkMemoryFragment(data, name, "memory", "add", strconv.Itoa(i))...)
+	}
+
+	cfg := v.config()
+	if cfg == nil {
+		return issues
+	}
+	resolved, err := profiles.ResolveProfile(v.syncDir, name)
+	if err != nil {
+		return append(issues, schema.At(data, err.Error(), "extends"))
+	}
+	inherited := resolved.Inherited
+
+	check := func(section string, remove []string, known ...[]string) {
+		have := make(map[string]bool)
+		for _, list := range known {
+			for _, item := range list {
+				have[item] = true
+			}
+		}
+		for i, item := range remove {
+			if !have[item] {
+				msg := fmt.Sprintf("removes %q, which isn't in config.yaml or added by a profile this one extends", item)
+				issues = append(issues, schema.At(data, msg, section, "remove", strconv.Itoa(i)))
+			}
+		}
+	}
+	check("plugins", p.Plugins.Remove, cfg.AllPluginKeys(), inherited.Plugins.Add)
+	check("hooks", p.Hooks.Remove, sortedKeys(cfg.Hooks), sortedKeys(inherited.Hooks.Add))
+	check("mcp", p.MCP.Remove, sortedKeys(cfg.MCP), sortedKeys(inherited.MCP.Add))
+	check("claude_md", p.ClaudeMD.Remove, cfg.ClaudeMD.Include, inherited.ClaudeMD.Add)
+	check("memory", p.Memory.Remove, cfg.Memory.Include, inherited.Memory.Add)
+	check("commands", p.Commands.Remove, cfg.Commands, inherited.Commands.Add)
+	check("skills", p.Skills.Remove, cfg.Skills, inherited.Skills.Add)
+	check("agents", p.Agents.Remove, cfg.Agents, inherited.Agents.Add)
+	check("output_styles", p.OutputStyles.Remove, cfg.OutputStyles, inherited.OutputStyles.Add)
+	return issues
+}
+
+func (v *validator) checkRules(data []byte) []schema.Issue {
+	var rules struct {
+		Rules []project.ProfileRule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return []schema.Issue{{Line: 1, Column: 1, Message: err.Error()}}
+	}
+	known, err := profiles.ListProfiles(v.syncDir)
+	if err != nil || v.syncDir == "" {
+		return nil
+	}
+	var issues []schema.Issue
+	for i, rule := range rules.Rules {
+		if rule.Profile != "" && !containsString(known, rule.Profile) {
+			msg := fmt.Sprintf("unknown profile %q", rule.Profile)
+			issues = append(issues, schema.At(data, msg, "rules", strconv.Itoa(i), "profile"))
+		}
+	}
+	return issues
+}
+
+func (v *validator) checkClaudeMDFragment(data []byte, name string, loc ...string) []schema.Issue {
+	if _, err := claudemd.ReadFragment(filepath.Join(v.syncDir, "claude-md"), name); errors.Is(err, os.ErrNotExist) {
+		return []schema.Issue{schema.At(data, fmt.Sprintf("CLAUDE.md fragment %q not found in claude-md/", name), loc...)}
+	}
+	return nil
+}
+
+func (v *validator) checkMemoryFragment(data []byte, name string, loc ...string) []schema.Issue {
+	if _, err := memory.ReadFragment(filepath.Join(v.syncDir, "memory"), name); errors.Is(err, os.ErrNotExist) {
+		return []schema.Issue{schema.At(data, fmt.Sprintf("memory fragment %q not found in memory/", name), loc...)}
+	}
+	return nil
+}
+
+// checkHookValues reports hook values that look like JSON but don't parse.
+// The parsers treat those as shell commands, which is never what was meant.
+func checkHookValues(data []byte, hooks any, loc ...string) []schema.Issue {
+	m, ok := hooks.(map[string]any)
+	if !ok {
+		return nil
+	}
+	events := make([]string, 0, len(m))
+	for event := range m {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	var issues []schema.Issue
+	for _, event := range events {
+		value, path := m[event], append(append([]string(nil), loc...), event)
+		if cond, ok := value.(map[string]any); ok {
+			value, path = cond["value"], append(path, "value")
+		}
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		trimmed := strings.TrimSpace(s)
+		if !strings.HasPrefix(trimmed, "[") && !strings.HasPrefix(trimmed, "{") {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+			msg := fmt.Sprintf("hook looks like JSON but doesn't parse (%v); it would run as a shell command", err)
+			issues = append(issues, schema.At(data, msg, path...))
+		} else if _, isList := v.([]any); !isList {
+			issues = append(issues, schema.At(data, "hook JSON must be a list of hook matchers; it would run as a shell command", path...))
+		}
+	}
+	return issues
+}
@@ -0,0 +1,115 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/commands"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func issueStrings(issues []commands.ValidationIssue) []string {
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = issue.String()
+	}
+	return out
+}
+
+func TestValidateSyncDir(t *testing.T) {
+	syncDir := t.TempDir()
+	writeFiles(t, syncDir, map[string]string{
+		"config.yaml": `version: "2.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+    - beads@beads-marketplace
+hooks:
+  PreToolUse: '[{"matcher": "Bash", "hooks": [{"type": "command", "command": "x"}'
+  Stop: notify
+claude_md:
+  include: [base, missing]
+memory:
+  include: [notes]
+`,
+		"claude-md/base.md": "# Base\n",
+		"memory/notes.md":   "notes\n",
+		"profiles/base.yaml": `plugins:
+  add: [extra@market]
+`,
+		"profiles/work.yaml": `extends: base
+plugins:
+  remove:
+    - extra@market
+    - beads@beads-marketplace
+    - nowhere@market
+hooks:
+  remove: [Stop, SessionStart]
+memory:
+  add: [gone]
+`,
+		"profile-rules.yaml": `rules:
+  - path: ~/work/**
+    profile: work
+  - remote: github.com/acme/*
+    profile: acme
+`,
+	})
+
+	issues, err := commands.ValidateSyncDir(syncDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`config.yaml:7:15: hooks.PreToolUse: hook looks like JSON but doesn't parse (unexpected end of JSON input); it would run as a shell command`,
+		`config.yaml:10:19: claude_md.include[1]: CLAUDE.md fragment "missing" not found in claude-md/`,
+		`profiles/work.yaml:6:7: plugins.remove[2]: removes "nowhere@market", which isn't in config.yaml or added by a profile this one extends`,
+		`profiles/work.yaml:8:18: hooks.remove[1]: removes "SessionStart", which isn't in config.yaml or added by a profile this one extends`,
+		`profiles/work.yaml:10:9: memory.add[0]: memory fragment "gone" not found in memory/`,
+		`profile-rules.yaml:5:14: rules[1].profile: unknown profile "acme"`,
+	}, issueStrings(issues))
+}
+
+func TestValidateSyncDir_Clean(t *testing.T) {
+	syncDir := t.TempDir()
+	writeFiles(t, syncDir, map[string]string{
+		"config.yaml": `version: "2.0.0"
+plugins:
+  upstream: [context7@claude-plugins-official]
+`,
+		"user-preferences.yaml": "sync_mode: union\n",
+	})
+
+	issues, err := commands.ValidateSyncDir(syncDir)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	_, err = commands.ValidateSyncDir(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".claude/.claude-sync.yaml": "version: \"1.0.0\"\nprofile: work\nproflie: typo\n",
+		"notes.yaml":                "a: 1\n",
+	})
+
+	issues, err := commands.ValidateFile(filepath.Join(dir, ".claude", ".claude-sync.yaml"))
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Equal(t, "unknown key", issues[0].Message)
+
+	_, err = commands.ValidateFile(filepath.Join(dir, "notes.yaml"))
+	assert.Error(t, err)
+}
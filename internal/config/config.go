@@ -3,9 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
+	"github.com/ruminaider/claude-sync/internal/yamledit"
 	"go.yaml.in/yaml/v3"
 )
 
@@ -20,9 +22,12 @@ type ClaudeMDConfig struct {
 	Include []string `yaml:"include,omitempty"`
 }
 
-// MemoryConfig holds memory fragment include paths.
+// MemoryConfig holds memory fragment include paths and the projects whose
+// memory is synced, by identity (github.com/acme/api, or
+// github.com/acme/mono#services/web for a subdirectory).
 type MemoryConfig struct {
-	Include []string `yaml:"include,omitempty"`
+	Include  []string `yaml:"include,omitempty"`
+	Projects []string `yaml:"projects,omitempty"`
 }
 
 // MCPServerMeta stores metadata about an imported MCP server.
@@ -38,6 +43,12 @@ type MarketplaceSource struct {
 	URL    string `yaml:"url,omitempty"`    // full URL for git
 }
 
+// SigningConfig turns on commit signature verification for the config repo
+// and its subscriptions. Stored in config.yaml under the "signing" key.
+type SigningConfig struct {
+	AllowedSigners string `yaml:"allowed_signers,omitempty"` // allow-list file, relative to the sync dir
+}
+
 // SubscriptionEntry represents a subscription to another team's config repo.
 // Stored in config.yaml under the "subscriptions" key.
 type SubscriptionEntry struct {
@@ -47,6 +58,7 @@ type SubscriptionEntry struct {
 	Exclude    map[string][]string `yaml:"exclude,omitempty"`
 	Include    map[string][]string `yaml:"include,omitempty"`
 	Prefer     map[string][]string `yaml:"prefer,omitempty"`
+	Lock       bool                `yaml:"lock,omitempty"` // stay on the commit in subscriptions.lock
 }
 
 // ConfigV2 represents ~/.claude-sync/config.yaml with categorized plugins.
@@ -66,8 +78,17 @@ type ConfigV2 struct {
 	Keybindings   map[string]any                `yaml:"-"`
 	Commands      []string                      `yaml:"-"`
 	Skills        []string                      `yaml:"-"`
+	Agents        []string                      `yaml:"-"`
+	OutputStyles  []string                      `yaml:"-"`
+	Scripts       []string                      `yaml:"-"` // ~/ paths of scripts settings and hooks run
+	Vars          map[string]any                `yaml:"-"` // CLAUDE.md template variables
 	Marketplaces  map[string]MarketplaceSource  `yaml:"-"`
 	Subscriptions map[string]SubscriptionEntry  `yaml:"-"`
+	When          map[string]Selector           `yaml:"-"` // WhenKey(section, name) -> selector; see ForMachine
+	Recipients    map[string]string             `yaml:"-"` // team member name -> age public key for ENC[age:...] values
+	Signing       SigningConfig                 `yaml:"-"`
+
+	source []byte // text Parse read, so MarshalV2 can preserve its layout
 }
 
 // ForkedMarketplace is the marketplace name for forked plugins.
@@ -127,6 +148,7 @@ func Parse(data []byte) (Config, error) {
 
 	var cfg Config
 	cfg.Pinned = make(map[string]string)
+	cfg.source = data
 
 	// Parse the mapping manually to handle plugins specially.
 	for i := 0; i < len(root.Content)-1; i += 2 {
@@ -141,18 +163,32 @@ func Parse(data []byte) (Config, error) {
 				return Config{}, fmt.Errorf("parsing config plugins: %w", err)
 			}
 		case "settings":
-			var settings map[string]any
-			if err := valNode.Decode(&settings); err != nil {
+			settings, err := ParseConditionalSettings(valNode, func(key string, sel Selector) {
+				cfg.setWhen(WhenSettings, key, sel)
+			})
+			if err != nil {
 				return Config{}, fmt.Errorf("parsing config settings: %w", err)
 			}
 			cfg.Settings = settings
 		case "hooks":
-			var hooks map[string]string
-			if err := valNode.Decode(&hooks); err != nil {
-				return Config{}, fmt.Errorf("parsing config hooks: %w", err)
+			if valNode.Kind != yaml.MappingNode {
+				var hooks map[string]string
+				if err := valNode.Decode(&hooks); err != nil {
+					return Config{}, fmt.Errorf("parsing config hooks: %w", err)
+				}
+				cfg.Hooks = make(map[string]json.RawMessage)
+				break
 			}
-			cfg.Hooks = make(map[string]json.RawMessage, len(hooks))
-			for k, v := range hooks {
+			cfg.Hooks = make(map[string]json.RawMessage, len(valNode.Content)/2)
+			for j := 0; j < len(valNode.Content)-1; j += 2 {
+				k := valNode.Content[j].Value
+				v, sel, err := ParseConditionalString(valNode.Content[j+1], "value")
+				if err != nil {
+					return Config{}, fmt.Errorf("parsing config hooks: %s: %w", k, err)
+				}
+				if sel != nil {
+					cfg.setWhen(WhenHooks, k, *sel)
+				}
 				if strings.HasPrefix(v, "[") && json.Valid([]byte(v)) {
 					cfg.Hooks[k] = json.RawMessage(v)
 				} else {
@@ -160,11 +196,9 @@ func Parse(data []byte) (Config, error) {
 				}
 			}
 		case "permissions":
-			var perms Permissions
-			if err := valNode.Decode(&perms); err != nil {
+			if err := parsePermissionsNode(valNode, &cfg); err != nil {
 				return Config{}, fmt.Errorf("parsing config permissions: %w", err)
 			}
-			cfg.Permissions = perms
 		case "claude_md":
 			var cmd ClaudeMDConfig
 			if err := valNode.Decode(&cmd); err != nil {
@@ -184,6 +218,13 @@ func Parse(data []byte) (Config, error) {
 			}
 			cfg.MCP = make(map[string]json.RawMessage, len(mcpRaw))
 			for k, v := range mcpRaw {
+				sel, err := SplitMCPWhen(v)
+				if err != nil {
+					return Config{}, fmt.Errorf("parsing config mcp %q: %w", k, err)
+				}
+				if sel != nil {
+					cfg.setWhen(WhenMCP, k, *sel)
+				}
 				data, err := json.Marshal(v)
 				if err != nil {
 					return Config{}, fmt.Errorf("encoding mcp entry %q: %w", k, err)
@@ -214,6 +255,35 @@ func Parse(data []byte) (Config, error) {
 				return Config{}, fmt.Errorf("parsing config skills: %w", err)
 			}
 			cfg.Skills = skills
+		case "agents":
+			var agents []string
+			if err := valNode.Decode(&agents); err != nil {
+				return Config{}, fmt.Errorf("parsing config agents: %w", err)
+			}
+			cfg.Agents = agents
+		case "output_styles":
+			var styles []string
+			if err := valNode.Decode(&styles); err != nil {
+				return Config{}, fmt.Errorf("parsing config output_styles: %w", err)
+			}
+			cfg.OutputStyles = styles
+		case "scripts":
+			var scripts []string
+			if err := valNode.Decode(&scripts); err != nil {
+				return Config{}, fmt.Errorf("parsing config scripts: %w", err)
+			}
+			for _, script := range scripts {
+				if !ValidScriptPath(script) {
+					return Config{}, fmt.Errorf("parsing config scripts: %q isn't a file under ~/", script)
+				}
+			}
+			cfg.Scripts = scripts
+		case "vars":
+			var vars map[string]any
+			if err := valNode.Decode(&vars); err != nil {
+				return Config{}, fmt.Errorf("parsing config vars: %w", err)
+			}
+			cfg.Vars = vars
 		case "marketplaces":
 			var mkts map[string]MarketplaceSource
 			if err := valNode.Decode(&mkts); err != nil {
@@ -226,6 +296,16 @@ func Parse(data []byte) (Config, error) {
 				return Config{}, fmt.Errorf("parsing config subscriptions: %w", err)
 			}
 			cfg.Subscriptions = subs
+		case "recipients":
+			var recipients map[string]string
+			if err := valNode.Decode(&recipients); err != nil {
+				return Config{}, fmt.Errorf("parsing config recipients: %w", err)
+			}
+			cfg.Recipients = recipients
+		case "signing":
+			if err := valNode.Decode(&cfg.Signing); err != nil {
+				return Config{}, fmt.Errorf("parsing config signing: %w", err)
+			}
 		}
 	}
 
@@ -242,8 +322,8 @@ func parsePluginsNode(node *yaml.Node, cfg *Config) error {
 
 			switch keyNode.Value {
 			case "upstream":
-				var upstream []string
-				if err := valNode.Decode(&upstream); err != nil {
+				upstream, err := parseConditionalPlugins(valNode, cfg)
+				if err != nil {
 					return fmt.Errorf("decoding upstream plugins: %w", err)
 				}
 				cfg.Upstream = upstream
@@ -252,8 +332,8 @@ func parsePluginsNode(node *yaml.Node, cfg *Config) error {
 					return err
 				}
 			case "forked":
-				var forked []string
-				if err := valNode.Decode(&forked); err != nil {
+				forked, err := parseConditionalPlugins(valNode, cfg)
+				if err != nil {
 					return fmt.Errorf("decoding forked plugins: %w", err)
 				}
 				cfg.Forked = forked
@@ -276,8 +356,76 @@ func parsePluginsNode(node *yaml.Node, cfg *Config) error {
 	}
 }
 
+// parseConditionalPlugins decodes a plugin list whose items are either plain
+// keys or {name: <key>, when: {...}} mappings, recording selectors in cfg.When.
+func parseConditionalPlugins(node *yaml.Node, cfg *Config) ([]string, error) {
+	if node.Kind != yaml.SequenceNode {
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	list := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		name, sel, err := ParseConditionalString(item, "name")
+		if err != nil {
+			return nil, err
+		}
+		if sel != nil {
+			cfg.setWhen(WhenPlugins, name, *sel)
+		}
+		list = append(list, name)
+	}
+	return list, nil
+}
+
+// parsePermissionsNode decodes permissions.allow and permissions.deny,
+// whose rules may be {rule: <rule>, when: {...}} mappings.
+func parsePermissionsNode(node *yaml.Node, cfg *Config) error {
+	if node.Kind != yaml.MappingNode {
+		return node.Decode(&cfg.Permissions)
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		var err error
+		switch key := node.Content[i].Value; key {
+		case "allow":
+			cfg.Permissions.Allow, err = ParseConditionalRules(node.Content[i+1], func(rule string, sel Selector) {
+				cfg.setWhen(WhenAllow, rule, sel)
+			})
+		case "deny":
+			cfg.Permissions.Deny, err = ParseConditionalRules(node.Content[i+1], func(rule string, sel Selector) {
+				cfg.setWhen(WhenDeny, rule, sel)
+			})
+		default:
+			err = fmt.Errorf("unexpected key %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setWhen records a selector for an entry.
+func (c *Config) setWhen(section, name string, sel Selector) {
+	if c.When == nil {
+		c.When = make(map[string]Selector)
+	}
+	c.When[WhenKey(section, name)] = sel
+}
+
+// whenFor returns the selector recorded for an entry, or nil.
+func (c *Config) whenFor(section, name string) *Selector {
+	if sel, ok := c.When[WhenKey(section, name)]; ok {
+		return &sel
+	}
+	return nil
+}
+
 // parsePinnedNode parses the pinned plugins section.
-// Each entry is a sequence of single-key mappings: - key: "version"
+// Each entry is a sequence of single-key mappings: - key: "version",
+// optionally followed by a when: selector in the same mapping.
 func parsePinnedNode(node *yaml.Node, cfg *Config) error {
 	if node.Kind != yaml.SequenceNode {
 		return fmt.Errorf("expected sequence for pinned plugins, got kind %d", node.Kind)
@@ -287,18 +435,68 @@ func parsePinnedNode(node *yaml.Node, cfg *Config) error {
 		if item.Kind != yaml.MappingNode {
 			return fmt.Errorf("expected mapping in pinned entry, got kind %d", item.Kind)
 		}
-		if len(item.Content) < 2 {
-			continue
+		var key string
+		var sel *Selector
+		for j := 0; j < len(item.Content)-1; j += 2 {
+			if item.Content[j].Value == "when" {
+				s, err := decodeWhen(item.Content[j+1])
+				if err != nil {
+					return fmt.Errorf("pinned entry: %w", err)
+				}
+				sel = &s
+				continue
+			}
+			if key == "" {
+				key = item.Content[j].Value
+				cfg.Pinned[key] = item.Content[j+1].Value
+			}
+		}
+		if key != "" && sel != nil {
+			cfg.setWhen(WhenPlugins, key, *sel)
 		}
-		key := item.Content[0].Value
-		version := item.Content[1].Value
-		cfg.Pinned[key] = version
 	}
 	return nil
 }
 
+// ValidScriptPath reports whether script is a clean ~/ path to a file under
+// the home directory. Pull writes scripts to these paths, so "~/../x.sh" and
+// "~/a/../../x.sh", which climb out of it, are rejected.
+func ValidScriptPath(script string) bool {
+	if !strings.HasPrefix(script, "~/") {
+		return false
+	}
+	rel := script[2:]
+	return rel == path.Clean(rel) && rel != "." && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
 // MarshalV2 serializes a Config to V2 YAML format with categorized plugins.
+// A Config returned by Parse is written over the text it was parsed from, so
+// comments, key order and formatting survive in everything that didn't
+// change.
 func MarshalV2(cfg Config) ([]byte, error) {
+	doc, err := buildDocument(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.source != nil {
+		if data, ok := yamledit.Rewrite(cfg.source, doc, rebuildDocument); ok {
+			return data, nil
+		}
+	}
+	return yaml.Marshal(doc)
+}
+
+// rebuildDocument parses data and renders it again, for yamledit.Rewrite.
+func rebuildDocument(data []byte) (*yaml.Node, error) {
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return buildDocument(cfg)
+}
+
+// buildDocument renders cfg as a fresh YAML document.
+func buildDocument(cfg Config) (*yaml.Node, error) {
 	// Build the YAML document manually for proper structure.
 	doc := &yaml.Node{
 		Kind: yaml.DocumentNode,
@@ -322,9 +520,11 @@ func MarshalV2(cfg Config) ([]byte, error) {
 	if len(cfg.Upstream) > 0 {
 		upstreamSeq := &yaml.Node{Kind: yaml.SequenceNode}
 		for _, u := range cfg.Upstream {
-			upstreamSeq.Content = append(upstreamSeq.Content,
-				&yaml.Node{Kind: yaml.ScalarNode, Value: u, Tag: "!!str"},
-			)
+			item, err := ConditionalStringNode(u, "name", cfg.whenFor(WhenPlugins, u))
+			if err != nil {
+				return nil, err
+			}
+			upstreamSeq.Content = append(upstreamSeq.Content, item)
 		}
 		pluginsMap.Content = append(pluginsMap.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "upstream", Tag: "!!str"},
@@ -351,6 +551,17 @@ func MarshalV2(cfg Config) ([]byte, error) {
 				&yaml.Node{Kind: yaml.ScalarNode, Value: k, Tag: "!!str"},
 				&yaml.Node{Kind: yaml.ScalarNode, Value: v, Tag: "!!str", Style: yaml.DoubleQuotedStyle},
 			)
+			if sel := cfg.whenFor(WhenPlugins, k); sel != nil && !sel.IsZero() {
+				var whenNode yaml.Node
+				if err := whenNode.Encode(sel); err != nil {
+					return nil, fmt.Errorf("encoding when: %w", err)
+				}
+				whenNode.Style = yaml.FlowStyle
+				entry.Content = append(entry.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Value: "when", Tag: "!!str"},
+					&whenNode,
+				)
+			}
 			pinnedSeq.Content = append(pinnedSeq.Content, entry)
 		}
 		pluginsMap.Content = append(pluginsMap.Content,
@@ -363,9 +574,11 @@ func MarshalV2(cfg Config) ([]byte, error) {
 	if len(cfg.Forked) > 0 {
 		forkedSeq := &yaml.Node{Kind: yaml.SequenceNode}
 		for _, f := range cfg.Forked {
-			forkedSeq.Content = append(forkedSeq.Content,
-				&yaml.Node{Kind: yaml.ScalarNode, Value: f, Tag: "!!str"},
-			)
+			item, err := ConditionalStringNode(f, "name", cfg.whenFor(WhenPlugins, f))
+			if err != nil {
+				return nil, err
+			}
+			forkedSeq.Content = append(forkedSeq.Content, item)
 		}
 		pluginsMap.Content = append(pluginsMap.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "forked", Tag: "!!str"},
@@ -396,13 +609,15 @@ func MarshalV2(cfg Config) ([]byte, error) {
 
 	// settings
 	if len(cfg.Settings) > 0 {
-		var settingsNode yaml.Node
-		if err := settingsNode.Encode(cfg.Settings); err != nil {
+		settingsNode, err := ConditionalSettingsNode(cfg.Settings, func(key string) *Selector {
+			return cfg.whenFor(WhenSettings, key)
+		})
+		if err != nil {
 			return nil, fmt.Errorf("encoding settings: %w", err)
 		}
 		root.Content = append(root.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "settings", Tag: "!!str"},
-			&settingsNode,
+			settingsNode,
 		)
 	}
 
@@ -416,6 +631,15 @@ func MarshalV2(cfg Config) ([]byte, error) {
 		if err := hooksNode.Encode(hooksStrMap); err != nil {
 			return nil, fmt.Errorf("encoding hooks: %w", err)
 		}
+		for j := 0; j < len(hooksNode.Content)-1; j += 2 {
+			if sel := cfg.whenFor(WhenHooks, hooksNode.Content[j].Value); sel != nil {
+				item, err := ConditionalStringNode(hooksNode.Content[j+1].Value, "value", sel)
+				if err != nil {
+					return nil, err
+				}
+				hooksNode.Content[j+1] = item
+			}
+		}
 		root.Content = append(root.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "hooks", Tag: "!!str"},
 			&hooksNode,
@@ -424,13 +648,31 @@ func MarshalV2(cfg Config) ([]byte, error) {
 
 	// permissions
 	if len(cfg.Permissions.Allow) > 0 || len(cfg.Permissions.Deny) > 0 {
-		var permsNode yaml.Node
-		if err := permsNode.Encode(cfg.Permissions); err != nil {
-			return nil, fmt.Errorf("encoding permissions: %w", err)
+		permsNode := &yaml.Node{Kind: yaml.MappingNode}
+		for _, list := range []struct {
+			key, section string
+			rules        []string
+		}{
+			{"allow", WhenAllow, cfg.Permissions.Allow},
+			{"deny", WhenDeny, cfg.Permissions.Deny},
+		} {
+			if len(list.rules) == 0 {
+				continue
+			}
+			rulesNode, err := ConditionalRulesNode(list.rules, func(rule string) *Selector {
+				return cfg.whenFor(list.section, rule)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("encoding permissions: %w", err)
+			}
+			permsNode.Content = append(permsNode.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: list.key, Tag: "!!str"},
+				rulesNode,
+			)
 		}
 		root.Content = append(root.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "permissions", Tag: "!!str"},
-			&permsNode,
+			permsNode,
 		)
 	}
 
@@ -447,7 +689,7 @@ func MarshalV2(cfg Config) ([]byte, error) {
 	}
 
 	// memory
-	if len(cfg.Memory.Include) > 0 {
+	if len(cfg.Memory.Include) > 0 || len(cfg.Memory.Projects) > 0 {
 		var memNode yaml.Node
 		if err := memNode.Encode(cfg.Memory); err != nil {
 			return nil, fmt.Errorf("encoding memory: %w", err)
@@ -464,6 +706,11 @@ func MarshalV2(cfg Config) ([]byte, error) {
 		for k, v := range cfg.MCP {
 			var val any
 			json.Unmarshal(v, &val)
+			if sel := cfg.whenFor(WhenMCP, k); sel != nil {
+				if obj, ok := val.(map[string]any); ok {
+					obj["when"] = *sel
+				}
+			}
 			mcpMap[k] = val
 		}
 		var mcpNode yaml.Node
@@ -528,6 +775,60 @@ func MarshalV2(cfg Config) ([]byte, error) {
 		)
 	}
 
+	// agents
+	if len(cfg.Agents) > 0 {
+		agentSeq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, a := range cfg.Agents {
+			agentSeq.Content = append(agentSeq.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: a, Tag: "!!str"},
+			)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "agents", Tag: "!!str"},
+			agentSeq,
+		)
+	}
+
+	// output styles
+	if len(cfg.OutputStyles) > 0 {
+		styleSeq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, st := range cfg.OutputStyles {
+			styleSeq.Content = append(styleSeq.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: st, Tag: "!!str"},
+			)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "output_styles", Tag: "!!str"},
+			styleSeq,
+		)
+	}
+
+	// scripts
+	if len(cfg.Scripts) > 0 {
+		scriptSeq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, sc := range cfg.Scripts {
+			scriptSeq.Content = append(scriptSeq.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: sc, Tag: "!!str"},
+			)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "scripts", Tag: "!!str"},
+			scriptSeq,
+		)
+	}
+
+	// vars
+	if len(cfg.Vars) > 0 {
+		var varsNode yaml.Node
+		if err := varsNode.Encode(cfg.Vars); err != nil {
+			return nil, fmt.Errorf("encoding vars: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "vars", Tag: "!!str"},
+			&varsNode,
+		)
+	}
+
 	// marketplaces
 	if len(cfg.Marketplaces) > 0 {
 		var mktsNode yaml.Node
@@ -552,7 +853,31 @@ func MarshalV2(cfg Config) ([]byte, error) {
 		)
 	}
 
-	return yaml.Marshal(doc)
+	// recipients
+	if len(cfg.Recipients) > 0 {
+		var recipientsNode yaml.Node
+		if err := recipientsNode.Encode(cfg.Recipients); err != nil {
+			return nil, fmt.Errorf("encoding recipients: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "recipients", Tag: "!!str"},
+			&recipientsNode,
+		)
+	}
+
+	// signing
+	if cfg.Signing.AllowedSigners != "" {
+		var signingNode yaml.Node
+		if err := signingNode.Encode(cfg.Signing); err != nil {
+			return nil, fmt.Errorf("encoding signing: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "signing", Tag: "!!str"},
+			&signingNode,
+		)
+	}
+
+	return doc, nil
 }
 
 // Marshal serializes a Config to YAML bytes.
@@ -627,6 +952,8 @@ func (p *AutoCommitPrefs) Mode(category string) AutoCommitMode {
 type SyncPrefs struct {
 	Skip       []string        `yaml:"skip,omitempty"`
 	AutoCommit AutoCommitPrefs `yaml:"auto_commit,omitempty"`
+	// SnapshotKeep is how many pre-pull snapshots to keep (0 = default).
+	SnapshotKeep int `yaml:"snapshot_keep,omitempty"`
 }
 
 // UserPreferences represents ~/.claude-sync/user-preferences.yaml.
@@ -636,6 +963,15 @@ type UserPreferences struct {
 	Plugins  UserPluginPrefs   `yaml:"plugins,omitempty"`
 	Pins     map[string]string `yaml:"pins,omitempty"`
 	Sync     SyncPrefs         `yaml:"sync,omitempty"`
+	// SecretCommand resolves ${NAME} references in MCP env values before the
+	// environment is consulted; "{name}" is replaced with the secret name.
+	SecretCommand string `yaml:"secret_command,omitempty"`
+	// Vars are CLAUDE.md template variables for this machine, layered over
+	// config and profile vars.
+	Vars map[string]any `yaml:"vars,omitempty"`
+	// TemplateEnv names the environment variables CLAUDE.md templates can
+	// read as .Env; the rest of the environment isn't exposed.
+	TemplateEnv []string `yaml:"template_env,omitempty"`
 }
 
 // UserPluginPrefs holds plugin override preferences.
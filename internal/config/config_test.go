@@ -153,6 +153,65 @@ func TestMarshalRoundTrip(t *testing.T) {
 	assert.Equal(t, cfg.Forked, parsed.Forked)
 }
 
+func TestMarshal_PreservesLayout(t *testing.T) {
+	src := `# Team config. Ask #platform before changing.
+version: "1.0.0"
+
+plugins:
+  upstream:
+    - context7@claude-plugins-official  # docs lookup
+    # needed for the beads workflow
+    - beads@beads-marketplace
+
+# Default model for everyone
+settings:
+  model: opus  # cheaper models struggle with our monorepo
+
+x-notes: kept even though claude-sync ignores it
+
+permissions:
+  allow:
+    # read-only git
+    - Bash(git status)
+
+hooks:
+  PreCompact: "bd prime"  # keeps beads context
+`
+	cfg, err := config.Parse([]byte(src))
+	require.NoError(t, err)
+
+	data, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, src, string(data), "unchanged config is written back byte for byte")
+
+	cfg.Upstream = append(cfg.Upstream, "new@mk")
+	cfg.Settings["model"] = "sonnet"
+	cfg.Hooks = nil
+	data, err = config.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, `# Team config. Ask #platform before changing.
+version: "1.0.0"
+
+plugins:
+  upstream:
+    - context7@claude-plugins-official # docs lookup
+    # needed for the beads workflow
+    - beads@beads-marketplace
+    - new@mk
+
+# Default model for everyone
+settings:
+  model: sonnet # cheaper models struggle with our monorepo
+
+x-notes: kept even though claude-sync ignores it
+
+permissions:
+  allow:
+    # read-only git
+    - Bash(git status)
+`, string(data))
+}
+
 func TestAllPluginKeys(t *testing.T) {
 	cfg := config.Config{
 		Version:  "1.0.0",
@@ -515,6 +574,25 @@ plugins:
 	})
 }
 
+func TestParseConfig_Recipients(t *testing.T) {
+	input := []byte(`version: "1.0.0"
+plugins:
+  upstream: []
+recipients:
+  alice: age1alice
+  bob: age1bob
+`)
+	cfg, err := config.Parse(input)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"alice": "age1alice", "bob": "age1bob"}, cfg.Recipients)
+
+	data, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	parsed, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Recipients, parsed.Recipients)
+}
+
 func TestParseMemory(t *testing.T) {
 	yaml := `
 version: "1.0.0"
@@ -542,6 +620,107 @@ func TestMarshalMemory(t *testing.T) {
 	assert.Equal(t, cfg.Memory.Include, parsed.Memory.Include)
 }
 
+func TestMarshalMemoryProjects(t *testing.T) {
+	cfg := config.Config{
+		Version: "1.0.0",
+		Pinned:  map[string]string{},
+		Memory:  config.MemoryConfig{Projects: []string{"github.com/acme/api", "github.com/acme/mono#services/web"}},
+	}
+	data, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "projects:")
+
+	parsed, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Memory.Projects, parsed.Memory.Projects)
+	assert.Empty(t, parsed.Memory.Include)
+}
+
+func TestAgentsRoundTrip(t *testing.T) {
+	yaml := `
+version: "1.0.0"
+skills:
+  - skill:global:brainstorming
+agents:
+  - agent:global:code-reviewer
+  - agent:global:test-runner
+`
+	cfg, err := config.Parse([]byte(yaml))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent:global:code-reviewer", "agent:global:test-runner"}, cfg.Agents)
+
+	data, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	parsed, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Agents, parsed.Agents)
+	assert.Equal(t, cfg.Skills, parsed.Skills)
+}
+
+func TestOutputStylesAndScriptsRoundTrip(t *testing.T) {
+	yaml := `
+version: "1.0.0"
+settings:
+  statusLine:
+    type: command
+    command: ~/.claude/statusline.sh
+output_styles:
+  - style:global:terse
+scripts:
+  - ~/.claude/statusline.sh
+`
+	cfg, err := config.Parse([]byte(yaml))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"style:global:terse"}, cfg.OutputStyles)
+	assert.Equal(t, []string{"~/.claude/statusline.sh"}, cfg.Scripts)
+
+	data, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	parsed, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.OutputStyles, parsed.OutputStyles)
+	assert.Equal(t, cfg.Scripts, parsed.Scripts)
+}
+
+func TestParse_RejectsScriptsOutsideHome(t *testing.T) {
+	for _, script := range []string{"~/../outside/pwn.sh", "~/.claude/../../pwn.sh", "~/", "/etc/profile", "~/a/./b.sh"} {
+		_, err := config.Parse([]byte("version: \"1.0.0\"\nscripts:\n  - " + script + "\n"))
+		assert.ErrorContains(t, err, "isn't a file under ~/", script)
+	}
+	cfg, err := config.Parse([]byte("version: \"1.0.0\"\nscripts:\n  - ~/..hidden/x.sh\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"~/..hidden/x.sh"}, cfg.Scripts)
+}
+
+func TestVarsRoundTrip(t *testing.T) {
+	yaml := `
+version: "1.0.0"
+vars:
+  editor: nvim
+  work: true
+`
+	cfg, err := config.Parse([]byte(yaml))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"editor": "nvim", "work": true}, cfg.Vars)
+
+	data, err := config.Marshal(cfg)
+	require.NoError(t, err)
+	parsed, err := config.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Vars, parsed.Vars)
+
+	prefs, err := config.ParseUserPreferences([]byte("vars:\n  editor: helix\ntemplate_env: [TEAM, EDITOR]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"editor": "helix"}, prefs.Vars)
+	assert.Equal(t, []string{"TEAM", "EDITOR"}, prefs.TemplateEnv)
+
+	data, err = config.MarshalUserPreferences(prefs)
+	require.NoError(t, err)
+	reparsed, err := config.ParseUserPreferences(data)
+	require.NoError(t, err)
+	assert.Equal(t, prefs.TemplateEnv, reparsed.TemplateEnv)
+}
+
 func TestParseUserPreferencesAutoCommit(t *testing.T) {
 	yaml := `
 sync_mode: union
@@ -0,0 +1,476 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Selector is a when: clause restricting an entry to matching machines.
+// Every field that is set must match: Host and OS match if any listed value
+// matches, Env and Binary only if all listed values are present.
+//
+//	when:
+//	  os: darwin
+//	  host: ["work-*", "ci-?"]
+//	  env: CORP_VPN
+//	  binary: docker
+type Selector struct {
+	Host   StringList `yaml:"host,omitempty"`   // hostname globs (path.Match syntax)
+	OS     StringList `yaml:"os,omitempty"`     // runtime.GOOS values
+	Env    StringList `yaml:"env,omitempty"`    // environment variables that must be set
+	Binary StringList `yaml:"binary,omitempty"` // executables that must be on PATH
+}
+
+// UnmarshalYAML rejects keys other than host, os, env and binary, so a typo
+// fails loudly instead of leaving a selector that matches every machine.
+func (s *Selector) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			switch key := node.Content[i].Value; key {
+			case "host", "os", "env", "binary":
+			default:
+				return fmt.Errorf("unknown selector key %q (want host, os, env or binary)", key)
+			}
+		}
+	}
+	type plain Selector
+	return node.Decode((*plain)(s))
+}
+
+// StringList is a list of strings that may be written in YAML as a single
+// scalar or as a sequence.
+type StringList []string
+
+// UnmarshalYAML accepts either a scalar or a sequence of scalars.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarshalYAML writes single-element lists as a scalar.
+func (l StringList) MarshalYAML() (any, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []string(l), nil
+}
+
+// Machine describes the local machine for selector evaluation.
+type Machine struct {
+	Hostname  string
+	GOOS      string
+	LookupEnv func(string) (string, bool)
+	LookPath  func(string) (string, error)
+}
+
+// CurrentMachine returns a Machine backed by the running process.
+func CurrentMachine() Machine {
+	host, _ := os.Hostname()
+	return Machine{
+		Hostname:  host,
+		GOOS:      runtime.GOOS,
+		LookupEnv: os.LookupEnv,
+		LookPath:  exec.LookPath,
+	}
+}
+
+// IsZero reports whether the selector has no conditions.
+func (s Selector) IsZero() bool {
+	return len(s.Host) == 0 && len(s.OS) == 0 && len(s.Env) == 0 && len(s.Binary) == 0
+}
+
+// Matches reports whether m satisfies every condition in s.
+func (s Selector) Matches(m Machine) bool {
+	if len(s.Host) > 0 {
+		ok := false
+		for _, pattern := range s.Host {
+			if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(m.Hostname)); matched {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(s.OS) > 0 {
+		ok := false
+		for _, goos := range s.OS {
+			if goos == m.GOOS {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, name := range s.Env {
+		if m.LookupEnv == nil {
+			return false
+		}
+		if v, ok := m.LookupEnv(name); !ok || v == "" {
+			return false
+		}
+	}
+	for _, bin := range s.Binary {
+		if m.LookPath == nil {
+			return false
+		}
+		if _, err := m.LookPath(bin); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the selector as "os=darwin host=work-*".
+func (s Selector) String() string {
+	var parts []string
+	add := func(key string, values StringList) {
+		if len(values) > 0 {
+			parts = append(parts, key+"="+strings.Join(values, ","))
+		}
+	}
+	add("host", s.Host)
+	add("os", s.OS)
+	add("env", s.Env)
+	add("binary", s.Binary)
+	return strings.Join(parts, " ")
+}
+
+// Sections that accept when: selectors, used as the first half of a WhenKey.
+const (
+	WhenPlugins  = "plugins" // upstream, pinned and forked plugins
+	WhenHooks    = "hooks"
+	WhenMCP      = "mcp"
+	WhenSettings = "settings" // top-level settings keys
+	WhenAllow    = "permissions.allow"
+	WhenDeny     = "permissions.deny"
+)
+
+// WhenKey returns the key under which an entry's selector is stored in
+// Config.When (and Profile.When).
+func WhenKey(section, name string) string {
+	return section + ":" + name
+}
+
+// FilteredEntry is an entry left out on this machine because its when:
+// selector didn't match.
+type FilteredEntry struct {
+	Section string
+	Name    string
+	When    Selector
+}
+
+// String returns e.g. `hooks PreToolUse (when os=darwin)`.
+func (f FilteredEntry) String() string {
+	return fmt.Sprintf("%s %s (when %s)", f.Section, f.Name, f.When)
+}
+
+// ForMachine returns a copy of cfg without the plugins, hooks, MCP servers,
+// settings and permission rules whose when: selector doesn't match m, plus
+// the entries dropped. cfg itself is not modified, so the result must not be
+// written back to config.yaml.
+func (c Config) ForMachine(m Machine) (Config, []FilteredEntry) {
+	if len(c.When) == 0 {
+		return c, nil
+	}
+
+	var filtered []FilteredEntry
+	excluded := func(section, name string) bool {
+		sel, ok := c.When[WhenKey(section, name)]
+		if !ok || sel.Matches(m) {
+			return false
+		}
+		filtered = append(filtered, FilteredEntry{Section: section, Name: name, When: sel})
+		return true
+	}
+
+	out := c
+	out.Upstream = nil
+	for _, p := range c.Upstream {
+		if !excluded(WhenPlugins, p) {
+			out.Upstream = append(out.Upstream, p)
+		}
+	}
+	if c.Pinned != nil {
+		out.Pinned = make(map[string]string, len(c.Pinned))
+		for p, v := range c.Pinned {
+			if !excluded(WhenPlugins, p) {
+				out.Pinned[p] = v
+			}
+		}
+	}
+	out.Forked = nil
+	for _, p := range c.Forked {
+		if !excluded(WhenPlugins, p) {
+			out.Forked = append(out.Forked, p)
+		}
+	}
+	if c.Settings != nil {
+		out.Settings = make(map[string]any, len(c.Settings))
+		for k, v := range c.Settings {
+			if !excluded(WhenSettings, k) {
+				out.Settings[k] = v
+			}
+		}
+	}
+	out.Permissions = Permissions{}
+	for _, r := range c.Permissions.Allow {
+		if !excluded(WhenAllow, r) {
+			out.Permissions.Allow = append(out.Permissions.Allow, r)
+		}
+	}
+	for _, r := range c.Permissions.Deny {
+		if !excluded(WhenDeny, r) {
+			out.Permissions.Deny = append(out.Permissions.Deny, r)
+		}
+	}
+	if c.Hooks != nil {
+		out.Hooks = make(map[string]json.RawMessage, len(c.Hooks))
+		for k, v := range c.Hooks {
+			if !excluded(WhenHooks, k) {
+				out.Hooks[k] = v
+			}
+		}
+	}
+	if c.MCP != nil {
+		out.MCP = make(map[string]json.RawMessage, len(c.MCP))
+		for k, v := range c.MCP {
+			if !excluded(WhenMCP, k) {
+				out.MCP[k] = v
+			}
+		}
+	}
+
+	SortFilteredEntries(filtered)
+	return out, filtered
+}
+
+// SortFilteredEntries orders entries by section and name for stable output.
+func SortFilteredEntries(entries []FilteredEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Section != entries[j].Section {
+			return entries[i].Section < entries[j].Section
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// decodeWhen decodes a when: node into a selector.
+func decodeWhen(node *yaml.Node) (Selector, error) {
+	var sel Selector
+	if err := node.Decode(&sel); err != nil {
+		return Selector{}, fmt.Errorf("parsing when: %w", err)
+	}
+	return sel, nil
+}
+
+// ParseConditionalString parses a list item or map value that is either a
+// plain scalar or a mapping of the form {<field>: <scalar>, when: {...}}.
+// It returns the scalar and the selector, if any. Exported for the profiles
+// package, which uses the same entry syntax.
+func ParseConditionalString(node *yaml.Node, field string) (string, *Selector, error) {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return "", nil, fmt.Errorf("expected string or mapping with %q and \"when\"", field)
+	}
+	var value string
+	var sel *Selector
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case field:
+			value = val.Value
+		case "when":
+			s, err := decodeWhen(val)
+			if err != nil {
+				return "", nil, err
+			}
+			sel = &s
+		default:
+			return "", nil, fmt.Errorf("unexpected key %q (expected %q or \"when\")", key.Value, field)
+		}
+	}
+	if value == "" {
+		return "", nil, fmt.Errorf("missing %q", field)
+	}
+	return value, sel, nil
+}
+
+// ConditionalStringNode is the inverse of ParseConditionalString.
+func ConditionalStringNode(value, field string, sel *Selector) (*yaml.Node, error) {
+	if sel == nil || sel.IsZero() {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: "!!str"}, nil
+	}
+	var whenNode yaml.Node
+	if err := whenNode.Encode(sel); err != nil {
+		return nil, fmt.Errorf("encoding when: %w", err)
+	}
+	whenNode.Style = yaml.FlowStyle
+	return &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: field, Tag: "!!str"},
+		{Kind: yaml.ScalarNode, Value: value, Tag: "!!str"},
+		{Kind: yaml.ScalarNode, Value: "when", Tag: "!!str"},
+		&whenNode,
+	}}, nil
+}
+
+// ParseConditionalSettings decodes a settings mapping whose values are
+// either plain values or {value: <any>, when: {...}} mappings, calling
+// setWhen for each selector. Exported for the profiles package.
+func ParseConditionalSettings(node *yaml.Node, setWhen func(key string, sel Selector)) (map[string]any, error) {
+	if node.Kind != yaml.MappingNode {
+		var settings map[string]any
+		if err := node.Decode(&settings); err != nil {
+			return nil, err
+		}
+		return settings, nil
+	}
+	settings := make(map[string]any, len(node.Content)/2)
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key, val := node.Content[i].Value, node.Content[i+1]
+		if valueNode, whenNode := splitConditionalValue(val); whenNode != nil {
+			sel, err := decodeWhen(whenNode)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			setWhen(key, sel)
+			val = valueNode
+		}
+		var v any
+		if err := val.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		settings[key] = v
+	}
+	return settings, nil
+}
+
+// splitConditionalValue returns the value and when nodes of a mapping with
+// exactly the keys "value" and "when", or nil, nil for any other node.
+func splitConditionalValue(node *yaml.Node) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 4 {
+		return nil, nil
+	}
+	var value, when *yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case "value":
+			value = node.Content[i+1]
+		case "when":
+			when = node.Content[i+1]
+		}
+	}
+	if value == nil || when == nil {
+		return nil, nil
+	}
+	return value, when
+}
+
+// ConditionalSettingsNode is the inverse of ParseConditionalSettings.
+func ConditionalSettingsNode(settings map[string]any, whenFor func(key string) *Selector) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(settings); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		sel := whenFor(node.Content[i].Value)
+		if sel == nil || sel.IsZero() {
+			continue
+		}
+		var whenNode yaml.Node
+		if err := whenNode.Encode(sel); err != nil {
+			return nil, fmt.Errorf("encoding when: %w", err)
+		}
+		whenNode.Style = yaml.FlowStyle
+		node.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "value", Tag: "!!str"},
+			node.Content[i+1],
+			{Kind: yaml.ScalarNode, Value: "when", Tag: "!!str"},
+			&whenNode,
+		}}
+	}
+	return &node, nil
+}
+
+// ParseConditionalRules decodes a list of permission rules whose items are
+// either plain rules or {rule: <rule>, when: {...}} mappings, calling
+// setWhen for each selector. Exported for the profiles package.
+func ParseConditionalRules(node *yaml.Node, setWhen func(rule string, sel Selector)) ([]string, error) {
+	if node.Kind != yaml.SequenceNode {
+		var rules []string
+		if err := node.Decode(&rules); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	}
+	rules := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		rule, sel, err := ParseConditionalString(item, "rule")
+		if err != nil {
+			return nil, err
+		}
+		if sel != nil {
+			setWhen(rule, *sel)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ConditionalRulesNode is the inverse of ParseConditionalRules.
+func ConditionalRulesNode(rules []string, whenFor func(rule string) *Selector) (*yaml.Node, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, r := range rules {
+		item, err := ConditionalStringNode(r, "rule", whenFor(r))
+		if err != nil {
+			return nil, err
+		}
+		seq.Content = append(seq.Content, item)
+	}
+	return seq, nil
+}
+
+// SplitMCPWhen removes a "when" key from a decoded MCP server definition and
+// returns its selector.
+func SplitMCPWhen(server any) (*Selector, error) {
+	m, ok := server.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	raw, ok := m["when"]
+	if !ok {
+		return nil, nil
+	}
+	delete(m, "when")
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("encoding when: %w", err)
+	}
+	var sel Selector
+	if err := yaml.Unmarshal(data, &sel); err != nil {
+		return nil, fmt.Errorf("parsing when: %w", err)
+	}
+	return &sel, nil
+}
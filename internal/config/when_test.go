@@ -0,0 +1,235 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMachine returns a Machine with the given env vars and PATH binaries.
+func fakeMachine(host, goos string, env map[string]string, bins ...string) config.Machine {
+	return config.Machine{
+		Hostname: host,
+		GOOS:     goos,
+		LookupEnv: func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		},
+		LookPath: func(name string) (string, error) {
+			for _, b := range bins {
+				if b == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	m := fakeMachine("Work-Laptop", "darwin", map[string]string{"CORP_VPN": "1"}, "docker")
+
+	tests := []struct {
+		name string
+		sel  config.Selector
+		want bool
+	}{
+		{"empty", config.Selector{}, true},
+		{"host glob", config.Selector{Host: config.StringList{"work-*"}}, true},
+		{"host glob any of", config.Selector{Host: config.StringList{"ci-*", "work-*"}}, true},
+		{"host mismatch", config.Selector{Host: config.StringList{"home-*"}}, false},
+		{"os", config.Selector{OS: config.StringList{"linux", "darwin"}}, true},
+		{"os mismatch", config.Selector{OS: config.StringList{"linux"}}, false},
+		{"env set", config.Selector{Env: config.StringList{"CORP_VPN"}}, true},
+		{"env unset", config.Selector{Env: config.StringList{"CORP_VPN", "OTHER"}}, false},
+		{"binary", config.Selector{Binary: config.StringList{"docker"}}, true},
+		{"binary missing", config.Selector{Binary: config.StringList{"podman"}}, false},
+		{"all must match", config.Selector{OS: config.StringList{"darwin"}, Binary: config.StringList{"podman"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sel.Matches(m))
+		})
+	}
+}
+
+func TestParseConfig_When(t *testing.T) {
+	input := []byte(`version: "1.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+    - name: xcode-tools@some-marketplace
+      when: {os: darwin}
+hooks:
+  PreCompact: "echo compact"
+  PreToolUse:
+    value: "docker-guard"
+    when:
+      binary: docker
+mcp:
+  internal-api:
+    command: api-server
+    when:
+      host: ["work-*", "ci-?"]
+      env: CORP_VPN
+`)
+	cfg, err := config.Parse(input)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"context7@claude-plugins-official", "xcode-tools@some-marketplace"}, cfg.Upstream)
+	assert.Equal(t, config.Selector{OS: config.StringList{"darwin"}},
+		cfg.When[config.WhenKey(config.WhenPlugins, "xcode-tools@some-marketplace")])
+	assert.Equal(t, config.Selector{Binary: config.StringList{"docker"}},
+		cfg.When[config.WhenKey(config.WhenHooks, "PreToolUse")])
+	assert.Equal(t, config.Selector{Host: config.StringList{"work-*", "ci-?"}, Env: config.StringList{"CORP_VPN"}},
+		cfg.When[config.WhenKey(config.WhenMCP, "internal-api")])
+	assertHookHasCommand(t, cfg.Hooks["PreToolUse"], "docker-guard")
+
+	var server map[string]any
+	require.NoError(t, json.Unmarshal(cfg.MCP["internal-api"], &server))
+	assert.NotContains(t, server, "when", "selector should not leak into the server definition")
+
+	t.Run("round-trip", func(t *testing.T) {
+		data, err := config.MarshalV2(cfg)
+		require.NoError(t, err)
+		parsed, err := config.Parse(data)
+		require.NoError(t, err)
+		assert.Equal(t, cfg.Upstream, parsed.Upstream)
+		assert.Equal(t, cfg.When, parsed.When)
+		assert.JSONEq(t, string(cfg.MCP["internal-api"]), string(parsed.MCP["internal-api"]))
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		_, err := config.Parse([]byte(`version: "1.0.0"
+plugins:
+  upstream:
+    - plugin: foo@bar
+`))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown selector key", func(t *testing.T) {
+		for _, input := range []string{
+			"plugins:\n  upstream:\n    - name: foo@bar\n      when: {hostnme: ci-*}\n",
+			"hooks:\n  Stop:\n    value: notify\n    when: {sytem: darwin}\n",
+			"mcp:\n  api:\n    command: api\n    when: {hostnme: ci-*}\n",
+			"settings:\n  model:\n    value: opus\n    when: {oss: linux}\n",
+		} {
+			_, err := config.Parse([]byte("version: \"1.0.0\"\n" + input))
+			assert.ErrorContains(t, err, "unknown selector key", input)
+		}
+	})
+}
+
+func TestConfig_ForMachine(t *testing.T) {
+	cfg, err := config.Parse([]byte(`version: "1.0.0"
+plugins:
+  upstream:
+    - a@m
+    - name: mac-only@m
+      when: {os: darwin}
+hooks:
+  PreToolUse:
+    value: "docker-guard"
+    when: {binary: docker}
+mcp:
+  internal-api:
+    command: api-server
+    when: {env: CORP_VPN}
+`))
+	require.NoError(t, err)
+
+	t.Run("matching machine keeps everything", func(t *testing.T) {
+		m := fakeMachine("laptop", "darwin", map[string]string{"CORP_VPN": "1"}, "docker")
+		out, filtered := cfg.ForMachine(m)
+		assert.Empty(t, filtered)
+		assert.Equal(t, cfg.Upstream, out.Upstream)
+		assert.Contains(t, out.Hooks, "PreToolUse")
+		assert.Contains(t, out.MCP, "internal-api")
+	})
+
+	t.Run("other machine drops entries", func(t *testing.T) {
+		m := fakeMachine("server", "linux", nil)
+		out, filtered := cfg.ForMachine(m)
+		assert.Equal(t, []string{"a@m"}, out.Upstream)
+		assert.NotContains(t, out.Hooks, "PreToolUse")
+		assert.NotContains(t, out.MCP, "internal-api")
+
+		require.Len(t, filtered, 3)
+		assert.Equal(t, "hooks PreToolUse (when binary=docker)", filtered[0].String())
+		assert.Equal(t, "mcp internal-api (when env=CORP_VPN)", filtered[1].String())
+		assert.Equal(t, "plugins mac-only@m (when os=darwin)", filtered[2].String())
+
+		// The original config is untouched.
+		assert.Len(t, cfg.Upstream, 2)
+		assert.Contains(t, cfg.MCP, "internal-api")
+	})
+}
+
+func TestConfig_WhenOnPinnedSettingsAndPermissions(t *testing.T) {
+	cfg, err := config.Parse([]byte(`version: "1.0.0"
+plugins:
+  pinned:
+    - a@m: "1.0.0"
+    - mac-only@m: "2.0.0"
+      when: {os: darwin}
+settings:
+  model: opus
+  apiKeyHelper:
+    value: ~/bin/key-helper.sh
+    when: {os: darwin}
+  statusLine:
+    type: command
+    command: status.sh
+permissions:
+  allow:
+    - Bash(ls *)
+    - rule: Bash(brew *)
+      when: {os: darwin}
+  deny:
+    - rule: Bash(apt *)
+      when: {os: linux}
+`))
+	require.NoError(t, err)
+
+	darwin := config.Selector{OS: config.StringList{"darwin"}}
+	assert.Equal(t, map[string]string{"a@m": "1.0.0", "mac-only@m": "2.0.0"}, cfg.Pinned)
+	assert.Equal(t, darwin, cfg.When[config.WhenKey(config.WhenPlugins, "mac-only@m")])
+	assert.Equal(t, "~/bin/key-helper.sh", cfg.Settings["apiKeyHelper"])
+	assert.Equal(t, darwin, cfg.When[config.WhenKey(config.WhenSettings, "apiKeyHelper")])
+	assert.Equal(t, map[string]any{"type": "command", "command": "status.sh"}, cfg.Settings["statusLine"],
+		"a mapping that isn't exactly {value, when} is an ordinary setting")
+	assert.Equal(t, []string{"Bash(ls *)", "Bash(brew *)"}, cfg.Permissions.Allow)
+	assert.Equal(t, darwin, cfg.When[config.WhenKey(config.WhenAllow, "Bash(brew *)")])
+	assert.Equal(t, []string{"Bash(apt *)"}, cfg.Permissions.Deny)
+
+	t.Run("round-trip", func(t *testing.T) {
+		data, err := config.MarshalV2(cfg)
+		require.NoError(t, err)
+		parsed, err := config.Parse(data)
+		require.NoError(t, err)
+		assert.Equal(t, cfg.Pinned, parsed.Pinned)
+		assert.Equal(t, cfg.Settings, parsed.Settings)
+		assert.Equal(t, cfg.Permissions, parsed.Permissions)
+		assert.Equal(t, cfg.When, parsed.When)
+	})
+
+	t.Run("for machine", func(t *testing.T) {
+		out, filtered := cfg.ForMachine(fakeMachine("server", "linux", nil))
+		assert.Equal(t, map[string]string{"a@m": "1.0.0"}, out.Pinned)
+		assert.NotContains(t, out.Settings, "apiKeyHelper")
+		assert.Contains(t, out.Settings, "model")
+		assert.Equal(t, []string{"Bash(ls *)"}, out.Permissions.Allow)
+		assert.Equal(t, []string{"Bash(apt *)"}, out.Permissions.Deny)
+		assert.Len(t, filtered, 3)
+
+		// The original config is untouched.
+		assert.Len(t, cfg.Pinned, 2)
+		assert.Contains(t, cfg.Settings, "apiKeyHelper")
+		assert.Len(t, cfg.Permissions.Allow, 2)
+	})
+}
@@ -1,10 +1,13 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Run executes a git command in the given directory and returns trimmed stdout.
@@ -55,6 +58,11 @@ func RevParse(dir, ref string) (string, error) {
 	return Run(dir, "rev-parse", ref)
 }
 
+// MergeBase returns the best common ancestor of two refs.
+func MergeBase(dir, a, b string) (string, error) {
+	return Run(dir, "merge-base", a, b)
+}
+
 // Init initializes a new git repo in dir with local user config
 // so commits work regardless of global git configuration.
 func Init(dir string) error {
@@ -236,6 +244,11 @@ func RemoteURL(dir, name string) (string, error) {
 	return Run(dir, "remote", "get-url", name)
 }
 
+// TopLevel returns the root of the working tree containing dir.
+func TopLevel(dir string) (string, error) {
+	return Run(dir, "rev-parse", "--show-toplevel")
+}
+
 // HasUpstream returns true if the current branch has an upstream tracking branch.
 func HasUpstream(dir string) bool {
 	_, err := Run(dir, "rev-parse", "--abbrev-ref", "@{u}")
@@ -281,14 +294,23 @@ func ResolveUpstreamURL(localRepoPath string) (string, error) {
 // ShallowClone clones a repository with depth 1 into dst.
 // If ref is empty, the remote's default branch is cloned.
 func ShallowClone(url, dst, ref string) error {
+	return ShallowCloneContext(context.Background(), url, dst, ref)
+}
+
+// ShallowCloneContext is ShallowClone, killing git when ctx is done. Git
+// never prompts for credentials, so an unreachable private remote fails
+// instead of waiting for input nobody will type.
+func ShallowCloneContext(ctx context.Context, url, dst, ref string) error {
 	args := []string{"clone", "--depth", "1"}
 	if ref != "" {
 		args = append(args, "--branch", ref)
 	}
 	args = append(args, url, dst)
-	cmd := exec.Command("git", args...)
-	out, err := cmd.CombinedOutput()
+	out, err := backgroundCommand(ctx, "", args...).CombinedOutput()
 	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("shallow clone: %w", ctx.Err())
+		}
 		return fmt.Errorf("shallow clone: %s", strings.TrimSpace(string(out)))
 	}
 	return nil
@@ -296,8 +318,45 @@ func ShallowClone(url, dst, ref string) error {
 
 // FetchShallow fetches the latest from origin with depth 1.
 func FetchShallow(dir string) error {
-	_, err := Run(dir, "fetch", "--depth", "1", "--quiet")
-	return err
+	return FetchShallowContext(context.Background(), dir)
+}
+
+// FetchShallowContext is FetchShallow, killing git when ctx is done. Like
+// ShallowCloneContext, it never prompts for credentials.
+func FetchShallowContext(ctx context.Context, dir string) error {
+	out, err := backgroundCommand(ctx, dir, "fetch", "--depth", "1", "--quiet").CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("fetch: %w", ctx.Err())
+		}
+		return fmt.Errorf("fetch: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// FetchCommitContext fetches a single commit from origin with depth 1, for
+// a shallow clone that doesn't have it. Like ShallowCloneContext, it never
+// prompts for credentials.
+func FetchCommitContext(ctx context.Context, dir, sha string) error {
+	out, err := backgroundCommand(ctx, dir, "fetch", "--depth", "1", "--quiet", "origin", sha).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("fetch %s: %w", sha, ctx.Err())
+		}
+		return fmt.Errorf("fetch %s: %s", sha, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// backgroundCommand returns a git command for network operations that run
+// unattended: it is killed when ctx is done and can't prompt.
+func backgroundCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	// Don't wait on a credential helper or ssh that outlives git.
+	cmd.WaitDelay = time.Second
+	return cmd
 }
 
 // ResetToFetchHead resets the working tree to FETCH_HEAD.
@@ -306,6 +365,12 @@ func ResetToFetchHead(dir string) error {
 	return err
 }
 
+// ResetHard resets the working tree to ref.
+func ResetHard(dir, ref string) error {
+	_, err := Run(dir, "reset", "--hard", ref)
+	return err
+}
+
 // HeadSHA returns the full SHA of HEAD.
 func HeadSHA(dir string) (string, error) {
 	return Run(dir, "rev-parse", "HEAD")
@@ -368,3 +433,129 @@ func CommitsAhead(dir string) int {
 	}
 	return n
 }
+
+// LogEntry is a single commit as returned by Log.
+type LogEntry struct {
+	SHA     string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// ShortSHA returns the first 7 characters of the commit SHA.
+func (e LogEntry) ShortSHA() string {
+	if len(e.SHA) > 7 {
+		return e.SHA[:7]
+	}
+	return e.SHA
+}
+
+// logFormat separates fields with the ASCII unit separator so subjects can
+// contain any printable character.
+const logFormat = "--format=%H%x1f%an%x1f%aI%x1f%s"
+
+// Log returns up to n commits reachable from HEAD, newest first.
+// n <= 0 means no limit.
+func Log(dir string, n int) ([]LogEntry, error) {
+	args := []string{"log", logFormat}
+	if n > 0 {
+		args = append(args, "-n", strconv.Itoa(n))
+	}
+	out, err := Run(dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git log: %s", out)
+	}
+	return parseLog(out), nil
+}
+
+// ShowCommit returns the commit that ref resolves to.
+func ShowCommit(dir, ref string) (LogEntry, error) {
+	out, err := Run(dir, "log", "-1", logFormat, ref+"^{commit}", "--")
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("unknown revision %q", ref)
+	}
+	entries := parseLog(out)
+	if len(entries) == 0 {
+		return LogEntry{}, fmt.Errorf("unknown revision %q", ref)
+	}
+	return entries[0], nil
+}
+
+func parseLog(out string) []LogEntry {
+	var entries []LogEntry
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		entries = append(entries, LogEntry{SHA: fields[0], Author: fields[1], Date: date, Subject: fields[3]})
+	}
+	return entries
+}
+
+// FileChange is a path touched by a commit. Status is "A", "M" or "D"
+// (renames are reported as a delete plus an add).
+type FileChange struct {
+	Status string
+	Path   string
+}
+
+// CommitChanges returns the files a commit changed relative to its first
+// parent, or every file for a root commit.
+func CommitChanges(dir, ref string) ([]FileChange, error) {
+	out, err := Run(dir, "diff-tree", "-r", "--root", "-m", "--first-parent", "--no-commit-id", "--no-renames", "--name-status", ref)
+	if err != nil {
+		return nil, fmt.Errorf("git diff-tree: %s", out)
+	}
+	var changes []FileChange
+	for _, line := range strings.Split(out, "\n") {
+		status, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		changes = append(changes, FileChange{Status: status[:1], Path: path})
+	}
+	return changes, nil
+}
+
+// ShowFile returns the content of path at ref. The error wraps
+// os.ErrNotExist if the path doesn't exist at that revision.
+func ShowFile(dir, ref, path string) ([]byte, error) {
+	if !PathExists(dir, ref, path) {
+		return nil, fmt.Errorf("%s:%s: %w", ref, path, os.ErrNotExist)
+	}
+	cmd := exec.Command("git", "show", ref+":"+path)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %w", ref, path, err)
+	}
+	return out, nil
+}
+
+// PathExists reports whether path (a file or directory) exists at ref.
+func PathExists(dir, ref, path string) bool {
+	_, err := Run(dir, "cat-file", "-e", ref+":"+path)
+	return err == nil
+}
+
+// RestorePaths makes paths in both the index and the working tree match ref.
+// Tracked files under paths that don't exist at ref are deleted. Paths that
+// exist neither at ref nor at HEAD are ignored.
+func RestorePaths(dir, ref string, paths ...string) error {
+	var present []string
+	for _, p := range paths {
+		if PathExists(dir, ref, p) || PathExists(dir, "HEAD", p) {
+			present = append(present, p)
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+	args := append([]string{"restore", "--source", ref, "--staged", "--worktree", "--"}, present...)
+	if out, err := Run(dir, args...); err != nil {
+		return fmt.Errorf("git restore: %s", out)
+	}
+	return nil
+}
@@ -413,3 +413,73 @@ func TestMergeFFOnly(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestLogAndCommitChanges(t *testing.T) {
+	dir := initTestRepo(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("one"), 0644)
+	mustExec(t, "git", "-C", dir, "add", ".")
+	mustExec(t, "git", "-C", dir, "commit", "-m", "first: with | odd chars")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two"), 0644)
+	os.Remove(filepath.Join(dir, "b.txt"))
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("new"), 0644)
+	mustExec(t, "git", "-C", dir, "add", "-A")
+	mustExec(t, "git", "-C", dir, "commit", "-m", "second")
+
+	entries, err := git.Log(dir, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "second", entries[0].Subject)
+	assert.Equal(t, "first: with | odd chars", entries[1].Subject)
+	assert.Equal(t, "Test", entries[0].Author)
+	assert.False(t, entries[0].Date.IsZero())
+	assert.Len(t, entries[0].ShortSHA(), 7)
+
+	limited, err := git.Log(dir, 1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	changes, err := git.CommitChanges(dir, "HEAD")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []git.FileChange{
+		{Status: "M", Path: "a.txt"},
+		{Status: "D", Path: "b.txt"},
+		{Status: "A", Path: "c.txt"},
+	}, changes)
+
+	root, err := git.CommitChanges(dir, "HEAD~1")
+	require.NoError(t, err)
+	assert.Len(t, root, 2, "root commit lists every file")
+
+	c, err := git.ShowCommit(dir, "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, entries[1].SHA, c.SHA)
+	_, err = git.ShowCommit(dir, "no-such-ref")
+	assert.Error(t, err)
+}
+
+func TestShowFileAndRestorePaths(t *testing.T) {
+	dir := initTestRepo(t)
+	os.MkdirAll(filepath.Join(dir, "profiles"), 0755)
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("v1\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "profiles", "work.yaml"), []byte("w\n"), 0644)
+	mustExec(t, "git", "-C", dir, "add", ".")
+	mustExec(t, "git", "-C", dir, "commit", "-m", "v1")
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("v2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "profiles", "home.yaml"), []byte("h\n"), 0644)
+	mustExec(t, "git", "-C", dir, "add", ".")
+	mustExec(t, "git", "-C", dir, "commit", "-m", "v2")
+
+	data, err := git.ShowFile(dir, "HEAD~1", "config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "v1\n", string(data), "content is not trimmed")
+	_, err = git.ShowFile(dir, "HEAD~1", "profiles/home.yaml")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, git.RestorePaths(dir, "HEAD~1", "config.yaml", "profiles", "missing-everywhere"))
+	got, _ := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	assert.Equal(t, "v1\n", string(got))
+	assert.NoFileExists(t, filepath.Join(dir, "profiles", "home.yaml"))
+	assert.FileExists(t, filepath.Join(dir, "profiles", "work.yaml"))
+	assert.True(t, git.HasStagedChanges(dir))
+}
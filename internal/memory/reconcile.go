@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -115,3 +116,57 @@ func Reconcile(sourceDir, syncMemDir string) (*ReconcileResult, error) {
 
 	return result, nil
 }
+
+// Sync mirrors every fragment in sourceDir into syncMemDir: it reconciles,
+// then imports new fragments and drops deleted ones from the manifest. It
+// suits directories synced as a whole, like project memory, where there is
+// no include list to opt fragments in.
+func Sync(sourceDir, syncMemDir string) (*ReconcileResult, error) {
+	result, err := Reconcile(sourceDir, syncMemDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.New) == 0 && len(result.Deleted) == 0 {
+		return result, nil
+	}
+
+	manifest, err := ReadManifest(syncMemDir)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	sort.Slice(result.New, func(i, j int) bool { return result.New[i].SlugName < result.New[j].SlugName })
+	for _, f := range result.New {
+		if err := WriteFragment(syncMemDir, f.SlugName, f.Content); err != nil {
+			return nil, fmt.Errorf("write new fragment %s: %w", f.SlugName, err)
+		}
+		fm, err := ParseFrontmatter(f.Content)
+		if err != nil {
+			fm.Name = strings.TrimSuffix(filepath.Base(f.FilePath), ".md")
+		}
+		manifest.Fragments[f.SlugName] = FragmentMeta{
+			Name:        fm.Name,
+			Description: fm.Description,
+			Type:        fm.Type,
+			Level:       "project",
+			ContentHash: ContentHash(f.Content),
+		}
+		manifest.Order = append(manifest.Order, f.SlugName)
+	}
+	sort.Strings(result.Deleted)
+	for _, slug := range result.Deleted {
+		if err := os.Remove(filepath.Join(syncMemDir, slug+".md")); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove fragment %s: %w", slug, err)
+		}
+		delete(manifest.Fragments, slug)
+		for i, name := range manifest.Order {
+			if name == slug {
+				manifest.Order = append(manifest.Order[:i], manifest.Order[i+1:]...)
+				break
+			}
+		}
+	}
+	if err := WriteManifest(syncMemDir, manifest); err != nil {
+		return nil, fmt.Errorf("writing updated manifest: %w", err)
+	}
+	return result, nil
+}
@@ -146,3 +146,40 @@ func TestReconcile(t *testing.T) {
 	assert.Equal(t, memory.ContentHash(unchangedContent), updatedManifest.Fragments["project-notes"].ContentHash,
 		"unchanged fragment hash should remain the same")
 }
+
+func TestSync(t *testing.T) {
+	sourceDir := t.TempDir()
+	syncMemDir := t.TempDir()
+
+	build := "---\nname: Build Steps\ndescription: How to build\ntype: project\n---\n\nRun make."
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "build-steps.md"), []byte(build), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "MEMORY.md"), []byte("# Memory\n"), 0o644))
+
+	result, err := memory.Sync(sourceDir, syncMemDir)
+	require.NoError(t, err)
+	require.Len(t, result.New, 1)
+	content, err := memory.ReadFragment(syncMemDir, "build-steps")
+	require.NoError(t, err)
+	assert.Equal(t, build, content)
+	m, err := memory.ReadManifest(syncMemDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build-steps"}, m.Order)
+	assert.Equal(t, "project", m.Fragments["build-steps"].Level)
+
+	// Nothing changed: a second sync is a no-op.
+	result, err = memory.Sync(sourceDir, syncMemDir)
+	require.NoError(t, err)
+	assert.Empty(t, result.New)
+	assert.Empty(t, result.Updated)
+
+	// Deleting locally drops the fragment from the sync dir.
+	require.NoError(t, os.Remove(filepath.Join(sourceDir, "build-steps.md")))
+	result, err = memory.Sync(sourceDir, syncMemDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build-steps"}, result.Deleted)
+	assert.NoFileExists(t, filepath.Join(syncMemDir, "build-steps.md"))
+	m, err = memory.ReadManifest(syncMemDir)
+	require.NoError(t, err)
+	assert.Empty(t, m.Fragments)
+	assert.Empty(t, m.Order)
+}
@@ -31,6 +31,17 @@ func UserPreferencesFile() string {
 	return filepath.Join(SyncDir(), "user-preferences.yaml")
 }
 
+// IdentityFile returns the age identity used to decrypt secrets:
+// $CLAUDE_SYNC_IDENTITY if set, otherwise ~/.config/claude-sync/identity.txt.
+// It lives outside the sync dir so the key never sits next to what it
+// protects.
+func IdentityFile() string {
+	if p := os.Getenv("CLAUDE_SYNC_IDENTITY"); p != "" {
+		return p
+	}
+	return filepath.Join(home(), ".config", "claude-sync", "identity.txt")
+}
+
 // ForkedPluginsDir returns ~/.claude-sync/plugins.
 func ForkedPluginsDir() string {
 	return filepath.Join(SyncDir(), "plugins")
@@ -46,6 +57,18 @@ func ClaudeMemoryDir() string {
 	return filepath.Join(ClaudeDir(), "memory")
 }
 
+// ProjectSlug returns the name Claude Code gives a project's directory
+// under ~/.claude/projects: its absolute path with every character other
+// than a letter or digit replaced by '-' (/home/me/api becomes -home-me-api).
+func ProjectSlug(dir string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, dir)
+}
+
 // CCSDir returns ~/.ccs.
 func CCSDir() string {
 	return filepath.Join(home(), ".ccs")
@@ -40,6 +40,11 @@ func TestMemoryPaths(t *testing.T) {
 	assert.Equal(t, filepath.Join(home, ".claude", "memory"), paths.ClaudeMemoryDir())
 }
 
+func TestProjectSlug(t *testing.T) {
+	assert.Equal(t, "-home-me-code-api", paths.ProjectSlug("/home/me/code/api"))
+	assert.Equal(t, "-Users-me--config-my-app", paths.ProjectSlug("/Users/me/.config/my_app"))
+}
+
 func TestCCSDir(t *testing.T) {
 	home, _ := os.UserHomeDir()
 	assert.Equal(t, filepath.Join(home, ".ccs"), paths.CCSDir())
@@ -0,0 +1,136 @@
+package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Conflict records an item that two or more active profiles disagree on,
+// e.g. one adds a plugin another removes, or both set a setting to
+// different values. The last profile to mention the item wins.
+type Conflict struct {
+	Section string
+	Item    string
+	Claims  []string // what each profile wants, in activation order, e.g. `"work" adds`
+	Winner  string   // profile whose directive was applied
+}
+
+// String returns a one-line description of the conflict.
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s: %s; using %q", c.Section, c.Item, strings.Join(c.Claims, ", "), c.Winner)
+}
+
+// claim is one profile's directive for an item.
+type claim struct {
+	profile string
+	op      string // "adds", "removes", "sets"
+	value   string // canonical value for sets and keyed adds, "" otherwise
+}
+
+func (c claim) describe() string {
+	if c.op == "sets" {
+		return fmt.Sprintf("%q sets %s", c.profile, c.value)
+	}
+	return fmt.Sprintf("%q %s", c.profile, c.op)
+}
+
+// findConflicts compares individually resolved profiles, in activation
+// order, and returns the items they disagree on sorted by section and item.
+func findConflicts(each []ResolvedProfile) []Conflict {
+	claims := make(map[[2]string][]claim)
+	add := func(section, item string, c claim) {
+		k := [2]string{section, item}
+		claims[k] = append(claims[k], c)
+	}
+	addList := func(section, name string, adds, removes []string) {
+		for _, item := range adds {
+			add(section, item, claim{profile: name, op: "adds"})
+		}
+		for _, item := range removes {
+			add(section, item, claim{profile: name, op: "removes"})
+		}
+	}
+	addRaw := func(section, name string, adds map[string]json.RawMessage, removes []string) {
+		for item, raw := range adds {
+			add(section, item, claim{profile: name, op: "adds", value: canonicalJSON(raw)})
+		}
+		for _, item := range removes {
+			add(section, item, claim{profile: name, op: "removes"})
+		}
+	}
+	addValues := func(section, name string, values map[string]any) {
+		for item, v := range values {
+			add(section, item, claim{profile: name, op: "sets", value: formatValue(v)})
+		}
+	}
+
+	for _, r := range each {
+		p, name := r.Profile, r.Name
+		addList("plugins", name, p.Plugins.Add, p.Plugins.Remove)
+		addValues("settings", name, p.Settings)
+		addRaw("hooks", name, p.Hooks.Add, p.Hooks.Remove)
+		addList("claude_md", name, p.ClaudeMD.Add, p.ClaudeMD.Remove)
+		addList("memory", name, p.Memory.Add, p.Memory.Remove)
+		addRaw("mcp", name, p.MCP.Add, p.MCP.Remove)
+		addValues("keybindings", name, p.Keybindings.Override)
+		addList("commands", name, p.Commands.Add, p.Commands.Remove)
+		addList("skills", name, p.Skills.Add, p.Skills.Remove)
+		addList("agents", name, p.Agents.Add, p.Agents.Remove)
+		addList("output_styles", name, p.OutputStyles.Add, p.OutputStyles.Remove)
+		addValues("vars", name, p.Vars)
+	}
+
+	var conflicts []Conflict
+	for k, cs := range claims {
+		if !disagree(cs) {
+			continue
+		}
+		c := Conflict{Section: k[0], Item: k[1], Winner: cs[len(cs)-1].profile}
+		for _, cl := range cs {
+			c.Claims = append(c.Claims, cl.describe())
+		}
+		conflicts = append(conflicts, c)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Section != conflicts[j].Section {
+			return sectionOrder(conflicts[i].Section) < sectionOrder(conflicts[j].Section)
+		}
+		return conflicts[i].Item < conflicts[j].Item
+	})
+	return conflicts
+}
+
+// disagree reports whether any two claims want different outcomes.
+func disagree(cs []claim) bool {
+	for _, c := range cs[1:] {
+		if c.op != cs[0].op || c.value != cs[0].value {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalJSON re-encodes raw so formatting differences don't count as
+// disagreement.
+func canonicalJSON(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// formatValue renders a setting value for comparison and display.
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
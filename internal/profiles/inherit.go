@@ -0,0 +1,360 @@
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/ruminaider/claude-sync/internal/sliceutil"
+)
+
+// ResolvedProfile is a profile with its extends chain flattened into a single
+// overlay that can be passed to the Merge* functions.
+type ResolvedProfile struct {
+	Name    string   // profile name; comma-separated when several are active
+	Chain   []string // profiles applied in order, lowest precedence first; Name is last
+	Profile Profile
+	// Inherited is the flattened overlay of everything before the last
+	// profile in Chain, i.e. what its own directives are applied on top of.
+	Inherited Profile
+	// Origins maps an item key (see ResolvedItem.Key) to the profile whose
+	// directive determined the item's final state.
+	Origins map[string]string
+}
+
+// ResolvedItem describes a single directive in a resolved profile.
+type ResolvedItem struct {
+	Section string // e.g. "plugins", "settings", "permissions"
+	Op      string // "add", "remove", "set", "allow", "deny"
+	Item    string
+	Origin  string // profile that contributed the directive
+}
+
+// Key returns the identity used for ResolvedProfile.Origins.
+func (i ResolvedItem) Key() string {
+	return i.Section + "." + i.Op + ":" + i.Item
+}
+
+// ResolveProfile reads profiles/<name>.yaml and every profile it extends,
+// and flattens them into one overlay.
+//
+// Precedence is deterministic: parents listed in extends are applied
+// depth-first, in the order listed, and the profile itself is applied last,
+// so later entries override earlier ones. A profile reachable through more
+// than one path (a diamond) is applied once, at its first position. Cycles
+// and references to missing profiles are errors.
+func ResolveProfile(syncDir, name string) (ResolvedProfile, error) {
+	return resolveChain(syncDir, []string{name})
+}
+
+// ResolveProfiles resolves several simultaneously active profiles as if they
+// were the parents of one anonymous profile, in the order given. Items the
+// profiles disagree on are still resolved in favor of the later profile,
+// but are also reported as conflicts so callers don't hide them.
+func ResolveProfiles(syncDir string, names []string) (ResolvedProfile, []Conflict, error) {
+	resolved, err := resolveChain(syncDir, names)
+	if err != nil {
+		return ResolvedProfile{}, nil, err
+	}
+	if len(names) < 2 {
+		return resolved, nil, nil
+	}
+
+	each := make([]ResolvedProfile, 0, len(names))
+	for _, name := range names {
+		r, err := ResolveProfile(syncDir, name)
+		if err != nil {
+			return ResolvedProfile{}, nil, err
+		}
+		each = append(each, r)
+	}
+	return resolved, findConflicts(each), nil
+}
+
+// resolveChain linearizes names in order and overlays the result.
+func resolveChain(syncDir string, names []string) (ResolvedProfile, error) {
+	loaded := make(map[string]Profile)
+	var order []string
+	for _, name := range names {
+		if err := linearize(syncDir, name, nil, loaded, &order); err != nil {
+			return ResolvedProfile{}, err
+		}
+	}
+
+	resolved := ResolvedProfile{
+		Name:    strings.Join(names, ", "),
+		Chain:   order,
+		Origins: make(map[string]string),
+	}
+	for i, n := range order {
+		if i == len(order)-1 {
+			resolved.Inherited = resolved.Profile
+		}
+		resolved.Profile = overlayProfile(resolved.Profile, loaded[n], n, resolved.Origins)
+	}
+	return resolved, nil
+}
+
+// linearize appends name's ancestors and then name itself to order,
+// depth-first. stack holds the profiles currently being resolved and is used
+// for cycle detection.
+func linearize(syncDir, name string, stack []string, loaded map[string]Profile, order *[]string) error {
+	for i, s := range stack {
+		if s == name {
+			cycle := append(append([]string{}, stack[i:]...), name)
+			return fmt.Errorf("profile inheritance cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if _, done := loaded[name]; done {
+		return nil
+	}
+
+	p, err := ReadProfile(syncDir, name)
+	if err != nil {
+		if len(stack) > 0 && errors.Is(err, os.ErrNotExist) {
+			// Deliberately not wrapped: a missing parent is a broken
+			// profile, not a missing one.
+			return fmt.Errorf("profile %q extends unknown profile %q", stack[len(stack)-1], name)
+		}
+		return err
+	}
+
+	stack = append(stack, name)
+	for _, parent := range p.Extends {
+		if err := linearize(syncDir, parent, stack, loaded, order); err != nil {
+			return err
+		}
+	}
+
+	loaded[name] = p
+	*order = append(*order, name)
+	return nil
+}
+
+// overlayProfile combines base and top into a single profile such that
+// applying the result to a config is equivalent to applying base and then
+// top. Origins for top's directives are recorded under name.
+func overlayProfile(base, top Profile, name string, origins map[string]string) Profile {
+	var out Profile
+
+	out.Plugins.Add, out.Plugins.Remove = overlayAddRemove(
+		base.Plugins.Add, base.Plugins.Remove, top.Plugins.Add, top.Plugins.Remove, "plugins", name, origins)
+	out.ClaudeMD.Add, out.ClaudeMD.Remove = overlayAddRemove(
+		base.ClaudeMD.Add, base.ClaudeMD.Remove, top.ClaudeMD.Add, top.ClaudeMD.Remove, "claude_md", name, origins)
+	out.Memory.Add, out.Memory.Remove = overlayAddRemove(
+		base.Memory.Add, base.Memory.Remove, top.Memory.Add, top.Memory.Remove, "memory", name, origins)
+	out.Commands.Add, out.Commands.Remove = overlayAddRemove(
+		base.Commands.Add, base.Commands.Remove, top.Commands.Add, top.Commands.Remove, "commands", name, origins)
+	out.Skills.Add, out.Skills.Remove = overlayAddRemove(
+		base.Skills.Add, base.Skills.Remove, top.Skills.Add, top.Skills.Remove, "skills", name, origins)
+	out.Agents.Add, out.Agents.Remove = overlayAddRemove(
+		base.Agents.Add, base.Agents.Remove, top.Agents.Add, top.Agents.Remove, "agents", name, origins)
+	out.OutputStyles.Add, out.OutputStyles.Remove = overlayAddRemove(
+		base.OutputStyles.Add, base.OutputStyles.Remove, top.OutputStyles.Add, top.OutputStyles.Remove, "output_styles", name, origins)
+
+	out.Hooks.Add, out.Hooks.Remove = overlayRawAddRemove(
+		base.Hooks.Add, base.Hooks.Remove, top.Hooks.Add, top.Hooks.Remove, "hooks", name, origins)
+	out.MCP.Add, out.MCP.Remove = overlayRawAddRemove(
+		base.MCP.Add, base.MCP.Remove, top.MCP.Add, top.MCP.Remove, "mcp", name, origins)
+
+	out.Settings = overlayValues(base.Settings, top.Settings, "settings", name, origins)
+	out.Keybindings.Override = overlayValues(base.Keybindings.Override, top.Keybindings.Override, "keybindings", name, origins)
+	out.Vars = overlayValues(base.Vars, top.Vars, "vars", name, origins)
+
+	out.When = overlayWhen(base, top)
+
+	out.Permissions.AddAllow = sliceutil.AppendUnique(base.Permissions.AddAllow, top.Permissions.AddAllow)
+	out.Permissions.AddDeny = sliceutil.AppendUnique(base.Permissions.AddDeny, top.Permissions.AddDeny)
+	for _, r := range top.Permissions.AddAllow {
+		if _, ok := origins[ResolvedItem{Section: "permissions", Op: "allow", Item: r}.Key()]; !ok {
+			origins[ResolvedItem{Section: "permissions", Op: "allow", Item: r}.Key()] = name
+		}
+	}
+	for _, r := range top.Permissions.AddDeny {
+		if _, ok := origins[ResolvedItem{Section: "permissions", Op: "deny", Item: r}.Key()]; !ok {
+			origins[ResolvedItem{Section: "permissions", Op: "deny", Item: r}.Key()] = name
+		}
+	}
+
+	return out
+}
+
+// overlayWhen combines selectors: an entry top adds takes top's selector (or
+// none), replacing whatever base had for it.
+func overlayWhen(base, top Profile) map[string]config.Selector {
+	if len(base.When) == 0 && len(top.When) == 0 {
+		return nil
+	}
+	out := make(map[string]config.Selector, len(base.When)+len(top.When))
+	for k, sel := range base.When {
+		out[k] = sel
+	}
+	for _, name := range top.Plugins.Add {
+		delete(out, config.WhenKey(config.WhenPlugins, name))
+	}
+	for name := range top.Hooks.Add {
+		delete(out, config.WhenKey(config.WhenHooks, name))
+	}
+	for name := range top.MCP.Add {
+		delete(out, config.WhenKey(config.WhenMCP, name))
+	}
+	for name := range top.Settings {
+		delete(out, config.WhenKey(config.WhenSettings, name))
+	}
+	for _, rule := range top.Permissions.AddAllow {
+		delete(out, config.WhenKey(config.WhenAllow, rule))
+	}
+	for _, rule := range top.Permissions.AddDeny {
+		delete(out, config.WhenKey(config.WhenDeny, rule))
+	}
+	for k, sel := range top.When {
+		out[k] = sel
+	}
+	return out
+}
+
+// overlayAddRemove combines two list add/remove pairs. An item added by base
+// and removed by top ends up removed, and vice versa.
+func overlayAddRemove(baseAdd, baseRemove, topAdd, topRemove []string, section, name string, origins map[string]string) (add, remove []string) {
+	add = sliceutil.AppendUnique(sliceutil.RemoveAll(baseAdd, topRemove), topAdd)
+	remove = sliceutil.AppendUnique(sliceutil.RemoveAll(baseRemove, topAdd), topRemove)
+	for _, item := range topAdd {
+		delete(origins, ResolvedItem{Section: section, Op: "remove", Item: item}.Key())
+		origins[ResolvedItem{Section: section, Op: "add", Item: item}.Key()] = name
+	}
+	for _, item := range topRemove {
+		delete(origins, ResolvedItem{Section: section, Op: "add", Item: item}.Key())
+		origins[ResolvedItem{Section: section, Op: "remove", Item: item}.Key()] = name
+	}
+	return add, remove
+}
+
+// overlayRawAddRemove is overlayAddRemove for keyed JSON entries (hooks, MCP).
+func overlayRawAddRemove(baseAdd map[string]json.RawMessage, baseRemove []string, topAdd map[string]json.RawMessage, topRemove []string, section, name string, origins map[string]string) (map[string]json.RawMessage, []string) {
+	var add map[string]json.RawMessage
+	if len(baseAdd) > 0 || len(topAdd) > 0 {
+		add = make(map[string]json.RawMessage, len(baseAdd)+len(topAdd))
+	}
+	for k, v := range baseAdd {
+		add[k] = v
+	}
+	for _, k := range topRemove {
+		delete(add, k)
+	}
+	for k, v := range topAdd {
+		add[k] = v
+	}
+	if len(add) == 0 {
+		add = nil
+	}
+
+	topAddKeys := make([]string, 0, len(topAdd))
+	for k := range topAdd {
+		topAddKeys = append(topAddKeys, k)
+	}
+	sort.Strings(topAddKeys)
+
+	remove := sliceutil.AppendUnique(sliceutil.RemoveAll(baseRemove, topAddKeys), topRemove)
+	for _, k := range topAddKeys {
+		delete(origins, ResolvedItem{Section: section, Op: "remove", Item: k}.Key())
+		origins[ResolvedItem{Section: section, Op: "add", Item: k}.Key()] = name
+	}
+	for _, k := range topRemove {
+		delete(origins, ResolvedItem{Section: section, Op: "add", Item: k}.Key())
+		origins[ResolvedItem{Section: section, Op: "remove", Item: k}.Key()] = name
+	}
+	return add, remove
+}
+
+// overlayValues overlays top's keys onto base's (settings, keybindings).
+func overlayValues(base, top map[string]any, section, name string, origins map[string]string) map[string]any {
+	if len(base) == 0 && len(top) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(base)+len(top))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range top {
+		out[k] = v
+		origins[ResolvedItem{Section: section, Op: "set", Item: k}.Key()] = name
+	}
+	return out
+}
+
+// Items lists every directive in the resolved profile with the profile it
+// came from, sorted by section, operation and item for stable output.
+func (r ResolvedProfile) Items() []ResolvedItem {
+	p := r.Profile
+	var items []ResolvedItem
+	addList := func(section, op string, values []string) {
+		for _, v := range values {
+			item := ResolvedItem{Section: section, Op: op, Item: v}
+			item.Origin = r.Origins[item.Key()]
+			items = append(items, item)
+		}
+	}
+	mapKeys := func(m map[string]json.RawMessage) []string {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+	valueKeys := func(m map[string]any) []string {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+
+	addList("plugins", "add", p.Plugins.Add)
+	addList("plugins", "remove", p.Plugins.Remove)
+	addList("settings", "set", valueKeys(p.Settings))
+	addList("hooks", "add", mapKeys(p.Hooks.Add))
+	addList("hooks", "remove", p.Hooks.Remove)
+	addList("permissions", "allow", p.Permissions.AddAllow)
+	addList("permissions", "deny", p.Permissions.AddDeny)
+	addList("claude_md", "add", p.ClaudeMD.Add)
+	addList("claude_md", "remove", p.ClaudeMD.Remove)
+	addList("memory", "add", p.Memory.Add)
+	addList("memory", "remove", p.Memory.Remove)
+	addList("mcp", "add", mapKeys(p.MCP.Add))
+	addList("mcp", "remove", p.MCP.Remove)
+	addList("keybindings", "set", valueKeys(p.Keybindings.Override))
+	addList("commands", "add", p.Commands.Add)
+	addList("commands", "remove", p.Commands.Remove)
+	addList("skills", "add", p.Skills.Add)
+	addList("skills", "remove", p.Skills.Remove)
+	addList("agents", "add", p.Agents.Add)
+	addList("agents", "remove", p.Agents.Remove)
+	addList("output_styles", "add", p.OutputStyles.Add)
+	addList("output_styles", "remove", p.OutputStyles.Remove)
+	addList("vars", "set", valueKeys(p.Vars))
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Section != items[j].Section {
+			return sectionOrder(items[i].Section) < sectionOrder(items[j].Section)
+		}
+		if items[i].Op != items[j].Op {
+			return items[i].Op < items[j].Op
+		}
+		return items[i].Item < items[j].Item
+	})
+	return items
+}
+
+// sectionOrder returns the position of a section in profile YAML order.
+func sectionOrder(section string) int {
+	for i, s := range []string{"plugins", "settings", "hooks", "permissions", "claude_md", "memory", "mcp", "keybindings", "commands", "skills", "agents", "output_styles", "vars"} {
+		if s == section {
+			return i
+		}
+	}
+	return len(section)
+}
@@ -0,0 +1,318 @@
+package profiles_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/profiles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProfiles writes each name → YAML pair to syncDir/profiles/<name>.yaml.
+func writeProfiles(t *testing.T, syncDir string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(syncDir, "profiles")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0644))
+	}
+}
+
+func TestParseProfile_Extends(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		p, err := profiles.ParseProfile([]byte("extends: [work, oncall]\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work", "oncall"}, p.Extends)
+	})
+
+	t.Run("single scalar", func(t *testing.T) {
+		p, err := profiles.ParseProfile([]byte("extends: work\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work"}, p.Extends)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		data, err := profiles.MarshalProfile(profiles.Profile{
+			Extends: []string{"work", "oncall"},
+			Plugins: profiles.ProfilePlugins{Add: []string{"a@m"}},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "extends:")
+
+		p, err := profiles.ParseProfile(data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work", "oncall"}, p.Extends)
+		assert.Equal(t, []string{"a@m"}, p.Plugins.Add)
+	})
+}
+
+func TestResolveProfile(t *testing.T) {
+	t.Run("no extends", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"work": "plugins:\n  add: [a@m]\n",
+		})
+
+		r, err := profiles.ResolveProfile(syncDir, "work")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work"}, r.Chain)
+		assert.Equal(t, []string{"a@m"}, r.Profile.Plugins.Add)
+		assert.Equal(t, "work", r.Origins["plugins.add:a@m"])
+	})
+
+	t.Run("chain applies parents first", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"work": `plugins:
+  add: [a@m, b@m]
+settings:
+  model: sonnet
+  theme: dark
+permissions:
+  add_allow: ["Bash(git *)"]
+`,
+			"work-backend": `extends: [work]
+plugins:
+  add: [c@m]
+  remove: [b@m]
+settings:
+  model: opus
+`,
+			"work-backend-oncall": `extends: [work-backend]
+plugins:
+  add: [b@m]
+permissions:
+  add_allow: ["Bash(kubectl *)"]
+`,
+		})
+
+		r, err := profiles.ResolveProfile(syncDir, "work-backend-oncall")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work", "work-backend", "work-backend-oncall"}, r.Chain)
+		assert.Empty(t, r.Profile.Extends)
+
+		// b@m was removed by work-backend and re-added by oncall.
+		assert.ElementsMatch(t, []string{"a@m", "c@m", "b@m"}, r.Profile.Plugins.Add)
+		assert.Empty(t, r.Profile.Plugins.Remove)
+		assert.Equal(t, "work-backend-oncall", r.Origins["plugins.add:b@m"])
+		assert.Equal(t, "work-backend", r.Origins["plugins.add:c@m"])
+
+		assert.Equal(t, "opus", r.Profile.Settings["model"])
+		assert.Equal(t, "dark", r.Profile.Settings["theme"])
+		assert.Equal(t, "work-backend", r.Origins["settings.set:model"])
+		assert.Equal(t, "work", r.Origins["settings.set:theme"])
+
+		assert.Equal(t, []string{"Bash(git *)", "Bash(kubectl *)"}, r.Profile.Permissions.AddAllow)
+
+		// Inherited excludes the profile's own directives.
+		assert.ElementsMatch(t, []string{"a@m", "c@m"}, r.Inherited.Plugins.Add)
+	})
+
+	t.Run("resolved overlay matches applying the chain in order", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"base":  "plugins:\n  remove: [x@m]\nmcp:\n  add:\n    srv: {command: a}\n",
+			"child": "extends: [base]\nplugins:\n  add: [x@m]\nmcp:\n  remove: [srv]\n",
+		})
+		basePlugins := []string{"x@m", "y@m"}
+		baseMCP := map[string]json.RawMessage{"other": json.RawMessage(`{"command":"o"}`)}
+
+		parent, err := profiles.ReadProfile(syncDir, "base")
+		require.NoError(t, err)
+		child, err := profiles.ReadProfile(syncDir, "child")
+		require.NoError(t, err)
+		wantPlugins := profiles.MergePlugins(profiles.MergePlugins(basePlugins, parent), child)
+		wantMCP := profiles.MergeMCP(profiles.MergeMCP(baseMCP, parent), child)
+
+		r, err := profiles.ResolveProfile(syncDir, "child")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, wantPlugins, profiles.MergePlugins(basePlugins, r.Profile))
+		assert.Equal(t, wantMCP, profiles.MergeMCP(baseMCP, r.Profile))
+	})
+
+	t.Run("later parents take precedence", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"work":   "settings:\n  model: sonnet\n",
+			"oncall": "settings:\n  model: opus\n",
+			"me":     "extends: [work, oncall]\n",
+		})
+
+		r, err := profiles.ResolveProfile(syncDir, "me")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work", "oncall", "me"}, r.Chain)
+		assert.Equal(t, "opus", r.Profile.Settings["model"])
+		assert.Equal(t, "oncall", r.Origins["settings.set:model"])
+	})
+
+	t.Run("diamond applies shared ancestor once", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"root":  "settings:\n  model: haiku\n",
+			"left":  "extends: [root]\nsettings:\n  model: sonnet\n",
+			"right": "extends: [root]\n",
+			"leaf":  "extends: [left, right]\n",
+		})
+
+		r, err := profiles.ResolveProfile(syncDir, "leaf")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"root", "left", "right", "leaf"}, r.Chain)
+		// root is not re-applied after left, so left's override survives.
+		assert.Equal(t, "sonnet", r.Profile.Settings["model"])
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"a": "extends: [b]\n",
+			"b": "extends: [c]\n",
+			"c": "extends: [a]\n",
+		})
+
+		_, err := profiles.ResolveProfile(syncDir, "a")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "a -> b -> c -> a")
+	})
+
+	t.Run("self reference", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{"a": "extends: [a]\n"})
+
+		_, err := profiles.ResolveProfile(syncDir, "a")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cycle")
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{"a": "extends: [missing]\n"})
+
+		_, err := profiles.ResolveProfile(syncDir, "a")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `profile "a" extends unknown profile "missing"`)
+		assert.NotErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		_, err := profiles.ResolveProfile(t.TempDir(), "nope")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestResolvedProfile_Items(t *testing.T) {
+	syncDir := t.TempDir()
+	writeProfiles(t, syncDir, map[string]string{
+		"work":  "plugins:\n  add: [a@m]\nkeybindings:\n  override:\n    ctrl+k: clear\n",
+		"local": "extends: [work]\nplugins:\n  remove: [z@m]\n",
+	})
+
+	r, err := profiles.ResolveProfile(syncDir, "local")
+	require.NoError(t, err)
+
+	assert.Equal(t, []profiles.ResolvedItem{
+		{Section: "plugins", Op: "add", Item: "a@m", Origin: "work"},
+		{Section: "plugins", Op: "remove", Item: "z@m", Origin: "local"},
+		{Section: "keybindings", Op: "set", Item: "ctrl+k", Origin: "work"},
+	}, r.Items())
+}
+
+func TestProfileSummary_Extends(t *testing.T) {
+	p := profiles.Profile{
+		Extends: []string{"work"},
+		Permissions: profiles.ProfilePermissions{
+			AddAllow: []string{"Bash(ls)"},
+		},
+	}
+	assert.Equal(t, "extends work, +1 allow permission", profiles.ProfileSummary(p))
+}
+
+func TestResolveProfiles(t *testing.T) {
+	t.Run("merges in order and reports conflicts", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"backend": `plugins:
+  add: [db@m, lint@m]
+settings:
+  model: sonnet
+  theme: dark
+`,
+			"security-reviewer": `plugins:
+  add: [audit@m, db@m]
+  remove: [lint@m]
+settings:
+  model: opus
+  theme: dark
+`,
+		})
+
+		r, conflicts, err := profiles.ResolveProfiles(syncDir, []string{"backend", "security-reviewer"})
+		require.NoError(t, err)
+		assert.Equal(t, "backend, security-reviewer", r.Name)
+		assert.Equal(t, []string{"backend", "security-reviewer"}, r.Chain)
+		assert.ElementsMatch(t, []string{"db@m", "audit@m"}, r.Profile.Plugins.Add)
+		assert.Equal(t, []string{"lint@m"}, r.Profile.Plugins.Remove)
+		assert.Equal(t, "opus", r.Profile.Settings["model"])
+
+		// Agreements (db@m, theme) are not conflicts.
+		require.Len(t, conflicts, 2)
+		assert.Equal(t, profiles.Conflict{
+			Section: "plugins",
+			Item:    "lint@m",
+			Claims:  []string{`"backend" adds`, `"security-reviewer" removes`},
+			Winner:  "security-reviewer",
+		}, conflicts[0])
+		assert.Equal(t, "settings", conflicts[1].Section)
+		assert.Equal(t, "model", conflicts[1].Item)
+		assert.Equal(t, `settings model: "backend" sets sonnet, "security-reviewer" sets opus; using "security-reviewer"`, conflicts[1].String())
+	})
+
+	t.Run("compares each profile's resolved chain", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"base":   "mcp:\n  add:\n    db: {command: a}\n",
+			"child":  "extends: [base]\n",
+			"other":  "mcp:\n  add:\n    db: {command: b}\n",
+			"same":   "mcp:\n  add:\n    db: {command: a}\n",
+			"remove": "mcp:\n  remove: [db]\n",
+		})
+
+		_, conflicts, err := profiles.ResolveProfiles(syncDir, []string{"child", "same"})
+		require.NoError(t, err)
+		assert.Empty(t, conflicts)
+
+		_, conflicts, err = profiles.ResolveProfiles(syncDir, []string{"child", "other"})
+		require.NoError(t, err)
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "mcp", conflicts[0].Section)
+		assert.Equal(t, "other", conflicts[0].Winner)
+
+		_, conflicts, err = profiles.ResolveProfiles(syncDir, []string{"remove", "child"})
+		require.NoError(t, err)
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, []string{`"remove" removes`, `"child" adds`}, conflicts[0].Claims)
+	})
+
+	t.Run("single profile has no conflicts", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{"a": "plugins:\n  add: [x@m]\n"})
+
+		r, conflicts, err := profiles.ResolveProfiles(syncDir, []string{"a"})
+		require.NoError(t, err)
+		assert.Empty(t, conflicts)
+		assert.Equal(t, "a", r.Name)
+	})
+
+	t.Run("broken chain in any profile is an error", func(t *testing.T) {
+		syncDir := t.TempDir()
+		writeProfiles(t, syncDir, map[string]string{
+			"a": "plugins:\n  add: [x@m]\n",
+			"b": "extends: [nope]\n",
+		})
+
+		_, _, err := profiles.ResolveProfiles(syncDir, []string{"a", "b"})
+		assert.Error(t, err)
+	})
+}
@@ -5,26 +5,41 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/ruminaider/claude-sync/internal/config"
 	"github.com/ruminaider/claude-sync/internal/sliceutil"
+	"github.com/ruminaider/claude-sync/internal/yamledit"
 	"go.yaml.in/yaml/v3"
 )
 
 // Profile represents a named profile that layers on top of base config.
 type Profile struct {
-	Plugins     ProfilePlugins     `yaml:"plugins,omitempty"`
-	Settings    map[string]any     `yaml:"settings,omitempty"`
-	Hooks       ProfileHooks       `yaml:"hooks,omitempty"`
-	Permissions ProfilePermissions `yaml:"permissions,omitempty"`
-	ClaudeMD    ProfileClaudeMD    `yaml:"claude_md,omitempty"`
-	Memory      ProfileMemory      `yaml:"memory,omitempty"`
-	MCP         ProfileMCP         `yaml:"mcp,omitempty"`
-	Keybindings ProfileKeybindings `yaml:"keybindings,omitempty"`
-	Commands    ProfileCommands    `yaml:"commands,omitempty"`
-	Skills      ProfileSkills      `yaml:"skills,omitempty"`
+	// Extends lists profiles whose directives are applied before this one's.
+	// See ResolveProfile.
+	Extends      []string            `yaml:"extends,omitempty"`
+	Plugins      ProfilePlugins      `yaml:"plugins,omitempty"`
+	Settings     map[string]any      `yaml:"settings,omitempty"`
+	Hooks        ProfileHooks        `yaml:"hooks,omitempty"`
+	Permissions  ProfilePermissions  `yaml:"permissions,omitempty"`
+	ClaudeMD     ProfileClaudeMD     `yaml:"claude_md,omitempty"`
+	Memory       ProfileMemory       `yaml:"memory,omitempty"`
+	MCP          ProfileMCP          `yaml:"mcp,omitempty"`
+	Keybindings  ProfileKeybindings  `yaml:"keybindings,omitempty"`
+	Commands     ProfileCommands     `yaml:"commands,omitempty"`
+	Skills       ProfileSkills       `yaml:"skills,omitempty"`
+	Agents       ProfileAgents       `yaml:"agents,omitempty"`
+	OutputStyles ProfileOutputStyles `yaml:"output_styles,omitempty"`
+	// Vars are CLAUDE.md template variables, layered over config vars.
+	Vars map[string]any `yaml:"vars,omitempty"`
+	// When holds when: selectors for plugins.add, hooks.add, mcp.add,
+	// settings and permissions entries, keyed by config.WhenKey. See
+	// ForMachine.
+	When map[string]config.Selector `yaml:"-"`
+
+	source []byte // text ParseProfile read, so MarshalProfile can preserve its layout
 }
 
 // ProfilePlugins holds plugin add/remove directives for a profile.
@@ -80,6 +95,18 @@ type ProfileSkills struct {
 	Remove []string `yaml:"remove,omitempty"`
 }
 
+// ProfileAgents holds subagent add/remove directives for a profile.
+type ProfileAgents struct {
+	Add    []string `yaml:"add,omitempty"`
+	Remove []string `yaml:"remove,omitempty"`
+}
+
+// ProfileOutputStyles holds output style add/remove directives for a profile.
+type ProfileOutputStyles struct {
+	Add    []string `yaml:"add,omitempty"`
+	Remove []string `yaml:"remove,omitempty"`
+}
+
 // ParseProfile parses a profile YAML file into a Profile struct.
 // Hook values in hooks.add are stored as JSON strings in YAML (same pattern
 // as config.go). If the string starts with '[' and is valid JSON, it's used
@@ -101,23 +128,32 @@ func ParseProfile(data []byte) (Profile, error) {
 		return Profile{}, fmt.Errorf("parsing profile: expected mapping at top level")
 	}
 
-	var p Profile
+	p := Profile{source: data}
 
 	for i := 0; i < len(root.Content)-1; i += 2 {
 		keyNode := root.Content[i]
 		valNode := root.Content[i+1]
 
 		switch keyNode.Value {
+		case "extends":
+			var extends []string
+			if valNode.Kind == yaml.ScalarNode {
+				extends = []string{valNode.Value}
+			} else if err := valNode.Decode(&extends); err != nil {
+				return Profile{}, fmt.Errorf("parsing profile extends: %w", err)
+			}
+			p.Extends = extends
+
 		case "plugins":
-			var plugins ProfilePlugins
-			if err := valNode.Decode(&plugins); err != nil {
-				return Profile{}, fmt.Errorf("parsing profile plugins: %w", err)
+			if err := parseProfilePlugins(valNode, &p); err != nil {
+				return Profile{}, err
 			}
-			p.Plugins = plugins
 
 		case "settings":
-			var settings map[string]any
-			if err := valNode.Decode(&settings); err != nil {
+			settings, err := config.ParseConditionalSettings(valNode, func(key string, sel config.Selector) {
+				p.setWhen(config.WhenSettings, key, sel)
+			})
+			if err != nil {
 				return Profile{}, fmt.Errorf("parsing profile settings: %w", err)
 			}
 			p.Settings = settings
@@ -128,11 +164,9 @@ func ParseProfile(data []byte) (Profile, error) {
 			}
 
 		case "permissions":
-			var perms ProfilePermissions
-			if err := valNode.Decode(&perms); err != nil {
-				return Profile{}, fmt.Errorf("parsing profile permissions: %w", err)
+			if err := parseProfilePermissions(valNode, &p); err != nil {
+				return Profile{}, err
 			}
-			p.Permissions = perms
 
 		case "claude_md":
 			var cmd ProfileClaudeMD
@@ -173,12 +207,123 @@ func ParseProfile(data []byte) (Profile, error) {
 				return Profile{}, fmt.Errorf("parsing profile skills: %w", err)
 			}
 			p.Skills = skills
+
+		case "agents":
+			var agents ProfileAgents
+			if err := valNode.Decode(&agents); err != nil {
+				return Profile{}, fmt.Errorf("parsing profile agents: %w", err)
+			}
+			p.Agents = agents
+
+		case "output_styles":
+			var styles ProfileOutputStyles
+			if err := valNode.Decode(&styles); err != nil {
+				return Profile{}, fmt.Errorf("parsing profile output_styles: %w", err)
+			}
+			p.OutputStyles = styles
+
+		case "vars":
+			var vars map[string]any
+			if err := valNode.Decode(&vars); err != nil {
+				return Profile{}, fmt.Errorf("parsing profile vars: %w", err)
+			}
+			p.Vars = vars
 		}
 	}
 
 	return p, nil
 }
 
+// parseProfilePlugins parses the plugins section of a profile YAML. Entries
+// in add may be {name: <key>, when: {...}} mappings.
+func parseProfilePlugins(node *yaml.Node, p *Profile) error {
+	var plugins ProfilePlugins
+	if node.Kind != yaml.MappingNode {
+		if err := node.Decode(&plugins); err != nil {
+			return fmt.Errorf("parsing profile plugins: %w", err)
+		}
+		p.Plugins = plugins
+		return nil
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		keyNode := node.Content[i]
+		valNode := node.Content[i+1]
+
+		switch keyNode.Value {
+		case "add":
+			if valNode.Kind != yaml.SequenceNode {
+				if err := valNode.Decode(&plugins.Add); err != nil {
+					return fmt.Errorf("parsing profile plugins.add: %w", err)
+				}
+				continue
+			}
+			for _, item := range valNode.Content {
+				name, sel, err := config.ParseConditionalString(item, "name")
+				if err != nil {
+					return fmt.Errorf("parsing profile plugins.add: %w", err)
+				}
+				if sel != nil {
+					p.setWhen(config.WhenPlugins, name, *sel)
+				}
+				plugins.Add = append(plugins.Add, name)
+			}
+		case "remove":
+			if err := valNode.Decode(&plugins.Remove); err != nil {
+				return fmt.Errorf("parsing profile plugins.remove: %w", err)
+			}
+		}
+	}
+	p.Plugins = plugins
+	return nil
+}
+
+// parseProfilePermissions parses the permissions section of a profile YAML.
+// Rules in add_allow and add_deny may be {rule: <rule>, when: {...}}
+// mappings.
+func parseProfilePermissions(node *yaml.Node, p *Profile) error {
+	if node.Kind != yaml.MappingNode {
+		if err := node.Decode(&p.Permissions); err != nil {
+			return fmt.Errorf("parsing profile permissions: %w", err)
+		}
+		return nil
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key := node.Content[i].Value
+		var err error
+		switch key {
+		case "add_allow":
+			p.Permissions.AddAllow, err = config.ParseConditionalRules(node.Content[i+1], func(rule string, sel config.Selector) {
+				p.setWhen(config.WhenAllow, rule, sel)
+			})
+		case "add_deny":
+			p.Permissions.AddDeny, err = config.ParseConditionalRules(node.Content[i+1], func(rule string, sel config.Selector) {
+				p.setWhen(config.WhenDeny, rule, sel)
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("parsing profile permissions.%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// setWhen records a selector for an add entry.
+func (p *Profile) setWhen(section, name string, sel config.Selector) {
+	if p.When == nil {
+		p.When = make(map[string]config.Selector)
+	}
+	p.When[config.WhenKey(section, name)] = sel
+}
+
+// whenFor returns the selector recorded for an add entry, or nil.
+func (p *Profile) whenFor(section, name string) *config.Selector {
+	if sel, ok := p.When[config.WhenKey(section, name)]; ok {
+		return &sel
+	}
+	return nil
+}
+
 // parseProfileHooks parses the hooks section of a profile YAML.
 func parseProfileHooks(node *yaml.Node, p *Profile) error {
 	if node.Kind != yaml.MappingNode {
@@ -191,12 +336,24 @@ func parseProfileHooks(node *yaml.Node, p *Profile) error {
 
 		switch keyNode.Value {
 		case "add":
-			var hooksMap map[string]string
-			if err := valNode.Decode(&hooksMap); err != nil {
-				return fmt.Errorf("parsing profile hooks.add: %w", err)
+			if valNode.Kind != yaml.MappingNode {
+				var hooksMap map[string]string
+				if err := valNode.Decode(&hooksMap); err != nil {
+					return fmt.Errorf("parsing profile hooks.add: %w", err)
+				}
+				p.Hooks.Add = make(map[string]json.RawMessage)
+				continue
 			}
-			p.Hooks.Add = make(map[string]json.RawMessage, len(hooksMap))
-			for k, v := range hooksMap {
+			p.Hooks.Add = make(map[string]json.RawMessage, len(valNode.Content)/2)
+			for j := 0; j < len(valNode.Content)-1; j += 2 {
+				k := valNode.Content[j].Value
+				v, sel, err := config.ParseConditionalString(valNode.Content[j+1], "value")
+				if err != nil {
+					return fmt.Errorf("parsing profile hooks.add: %s: %w", k, err)
+				}
+				if sel != nil {
+					p.setWhen(config.WhenHooks, k, *sel)
+				}
 				if strings.HasPrefix(v, "[") && json.Valid([]byte(v)) {
 					p.Hooks.Add[k] = json.RawMessage(v)
 				} else {
@@ -233,6 +390,13 @@ func parseProfileMCP(node *yaml.Node, p *Profile) error {
 			}
 			p.MCP.Add = make(map[string]json.RawMessage, len(mcpRaw))
 			for k, v := range mcpRaw {
+				sel, err := config.SplitMCPWhen(v)
+				if err != nil {
+					return fmt.Errorf("parsing profile mcp.add %q: %w", k, err)
+				}
+				if sel != nil {
+					p.setWhen(config.WhenMCP, k, *sel)
+				}
 				data, err := json.Marshal(v)
 				if err != nil {
 					return fmt.Errorf("encoding profile mcp entry %q: %w", k, err)
@@ -253,18 +417,66 @@ func parseProfileMCP(node *yaml.Node, p *Profile) error {
 
 // MarshalProfile serializes a Profile to YAML bytes.
 // For hooks.add, json.RawMessage values are stored as their string
-// representation in YAML (same pattern as config.go MarshalV2).
+// representation in YAML (same pattern as config.go MarshalV2). Like
+// MarshalV2, a Profile returned by ParseProfile is written over the text it
+// was parsed from, keeping comments and layout where nothing changed.
 func MarshalProfile(p Profile) ([]byte, error) {
+	doc, err := buildProfileDocument(p)
+	if err != nil {
+		return nil, err
+	}
+	if p.source != nil {
+		if data, ok := yamledit.Rewrite(p.source, doc, rebuildProfileDocument); ok {
+			return data, nil
+		}
+	}
+	return yaml.Marshal(doc)
+}
+
+// rebuildProfileDocument parses data and renders it again, for
+// yamledit.Rewrite.
+func rebuildProfileDocument(data []byte) (*yaml.Node, error) {
+	p, err := ParseProfile(data)
+	if err != nil {
+		return nil, err
+	}
+	return buildProfileDocument(p)
+}
+
+// buildProfileDocument renders p as a fresh YAML document.
+func buildProfileDocument(p Profile) (*yaml.Node, error) {
 	doc := &yaml.Node{Kind: yaml.DocumentNode}
 	root := &yaml.Node{Kind: yaml.MappingNode}
 	doc.Content = append(doc.Content, root)
 
+	// extends
+	if len(p.Extends) > 0 {
+		var extendsNode yaml.Node
+		if err := extendsNode.Encode(p.Extends); err != nil {
+			return nil, fmt.Errorf("encoding profile extends: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "extends", Tag: "!!str"},
+			&extendsNode,
+		)
+	}
+
 	// plugins
 	if len(p.Plugins.Add) > 0 || len(p.Plugins.Remove) > 0 {
 		var pluginsNode yaml.Node
 		if err := pluginsNode.Encode(p.Plugins); err != nil {
 			return nil, fmt.Errorf("encoding profile plugins: %w", err)
 		}
+		if len(p.When) > 0 && len(pluginsNode.Content) >= 2 && pluginsNode.Content[0].Value == "add" {
+			addSeq := pluginsNode.Content[1]
+			for j, item := range addSeq.Content {
+				node, err := config.ConditionalStringNode(item.Value, "name", p.whenFor(config.WhenPlugins, item.Value))
+				if err != nil {
+					return nil, err
+				}
+				addSeq.Content[j] = node
+			}
+		}
 		root.Content = append(root.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "plugins", Tag: "!!str"},
 			&pluginsNode,
@@ -273,13 +485,15 @@ func MarshalProfile(p Profile) ([]byte, error) {
 
 	// settings
 	if len(p.Settings) > 0 {
-		var settingsNode yaml.Node
-		if err := settingsNode.Encode(p.Settings); err != nil {
+		settingsNode, err := config.ConditionalSettingsNode(p.Settings, func(key string) *config.Selector {
+			return p.whenFor(config.WhenSettings, key)
+		})
+		if err != nil {
 			return nil, fmt.Errorf("encoding profile settings: %w", err)
 		}
 		root.Content = append(root.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "settings", Tag: "!!str"},
-			&settingsNode,
+			settingsNode,
 		)
 	}
 
@@ -297,6 +511,15 @@ func MarshalProfile(p Profile) ([]byte, error) {
 			if err := addNode.Encode(hooksStrMap); err != nil {
 				return nil, fmt.Errorf("encoding profile hooks.add: %w", err)
 			}
+			for j := 0; j < len(addNode.Content)-1; j += 2 {
+				if sel := p.whenFor(config.WhenHooks, addNode.Content[j].Value); sel != nil {
+					node, err := config.ConditionalStringNode(addNode.Content[j+1].Value, "value", sel)
+					if err != nil {
+						return nil, err
+					}
+					addNode.Content[j+1] = node
+				}
+			}
 			hooksMap.Content = append(hooksMap.Content,
 				&yaml.Node{Kind: yaml.ScalarNode, Value: "add", Tag: "!!str"},
 				&addNode,
@@ -322,13 +545,31 @@ func MarshalProfile(p Profile) ([]byte, error) {
 
 	// permissions
 	if len(p.Permissions.AddAllow) > 0 || len(p.Permissions.AddDeny) > 0 {
-		var permsNode yaml.Node
-		if err := permsNode.Encode(p.Permissions); err != nil {
-			return nil, fmt.Errorf("encoding profile permissions: %w", err)
+		permsNode := &yaml.Node{Kind: yaml.MappingNode}
+		for _, list := range []struct {
+			key, section string
+			rules        []string
+		}{
+			{"add_allow", config.WhenAllow, p.Permissions.AddAllow},
+			{"add_deny", config.WhenDeny, p.Permissions.AddDeny},
+		} {
+			if len(list.rules) == 0 {
+				continue
+			}
+			rulesNode, err := config.ConditionalRulesNode(list.rules, func(rule string) *config.Selector {
+				return p.whenFor(list.section, rule)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("encoding profile permissions: %w", err)
+			}
+			permsNode.Content = append(permsNode.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: list.key, Tag: "!!str"},
+				rulesNode,
+			)
 		}
 		root.Content = append(root.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Value: "permissions", Tag: "!!str"},
-			&permsNode,
+			permsNode,
 		)
 	}
 
@@ -365,6 +606,11 @@ func MarshalProfile(p Profile) ([]byte, error) {
 			for k, v := range p.MCP.Add {
 				var val any
 				json.Unmarshal(v, &val)
+				if sel := p.whenFor(config.WhenMCP, k); sel != nil {
+					if obj, ok := val.(map[string]any); ok {
+						obj["when"] = *sel
+					}
+				}
 				addMap[k] = val
 			}
 			var addNode yaml.Node
@@ -430,7 +676,43 @@ func MarshalProfile(p Profile) ([]byte, error) {
 		)
 	}
 
-	return yaml.Marshal(doc)
+	// agents
+	if len(p.Agents.Add) > 0 || len(p.Agents.Remove) > 0 {
+		var agentsNode yaml.Node
+		if err := agentsNode.Encode(p.Agents); err != nil {
+			return nil, fmt.Errorf("encoding profile agents: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "agents", Tag: "!!str"},
+			&agentsNode,
+		)
+	}
+
+	// output styles
+	if len(p.OutputStyles.Add) > 0 || len(p.OutputStyles.Remove) > 0 {
+		var stylesNode yaml.Node
+		if err := stylesNode.Encode(p.OutputStyles); err != nil {
+			return nil, fmt.Errorf("encoding profile output_styles: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "output_styles", Tag: "!!str"},
+			&stylesNode,
+		)
+	}
+
+	// vars
+	if len(p.Vars) > 0 {
+		var varsNode yaml.Node
+		if err := varsNode.Encode(p.Vars); err != nil {
+			return nil, fmt.Errorf("encoding profile vars: %w", err)
+		}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "vars", Tag: "!!str"},
+			&varsNode,
+		)
+	}
+
+	return doc, nil
 }
 
 // ListProfiles returns sorted profile names by scanning profiles/*.yaml
@@ -471,29 +753,54 @@ func ReadProfile(syncDir, name string) (Profile, error) {
 	return ParseProfile(data)
 }
 
-// ReadActiveProfile reads the active-profile file from syncDir.
-// Returns "" and nil error if the file doesn't exist.
-func ReadActiveProfile(syncDir string) (string, error) {
+// ReadActiveProfiles reads the active-profile file from syncDir. The file
+// lists one profile name per line, in the order they are applied; later
+// profiles take precedence. Returns nil and nil error if the file doesn't
+// exist.
+func ReadActiveProfiles(syncDir string) ([]string, error) {
 	path := filepath.Join(syncDir, "active-profile")
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading active profile: %w", err)
+	}
+	var names []string
+	for _, name := range strings.Fields(string(data)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
 		}
-		return "", fmt.Errorf("reading active profile: %w", err)
 	}
-	return strings.TrimSpace(string(data)), nil
+	return names, nil
 }
 
-// WriteActiveProfile writes the profile name to the active-profile file in syncDir.
-func WriteActiveProfile(syncDir, name string) error {
+// ReadActiveProfile returns the highest-precedence (last) active profile,
+// which is where profile-scoped edits are written.
+// Returns "" and nil error if the file doesn't exist.
+func ReadActiveProfile(syncDir string) (string, error) {
+	names, err := ReadActiveProfiles(syncDir)
+	if err != nil || len(names) == 0 {
+		return "", err
+	}
+	return names[len(names)-1], nil
+}
+
+// WriteActiveProfiles writes the ordered profile names to the active-profile
+// file in syncDir.
+func WriteActiveProfiles(syncDir string, names []string) error {
 	path := filepath.Join(syncDir, "active-profile")
-	if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+	if err := os.WriteFile(path, []byte(strings.Join(names, "\n")+"\n"), 0644); err != nil {
 		return fmt.Errorf("writing active profile: %w", err)
 	}
 	return nil
 }
 
+// WriteActiveProfile writes the profile name to the active-profile file in syncDir.
+func WriteActiveProfile(syncDir, name string) error {
+	return WriteActiveProfiles(syncDir, []string{name})
+}
+
 // DeleteActiveProfile removes the active-profile file. No error if it doesn't exist.
 func DeleteActiveProfile(syncDir string) error {
 	path := filepath.Join(syncDir, "active-profile")
@@ -562,6 +869,22 @@ func MergeSettings(base map[string]any, profile Profile) map[string]any {
 	return result
 }
 
+// MergeVars copies base, then overlays profile.Vars on top. Same pattern as
+// MergeSettings.
+func MergeVars(base map[string]any, profile Profile) map[string]any {
+	if base == nil && profile.Vars == nil {
+		return nil
+	}
+	result := make(map[string]any, len(base)+len(profile.Vars))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range profile.Vars {
+		result[k] = v
+	}
+	return result
+}
+
 // MergeHooks copies base, adds profile.Hooks.Add entries, then removes
 // profile.Hooks.Remove entries.
 func MergeHooks(base map[string]json.RawMessage, profile Profile) map[string]json.RawMessage {
@@ -774,12 +1097,150 @@ func MergeSkills(base []string, profile Profile) []string {
 	return result
 }
 
+// MergeAgents starts with base, adds profile.Agents.Add (no duplicates),
+// then removes profile.Agents.Remove. Same pattern as MergePlugins.
+func MergeAgents(base []string, profile Profile) []string {
+	seen := make(map[string]bool, len(base))
+	result := make([]string, 0, len(base)+len(profile.Agents.Add))
+
+	for _, s := range base {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	for _, s := range profile.Agents.Add {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	if len(profile.Agents.Remove) > 0 {
+		removeSet := make(map[string]bool, len(profile.Agents.Remove))
+		for _, s := range profile.Agents.Remove {
+			removeSet[s] = true
+		}
+		filtered := result[:0]
+		for _, s := range result {
+			if !removeSet[s] {
+				filtered = append(filtered, s)
+			}
+		}
+		result = filtered
+	}
+	return result
+}
+
+// MergeOutputStyles starts with base, adds profile.OutputStyles.Add (no
+// duplicates), then removes profile.OutputStyles.Remove. Same pattern as
+// MergePlugins.
+func MergeOutputStyles(base []string, profile Profile) []string {
+	seen := make(map[string]bool, len(base))
+	result := make([]string, 0, len(base)+len(profile.OutputStyles.Add))
+
+	for _, s := range base {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	for _, s := range profile.OutputStyles.Add {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	if len(profile.OutputStyles.Remove) > 0 {
+		removeSet := make(map[string]bool, len(profile.OutputStyles.Remove))
+		for _, s := range profile.OutputStyles.Remove {
+			removeSet[s] = true
+		}
+		filtered := result[:0]
+		for _, s := range result {
+			if !removeSet[s] {
+				filtered = append(filtered, s)
+			}
+		}
+		result = filtered
+	}
+	return result
+}
+
+// ForMachine returns a copy of p without the plugins.add, hooks.add,
+// mcp.add, settings and permissions entries whose when: selector doesn't
+// match m, plus the entries dropped. p itself is not modified.
+func (p Profile) ForMachine(m config.Machine) (Profile, []config.FilteredEntry) {
+	if len(p.When) == 0 {
+		return p, nil
+	}
+
+	var filtered []config.FilteredEntry
+	excluded := func(section, name string) bool {
+		sel := p.whenFor(section, name)
+		if sel == nil || sel.Matches(m) {
+			return false
+		}
+		filtered = append(filtered, config.FilteredEntry{Section: section, Name: name, When: *sel})
+		return true
+	}
+
+	out := p
+	out.Plugins.Add = nil
+	for _, name := range p.Plugins.Add {
+		if !excluded(config.WhenPlugins, name) {
+			out.Plugins.Add = append(out.Plugins.Add, name)
+		}
+	}
+	if p.Hooks.Add != nil {
+		out.Hooks.Add = make(map[string]json.RawMessage, len(p.Hooks.Add))
+		for k, v := range p.Hooks.Add {
+			if !excluded(config.WhenHooks, k) {
+				out.Hooks.Add[k] = v
+			}
+		}
+	}
+	if p.MCP.Add != nil {
+		out.MCP.Add = make(map[string]json.RawMessage, len(p.MCP.Add))
+		for k, v := range p.MCP.Add {
+			if !excluded(config.WhenMCP, k) {
+				out.MCP.Add[k] = v
+			}
+		}
+	}
+	if p.Settings != nil {
+		out.Settings = make(map[string]any, len(p.Settings))
+		for k, v := range p.Settings {
+			if !excluded(config.WhenSettings, k) {
+				out.Settings[k] = v
+			}
+		}
+	}
+	out.Permissions = ProfilePermissions{}
+	for _, r := range p.Permissions.AddAllow {
+		if !excluded(config.WhenAllow, r) {
+			out.Permissions.AddAllow = append(out.Permissions.AddAllow, r)
+		}
+	}
+	for _, r := range p.Permissions.AddDeny {
+		if !excluded(config.WhenDeny, r) {
+			out.Permissions.AddDeny = append(out.Permissions.AddDeny, r)
+		}
+	}
+
+	config.SortFilteredEntries(filtered)
+	return out, filtered
+}
+
 // ProfileSummary returns a human-readable summary of profile changes.
 // Format: "+N plugin(s), -N plugin(s), key -> value, +N hook(s), -N hook(s)"
 // Returns "no changes" if the profile has no directives.
 func ProfileSummary(p Profile) string {
 	var parts []string
 
+	if len(p.Extends) > 0 {
+		parts = append(parts, "extends "+strings.Join(p.Extends, ", "))
+	}
+
 	if n := len(p.Plugins.Add); n > 0 {
 		parts = append(parts, fmt.Sprintf("+%d %s", n, pluralize("plugin", n)))
 	}
@@ -850,6 +1311,21 @@ func ProfileSummary(p Profile) string {
 	if n := len(p.Skills.Remove); n > 0 {
 		parts = append(parts, fmt.Sprintf("-%d %s", n, pluralize("skill", n)))
 	}
+	if n := len(p.Agents.Add); n > 0 {
+		parts = append(parts, fmt.Sprintf("+%d %s", n, pluralize("agent", n)))
+	}
+	if n := len(p.Agents.Remove); n > 0 {
+		parts = append(parts, fmt.Sprintf("-%d %s", n, pluralize("agent", n)))
+	}
+	if n := len(p.OutputStyles.Add); n > 0 {
+		parts = append(parts, fmt.Sprintf("+%d %s", n, pluralize("output style", n)))
+	}
+	if n := len(p.OutputStyles.Remove); n > 0 {
+		parts = append(parts, fmt.Sprintf("-%d %s", n, pluralize("output style", n)))
+	}
+	if n := len(p.Vars); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", n, pluralize("var", n)))
+	}
 
 	if len(parts) == 0 {
 		return "no changes"
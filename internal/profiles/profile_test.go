@@ -520,6 +520,38 @@ func TestActiveProfile(t *testing.T) {
 		err := profiles.DeleteActiveProfile(dir)
 		require.NoError(t, err) // should not error
 	})
+
+	t.Run("multiple profiles round-trip in order", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, profiles.WriteActiveProfiles(dir, []string{"backend", "security-reviewer"}))
+
+		names, err := profiles.ReadActiveProfiles(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backend", "security-reviewer"}, names)
+
+		// The single-name reader returns the highest-precedence profile.
+		name, err := profiles.ReadActiveProfile(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "security-reviewer", name)
+	})
+
+	t.Run("read list from single-name file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "active-profile"), []byte("work\n"), 0644))
+
+		names, err := profiles.ReadActiveProfiles(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work"}, names)
+	})
+
+	t.Run("read list ignores blank lines and duplicates", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "active-profile"), []byte("a\n\nb\na\n"), 0644))
+
+		names, err := profiles.ReadActiveProfiles(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, names)
+	})
 }
 
 func TestProfileSummary(t *testing.T) {
@@ -974,3 +1006,186 @@ memory:
 	assert.Contains(t, string(data), "memory:")
 	assert.Contains(t, string(data), "feedback-wheel-intake")
 }
+
+func TestProfileAgents(t *testing.T) {
+	p, err := profiles.ParseProfile([]byte(`
+agents:
+  add:
+    - agent:global:db-migrator
+  remove:
+    - agent:global:test-runner
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent:global:db-migrator"}, p.Agents.Add)
+	assert.Equal(t, []string{"agent:global:test-runner"}, p.Agents.Remove)
+
+	base := []string{"agent:global:code-reviewer", "agent:global:test-runner"}
+	assert.Equal(t, []string{"agent:global:code-reviewer", "agent:global:db-migrator"}, profiles.MergeAgents(base, p))
+
+	data, err := profiles.MarshalProfile(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "agents:")
+	assert.Contains(t, string(data), "agent:global:db-migrator")
+}
+
+func TestProfileOutputStyles(t *testing.T) {
+	p, err := profiles.ParseProfile([]byte(`
+output_styles:
+  add:
+    - style:global:explanatory
+  remove:
+    - style:global:terse
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"style:global:explanatory"}, p.OutputStyles.Add)
+
+	base := []string{"style:global:terse"}
+	assert.Equal(t, []string{"style:global:explanatory"}, profiles.MergeOutputStyles(base, p))
+
+	data, err := profiles.MarshalProfile(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "output_styles:")
+}
+
+func TestProfileVars(t *testing.T) {
+	p, err := profiles.ParseProfile([]byte(`
+vars:
+  team: platform
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"team": "platform"}, p.Vars)
+
+	base := map[string]any{"team": "core", "editor": "nvim"}
+	assert.Equal(t, map[string]any{"team": "platform", "editor": "nvim"}, profiles.MergeVars(base, p))
+	assert.Equal(t, "core", base["team"], "base is not modified")
+
+	data, err := profiles.MarshalProfile(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "vars:")
+}
+
+func TestParseProfile_When(t *testing.T) {
+	input := []byte(`plugins:
+  add:
+    - name: xcode-tools@m
+      when: {os: darwin}
+    - lint@m
+hooks:
+  add:
+    PreToolUse:
+      value: "docker-guard"
+      when: {binary: docker}
+mcp:
+  add:
+    internal-api:
+      command: api-server
+      when: {env: CORP_VPN}
+`)
+	p, err := profiles.ParseProfile(input)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"xcode-tools@m", "lint@m"}, p.Plugins.Add)
+	assertHookHasCommand(t, p.Hooks.Add["PreToolUse"], "docker-guard")
+	assert.Len(t, p.When, 3)
+
+	t.Run("round-trip", func(t *testing.T) {
+		data, err := profiles.MarshalProfile(p)
+		require.NoError(t, err)
+		parsed, err := profiles.ParseProfile(data)
+		require.NoError(t, err)
+		assert.Equal(t, p.Plugins.Add, parsed.Plugins.Add)
+		assert.Equal(t, p.When, parsed.When)
+		assert.JSONEq(t, string(p.MCP.Add["internal-api"]), string(parsed.MCP.Add["internal-api"]))
+	})
+
+	t.Run("ForMachine", func(t *testing.T) {
+		linux := config.Machine{
+			GOOS:      "linux",
+			LookupEnv: func(string) (string, bool) { return "", false },
+			LookPath:  func(string) (string, error) { return "", os.ErrNotExist },
+		}
+		out, filtered := p.ForMachine(linux)
+		assert.Equal(t, []string{"lint@m"}, out.Plugins.Add)
+		assert.Empty(t, out.Hooks.Add)
+		assert.Empty(t, out.MCP.Add)
+		assert.Len(t, filtered, 3)
+		assert.Len(t, p.Plugins.Add, 2, "original profile is untouched")
+	})
+}
+
+func TestParseProfile_WhenOnSettingsAndPermissions(t *testing.T) {
+	input := []byte(`settings:
+  model: opus
+  apiKeyHelper:
+    value: ~/bin/key-helper.sh
+    when: {os: darwin}
+permissions:
+  add_allow:
+    - Bash(ls *)
+    - rule: Bash(brew *)
+      when: {os: darwin}
+  add_deny:
+    - rule: Bash(apt *)
+      when: {os: linux}
+`)
+	p, err := profiles.ParseProfile(input)
+	require.NoError(t, err)
+	assert.Equal(t, "~/bin/key-helper.sh", p.Settings["apiKeyHelper"])
+	assert.Equal(t, []string{"Bash(ls *)", "Bash(brew *)"}, p.Permissions.AddAllow)
+	assert.Equal(t, []string{"Bash(apt *)"}, p.Permissions.AddDeny)
+	assert.Len(t, p.When, 3)
+
+	data, err := profiles.MarshalProfile(p)
+	require.NoError(t, err)
+	parsed, err := profiles.ParseProfile(data)
+	require.NoError(t, err)
+	assert.Equal(t, p.Settings, parsed.Settings)
+	assert.Equal(t, p.Permissions, parsed.Permissions)
+	assert.Equal(t, p.When, parsed.When)
+
+	linux := config.Machine{
+		GOOS:      "linux",
+		LookupEnv: func(string) (string, bool) { return "", false },
+		LookPath:  func(string) (string, error) { return "", os.ErrNotExist },
+	}
+	out, filtered := p.ForMachine(linux)
+	assert.Equal(t, map[string]any{"model": "opus"}, out.Settings)
+	assert.Equal(t, []string{"Bash(ls *)"}, out.Permissions.AddAllow)
+	assert.Equal(t, []string{"Bash(apt *)"}, out.Permissions.AddDeny)
+	assert.Len(t, filtered, 2)
+}
+
+func TestMarshalProfile_PreservesLayout(t *testing.T) {
+	src := `# On-call additions
+extends: work
+
+plugins:
+  add:
+    - pagerduty@tools  # incident lookups
+
+permissions:
+  add_allow:
+    - Bash(kubectl get *)  # read-only
+`
+	p, err := profiles.ParseProfile([]byte(src))
+	require.NoError(t, err)
+
+	data, err := profiles.MarshalProfile(p)
+	require.NoError(t, err)
+	assert.Equal(t, src, string(data))
+
+	p.Permissions.AddAllow = append(p.Permissions.AddAllow, "Bash(kubectl logs *)")
+	data, err = profiles.MarshalProfile(p)
+	require.NoError(t, err)
+	assert.Equal(t, `# On-call additions
+extends: work
+
+plugins:
+  add:
+    - pagerduty@tools  # incident lookups
+
+permissions:
+  add_allow:
+    - Bash(kubectl get *) # read-only
+    - Bash(kubectl logs *)
+`, string(data))
+}
@@ -0,0 +1,184 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// RulesFileName is the file in the sync repo that maps project directories
+// to profiles.
+const RulesFileName = "profile-rules.yaml"
+
+// DefaultProjectedKeys are projected when a rule doesn't list keys.
+var DefaultProjectedKeys = []string{"hooks", "permissions"}
+
+// ProfileRule selects a profile for projects matching Path and/or Remote.
+// When both are set, both must match.
+type ProfileRule struct {
+	Path    string   `yaml:"path,omitempty"`   // directory glob; "~" is the home dir and "**" spans directories
+	Remote  string   `yaml:"remote,omitempty"` // origin remote glob, e.g. github.com/acme/*
+	Profile string   `yaml:"profile"`          // empty = base config only
+	Keys    []string `yaml:"keys,omitempty"`   // projected keys; defaults to DefaultProjectedKeys
+}
+
+// ProfileRules represents profile-rules.yaml.
+type ProfileRules struct {
+	Rules []ProfileRule `yaml:"rules"`
+}
+
+// ProjectedKeys returns the rule's keys, or the defaults if none are listed.
+func (r ProfileRule) ProjectedKeys() []string {
+	if len(r.Keys) > 0 {
+		return r.Keys
+	}
+	return DefaultProjectedKeys
+}
+
+// String describes the rule's conditions, e.g. `path ~/work/**, remote github.com/acme/*`.
+func (r ProfileRule) String() string {
+	var parts []string
+	if r.Path != "" {
+		parts = append(parts, "path "+r.Path)
+	}
+	if r.Remote != "" {
+		parts = append(parts, "remote "+r.Remote)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReadProfileRules reads profile-rules.yaml from syncDir. A missing file
+// yields no rules.
+func ReadProfileRules(syncDir string) ([]ProfileRule, error) {
+	data, err := os.ReadFile(filepath.Join(syncDir, RulesFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rules ProfileRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", RulesFileName, err)
+	}
+	for i, r := range rules.Rules {
+		if r.Path == "" && r.Remote == "" {
+			return nil, fmt.Errorf("parsing %s: rule %d has neither path nor remote", RulesFileName, i+1)
+		}
+	}
+	return rules.Rules, nil
+}
+
+// RuleEvaluation explains whether one rule matched a directory and why.
+type RuleEvaluation struct {
+	Rule    ProfileRule
+	Matched bool
+	Reason  string
+}
+
+// EvaluateRules checks every rule, in file order, against dir and its
+// normalized origin remote (empty if it has none). The first matching
+// evaluation is the one that applies.
+func EvaluateRules(rules []ProfileRule, dir, remote string) []RuleEvaluation {
+	home, _ := os.UserHomeDir()
+	evals := make([]RuleEvaluation, 0, len(rules))
+	for _, r := range rules {
+		eval := RuleEvaluation{Rule: r, Matched: true}
+		var reasons []string
+		if r.Path != "" {
+			pattern := expandHome(r.Path, home)
+			if matchGlob(filepath.ToSlash(pattern), filepath.ToSlash(dir)) {
+				reasons = append(reasons, fmt.Sprintf("path %s matches %s", r.Path, dir))
+			} else {
+				eval.Matched = false
+				reasons = append(reasons, fmt.Sprintf("path %s does not match %s", r.Path, dir))
+			}
+		}
+		if r.Remote != "" {
+			switch {
+			case remote == "":
+				eval.Matched = false
+				reasons = append(reasons, "no origin remote")
+			case matchGlob(strings.ToLower(r.Remote), strings.ToLower(remote)):
+				reasons = append(reasons, fmt.Sprintf("remote %s matches %s", r.Remote, remote))
+			default:
+				eval.Matched = false
+				reasons = append(reasons, fmt.Sprintf("remote %s does not match %s", r.Remote, remote))
+			}
+		}
+		eval.Reason = strings.Join(reasons, "; ")
+		evals = append(evals, eval)
+	}
+	return evals
+}
+
+// MatchRule returns the first rule matching dir and remote.
+func MatchRule(rules []ProfileRule, dir, remote string) (ProfileRule, bool) {
+	for _, e := range EvaluateRules(rules, dir, remote) {
+		if e.Matched {
+			return e.Rule, true
+		}
+	}
+	return ProfileRule{}, false
+}
+
+// NormalizeRemote reduces a git remote URL to host/path form, so that
+// git@github.com:acme/api.git and https://github.com/acme/api both become
+// github.com/acme/api.
+func NormalizeRemote(url string) string {
+	s := strings.TrimSpace(url)
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	} else if at := strings.Index(s, "@"); at >= 0 {
+		// SCP-style: user@host:path
+		s = strings.Replace(s[at+1:], ":", "/", 1)
+	}
+	if at := strings.Index(s, "@"); at >= 0 && at < strings.Index(s+"/", "/") {
+		s = s[at+1:]
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/"), ".git")
+	return s
+}
+
+func expandHome(p, home string) string {
+	if home != "" && (p == "~" || strings.HasPrefix(p, "~/")) {
+		return home + p[1:]
+	}
+	return p
+}
+
+// matchGlob matches a slash-separated name against pattern, where each
+// segment uses path.Match syntax and a "**" segment matches zero or more
+// segments.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(splitSegments(pattern), splitSegments(name))
+}
+
+func splitSegments(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == '/' })
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,99 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"/home/u/work/**", "/home/u/work", true},
+		{"/home/u/work/**", "/home/u/work/api", true},
+		{"/home/u/work/**", "/home/u/work/team/api", true},
+		{"/home/u/work/**", "/home/u/personal/api", false},
+		{"/home/u/work/*", "/home/u/work/api", true},
+		{"/home/u/work/*", "/home/u/work/team/api", false},
+		{"/home/u/**/api", "/home/u/work/team/api", true},
+		{"github.com/acme/*", "github.com/acme/api", true},
+		{"github.com/acme/*", "github.com/acme-labs/api", false},
+		{"*.com/acme/**", "gitlab.com/acme/group/api", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchGlob(tt.pattern, tt.name), "%s vs %s", tt.pattern, tt.name)
+	}
+}
+
+func TestNormalizeRemote(t *testing.T) {
+	for url, want := range map[string]string{
+		"git@github.com:acme/api.git":          "github.com/acme/api",
+		"https://github.com/acme/api.git":      "github.com/acme/api",
+		"https://token@github.com/acme/api":    "github.com/acme/api",
+		"ssh://git@gitlab.com/acme/group/api/": "gitlab.com/acme/group/api",
+	} {
+		assert.Equal(t, want, NormalizeRemote(url), url)
+	}
+}
+
+func TestReadProfileRules(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		rules, err := ReadProfileRules(t.TempDir())
+		require.NoError(t, err)
+		assert.Nil(t, rules)
+	})
+
+	t.Run("parses rules", func(t *testing.T) {
+		dir := t.TempDir()
+		data := "rules:\n  - path: ~/work/**\n    profile: work\n  - remote: github.com/acme/*\n    profile: acme\n    keys: [hooks, mcp]\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, RulesFileName), []byte(data), 0644))
+
+		rules, err := ReadProfileRules(dir)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, "~/work/**", rules[0].Path)
+		assert.Equal(t, DefaultProjectedKeys, rules[0].ProjectedKeys())
+		assert.Equal(t, []string{"hooks", "mcp"}, rules[1].ProjectedKeys())
+	})
+
+	t.Run("rule without conditions", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, RulesFileName), []byte("rules:\n  - profile: work\n"), 0644))
+		_, err := ReadProfileRules(dir)
+		assert.ErrorContains(t, err, "rule 1 has neither path nor remote")
+	})
+}
+
+func TestEvaluateRules(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	rules := []ProfileRule{
+		{Remote: "github.com/acme/*", Profile: "acme"},
+		{Path: "~/work/**", Profile: "work"},
+		{Path: "~/work/**", Remote: "github.com/other/*", Profile: "other"},
+	}
+	dir := filepath.Join(home, "work", "api")
+
+	evals := EvaluateRules(rules, dir, "github.com/other/api")
+	require.Len(t, evals, 3)
+	assert.False(t, evals[0].Matched)
+	assert.Equal(t, "remote github.com/acme/* does not match github.com/other/api", evals[0].Reason)
+	assert.True(t, evals[1].Matched)
+	assert.True(t, evals[2].Matched)
+
+	rule, ok := MatchRule(rules, dir, "github.com/other/api")
+	require.True(t, ok)
+	assert.Equal(t, "work", rule.Profile, "first matching rule wins")
+
+	rule, ok = MatchRule(rules, "/srv/api", "github.com/acme/api")
+	require.True(t, ok)
+	assert.Equal(t, "acme", rule.Profile)
+
+	_, ok = MatchRule(rules, "/srv/api", "")
+	assert.False(t, ok)
+}
@@ -0,0 +1,326 @@
+// Package schema validates claude-sync's YAML files against the JSON Schemas
+// embedded here, reporting each problem at the line and column it occurs.
+//
+// The schemas describe what the parsers in config, profiles and project
+// accept. They are stricter in one way: keys the parsers would silently
+// ignore are reported as unknown.
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Kind names one of the file formats a schema exists for.
+type Kind string
+
+const (
+	Config          Kind = "config"           // config.yaml
+	Profile         Kind = "profile"          // profiles/<name>.yaml
+	ProfileRules    Kind = "profile-rules"    // profile-rules.yaml
+	Project         Kind = "project"          // .claude/.claude-sync.yaml
+	UserPreferences Kind = "user-preferences" // user-preferences.yaml
+)
+
+// Kinds lists every kind with a schema.
+var Kinds = []Kind{Config, Profile, ProfileRules, Project, UserPreferences}
+
+const baseURL = "https://github.com/ruminaider/claude-sync/schema/"
+
+// pluginKeyPattern is the pattern of the pluginKey, pluginVersions and
+// pinnedEntry definitions, recognized in errors so they can say what's
+// wrong in plain words.
+const pluginKeyPattern = `^[^@\s]+@[^@\s]+$`
+
+// scriptPathPattern is the pattern of scripts entries: a clean ~/ path that
+// doesn't climb out of the home directory.
+const scriptPathPattern = `^~/(?:(?:[^./][^/]*|\.[^./][^/]*|\.\.[^/]+)/)*(?:[^./][^/]*|\.[^./][^/]*|\.\.[^/]+)$`
+
+//go:embed *.schema.json
+var files embed.FS
+
+var (
+	compileOnce sync.Once
+	compiled    map[Kind]*jsonschema.Schema
+	compileErr  error
+)
+
+// Issue is one problem found in a file.
+type Issue struct {
+	Line    int
+	Column  int
+	Path    string // e.g. plugins.upstream[2]; empty for the document itself
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Path, i.Message)
+}
+
+// Source returns the JSON Schema for kind.
+func Source(k Kind) ([]byte, error) {
+	data, err := files.ReadFile(string(k) + ".schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %q", k)
+	}
+	return data, nil
+}
+
+// Validate checks data, a YAML document of the given kind, against its
+// schema. Syntax errors are reported as issues too; the error is only for an
+// unknown kind.
+func Validate(k Kind, data []byte) ([]Issue, error) {
+	sch, err := schemaFor(k)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Issue{syntaxIssue(err)}, nil
+	}
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+
+	var raw any
+	if doc.Kind != 0 {
+		if err := root.Decode(&raw); err != nil {
+			return []Issue{syntaxIssue(err)}, nil
+		}
+	}
+	encoded, err := json.Marshal(jsonValue(raw))
+	if err != nil {
+		return []Issue{{Line: 1, Column: 1, Message: err.Error()}}, nil
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+	if err != nil {
+		return []Issue{{Line: 1, Column: 1, Message: err.Error()}}, nil
+	}
+
+	verr, ok := sch.Validate(inst).(*jsonschema.ValidationError)
+	if !ok {
+		return nil, nil
+	}
+	var issues []Issue
+	collect(verr, root, &issues)
+	return sortIssues(issues), nil
+}
+
+// At returns an issue with msg located at loc, a path of mapping keys and
+// sequence indexes into the YAML document data. It is for checks made
+// outside the schema that still want to point at the offending line.
+func At(data []byte, msg string, loc ...string) Issue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return Issue{Line: 1, Column: 1, Message: msg}
+	}
+	return issueAt(doc.Content[0], loc, false, msg)
+}
+
+func schemaFor(k Kind) (*jsonschema.Schema, error) {
+	compileOnce.Do(func() {
+		c := jsonschema.NewCompiler()
+		entries, err := files.ReadDir(".")
+		if err != nil {
+			compileErr = err
+			return
+		}
+		for _, e := range entries {
+			data, err := files.ReadFile(e.Name())
+			if err != nil {
+				compileErr = err
+				return
+			}
+			doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+			if err != nil {
+				compileErr = fmt.Errorf("%s: %w", e.Name(), err)
+				return
+			}
+			if err := c.AddResource(baseURL+e.Name(), doc); err != nil {
+				compileErr = err
+				return
+			}
+		}
+		compiled = make(map[Kind]*jsonschema.Schema, len(Kinds))
+		for _, kind := range Kinds {
+			sch, err := c.Compile(baseURL + string(kind) + ".schema.json")
+			if err != nil {
+				compileErr = err
+				return
+			}
+			compiled[kind] = sch
+		}
+	})
+	if compileErr != nil {
+		return nil, fmt.Errorf("compiling schemas: %w", compileErr)
+	}
+	sch, ok := compiled[k]
+	if !ok {
+		return nil, fmt.Errorf("no schema for %q", k)
+	}
+	return sch, nil
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// syntaxIssue turns a YAML parse error into an issue at the line it names.
+func syntaxIssue(err error) Issue {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	line := 1
+	if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = strings.TrimPrefix(msg, m[0]+": ")
+	}
+	return Issue{Line: line, Column: 1, Message: msg}
+}
+
+// jsonValue converts a decoded YAML value into one encoding/json accepts.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = jsonValue(val)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[fmt.Sprint(k)] = jsonValue(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = jsonValue(val)
+		}
+		return out
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return v
+}
+
+var printer = message.NewPrinter(language.English)
+
+// collect appends an issue for every leaf error under e.
+func collect(e *jsonschema.ValidationError, root *yaml.Node, issues *[]Issue) {
+	switch k := e.ErrorKind.(type) {
+	case *kind.AdditionalProperties:
+		for _, prop := range k.Properties {
+			loc := append(append([]string(nil), e.InstanceLocation...), prop)
+			msg := "unknown key"
+			if strings.HasSuffix(e.SchemaURL, "#/$defs/pluginVersions") || strings.HasSuffix(e.SchemaURL, "#/$defs/pinnedEntry") {
+				msg = fmt.Sprintf("malformed plugin key %q (want name@marketplace)", prop)
+			}
+			*issues = append(*issues, issueAt(root, loc, true, msg))
+		}
+		return
+	case *kind.Pattern:
+		if k.Want == pluginKeyPattern {
+			msg := fmt.Sprintf("malformed plugin key %q (want name@marketplace)", k.Got)
+			*issues = append(*issues, issueAt(root, e.InstanceLocation, false, msg))
+			return
+		}
+		if k.Want == scriptPathPattern {
+			msg := fmt.Sprintf("script %q isn't a file under ~/", k.Got)
+			*issues = append(*issues, issueAt(root, e.InstanceLocation, false, msg))
+			return
+		}
+	}
+	if len(e.Causes) == 0 {
+		*issues = append(*issues, issueAt(root, e.InstanceLocation, false, e.ErrorKind.LocalizedString(printer)))
+		return
+	}
+	for _, c := range e.Causes {
+		collect(c, root, issues)
+	}
+}
+
+// issueAt locates loc in the YAML tree. With key set, the issue points at
+// the mapping key rather than its value. If loc can't be followed all the way
+// (through a merge key, say) the issue points at the deepest node found.
+func issueAt(root *yaml.Node, loc []string, key bool, msg string) Issue {
+	node := root
+	var path strings.Builder
+	found := true
+	for i, tok := range loc {
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		if node.Kind == yaml.SequenceNode {
+			path.WriteString("[" + tok + "]")
+		} else {
+			if path.Len() > 0 {
+				path.WriteString(".")
+			}
+			path.WriteString(tok)
+		}
+		if !found {
+			continue
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == tok {
+					next = node.Content[j+1]
+					if key && i == len(loc)-1 {
+						next = node.Content[j]
+					}
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if n, err := strconv.Atoi(tok); err == nil && n < len(node.Content) {
+				next = node.Content[n]
+			}
+		}
+		if next == nil {
+			found = false
+			continue
+		}
+		node = next
+	}
+	line, col := node.Line, node.Column
+	if line == 0 {
+		line, col = 1, 1
+	}
+	return Issue{Line: line, Column: col, Path: path.String(), Message: msg}
+}
+
+func sortIssues(issues []Issue) []Issue {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	out := issues[:0]
+	for i, issue := range issues {
+		if i > 0 && issue == issues[i-1] {
+			continue
+		}
+		out = append(out, issue)
+	}
+	return out
+}
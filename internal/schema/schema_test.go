@@ -0,0 +1,130 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func messages(issues []schema.Issue) []string {
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = issue.String()
+	}
+	return out
+}
+
+func TestValidate_Config(t *testing.T) {
+	valid := `version: "2.0.0"
+plugins:
+  upstream:
+    - context7@claude-plugins-official
+    - name: beads@beads-marketplace
+      when:
+        os: darwin
+  pinned:
+    - superpowers@superpowers-marketplace: "1.2.0"
+    - brew-tools@tools-marketplace: "0.3.0"
+      when: {os: darwin}
+  forked:
+    - my-plugin
+settings:
+  model: opus
+  apiKeyHelper:
+    value: ~/bin/key-helper.sh
+    when: {os: darwin}
+permissions:
+  allow:
+    - Bash(ls *)
+    - rule: Bash(brew *)
+      when: {os: darwin}
+hooks:
+  PreToolUse: echo hi
+  Stop:
+    value: notify
+    when: {binary: [notify]}
+mcp:
+  github:
+    command: gh-mcp
+    env:
+      TOKEN: ${GITHUB_TOKEN}
+    when:
+      env: GITHUB_TOKEN
+claude_md:
+  include: [base, python::testing]
+scripts:
+  - ~/.claude/statusline.sh
+  - ~/..hidden/helper.sh
+`
+	issues, err := schema.Validate(schema.Config, []byte(valid))
+	require.NoError(t, err)
+	assert.Empty(t, messages(issues))
+
+	invalid := `version: "2.0.0"
+plugin:
+  upstream: []
+plugins:
+  upstream:
+    - context7
+    - name: beads@beads-marketplace
+      when:
+        hostname: laptop
+  pinned:
+    - superpowers: "1.2.0"
+hooks:
+  Stop:
+    command: notify
+mcp:
+  github:
+    env:
+      PORT: 8080
+scripts:
+  - ~/../outside/pwn.sh
+`
+	issues, err = schema.Validate(schema.Config, []byte(invalid))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`2:1: plugin: unknown key`,
+		`6:7: plugins.upstream[0]: malformed plugin key "context7" (want name@marketplace)`,
+		`9:9: plugins.upstream[1].when.hostname: unknown key`,
+		`11:7: plugins.pinned[0].superpowers: malformed plugin key "superpowers" (want name@marketplace)`,
+		`14:5: hooks.Stop: missing property 'value'`,
+		`14:5: hooks.Stop.command: unknown key`,
+		`18:13: mcp.github.env.PORT: got number, want string`,
+		`20:5: scripts[0]: script "~/../outside/pwn.sh" isn't a file under ~/`,
+	}, messages(issues))
+}
+
+func TestValidate_SyntaxError(t *testing.T) {
+	issues, err := schema.Validate(schema.Profile, []byte("extends: base\nsettings: x\n model: opus\n"))
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+}
+
+func TestValidate_OtherKinds(t *testing.T) {
+	issues, err := schema.Validate(schema.Profile, nil)
+	require.NoError(t, err)
+	assert.Empty(t, issues, "an empty profile is valid")
+
+	issues, err = schema.Validate(schema.UserPreferences, []byte("sync_mode: strict\nsync:\n  snapshot_keep: -1\n"))
+	require.NoError(t, err)
+	assert.Len(t, issues, 2)
+
+	issues, err = schema.Validate(schema.UserPreferences, []byte("template_env: [TEAM, EDITOR]\nvars:\n  editor: nvim\n"))
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	issues, err = schema.Validate(schema.Project, []byte("version: \"1\"\nprofile: work\noverides: {}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3:1: overides: unknown key"}, messages(issues))
+
+	issues, err = schema.Validate(schema.ProfileRules, []byte("rules:\n  - path: ~/work/**\n    profile: work\n"))
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	_, err = schema.Validate("nope", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+// Package secrets resolves the ${NAME} references that mcp-import leaves in
+// MCP server env blocks. Values come from a chain of resolvers: an
+// age-encrypted local store, an optional user-supplied command (e.g.
+// "pass show {name}") and finally the process environment.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Resolver looks up secret values by name.
+type Resolver interface {
+	// Resolve returns the value of name. ok is false if the resolver has no
+	// value for it, in which case the next resolver in a Chain is tried.
+	Resolve(name string) (value string, ok bool, err error)
+}
+
+// Chain tries each resolver in order and returns the first value found.
+// An error from one resolver doesn't stop the others from being consulted;
+// it is returned only if no resolver has the value.
+type Chain []Resolver
+
+func (c Chain) Resolve(name string) (string, bool, error) {
+	var firstErr error
+	for _, r := range c {
+		v, ok, err := r.Resolve(name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok {
+			return v, true, nil
+		}
+	}
+	return "", false, firstErr
+}
+
+// Env resolves secrets from the process environment.
+type Env struct{}
+
+func (Env) Resolve(name string) (string, bool, error) {
+	v, ok := os.LookupEnv(name)
+	return v, ok, nil
+}
+
+// Command resolves secrets by running a shell command. Every "{name}" in
+// Template is replaced with the shell-quoted secret name. The first line of
+// stdout is the value; a non-zero exit or empty output means "not found",
+// which is how pass, gopass and most keychain CLIs report a missing entry.
+type Command struct {
+	Template string
+}
+
+func (c Command) Resolve(name string) (string, bool, error) {
+	line := strings.ReplaceAll(c.Template, "{name}", shellQuote(name))
+	cmd := exec.Command("sh", "-c", line)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("running secret_command: %w", err)
+	}
+	value, _, _ := strings.Cut(stdout.String(), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	if value == "" {
+		return "", false, nil
+	}
+	return value, true, nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,159 @@
+package secrets_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapResolver map[string]string
+
+func (m mapResolver) Resolve(name string) (string, bool, error) {
+	v, ok := m[name]
+	return v, ok, nil
+}
+
+type failingResolver struct{}
+
+func (failingResolver) Resolve(string) (string, bool, error) {
+	return "", false, errors.New("backend unavailable")
+}
+
+func TestChain_Resolve(t *testing.T) {
+	chain := secrets.Chain{
+		mapResolver{"A": "from-first"},
+		failingResolver{},
+		mapResolver{"A": "shadowed", "B": "from-last"},
+	}
+
+	v, ok, err := chain.Resolve("A")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "from-first", v)
+
+	// An error in one resolver doesn't hide a value from a later one.
+	v, ok, err = chain.Resolve("B")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "from-last", v)
+
+	// With no value anywhere, the error is reported.
+	_, ok, err = chain.Resolve("C")
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "backend unavailable")
+}
+
+func TestCommand_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "API_KEY"), []byte("s3cret\nurl: https://example.com\n"), 0600))
+	cmd := secrets.Command{Template: "cat " + dir + "/{name}"}
+
+	// First line of output only, as with pass.
+	v, ok, err := cmd.Resolve("API_KEY")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "s3cret", v)
+
+	// Non-zero exit means not found.
+	_, ok, err = cmd.Resolve("MISSING")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// The name is quoted, so it can't inject shell syntax.
+	_, ok, err = cmd.Resolve("x; echo injected")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "secrets.age")
+	idPath := filepath.Join(dir, "keys", "identity.txt")
+	store := secrets.NewStore(storePath, idPath)
+
+	// Nothing stored yet: empty, no identity needed.
+	names, err := store.Names()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	_, ok, err := store.Resolve("API_KEY")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Set("API_KEY", "s3cret"))
+	require.NoError(t, store.Set("OTHER_TOKEN", "t0ken"))
+	assert.Error(t, store.Set("not a name", "x"))
+
+	info, err := os.Stat(idPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(storePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cret")
+
+	// A fresh store instance decrypts with the same identity.
+	reopened := secrets.NewStore(storePath, idPath)
+	v, ok, err := reopened.Resolve("API_KEY")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "s3cret", v)
+
+	names, err = reopened.Names()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API_KEY", "OTHER_TOKEN"}, names)
+
+	removed, err := reopened.Delete("OTHER_TOKEN")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	_, ok, err = reopened.Get("OTHER_TOKEN")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Another identity can't read the store.
+	other := secrets.NewStore(storePath, filepath.Join(dir, "other.txt"))
+	_, err = secrets.LoadOrCreateIdentity(other.IdentityPath)
+	require.NoError(t, err)
+	_, _, err = other.Get("API_KEY")
+	assert.Error(t, err)
+}
+
+func TestEncrypt_DecryptsForEachRecipient(t *testing.T) {
+	dir := t.TempDir()
+	alice, err := secrets.LoadOrCreateIdentity(filepath.Join(dir, "alice.txt"))
+	require.NoError(t, err)
+	bob, err := secrets.LoadOrCreateIdentity(filepath.Join(dir, "bob.txt"))
+	require.NoError(t, err)
+	_, err = secrets.LoadOrCreateIdentity(filepath.Join(dir, "carol.txt"))
+	require.NoError(t, err)
+
+	_, err = secrets.Encrypt("tok", nil)
+	assert.Error(t, err)
+	_, err = secrets.Encrypt("tok", map[string]string{"x": "not-a-key"})
+	assert.Error(t, err)
+
+	enc, err := secrets.Encrypt("tok", map[string]string{
+		"alice": alice.Recipient().String(),
+		"bob":   bob.Recipient().String(),
+	})
+	require.NoError(t, err)
+	assert.True(t, secrets.IsEncrypted(enc))
+	assert.False(t, secrets.IsEncrypted("tok"))
+
+	for _, name := range []string{"alice", "bob"} {
+		dec := &secrets.IdentityDecrypter{Path: filepath.Join(dir, name+".txt")}
+		plain, err := dec.Decrypt(enc)
+		require.NoError(t, err, name)
+		assert.Equal(t, "tok", plain)
+	}
+
+	_, err = (&secrets.IdentityDecrypter{Path: filepath.Join(dir, "carol.txt")}).Decrypt(enc)
+	assert.ErrorContains(t, err, "not encrypted to your key")
+
+	_, err = (&secrets.IdentityDecrypter{Path: filepath.Join(dir, "missing.txt")}).Decrypt(enc)
+	assert.ErrorIs(t, err, secrets.ErrNoIdentity)
+}
@@ -0,0 +1,224 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"filippo.io/age"
+	"go.yaml.in/yaml/v3"
+)
+
+// namePattern restricts store entries to names usable as ${NAME} references.
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidName reports whether name can be stored and referenced as ${name}.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Store is a name -> value map kept in a single age-encrypted file. The file
+// is encrypted to the X25519 identity at IdentityPath, which is generated on
+// the first Set.
+type Store struct {
+	Path         string
+	IdentityPath string
+
+	loaded map[string]string // cache for Resolve
+}
+
+// NewStore returns a store backed by path and encrypted to identityPath.
+func NewStore(path, identityPath string) *Store {
+	return &Store{Path: path, IdentityPath: identityPath}
+}
+
+// Load decrypts and returns every entry. A missing store file is empty.
+func (s *Store) Load() (map[string]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading secret store: %w", err)
+	}
+	id, err := LoadIdentity(s.IdentityPath)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), id)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", s.Path, err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", s.Path, err)
+	}
+	values := map[string]string{}
+	if err := yaml.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("parsing secret store: %w", err)
+	}
+	if values == nil {
+		values = map[string]string{}
+	}
+	return values, nil
+}
+
+// Names returns the stored secret names, sorted.
+func (s *Store) Names() ([]string, error) {
+	values, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Get returns the stored value for name.
+func (s *Store) Get(name string) (string, bool, error) {
+	values, err := s.Load()
+	if err != nil {
+		return "", false, err
+	}
+	v, ok := values[name]
+	return v, ok, nil
+}
+
+// Set stores value under name, creating the store and identity if needed.
+func (s *Store) Set(name, value string) error {
+	if !ValidName(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits and underscores", name)
+	}
+	if _, err := LoadOrCreateIdentity(s.IdentityPath); err != nil {
+		return err
+	}
+	values, err := s.Load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return s.save(values)
+}
+
+// Delete removes name from the store. It reports whether name was present.
+func (s *Store) Delete(name string) (bool, error) {
+	values, err := s.Load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := values[name]; !ok {
+		return false, nil
+	}
+	delete(values, name)
+	return true, s.save(values)
+}
+
+// Resolve implements Resolver. The store is decrypted once and cached. A
+// store that doesn't exist yet resolves nothing.
+func (s *Store) Resolve(name string) (string, bool, error) {
+	if s.loaded == nil {
+		values, err := s.Load()
+		if err != nil {
+			return "", false, err
+		}
+		s.loaded = values
+	}
+	v, ok := s.loaded[name]
+	return v, ok, nil
+}
+
+func (s *Store) save(values map[string]string) error {
+	id, err := LoadIdentity(s.IdentityPath)
+	if err != nil {
+		return err
+	}
+	plain, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("encoding secret store: %w", err)
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, id.Recipient())
+	if err != nil {
+		return fmt.Errorf("encrypting secret store: %w", err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		return fmt.Errorf("encrypting secret store: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("encrypting secret store: %w", err)
+	}
+	s.loaded = nil
+	return writePrivate(s.Path, buf.Bytes())
+}
+
+// LoadIdentity reads an age X25519 identity file as written by age-keygen.
+func LoadIdentity(path string) (*age.X25519Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading identity: %w", err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing identity %s: %w", path, err)
+	}
+	for _, id := range ids {
+		if x, ok := id.(*age.X25519Identity); ok {
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("no X25519 identity in %s", path)
+}
+
+// LoadOrCreateIdentity reads the identity at path, generating and writing a
+// new one (mode 0600) if the file doesn't exist.
+func LoadOrCreateIdentity(path string) (*age.X25519Identity, error) {
+	if _, err := os.Stat(path); err == nil {
+		return LoadIdentity(path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading identity: %w", err)
+	}
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, fmt.Errorf("generating identity: %w", err)
+	}
+	content := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n",
+		time.Now().Format(time.RFC3339), id.Recipient(), id)
+	if err := writePrivate(path, []byte(content)); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// writePrivate atomically writes data to path with mode 0600.
+func writePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Team secrets are values in config.yaml encrypted to every recipient listed
+// under "recipients:". They are stored inline as ENC[age:<base64>], so a
+// value can be moved between MCP env blocks and settings without re-encoding.
+const (
+	encPrefix = "ENC[age:"
+	encSuffix = "]"
+)
+
+// ErrNoIdentity is returned when decrypting without an identity file.
+var ErrNoIdentity = errors.New("no identity; run 'claude-sync secrets pubkey' and ask a team member to add you as a recipient")
+
+// IsEncrypted reports whether v is an ENC[age:...] value.
+func IsEncrypted(v string) bool {
+	return strings.HasPrefix(v, encPrefix) && strings.HasSuffix(v, encSuffix)
+}
+
+// ParseRecipient validates an age X25519 public key ("age1...").
+func ParseRecipient(key string) (*age.X25519Recipient, error) {
+	r, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", key, err)
+	}
+	return r, nil
+}
+
+// Encrypt encrypts plaintext to every recipient in recipients (name ->
+// public key) and returns it as an ENC[age:...] value.
+func Encrypt(plaintext string, recipients map[string]string) (string, error) {
+	if len(recipients) == 0 {
+		return "", fmt.Errorf("no recipients in config.yaml; add one with 'claude-sync secrets recipients add'")
+	}
+	names := make([]string, 0, len(recipients))
+	for name := range recipients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rs := make([]age.Recipient, 0, len(names))
+	for _, name := range names {
+		r, err := ParseRecipient(recipients[name])
+		if err != nil {
+			return "", fmt.Errorf("recipient %s: %w", name, err)
+		}
+		rs = append(rs, r)
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, rs...)
+	if err != nil {
+		return "", fmt.Errorf("encrypting: %w", err)
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return "", fmt.Errorf("encrypting: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("encrypting: %w", err)
+	}
+	return encPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()) + encSuffix, nil
+}
+
+// Decrypter decrypts ENC[age:...] values.
+type Decrypter interface {
+	Decrypt(value string) (string, error)
+}
+
+// IdentityDecrypter decrypts with the age identity at Path, loaded on first
+// use.
+type IdentityDecrypter struct {
+	Path string
+
+	id *age.X25519Identity
+}
+
+func (d *IdentityDecrypter) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("not an encrypted value")
+	}
+	if d.id == nil {
+		if _, err := os.Stat(d.Path); errors.Is(err, os.ErrNotExist) {
+			return "", ErrNoIdentity
+		}
+		id, err := LoadIdentity(d.Path)
+		if err != nil {
+			return "", err
+		}
+		d.id = id
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value[len(encPrefix) : len(value)-len(encSuffix)])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), d.id)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return "", fmt.Errorf("not encrypted to your key (%s)", d.id.Recipient())
+		}
+		return "", fmt.Errorf("decrypting: %w", err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("decrypting: %w", err)
+	}
+	return string(plain), nil
+}
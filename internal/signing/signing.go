@@ -0,0 +1,155 @@
+// Package signing checks that commits are signed by an allowed key. Pull can
+// install hooks and MCP servers, which run arbitrary commands, so a config
+// repo can require that what it applies comes from a commit signed by
+// someone on its allow-list rather than anyone who can push.
+//
+// The allow-list is one file in the format of ssh-keygen's allowed_signers
+// (see ssh-keygen(1)), which may also hold GPG key fingerprints, one per
+// line. Git does the verification itself: SSH signatures against the
+// allowed_signers lines, GPG signatures against the user's keyring, after
+// which the signing key's fingerprint must be on the list.
+package signing
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ruminaider/claude-sync/internal/git"
+)
+
+// Status is the outcome of verifying one commit.
+type Status string
+
+const (
+	Trusted   Status = "trusted"   // signed by an allowed key
+	Unsigned  Status = "unsigned"  // no signature
+	Untrusted Status = "untrusted" // good signature from a key not on the list
+	Invalid   Status = "invalid"   // bad, expired or revoked, or can't be checked
+)
+
+// Policy is a parsed allow-list.
+type Policy struct {
+	ssh []string        // allowed_signers lines
+	gpg map[string]bool // upper-case fingerprints
+}
+
+var (
+	gpgFingerprintRe = regexp.MustCompile(`^(?:0x)?[0-9A-Fa-f]{40}$`)
+	logLineRe        = regexp.MustCompile(`^[0-9a-f]{40,64}\|.\|`)
+)
+
+// ParsePolicy parses an allow-list. Blank lines and # comments are ignored;
+// a line that is a 40-digit hex fingerprint is a GPG key, anything else an
+// allowed_signers entry.
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{gpg: make(map[string]bool)}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if compact := strings.ReplaceAll(line, " ", ""); gpgFingerprintRe.MatchString(compact) {
+			p.gpg[strings.ToUpper(strings.TrimPrefix(compact, "0x"))] = true
+			continue
+		}
+		if len(strings.Fields(line)) < 3 {
+			return nil, fmt.Errorf("allowed signers: %q is neither a GPG fingerprint nor \"<principal> <key-type> <key>\"", line)
+		}
+		p.ssh = append(p.ssh, line)
+	}
+	if len(p.ssh) == 0 && len(p.gpg) == 0 {
+		return nil, fmt.Errorf("allowed signers: no keys listed")
+	}
+	return p, nil
+}
+
+// Verification is the result of checking one commit.
+type Verification struct {
+	Commit string
+	Status Status
+	Signer string // principal or GPG user ID, when signed
+	Key    string // fingerprint of the signing key, when signed
+}
+
+// Trusted reports whether the commit is signed by an allowed key.
+func (v Verification) Trusted() bool {
+	return v.Status == Trusted
+}
+
+// Reason describes the verification in a sentence.
+func (v Verification) Reason() string {
+	short := v.Commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	switch v.Status {
+	case Trusted:
+		return fmt.Sprintf("commit %s is signed by %s", short, v.Signer)
+	case Unsigned:
+		return fmt.Sprintf("commit %s is not signed", short)
+	case Untrusted:
+		return fmt.Sprintf("commit %s is signed by key %s, which isn't in the allowed signers", short, v.Key)
+	}
+	if v.Key != "" {
+		return fmt.Sprintf("commit %s has a signature by key %s that can't be verified", short, v.Key)
+	}
+	return fmt.Sprintf("commit %s has a signature that can't be verified", short)
+}
+
+// Verify checks the signature of rev in the repo at dir against p.
+func Verify(dir, rev string, p *Policy) (Verification, error) {
+	f, err := os.CreateTemp("", "claude-sync-allowed-signers-*")
+	if err != nil {
+		return Verification{}, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(strings.Join(p.ssh, "\n") + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Verification{}, err
+	}
+
+	out, err := git.Run(dir, "-c", "gpg.ssh.allowedSignersFile="+f.Name(),
+		"log", "-1", "--format=%H|%G?|%GF|%GP|%GS", rev, "--")
+	if err != nil {
+		return Verification{}, fmt.Errorf("reading signature of %s: %s", rev, out)
+	}
+	// git may print verification diagnostics around the formatted line.
+	var fields []string
+	for _, line := range strings.Split(out, "\n") {
+		if logLineRe.MatchString(line) {
+			fields = strings.SplitN(line, "|", 5)
+		}
+	}
+	if fields == nil {
+		return Verification{}, fmt.Errorf("reading signature of %s: unexpected output %q", rev, out)
+	}
+	v := Verification{Commit: fields[0], Signer: fields[4], Key: fields[2]}
+	primary := fields[3]
+
+	switch fields[1] {
+	case "N":
+		v.Status = Unsigned
+	case "G", "U":
+		switch {
+		case strings.HasPrefix(v.Key, "SHA256:"):
+			// SSH: git reports G only when a principal in the file matched.
+			if fields[1] == "G" {
+				v.Status = Trusted
+			} else {
+				v.Status = Untrusted
+			}
+		case p.gpg[strings.ToUpper(v.Key)] || p.gpg[strings.ToUpper(primary)]:
+			v.Status = Trusted
+		default:
+			v.Status = Untrusted
+		}
+	default:
+		v.Status = Invalid
+	}
+	return v, nil
+}
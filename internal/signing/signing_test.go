@@ -0,0 +1,89 @@
+package signing_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sshKey generates an SSH signing key, returning its private key path and
+// public key line.
+func sshKey(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	path := filepath.Join(dir, name)
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", path).CombinedOutput()
+	require.NoError(t, err, string(out))
+	pub, err := os.ReadFile(path + ".pub")
+	require.NoError(t, err)
+	return path, strings.TrimSpace(string(pub))
+}
+
+func commit(t *testing.T, repo, key string) {
+	t.Helper()
+	args := []string{"-C", repo}
+	if key != "" {
+		args = append(args, "-c", "gpg.format=ssh", "-c", "user.signingkey="+key)
+	}
+	args = append(args, "commit", "--allow-empty", "-m", "change")
+	if key != "" {
+		args = append(args, "-S")
+	}
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestParsePolicy(t *testing.T) {
+	_, err := signing.ParsePolicy([]byte("# nobody yet\n\n"))
+	assert.Error(t, err)
+
+	_, err = signing.ParsePolicy([]byte("alice ssh-ed25519\n"))
+	assert.Error(t, err)
+
+	_, err = signing.ParsePolicy([]byte(`# alice
+alice@example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEXAMPLE
+# bob's GPG key
+0x3AA5 C343 7156 7BD2 3AA5  C343 7156 7BD2 3AA5 C343
+`))
+	assert.NoError(t, err)
+}
+
+func TestVerify_SSH(t *testing.T) {
+	dir := t.TempDir()
+	aliceKey, alicePub := sshKey(t, dir, "alice")
+	malloryKey, _ := sshKey(t, dir, "mallory")
+	policy, err := signing.ParsePolicy([]byte("alice@example.com " + alicePub + "\n"))
+	require.NoError(t, err)
+
+	repo := filepath.Join(dir, "repo")
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	require.NoError(t, exec.Command("git", "-C", repo, "config", "user.email", "test@test.com").Run())
+	require.NoError(t, exec.Command("git", "-C", repo, "config", "user.name", "Test").Run())
+
+	commit(t, repo, aliceKey)
+	v, err := signing.Verify(repo, "HEAD", policy)
+	require.NoError(t, err)
+	assert.Equal(t, signing.Trusted, v.Status)
+	assert.Equal(t, "alice@example.com", v.Signer)
+	assert.True(t, v.Trusted())
+
+	commit(t, repo, malloryKey)
+	v, err = signing.Verify(repo, "HEAD", policy)
+	require.NoError(t, err)
+	assert.Equal(t, signing.Untrusted, v.Status)
+	assert.Contains(t, v.Reason(), "isn't in the allowed signers")
+
+	commit(t, repo, "")
+	v, err = signing.Verify(repo, "HEAD", policy)
+	require.NoError(t, err)
+	assert.Equal(t, signing.Unsigned, v.Status)
+	assert.Len(t, v.Commit, 40)
+}
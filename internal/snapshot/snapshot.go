@@ -0,0 +1,415 @@
+// Package snapshot saves copies of local files before claude-sync overwrites
+// them and restores them on request.
+//
+// Each snapshot is a directory <root>/<id>/ holding manifest.yaml and a
+// files/ tree that mirrors the absolute paths of the captured files.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	manifestName = "manifest.yaml"
+	filesDir     = "files"
+	idLayout     = "20060102T150405Z"
+)
+
+// DefaultKeep is how many snapshots Prune keeps when no limit is configured.
+const DefaultKeep = 10
+
+// ErrNotFound is returned when a snapshot ID doesn't exist.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Manifest describes one snapshot.
+type Manifest struct {
+	ID      string    `yaml:"id"`
+	Created time.Time `yaml:"created"`
+	Reason  string    `yaml:"reason"`
+	// Dirs were captured whole: restoring removes files added under them
+	// after the snapshot was taken.
+	Dirs  []string `yaml:"dirs,omitempty"`
+	Files []File   `yaml:"files"`
+}
+
+// File is one captured path.
+type File struct {
+	Path    string      `yaml:"path"`              // absolute path on disk
+	Missing bool        `yaml:"missing,omitempty"` // didn't exist; restoring deletes it
+	Mode    fs.FileMode `yaml:"mode,omitempty"`
+	SHA256  string      `yaml:"sha256,omitempty"`
+}
+
+// Take captures targets (files or directories) into a new snapshot under
+// root and returns its manifest. Targets that don't exist are recorded as
+// missing. If nothing changed since the newest snapshot, that snapshot is
+// returned instead of creating a duplicate.
+func Take(root string, targets []string, reason string) (*Manifest, error) {
+	m := &Manifest{Created: time.Now().UTC(), Reason: reason}
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		target = filepath.Clean(target)
+		info, err := os.Stat(target)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if !seen[target] {
+				seen[target] = true
+				m.Files = append(m.Files, File{Path: target, Missing: true})
+			}
+		case err != nil:
+			return nil, err
+		case info.IsDir():
+			m.Dirs = append(m.Dirs, target)
+			err := filepath.WalkDir(target, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() || seen[path] {
+					return err
+				}
+				seen[path] = true
+				f, err := describe(path)
+				if err != nil {
+					return err
+				}
+				m.Files = append(m.Files, f)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("snapshotting %s: %w", target, err)
+			}
+		case info.Mode().IsRegular():
+			if !seen[target] {
+				seen[target] = true
+				f, err := describe(target)
+				if err != nil {
+					return nil, err
+				}
+				m.Files = append(m.Files, f)
+			}
+		}
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	sort.Strings(m.Dirs)
+
+	if latest, err := List(root); err == nil && len(latest) > 0 && sameState(latest[0], *m) {
+		return &latest[0], nil
+	}
+
+	m.ID = newID(root, m.Created)
+	dir := filepath.Join(root, m.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	for _, f := range m.Files {
+		if f.Missing {
+			continue
+		}
+		if err := copyFile(f.Path, storedPath(dir, f.Path), 0600); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("snapshotting %s: %w", f.Path, err)
+		}
+	}
+	if err := writeManifest(dir, m); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return m, nil
+}
+
+// List returns all snapshots under root, newest first.
+func List(root string) ([]Manifest, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var manifests []Manifest
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		m, err := Load(root, e.Name())
+		if err != nil {
+			continue // partial or foreign directory
+		}
+		manifests = append(manifests, *m)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].ID > manifests[j].ID })
+	return manifests, nil
+}
+
+// Load reads the manifest of snapshot id.
+func Load(root, id string) (*Manifest, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	data, err := os.ReadFile(filepath.Join(root, id, manifestName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s manifest: %w", id, err)
+	}
+	m.ID = id
+	return &m, nil
+}
+
+// Prune deletes all but the newest keep snapshots and returns the IDs removed.
+func Prune(root string, keep int) ([]string, error) {
+	manifests, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i := keep; i < len(manifests); i++ {
+		if err := os.RemoveAll(filepath.Join(root, manifests[i].ID)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, manifests[i].ID)
+	}
+	return removed, nil
+}
+
+// FileStatus compares a captured file with what is on disk now.
+type FileStatus struct {
+	Path   string
+	Status string // "unchanged", "modified", "deleted" (since the snapshot), "created" (since the snapshot)
+}
+
+// Compare reports how each captured path, and each file added under a
+// captured directory, differs from the current disk state.
+func (m Manifest) Compare() []FileStatus {
+	var statuses []FileStatus
+	captured := make(map[string]bool, len(m.Files))
+	for _, f := range m.Files {
+		captured[f.Path] = true
+		current, err := describe(f.Path)
+		exists := err == nil
+		status := "unchanged"
+		switch {
+		case f.Missing && exists:
+			status = "created"
+		case f.Missing:
+		case !exists:
+			status = "deleted"
+		case current.SHA256 != f.SHA256:
+			status = "modified"
+		}
+		statuses = append(statuses, FileStatus{Path: f.Path, Status: status})
+	}
+	for _, path := range m.addedFiles(captured) {
+		statuses = append(statuses, FileStatus{Path: path, Status: "created"})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Path < statuses[j].Path })
+	return statuses
+}
+
+// RestoreResult lists what Restore changed.
+type RestoreResult struct {
+	Backup   *Manifest // snapshot of the state just before restoring
+	Restored []string  // files written back
+	Removed  []string  // files deleted because they didn't exist at snapshot time
+}
+
+// Restore puts every file captured by snapshot id back in place. The
+// current state is snapshotted first, so a restore can itself be undone.
+// All files are staged next to their destination before any is replaced,
+// so a failure part way through staging leaves the disk untouched.
+func Restore(root, id string) (*RestoreResult, error) {
+	m, err := Load(root, id)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, m.ID)
+
+	// Verify stored copies before touching anything.
+	for _, f := range m.Files {
+		if f.Missing {
+			continue
+		}
+		sum, err := hashFile(storedPath(dir, f.Path))
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s is incomplete: %w", m.ID, err)
+		}
+		if sum != f.SHA256 {
+			return nil, fmt.Errorf("snapshot %s is corrupt: %s does not match its checksum", m.ID, f.Path)
+		}
+	}
+
+	captured := make(map[string]bool, len(m.Files))
+	targets := append([]string{}, m.Dirs...)
+	for _, f := range m.Files {
+		captured[f.Path] = true
+		targets = append(targets, f.Path)
+	}
+	added := m.addedFiles(captured)
+
+	backup, err := Take(root, targets, "before restoring "+m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("snapshotting current state: %w", err)
+	}
+	result := &RestoreResult{Backup: backup}
+
+	// Stage.
+	type staged struct{ tmp, dst string }
+	var stagedFiles []staged
+	cleanup := func() {
+		for _, s := range stagedFiles {
+			os.Remove(s.tmp)
+		}
+	}
+	for _, f := range m.Files {
+		if f.Missing {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			cleanup()
+			return nil, err
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(f.Path), ".claude-sync-restore-*")
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		tmp.Close()
+		stagedFiles = append(stagedFiles, staged{tmp: tmp.Name(), dst: f.Path})
+		perm := f.Mode.Perm()
+		if perm == 0 {
+			perm = 0644
+		}
+		if err := copyFile(storedPath(dir, f.Path), tmp.Name(), perm); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("staging %s: %w", f.Path, err)
+		}
+	}
+
+	// Commit.
+	for _, s := range stagedFiles {
+		if err := os.Rename(s.tmp, s.dst); err != nil {
+			cleanup()
+			return result, fmt.Errorf("restoring %s: %w (previous state saved as snapshot %s)", s.dst, err, backup.ID)
+		}
+		result.Restored = append(result.Restored, s.dst)
+	}
+	for _, f := range m.Files {
+		if f.Missing {
+			if err := os.Remove(f.Path); err == nil {
+				result.Removed = append(result.Removed, f.Path)
+			}
+		}
+	}
+	for _, path := range added {
+		if err := os.Remove(path); err == nil {
+			result.Removed = append(result.Removed, path)
+		}
+	}
+	return result, nil
+}
+
+// addedFiles returns regular files under m.Dirs that aren't in captured.
+func (m Manifest) addedFiles(captured map[string]bool) []string {
+	var added []string
+	for _, d := range m.Dirs {
+		filepath.WalkDir(d, func(path string, e fs.DirEntry, err error) error {
+			if err == nil && e.Type().IsRegular() && !captured[path] {
+				added = append(added, path)
+			}
+			return nil
+		})
+	}
+	return added
+}
+
+func describe(path string) (File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return File{}, err
+	}
+	sum, err := hashFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: path, Mode: info.Mode().Perm(), SHA256: sum}, nil
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, perm); err != nil {
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
+
+// storedPath maps an absolute path to its copy inside snapshot dir.
+func storedPath(dir, path string) string {
+	rel := strings.TrimPrefix(filepath.ToSlash(path), "/")
+	rel = strings.ReplaceAll(rel, ":", "") // drop Windows volume separators
+	return filepath.Join(dir, filesDir, filepath.FromSlash(rel))
+}
+
+func writeManifest(dir string, m *Manifest) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestName), data, 0644)
+}
+
+// newID returns a timestamp ID, suffixed if one already exists for that second.
+func newID(root string, t time.Time) string {
+	id := t.Format(idLayout)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(root, id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", t.Format(idLayout), i)
+	}
+}
+
+// sameState reports whether two manifests captured identical content.
+func sameState(a, b Manifest) bool {
+	if len(a.Files) != len(b.Files) || len(a.Dirs) != len(b.Dirs) {
+		return false
+	}
+	for i := range a.Dirs {
+		if a.Dirs[i] != b.Dirs[i] {
+			return false
+		}
+	}
+	for i := range a.Files {
+		if a.Files[i] != b.Files[i] {
+			return false
+		}
+	}
+	return true
+}
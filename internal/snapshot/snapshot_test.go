@@ -0,0 +1,141 @@
+package snapshot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestTakeAndRestore(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	settings := filepath.Join(home, "settings.json")
+	claudeMD := filepath.Join(home, "CLAUDE.md")
+	commandsDir := filepath.Join(home, "commands")
+	writeFile(t, settings, `{"model":"sonnet"}`)
+	writeFile(t, filepath.Join(commandsDir, "review.md"), "review")
+
+	m, err := snapshot.Take(root, []string{settings, claudeMD, commandsDir}, "pull")
+	require.NoError(t, err)
+	require.NotEmpty(t, m.ID)
+	assert.Equal(t, []string{commandsDir}, m.Dirs)
+	require.Len(t, m.Files, 3)
+
+	// Simulate a pull: modify, create and delete files.
+	writeFile(t, settings, `{"model":"opus"}`)
+	writeFile(t, claudeMD, "# team")
+	writeFile(t, filepath.Join(commandsDir, "deploy.md"), "deploy")
+	require.NoError(t, os.Remove(filepath.Join(commandsDir, "review.md")))
+
+	loaded, err := snapshot.Load(root, m.ID)
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, s := range loaded.Compare() {
+		statuses[s.Path] = s.Status
+	}
+	assert.Equal(t, map[string]string{
+		settings:                                "modified",
+		claudeMD:                                "created",
+		filepath.Join(commandsDir, "review.md"): "deleted",
+		filepath.Join(commandsDir, "deploy.md"): "created",
+	}, statuses)
+
+	result, err := snapshot.Restore(root, m.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `{"model":"sonnet"}`, readFile(t, settings))
+	assert.Equal(t, "review", readFile(t, filepath.Join(commandsDir, "review.md")))
+	assert.NoFileExists(t, claudeMD)
+	assert.NoFileExists(t, filepath.Join(commandsDir, "deploy.md"))
+	assert.ElementsMatch(t, []string{claudeMD, filepath.Join(commandsDir, "deploy.md")}, result.Removed)
+
+	// The pre-restore state was saved and can be restored in turn.
+	require.NotNil(t, result.Backup)
+	assert.NotEqual(t, m.ID, result.Backup.ID)
+	_, err = snapshot.Restore(root, result.Backup.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `{"model":"opus"}`, readFile(t, settings))
+	assert.Equal(t, "# team", readFile(t, claudeMD))
+}
+
+func TestTake_ReusesUnchangedSnapshot(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(t.TempDir(), "settings.json")
+	writeFile(t, file, "{}")
+
+	first, err := snapshot.Take(root, []string{file}, "pull")
+	require.NoError(t, err)
+	second, err := snapshot.Take(root, []string{file}, "pull")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+
+	list, err := snapshot.List(root)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestRestore_CorruptSnapshotTouchesNothing(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(t.TempDir(), "settings.json")
+	writeFile(t, file, "old")
+	m, err := snapshot.Take(root, []string{file}, "pull")
+	require.NoError(t, err)
+
+	// Tamper with the stored copy.
+	err = filepath.WalkDir(filepath.Join(root, m.ID, "files"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			return os.WriteFile(path, []byte("tampered"), 0644)
+		}
+		return err
+	})
+	require.NoError(t, err)
+
+	writeFile(t, file, "new")
+	_, err = snapshot.Restore(root, m.ID)
+	assert.ErrorContains(t, err, "corrupt")
+	assert.Equal(t, "new", readFile(t, file))
+}
+
+func TestListAndPrune(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(t.TempDir(), "settings.json")
+	var ids []string
+	for _, content := range []string{"1", "2", "3"} {
+		writeFile(t, file, content)
+		m, err := snapshot.Take(root, []string{file}, "pull")
+		require.NoError(t, err)
+		ids = append(ids, m.ID)
+	}
+
+	list, err := snapshot.List(root)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, ids[2], list[0].ID, "newest first")
+
+	removed, err := snapshot.Prune(root, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[0]}, removed)
+	list, _ = snapshot.List(root)
+	assert.Len(t, list, 2)
+
+	_, err = snapshot.Load(root, ids[0])
+	assert.ErrorIs(t, err, snapshot.ErrNotFound)
+	_, err = snapshot.Load(root, "../escape")
+	assert.ErrorIs(t, err, snapshot.ErrNotFound)
+}
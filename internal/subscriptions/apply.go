@@ -62,6 +62,9 @@ func ApplyToConfig(localCfg *config.Config, merged *MergedResult) {
 
 	// Skills — additive.
 	localCfg.Skills = mergeStringSlices(localCfg.Skills, merged.Skills)
+
+	// Agents — additive.
+	localCfg.Agents = mergeStringSlices(localCfg.Agents, merged.Agents)
 }
 
 // mergeStringSlices appends items from add to base, skipping duplicates.
@@ -1,53 +1,140 @@
 package subscriptions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ruminaider/claude-sync/internal/config"
 	gitpkg "github.com/ruminaider/claude-sync/internal/git"
 )
 
+// Defaults for FetchOptions.
+const (
+	DefaultFetchWorkers = 4
+	DefaultFetchTimeout = 20 * time.Second
+)
+
+// FetchOptions bounds FetchAll. Zero values use the defaults.
+type FetchOptions struct {
+	Workers int           // subscriptions fetched at once
+	Timeout time.Duration // per subscription, covering clone or fetch and reset
+}
+
 // FetchResult holds the result of fetching one subscription.
 type FetchResult struct {
-	Name       string
-	CommitSHA  string
-	Changed    bool  // true if the SHA differs from the last-fetched state
-	Error      error
+	Name      string
+	CommitSHA string
+	Changed   bool // true if the SHA differs from the last-fetched state
+	// Stale is set when the fetch failed but the clone is usable at the last
+	// good commit recorded in state: CommitSHA is that commit, LastFetched
+	// when it was fetched, and Error why this fetch failed.
+	Stale       bool
+	LastFetched time.Time
+	Error       error
 }
 
-// FetchAll fetches all subscriptions, creating shallow clones if needed.
-// Returns a result per subscription.
-func FetchAll(syncDir string, subs map[string]config.SubscriptionEntry, state SubscriptionState) []FetchResult {
-	var results []FetchResult
-	for name, sub := range subs {
-		result := FetchOne(syncDir, name, sub, state)
-		results = append(results, result)
+// Usable reports whether the subscription's clone can be merged: it was
+// fetched, or it fell back to the last good commit.
+func (r FetchResult) Usable() bool {
+	return r.Error == nil || r.Stale
+}
+
+// FetchAll fetches all subscriptions concurrently, creating shallow clones if
+// needed. Locked subscriptions stay on their commit in lock. At most
+// opts.Workers fetches run at once and each is cut off after opts.Timeout.
+// Returns a result per subscription, sorted by name.
+func FetchAll(syncDir string, subs map[string]config.SubscriptionEntry, state SubscriptionState, lock LockFile, opts FetchOptions) []FetchResult {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultFetchWorkers
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultFetchTimeout
+	}
+
+	names := make([]string, 0, len(subs))
+	for name := range subs {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	results := make([]FetchResult, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Workers, len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				name, sub := names[i], subs[names[i]]
+				var pin string
+				if sub.Lock {
+					pin = lock.Commit(name)
+				}
+				results[i] = FetchOne(syncDir, name, sub, state, pin, opts.Timeout)
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 	return results
 }
 
-// FetchOne fetches a single subscription. If the clone doesn't exist yet,
-// performs a shallow clone. Otherwise, fetches and resets.
-func FetchOne(syncDir, name string, sub config.SubscriptionEntry, state SubscriptionState) FetchResult {
+// FetchOne fetches a single subscription, giving up after timeout (zero
+// means no limit). If the clone doesn't exist yet, performs a shallow clone.
+// Otherwise, fetches and resets. A non-empty pin is a locked commit: the
+// clone is checked out there instead, fetching only if it doesn't have it.
+// If that fails, the clone is returned to the commit recorded in state and
+// the result is marked Stale.
+func FetchOne(syncDir, name string, sub config.SubscriptionEntry, state SubscriptionState, pin string, timeout time.Duration) FetchResult {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := fetchOne(ctx, syncDir, name, sub, state, pin)
+	if result.Error == nil {
+		return result
+	}
+	if errors.Is(result.Error, context.DeadlineExceeded) {
+		result.Error = fmt.Errorf("fetching %q: timed out after %s", name, timeout)
+	}
+	return fallBack(syncDir, name, state, pin, result.Error)
+}
+
+func fetchOne(ctx context.Context, syncDir, name string, sub config.SubscriptionEntry, state SubscriptionState, pin string) FetchResult {
 	dir := SubDir(syncDir, name)
 	ref := sub.Ref // empty ref clones the remote's default branch
 
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		// First fetch — shallow clone.
-		if err := gitpkg.ShallowClone(sub.URL, dir, ref); err != nil {
+		if err := gitpkg.ShallowCloneContext(ctx, sub.URL, dir, ref); err != nil {
+			os.RemoveAll(dir) // don't leave a partial clone to be mistaken for a good one
 			return FetchResult{Name: name, Error: fmt.Errorf("cloning %q: %w", name, err)}
 		}
-	} else {
+	} else if pin == "" {
 		// Existing clone — fetch and reset.
-		if err := gitpkg.FetchShallow(dir); err != nil {
+		if err := gitpkg.FetchShallowContext(ctx, dir); err != nil {
 			return FetchResult{Name: name, Error: fmt.Errorf("fetching %q: %w", name, err)}
 		}
 		if err := gitpkg.ResetToFetchHead(dir); err != nil {
 			return FetchResult{Name: name, Error: fmt.Errorf("resetting %q: %w", name, err)}
 		}
 	}
+	if pin != "" {
+		if err := checkoutPin(ctx, dir, pin); err != nil {
+			return FetchResult{Name: name, Error: fmt.Errorf("checking out locked commit of %q: %w", name, err)}
+		}
+	}
 
 	sha, err := gitpkg.HeadSHA(dir)
 	if err != nil {
@@ -64,6 +151,47 @@ func FetchOne(syncDir, name string, sub config.SubscriptionEntry, state Subscrip
 	}
 }
 
+// checkoutPin resets the clone in dir to pin. A shallow clone may not have
+// that commit, in which case it is fetched on its own.
+func checkoutPin(ctx context.Context, dir, pin string) error {
+	if sha, err := gitpkg.HeadSHA(dir); err == nil && sha == pin {
+		return nil
+	}
+	if err := gitpkg.ResetHard(dir, pin); err == nil {
+		return nil
+	}
+	if err := gitpkg.FetchCommitContext(ctx, dir, pin); err != nil {
+		return err
+	}
+	return gitpkg.ResetHard(dir, pin)
+}
+
+// fallBack returns the clone of a subscription whose fetch failed to the
+// last good commit recorded in state, if there is one. A locked
+// subscription only falls back to its locked commit, pin; any other commit
+// would apply content the lock doesn't allow.
+func fallBack(syncDir, name string, state SubscriptionState, pin string, fetchErr error) FetchResult {
+	result := FetchResult{Name: name, Error: fetchErr}
+	prev, ok := state.Subscriptions[name]
+	if !ok || prev.CommitSHA == "" {
+		return result
+	}
+	if pin != "" && prev.CommitSHA != pin {
+		result.Error = fmt.Errorf("%w (the last good copy, %s, isn't the locked commit %s)", fetchErr, prev.CommitSHA, pin)
+		return result
+	}
+	dir := SubDir(syncDir, name)
+	if sha, err := gitpkg.HeadSHA(dir); err != nil || sha != prev.CommitSHA {
+		if err := gitpkg.ResetHard(dir, prev.CommitSHA); err != nil {
+			return result
+		}
+	}
+	result.Stale = true
+	result.CommitSHA = prev.CommitSHA
+	result.LastFetched = prev.LastFetched
+	return result
+}
+
 // UpdateState updates the subscription state after a successful fetch.
 func UpdateState(state *SubscriptionState, name, sha string, acceptedItems map[string][]string) {
 	if state.Subscriptions == nil {
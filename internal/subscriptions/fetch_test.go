@@ -0,0 +1,107 @@
+package subscriptions
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTeamRepo creates a git repo with a config.yaml to subscribe to.
+func setupTeamRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(out))
+	}
+	run("init")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("version: \"1.0.0\"\n"), 0644))
+	run("add", "config.yaml")
+	run("commit", "-m", "init")
+	return dir
+}
+
+func TestFetchAll_ConcurrentWithStaleFallback(t *testing.T) {
+	syncDir := t.TempDir()
+	subs := map[string]config.SubscriptionEntry{
+		"alpha": {URL: setupTeamRepo(t)},
+		"beta":  {URL: setupTeamRepo(t)},
+		"gamma": {URL: setupTeamRepo(t)},
+	}
+	state := SubscriptionState{Subscriptions: map[string]SubState{}}
+
+	results := FetchAll(syncDir, subs, state, LockFile{}, FetchOptions{Workers: 2, Timeout: 30 * time.Second})
+	require.Len(t, results, 3)
+	for i, name := range []string{"alpha", "beta", "gamma"} {
+		r := results[i]
+		assert.Equal(t, name, r.Name)
+		require.NoError(t, r.Error)
+		assert.True(t, r.Changed)
+		assert.True(t, r.Usable())
+		UpdateState(&state, r.Name, r.CommitSHA, nil)
+	}
+	goodSHA := state.Subscriptions["alpha"].CommitSHA
+
+	// alpha's remote disappears: the clone is still usable at the last good
+	// commit. delta has never been fetched, so there's nothing to fall back to.
+	require.NoError(t, os.RemoveAll(subs["alpha"].URL))
+	subs["delta"] = config.SubscriptionEntry{URL: filepath.Join(t.TempDir(), "missing")}
+
+	results = FetchAll(syncDir, subs, state, LockFile{}, FetchOptions{})
+	byName := make(map[string]FetchResult)
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+
+	alpha := byName["alpha"]
+	assert.Error(t, alpha.Error)
+	assert.True(t, alpha.Stale)
+	assert.True(t, alpha.Usable())
+	assert.Equal(t, goodSHA, alpha.CommitSHA)
+	assert.Equal(t, state.Subscriptions["alpha"].LastFetched, alpha.LastFetched)
+
+	delta := byName["delta"]
+	assert.Error(t, delta.Error)
+	assert.False(t, delta.Usable())
+	assert.NoDirExists(t, SubDir(syncDir, "delta"), "a failed clone is removed")
+
+	assert.NoError(t, byName["beta"].Error)
+	assert.False(t, byName["beta"].Changed)
+}
+
+func TestFetchOne_LockedFallsBackOnlyToLockedCommit(t *testing.T) {
+	syncDir := t.TempDir()
+	sub := config.SubscriptionEntry{URL: setupTeamRepo(t), Lock: true}
+	state := SubscriptionState{Subscriptions: map[string]SubState{}}
+
+	r := FetchOne(syncDir, "team", sub, state, "", 0)
+	require.NoError(t, r.Error)
+	UpdateState(&state, "team", r.CommitSHA, nil)
+
+	// The locked commit can't be fetched, and the last good copy is a
+	// different commit: the subscription is skipped, not applied from there.
+	missing := "0123456789abcdef0123456789abcdef01234567"
+	r = FetchOne(syncDir, "team", sub, state, missing, 0)
+	assert.ErrorContains(t, r.Error, "isn't the locked commit")
+	assert.False(t, r.Stale)
+	assert.False(t, r.Usable())
+
+	// When the last good copy is the locked commit, it's still used.
+	good := state.Subscriptions["team"].CommitSHA
+	require.NoError(t, os.RemoveAll(sub.URL))
+	state.Subscriptions["team"] = SubState{CommitSHA: good}
+	r = fallBack(syncDir, "team", state, good, assert.AnError)
+	assert.True(t, r.Usable())
+	assert.Equal(t, good, r.CommitSHA)
+}
@@ -69,6 +69,8 @@ func categoryMode(sub Subscription, category string) CategoryMode {
 		return CategoryNone
 	case "skills":
 		return effectiveMode(sub.Categories.Skills)
+	case "agents":
+		return effectiveMode(sub.Categories.Agents)
 	default:
 		return CategoryNone
 	}
@@ -0,0 +1,77 @@
+package subscriptions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// LockFile is subscriptions.lock: the commit each locked subscription stays
+// on. Unlike subscription-state.yaml it is committed, so every machine pulls
+// the same commit and advancing it is a change the team can review.
+type LockFile struct {
+	Subscriptions map[string]LockEntry `yaml:"subscriptions"`
+}
+
+// LockEntry pins one subscription.
+type LockEntry struct {
+	Commit   string    `yaml:"commit"`
+	LockedAt time.Time `yaml:"locked_at"`
+}
+
+// LockPath returns the path to subscriptions.lock in the sync dir.
+func LockPath(syncDir string) string {
+	return filepath.Join(syncDir, "subscriptions.lock")
+}
+
+// ReadLock reads subscriptions.lock from the sync dir. A missing file is an
+// empty lock.
+func ReadLock(syncDir string) (LockFile, error) {
+	data, err := os.ReadFile(LockPath(syncDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LockFile{Subscriptions: make(map[string]LockEntry)}, nil
+		}
+		return LockFile{}, fmt.Errorf("reading subscriptions.lock: %w", err)
+	}
+	var lock LockFile
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return LockFile{}, fmt.Errorf("parsing subscriptions.lock: %w", err)
+	}
+	if lock.Subscriptions == nil {
+		lock.Subscriptions = make(map[string]LockEntry)
+	}
+	return lock, nil
+}
+
+// WriteLock writes subscriptions.lock to the sync dir, or removes it when no
+// subscription is locked.
+func WriteLock(syncDir string, lock LockFile) error {
+	if len(lock.Subscriptions) == 0 {
+		if err := os.Remove(LockPath(syncDir)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing subscriptions.lock: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("marshaling subscriptions.lock: %w", err)
+	}
+	return os.WriteFile(LockPath(syncDir), data, 0644)
+}
+
+// Commit returns the commit name is locked to, or "" if it isn't locked.
+func (l LockFile) Commit(name string) string {
+	return l.Subscriptions[name].Commit
+}
+
+// Set locks name to sha.
+func (l *LockFile) Set(name, sha string) {
+	if l.Subscriptions == nil {
+		l.Subscriptions = make(map[string]LockEntry)
+	}
+	l.Subscriptions[name] = LockEntry{Commit: sha, LockedAt: time.Now().UTC()}
+}
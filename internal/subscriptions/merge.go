@@ -21,6 +21,7 @@ type MergedResult struct {
 	ClaudeMD    []string                      // fragment names to add
 	Commands    []string                      // command keys
 	Skills      []string                      // skill keys
+	Agents      []string                      // agent keys
 	Provenance  map[string]map[string]string  // category -> item -> source subscription name
 }
 
@@ -203,6 +204,12 @@ func MergeAll(syncDir string, subs map[string]config.SubscriptionEntry, localCfg
 			result.Skills = appendUnique(result.Skills, name)
 		}
 
+		// --- Agents ---
+		selectedAgents := ResolveItems(filterSub, "agents", remoteCfg.Agents)
+		for name := range selectedAgents {
+			result.Agents = appendUnique(result.Agents, name)
+		}
+
 		// --- Permissions (additive, no conflict) ---
 		permAllowNames := remoteCfg.Permissions.Allow
 		selectedAllows := ResolveItems(filterSub, "permissions", permAllowNames)
@@ -321,6 +328,8 @@ func parseCategories(cats map[string]any) SubscriptionCategories {
 			}
 		case "skills":
 			sc.Skills = mode
+		case "agents":
+			sc.Agents = mode
 		}
 	}
 	return sc
@@ -59,6 +59,30 @@ func TestMergeAll_SingleSubscription_MCPAll(t *testing.T) {
 	assert.Contains(t, merged.MCP, "datadog")
 }
 
+func TestMergeAll_Agents(t *testing.T) {
+	syncDir := t.TempDir()
+	setupSubConfig(t, syncDir, "team", config.ConfigV2{
+		Version: "2.1",
+		Agents:  []string{"agent:global:code-reviewer", "agent:global:db-migrator"},
+	})
+
+	subs := map[string]config.SubscriptionEntry{
+		"team": {
+			URL:        "git@github.com:org/team.git",
+			Categories: map[string]any{"agents": "all"},
+		},
+	}
+	merged, conflicts, err := MergeAll(syncDir, subs, config.Config{Version: "2.1"})
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.ElementsMatch(t, []string{"agent:global:code-reviewer", "agent:global:db-migrator"}, merged.Agents)
+
+	subs["team"] = config.SubscriptionEntry{URL: "git@github.com:org/team.git", Categories: map[string]any{"skills": "all"}}
+	merged, _, err = MergeAll(syncDir, subs, config.Config{Version: "2.1"})
+	require.NoError(t, err)
+	assert.Empty(t, merged.Agents, "agents not subscribed")
+}
+
 func TestMergeAll_LocalWins(t *testing.T) {
 	syncDir := t.TempDir()
 
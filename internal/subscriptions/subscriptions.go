@@ -38,6 +38,7 @@ type SubscriptionCategories struct {
 	ClaudeMD    CategoryMode              `yaml:"claude_md,omitempty"`
 	Commands    *SubscriptionCommandsMode `yaml:"commands,omitempty"`
 	Skills      CategoryMode              `yaml:"skills,omitempty"`
+	Agents      CategoryMode              `yaml:"agents,omitempty"`
 }
 
 // SubscriptionCommandsMode supports both simple mode and granular include.
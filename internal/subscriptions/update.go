@@ -0,0 +1,161 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ruminaider/claude-sync/internal/config"
+)
+
+// Update is what advancing a locked subscription from the commit in
+// subscriptions.lock to the latest commit of its ref would change. Each map
+// is category -> item names, covering the categories IncrementalDiff does.
+type Update struct {
+	Name    string
+	From    string // locked commit; empty if the subscription wasn't locked yet
+	To      string // latest commit
+	New     map[string][]string
+	Changed map[string][]string // selected at both commits, with a different value
+	Removed map[string][]string // selected at From, gone at To
+}
+
+// UpToDate reports whether the lock is already at the latest commit.
+func (u *Update) UpToDate() bool {
+	return u.From == u.To
+}
+
+// PreviewUpdate fetches the latest commit of a locked subscription and
+// compares it with the locked one. New items are those IncrementalDiff
+// reports as not accepted before. The clone is left at the latest commit:
+// call AcceptUpdate to advance the lock, or CheckoutLocked to go back.
+func PreviewUpdate(syncDir, name string, sub config.SubscriptionEntry, state SubscriptionState, lock LockFile, timeout time.Duration) (*Update, error) {
+	u := &Update{Name: name, From: lock.Commit(name)}
+
+	var before config.Config
+	if u.From != "" {
+		if r := FetchOne(syncDir, name, sub, state, u.From, timeout); r.Error != nil {
+			return nil, r.Error
+		}
+		cfg, err := readRemoteConfig(SubDir(syncDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("reading config for %q at %s: %w", name, u.From, err)
+		}
+		before = cfg
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	r := fetchOne(ctx, syncDir, name, sub, state, "")
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	u.To = r.CommitSHA
+
+	after, err := readRemoteConfig(SubDir(syncDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("reading config for %q at %s: %w", name, u.To, err)
+	}
+	if u.New, err = IncrementalDiff(name, sub, syncDir, state); err != nil {
+		return nil, err
+	}
+	u.Changed, u.Removed = compareSelected(toFilterSubscription(sub), before, after)
+	return u, nil
+}
+
+// AcceptUpdate advances the lock to u.To and records the new items as
+// accepted in state.
+func AcceptUpdate(state *SubscriptionState, lock *LockFile, u *Update) {
+	accepted := make(map[string][]string)
+	for category, items := range state.Subscriptions[u.Name].AcceptedItems {
+		accepted[category] = append([]string(nil), items...)
+	}
+	for category, items := range u.New {
+		for _, item := range items {
+			accepted[category] = appendUnique(accepted[category], item)
+		}
+		sort.Strings(accepted[category])
+	}
+	lock.Set(u.Name, u.To)
+	UpdateState(state, u.Name, u.To, accepted)
+}
+
+// CheckoutLocked returns a subscription's clone to its locked commit, e.g.
+// after a rejected PreviewUpdate.
+func CheckoutLocked(syncDir, name string, sub config.SubscriptionEntry, state SubscriptionState, lock LockFile) error {
+	pin := lock.Commit(name)
+	if pin == "" {
+		return nil
+	}
+	return FetchOne(syncDir, name, sub, state, pin, DefaultFetchTimeout).Error
+}
+
+// compareSelected reports the items sub selects at both configs whose value
+// differs, and those it selects in before that are gone from after.
+func compareSelected(sub Subscription, before, after config.Config) (changed, removed map[string][]string) {
+	changed = make(map[string][]string)
+	removed = make(map[string][]string)
+	old, cur := itemValues(before), itemValues(after)
+	for category, oldItems := range old {
+		selected := ResolveItems(sub, category, sortedNames(oldItems))
+		for name := range selected {
+			value, ok := cur[category][name]
+			switch {
+			case !ok:
+				removed[category] = append(removed[category], name)
+			case value != oldItems[name]:
+				changed[category] = append(changed[category], name)
+			}
+		}
+		sort.Strings(changed[category])
+		sort.Strings(removed[category])
+	}
+	for _, m := range []map[string][]string{changed, removed} {
+		for k, v := range m {
+			if len(v) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+	return changed, removed
+}
+
+// itemValues returns category -> item -> value for the categories
+// IncrementalDiff covers. A plugin's value is its pinned version, if any.
+func itemValues(cfg config.Config) map[string]map[string]string {
+	values := map[string]map[string]string{
+		"mcp":      {},
+		"plugins":  {},
+		"settings": {},
+		"hooks":    {},
+	}
+	for name, raw := range cfg.MCP {
+		values["mcp"][name] = string(raw)
+	}
+	for _, key := range cfg.AllPluginKeys() {
+		values["plugins"][key] = cfg.Pinned[key]
+	}
+	for name, v := range cfg.Settings {
+		data, _ := json.Marshal(v)
+		values["settings"][name] = string(data)
+	}
+	for name, raw := range cfg.Hooks {
+		values["hooks"][name] = string(raw)
+	}
+	return values
+}
+
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
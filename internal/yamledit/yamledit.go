@@ -0,0 +1,320 @@
+// Package yamledit writes a regenerated YAML document back over the text it
+// was parsed from, so comments, key order and formatting survive everywhere
+// the content didn't change.
+//
+// config.yaml and profile files are parsed into structs and rebuilt from
+// scratch as yaml.Nodes when saved. Render takes that rebuilt document and
+// splices it into the original text one top-level section at a time.
+package yamledit
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Rewrite lays fresh out over src (see Render) and checks the result means
+// the same thing: build parses a document and renders it with the builder
+// that produced fresh, and must give the same YAML for the result as for
+// fresh. It reports false if src can't be used or the check fails, in which
+// case callers should write fresh as-is.
+func Rewrite(src []byte, fresh *yaml.Node, build func([]byte) (*yaml.Node, error)) ([]byte, bool) {
+	base, err := build(src)
+	if err != nil {
+		return nil, false
+	}
+	want, err := yaml.Marshal(fresh)
+	if err != nil {
+		return nil, false
+	}
+	data, err := Render(src, base, fresh)
+	if err != nil {
+		return nil, false
+	}
+	check, err := build(data)
+	if err != nil {
+		return nil, false
+	}
+	got, err := yaml.Marshal(check)
+	if err != nil || !bytes.Equal(got, want) {
+		return nil, false
+	}
+	return data, true
+}
+
+// Render returns fresh laid out like src. base is what the same builder
+// produces for the document src parses to; comparing it with fresh tells
+// which parts actually changed.
+//
+//   - Top-level sections that are equal in base and fresh are copied from src
+//     byte for byte, comments and all.
+//   - Changed sections are re-encoded from src's nodes. Map entries and list
+//     items whose value didn't change keep their original nodes and comments.
+//   - Top-level keys in src that base doesn't have (keys the builder doesn't
+//     know about) are kept. Keys base has and fresh doesn't are removed.
+//   - New sections are appended.
+func Render(src []byte, base, fresh *yaml.Node) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+	orig, b, f := mappingRoot(&doc), mappingRoot(base), mappingRoot(fresh)
+	if orig == nil || b == nil || f == nil {
+		return nil, errors.New("yamledit: document is not a mapping")
+	}
+
+	lines := strings.SplitAfter(string(src), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	regions := sectionRegions(orig, lines)
+	indent := detectIndent(lines)
+
+	var out bytes.Buffer
+	writeLines := func(from, to int) {
+		for _, l := range lines[from:to] {
+			out.WriteString(l)
+		}
+		if to > from && !strings.HasSuffix(lines[to-1], "\n") {
+			out.WriteString("\n")
+		}
+	}
+
+	epilogue := len(lines)
+	if len(regions) > 0 {
+		writeLines(0, regions[0].start)
+		epilogue = regions[len(regions)-1].end
+	}
+
+	// Separate appended sections the way src separates its own.
+	spaced := false
+	for _, r := range regions[min(1, len(regions)):] {
+		if r.start < r.keyLine && strings.TrimSpace(lines[r.start]) == "" {
+			spaced = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range regions {
+		key := orig.Content[r.index].Value
+		ov := orig.Content[r.index+1]
+		seen[key] = true
+		bv, fv := lookup(b, key), lookup(f, key)
+		switch {
+		case bv == nil && fv == nil, bv != nil && fv != nil && equal(bv, fv):
+			writeLines(r.start, r.end)
+		case fv == nil:
+			// Removed.
+		default:
+			writeLines(r.start, r.keyLine)
+			data, err := encodeSection(orig.Content[r.index], merge(ov, bv, fv), indent)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data)
+		}
+	}
+	for i := 0; i+1 < len(f.Content); i += 2 {
+		if seen[f.Content[i].Value] {
+			continue
+		}
+		data, err := encodeSection(f.Content[i], f.Content[i+1], indent)
+		if err != nil {
+			return nil, err
+		}
+		if spaced {
+			out.WriteString("\n")
+		}
+		out.Write(data)
+	}
+	writeLines(epilogue, len(lines))
+	return out.Bytes(), nil
+}
+
+// region is the text of one top-level section: lines [start, end), with the
+// key itself on keyLine. Column-0 comments and blank lines directly above a
+// key belong to its region.
+type region struct {
+	index      int // of the key node in the root mapping's Content
+	start, end int
+	keyLine    int
+}
+
+func sectionRegions(root *yaml.Node, lines []string) []region {
+	var regions []region
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keyLine := root.Content[i].Line - 1
+		start := keyLine
+		if len(regions) > 0 {
+			prev := regions[len(regions)-1].keyLine
+			for start-1 > prev && isTopLevelFiller(lines[start-1]) {
+				start--
+			}
+			regions[len(regions)-1].end = start
+		}
+		regions = append(regions, region{index: i, start: start, keyLine: keyLine})
+	}
+	if len(regions) > 0 {
+		last := &regions[len(regions)-1]
+		end := len(lines)
+		for end-1 > last.keyLine && isTopLevelFiller(lines[end-1]) {
+			end--
+		}
+		last.end = end
+	}
+	return regions
+}
+
+// isTopLevelFiller reports whether line is blank or a column-0 comment.
+func isTopLevelFiller(line string) bool {
+	return strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#")
+}
+
+// detectIndent returns the smallest indentation used in lines, or 4 (the
+// yaml.Marshal default) if nothing is indented.
+func detectIndent(lines []string) int {
+	indent := 0
+	for _, l := range lines {
+		trimmed := strings.TrimLeft(l, " ")
+		if trimmed == "" || trimmed == "\n" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := len(l) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 4
+	}
+	return indent
+}
+
+// merge returns fresh, reusing nodes from orig wherever base (orig as the
+// builder renders it) shows the content is unchanged.
+func merge(orig, base, fresh *yaml.Node) *yaml.Node {
+	if base != nil && equal(base, fresh) {
+		return orig
+	}
+	switch {
+	case base != nil && orig.Kind == yaml.MappingNode && base.Kind == yaml.MappingNode && fresh.Kind == yaml.MappingNode:
+		out := *orig
+		out.Content = nil
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(orig.Content); i += 2 {
+			key := orig.Content[i].Value
+			seen[key] = true
+			bv, fv := lookup(base, key), lookup(fresh, key)
+			switch {
+			case bv == nil && fv == nil:
+				out.Content = append(out.Content, orig.Content[i], orig.Content[i+1])
+			case fv != nil:
+				out.Content = append(out.Content, orig.Content[i], merge(orig.Content[i+1], bv, fv))
+			}
+		}
+		for i := 0; i+1 < len(fresh.Content); i += 2 {
+			if !seen[fresh.Content[i].Value] {
+				out.Content = append(out.Content, fresh.Content[i], fresh.Content[i+1])
+			}
+		}
+		return &out
+	case orig.Kind == yaml.SequenceNode && fresh.Kind == yaml.SequenceNode:
+		out := *orig
+		out.Content = nil
+		taken := make([]bool, len(orig.Content))
+		for _, item := range fresh.Content {
+			match := -1
+			for j, o := range orig.Content {
+				if !taken[j] && equal(o, item) {
+					match = j
+					break
+				}
+			}
+			if match >= 0 {
+				taken[match] = true
+				out.Content = append(out.Content, orig.Content[match])
+			} else {
+				out.Content = append(out.Content, item)
+			}
+		}
+		return &out
+	case orig.Kind == fresh.Kind && orig.Kind == yaml.ScalarNode:
+		out := *fresh
+		out.HeadComment, out.LineComment, out.FootComment = orig.HeadComment, orig.LineComment, orig.FootComment
+		return &out
+	}
+	return fresh
+}
+
+// encodeSection encodes a single top-level key and its value. Comments
+// above the key and after the section are left to the copied source text.
+func encodeSection(key, value *yaml.Node, indent int) ([]byte, error) {
+	k := *key
+	k.HeadComment = ""
+	k.FootComment = ""
+	v := *value
+	clearTrailingFootComments(&v)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{&k, &v}}); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearTrailingFootComments removes foot comments along the last-child
+// chain of n, copying nodes rather than modifying them.
+func clearTrailingFootComments(n *yaml.Node) {
+	n.FootComment = ""
+	if len(n.Content) == 0 {
+		return
+	}
+	content := append([]*yaml.Node(nil), n.Content...)
+	if n.Kind == yaml.MappingNode && len(content) >= 2 {
+		k := *content[len(content)-2]
+		k.FootComment = ""
+		content[len(content)-2] = &k
+	}
+	last := *content[len(content)-1]
+	clearTrailingFootComments(&last)
+	content[len(content)-1] = &last
+	n.Content = content
+}
+
+func mappingRoot(n *yaml.Node) *yaml.Node {
+	if n != nil && n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	return n
+}
+
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// equal reports whether two nodes decode to the same value.
+func equal(a, b *yaml.Node) bool {
+	var av, bv any
+	if a.Decode(&av) != nil || b.Decode(&bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
@@ -0,0 +1,90 @@
+package yamledit_test
+
+import (
+	"testing"
+
+	"github.com/ruminaider/claude-sync/internal/yamledit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+// build renders a document the way a struct-based builder would: from its
+// decoded value, with no comments and sorted keys.
+func build(data []byte) (*yaml.Node, error) {
+	var v map[string]any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&n}}, nil
+}
+
+const src = `# header
+zeta: 1  # keep me
+
+# about alpha
+alpha:
+  b: 2
+  a: 1   # one
+list:
+  - x  # ex
+  - y
+anchored: &anchor
+  k: v
+# footer
+`
+
+func TestRender_UnchangedIsByteForByte(t *testing.T) {
+	fresh, err := build([]byte(src))
+	require.NoError(t, err)
+	out, ok := yamledit.Rewrite([]byte(src), fresh, build)
+	require.True(t, ok)
+	assert.Equal(t, src, string(out))
+}
+
+func TestRender_SurgicalEdits(t *testing.T) {
+	fresh, err := build([]byte(`zeta: 2
+alpha: {a: 1, b: 3, c: 4}
+list: [x, z]
+anchored: {k: v}
+added: true
+`))
+	require.NoError(t, err)
+	base, err := build([]byte(src))
+	require.NoError(t, err)
+
+	out, err := yamledit.Render([]byte(src), base, fresh)
+	require.NoError(t, err)
+	assert.Equal(t, `# header
+zeta: 2 # keep me
+
+# about alpha
+alpha:
+  b: 3
+  a: 1 # one
+  c: 4
+list:
+  - x # ex
+  - z
+anchored: &anchor
+  k: v
+
+added: true
+# footer
+`, string(out))
+}
+
+func TestRender_RemovesSectionWithItsComments(t *testing.T) {
+	fresh, err := build([]byte("zeta: 1\nlist: [x, y]\nanchored: {k: v}\n"))
+	require.NoError(t, err)
+	out, ok := yamledit.Rewrite([]byte(src), fresh, build)
+	require.True(t, ok)
+	assert.NotContains(t, string(out), "alpha")
+	assert.NotContains(t, string(out), "# about alpha")
+	assert.Contains(t, string(out), "zeta: 1  # keep me\n")
+	assert.Contains(t, string(out), "# footer\n")
+}